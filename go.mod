@@ -2,6 +2,8 @@ module github.com/ArtemVoronov/indefinite-studies-api
 
 go 1.18
 
+require golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+
 require (
 	github.com/antonholmquist/jason v1.0.0 // indirect
 	github.com/bsiegert/ranges v0.0.0-20111221115336-19303dc7aa63 // indirect
@@ -33,7 +35,6 @@ require (
 	github.com/stretchr/testify v1.8.0 // indirect
 	github.com/ugorji/go/codec v1.2.7 // indirect
 	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
-	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
 	golang.org/x/sys v0.0.0-20211007075335-d3039528d8ac // indirect
 	golang.org/x/text v0.3.6 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
@@ -0,0 +1,58 @@
+package activity
+
+import "github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+
+type Achievement struct {
+	Code        string
+	Title       string
+	Description string
+	earned      func(summary Summary, goals []GoalProgress) bool
+}
+
+func countAtLeast(activityType string, count int) func(Summary, []GoalProgress) bool {
+	return func(summary Summary, goals []GoalProgress) bool {
+		return summary.Totals[activityType] >= count
+	}
+}
+
+func streakAtLeast(days int) func(Summary, []GoalProgress) bool {
+	return func(summary Summary, goals []GoalProgress) bool {
+		return summary.LongestStreak >= days
+	}
+}
+
+func allGoalsAchieved(summary Summary, goals []GoalProgress) bool {
+	for _, goal := range goals {
+		if !goal.Achieved {
+			return false
+		}
+	}
+	return len(goals) > 0
+}
+
+var achievements = []Achievement{
+	{Code: "FIRST_NOTE", Title: "First note", Description: "Write the first note", earned: countAtLeast(entities.ACTIVITY_TYPE_NOTE_EDIT, 1)},
+	{Code: "NOTES_100", Title: "Writer", Description: "Write or edit notes 100 times", earned: countAtLeast(entities.ACTIVITY_TYPE_NOTE_EDIT, 100)},
+	{Code: "FIRST_REVIEW", Title: "First review", Description: "Review the first card", earned: countAtLeast(entities.ACTIVITY_TYPE_CARD_REVIEW, 1)},
+	{Code: "REVIEWS_1000", Title: "Memory master", Description: "Review cards 1000 times", earned: countAtLeast(entities.ACTIVITY_TYPE_CARD_REVIEW, 1000)},
+	{Code: "TASKS_10", Title: "Doer", Description: "Complete 10 tasks", earned: countAtLeast(entities.ACTIVITY_TYPE_TASK_DONE, 10)},
+	{Code: "STREAK_7", Title: "Week streak", Description: "Study 7 days in a row", earned: streakAtLeast(7)},
+	{Code: "STREAK_30", Title: "Month streak", Description: "Study 30 days in a row", earned: streakAtLeast(30)},
+	{Code: "WEEKLY_GOALS", Title: "Goal getter", Description: "Achieve all weekly goals", earned: allGoalsAchieved},
+}
+
+// returns all achievements in order of their difficulty
+func Achievements() []Achievement {
+	return achievements
+}
+
+// returns codes of the achievements which are earned by the summary and the goals
+func Earned(summary Summary, goals []GoalProgress) []string {
+	var result []string
+	for _, achievement := range achievements {
+		if achievement.earned(summary, goals) {
+			result = append(result, achievement.Code)
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package activity
+
+import (
+	"sort"
+	"time"
+
+	// the timezones of users should be known even if the system has no timezone database
+	_ "time/tzdata"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+const DAY_LAYOUT string = "2006-01-02"
+
+type Summary struct {
+	Today          string
+	ActiveToday    bool
+	CurrentStreak  int
+	LongestStreak  int
+	ActiveDays     int
+	Totals         map[string]int
+	WeekStart      string
+	WeekCounts     map[string]int
+	WeekActiveDays int
+}
+
+type GoalProgress struct {
+	Type     string
+	Target   int
+	Current  int
+	Achieved bool
+}
+
+// the dates of db are the wall clock of the server, so they are converted into the user's timezone through the server timezone
+func ToLocation(serverTime time.Time, loc *time.Location) time.Time {
+	return time.Date(serverTime.Year(), serverTime.Month(), serverTime.Day(), serverTime.Hour(), serverTime.Minute(), serverTime.Second(), serverTime.Nanosecond(), time.Local).In(loc)
+}
+
+// returns the first day of the week (Monday) of the date
+func WeekStart(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// computes the streaks and the counts of activities by the days of the user's timezone. The streak is kept
+// if there is no activity today yet, so it is broken only when the whole day is missed
+func Summarize(buckets []entities.ActivityBucket, now time.Time, loc *time.Location) Summary {
+	now = now.In(loc)
+	weekStart := WeekStart(now)
+	summary := Summary{
+		Today:      now.Format(DAY_LAYOUT),
+		WeekStart:  weekStart.Format(DAY_LAYOUT),
+		Totals:     make(map[string]int),
+		WeekCounts: make(map[string]int),
+	}
+
+	days := make(map[string]bool)
+	weekDays := make(map[string]bool)
+	for _, bucket := range buckets {
+		date := ToLocation(bucket.Minute, loc)
+		day := date.Format(DAY_LAYOUT)
+		days[day] = true
+		summary.Totals[bucket.Type] += bucket.Count
+		if !date.Before(weekStart) {
+			weekDays[day] = true
+			summary.WeekCounts[bucket.Type] += bucket.Count
+		}
+	}
+
+	summary.ActiveDays = len(days)
+	summary.WeekActiveDays = len(weekDays)
+	summary.ActiveToday = days[summary.Today]
+
+	day := civilDay(now)
+	if !summary.ActiveToday {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day.Format(DAY_LAYOUT)] {
+		summary.CurrentStreak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	summary.LongestStreak = longestStreak(days)
+	return summary
+}
+
+// returns the progress of the weekly goals
+func Goals(goals []entities.UserGoal, summary Summary) []GoalProgress {
+	result := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		current := summary.WeekCounts[goal.Type]
+		if goal.Type == entities.GOAL_TYPE_ACTIVE_DAYS {
+			current = summary.WeekActiveDays
+		}
+		result = append(result, GoalProgress{Type: goal.Type, Target: goal.Target, Current: current, Achieved: current >= goal.Target})
+	}
+	return result
+}
+
+func longestStreak(days map[string]bool) int {
+	var sorted []time.Time
+	for day := range days {
+		date, err := time.Parse(DAY_LAYOUT, day)
+		if err == nil {
+			sorted = append(sorted, date)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	result, current := 0, 0
+	for i, day := range sorted {
+		if i > 0 && sorted[i-1].AddDate(0, 0, 1).Equal(day) {
+			current++
+		} else {
+			current = 1
+		}
+		if current > result {
+			result = current
+		}
+	}
+	return result
+}
+
+// the date without time in UTC, so adding days is not affected by daylight saving time
+func civilDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
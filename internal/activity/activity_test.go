@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+package activity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/activity"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// the db keeps the wall clock of the server
+func bucket(activityType string, date time.Time, count int) entities.ActivityBucket {
+	local := date.In(time.Local)
+	minute := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), 0, 0, time.UTC)
+	return entities.ActivityBucket{Type: activityType, Minute: minute, Count: count}
+}
+
+func TestSummarizeStreaks(t *testing.T) {
+	now := time.Date(2022, 8, 10, 12, 0, 0, 0, time.UTC) // Wednesday
+	buckets := []entities.ActivityBucket{
+		bucket(entities.ACTIVITY_TYPE_NOTE_EDIT, now.AddDate(0, 0, -10), 1),
+		bucket(entities.ACTIVITY_TYPE_NOTE_EDIT, now.AddDate(0, 0, -9), 2),
+		bucket(entities.ACTIVITY_TYPE_NOTE_EDIT, now.AddDate(0, 0, -8), 1),
+		bucket(entities.ACTIVITY_TYPE_CARD_REVIEW, now.AddDate(0, 0, -2), 10),
+		bucket(entities.ACTIVITY_TYPE_TASK_DONE, now.AddDate(0, 0, -1), 1),
+	}
+
+	summary := activity.Summarize(buckets, now, time.UTC)
+	assert.Equal(t, "2022-08-10", summary.Today)
+	assert.Equal(t, "2022-08-08", summary.WeekStart)
+	assert.False(t, summary.ActiveToday)
+	// the streak is not broken until the end of today
+	assert.Equal(t, 2, summary.CurrentStreak)
+	assert.Equal(t, 3, summary.LongestStreak)
+	assert.Equal(t, 5, summary.ActiveDays)
+	assert.Equal(t, 4, summary.Totals[entities.ACTIVITY_TYPE_NOTE_EDIT])
+	assert.Equal(t, 10, summary.WeekCounts[entities.ACTIVITY_TYPE_CARD_REVIEW])
+	assert.Equal(t, 0, summary.WeekCounts[entities.ACTIVITY_TYPE_NOTE_EDIT])
+	assert.Equal(t, 2, summary.WeekActiveDays)
+
+	summary = activity.Summarize(buckets, now.AddDate(0, 0, 1), time.UTC)
+	assert.Equal(t, 0, summary.CurrentStreak)
+}
+
+func TestSummarizeTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	// 23:30 UTC is the next day in Tokyo
+	now := time.Date(2022, 8, 10, 23, 30, 0, 0, time.UTC)
+	buckets := []entities.ActivityBucket{bucket(entities.ACTIVITY_TYPE_NOTE_EDIT, now, 1)}
+
+	summary := activity.Summarize(buckets, now, time.UTC)
+	assert.Equal(t, "2022-08-10", summary.Today)
+	assert.True(t, summary.ActiveToday)
+
+	summary = activity.Summarize(buckets, now, tokyo)
+	assert.Equal(t, "2022-08-11", summary.Today)
+	assert.True(t, summary.ActiveToday)
+	assert.Equal(t, 1, summary.CurrentStreak)
+}
+
+func TestGoalsAndAchievements(t *testing.T) {
+	now := time.Date(2022, 8, 10, 12, 0, 0, 0, time.UTC)
+	buckets := []entities.ActivityBucket{bucket(entities.ACTIVITY_TYPE_NOTE_EDIT, now, 5)}
+	summary := activity.Summarize(buckets, now, time.UTC)
+
+	goals := activity.Goals([]entities.UserGoal{
+		{Type: entities.GOAL_TYPE_NOTE_EDITS, Target: 5},
+		{Type: entities.GOAL_TYPE_ACTIVE_DAYS, Target: 3},
+	}, summary)
+	assert.Equal(t, 2, len(goals))
+	assert.True(t, goals[0].Achieved)
+	assert.Equal(t, 1, goals[1].Current)
+	assert.False(t, goals[1].Achieved)
+
+	assert.Equal(t, []string{"FIRST_NOTE"}, activity.Earned(summary, goals))
+	assert.Equal(t, []string{"FIRST_NOTE", "WEEKLY_GOALS"}, activity.Earned(summary, goals[:1]))
+}
@@ -0,0 +1,105 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/auth"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+// returns id of the caller, sends 401 and returns false if the caller is unknown
+func Caller(c *gin.Context) (int, bool) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, "Unauthorized")
+		return -1, false
+	}
+	return userId, true
+}
+
+// checks that the caller has at least the required permission for the note and returns the caller id.
+// Sends 404 if the note is not visible to the caller (so the existence of private notes is not disclosed) and 403 if it is visible, but the permission is not enough
+func CheckNotePermission(c *gin.Context, noteId int, required string) (int, bool) {
+	userId, ok := Caller(c)
+	if !ok {
+		return -1, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		permission, err := queries.GetNotePermission(tx, ctx, noteId, userId)
+		return permission, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to check note permission")
+			log.Printf("Unable to check note permission : %s", err)
+		}
+		return -1, false
+	}
+
+	permission, ok := data.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to check note permission")
+		log.Printf("Unable to check note permission : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, false
+	}
+
+	if permission == entities.NOTE_PERMISSION_NONE {
+		c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		return -1, false
+	}
+	if !entities.IsNotePermissionSufficient(permission, required) {
+		c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		return -1, false
+	}
+
+	return userId, true
+}
+
+// checks that the caller has one of the roles and returns the caller id. Sends 403 if the role is not enough, e.g. for the moderation
+func CheckRole(c *gin.Context, roles []string) (int, bool) {
+	userId, ok := Caller(c)
+	if !ok {
+		return -1, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		user, err := queries.GetUser(tx, ctx, userId)
+		return user, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to check user role")
+			log.Printf("Unable to check user role : %s", err)
+		}
+		return -1, false
+	}
+
+	user, ok := data.(entities.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to check user role")
+		log.Printf("Unable to check user role : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, false
+	}
+
+	if !utils.Contains(roles, user.Role) {
+		c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		return -1, false
+	}
+
+	return userId, true
+}
@@ -6,10 +6,70 @@ const (
 	DELETE_VIA_PUT_REQUEST_IS_FODBIDDEN  string = "DELETE_VIA_PUT_REQUEST_IS_FODBIDDEN"
 	DELETE_VIA_POST_REQUEST_IS_FODBIDDEN string = "DELETE_VIA_POST_REQUEST_IS_FODBIDDEN"
 	PAGE_NOT_FOUND                       string = "404 page not found"
+	ACCESS_DENIED                        string = "ACCESS_DENIED"
 
 	ERROR_MESSAGE_PARSING_BODY_JSON string = "Error during parsing of HTTP request body. Please check it format correctness: missed brackets, double quotes, commas, matching of names and data types and etc"
 	ERROR_ID_WRONG_FORMAT           string = "Wrong ID format. Expected number"
 	ERROR_ASSERT_RESULT_TYPE        string = "unable to assert result type"
 	ERROR_WRONG_PASSWORD_OR_EMAIL   string = "Wrong password or email"
 	ERROR_TOKEN_IS_EXPIRED          string = "Token is expired"
+
+	ERROR_ATTACHMENT_FILE_IS_MISSED           string = "Missed file. Expected multipart form with field '%s'"
+	ERROR_ATTACHMENT_IS_TOO_LARGE             string = "Attachment is too large. Max size in bytes: %d"
+	ERROR_ATTACHMENT_MIME_TYPE_IS_NOT_ALLOWED string = "Attachment type '%s' is not allowed. Possible values: %v"
+
+	ERROR_IMPORT_ARCHIVE_IS_MISSED        string = "Missed archive. Expected multipart form with zip archive in field '%s'"
+	ERROR_IMPORT_ARCHIVE_IS_TOO_LARGE     string = "Archive is too large. Max size in bytes: %d"
+	ERROR_IMPORT_ARCHIVE_HAS_WRONG_FORMAT string = "Wrong archive format. Expected zip archive"
+
+	ERROR_NOTE_STATE_TRANSITION_IS_NOT_ALLOWED string = "Unable to change note state from '%s' to '%s'. Possible values: %v"
+	ERROR_NOTE_PUBLISH_AT_IS_WRONG             string = "Wrong 'publishAt' value. Expected date in the future for the note in state 'DRAFT'"
+	ERROR_NOTE_IS_NOT_DRAFT                    string = "Note is not a draft"
+
+	ERROR_COURSE_ITEM_WRONG_REFERENCE string = "Wrong course item. Expected either 'noteId' or 'taskId' of existing note or task"
+	ERROR_COURSE_ORDER_MISMATCH       string = "Wrong order. Expected ids of all %s exactly once"
+	ERROR_COURSE_IS_NOT_ENROLLED      string = "Caller is not enrolled into the course"
+
+	ERROR_QUIZ_WRONG_REFERENCE     string = "Wrong quiz. Expected either 'noteId' or 'courseId' of existing note or course"
+	ERROR_QUIZ_QUESTION_IS_INVALID string = "Wrong question: %s"
+	ERROR_QUIZ_HAS_NO_QUESTIONS    string = "Quiz has no questions"
+	ERROR_QUIZ_ATTEMPT_IS_FINISHED string = "Quiz attempt is finished already"
+
+	ERROR_TIMEZONE_IS_UNKNOWN string = "Unknown timezone '%s'. Expected IANA timezone name, e.g. 'Europe/Berlin'"
+
+	ERROR_TIME_ENTRY_WRONG_REFERENCE string = "Wrong time entry. Expected at most one of 'noteId', 'taskId' or 'courseId' of existing note, task or course"
+	ERROR_TIME_ENTRY_WRONG_DATES     string = "Wrong time entry dates. Expected 'end' after 'start', not in the future and at most %v later"
+	ERROR_TIME_ENTRY_IS_RUNNING      string = "Time entry is running. Stop the timer first"
+	ERROR_TIMER_IS_RUNNING           string = "Timer is running already. Stop it first"
+	ERROR_TIMER_IS_NOT_RUNNING       string = "Timer is not running"
+	ERROR_REPORT_WRONG_RANGE         string = "Wrong report range. Expected 'from' and 'to' dates in format YYYY-MM-DD, 'from' is not after 'to' and at most %d days"
+
+	ERROR_TAG_PREREQUISITE_MAKES_CYCLE string = "Prerequisite makes a cycle: %s"
+	ERROR_TAG_WRONG_REFERENCE          string = "Wrong '%s'. Expected id of existing tag"
+	ERROR_TAG_PARENT_MAKES_CYCLE       string = "Wrong 'parentId'. Expected tag outside of the tag and its descendants"
+	ERROR_TAG_MERGE_INTO_ITSELF        string = "Unable to merge tag into itself"
+
+	ERROR_RESOURCE_WRONG_REFERENCE         string = "Wrong resource links. Expected 'tagIds' of existing tags and 'noteIds' of visible notes"
+	ERROR_RESOURCE_PROGRESS_IS_NOT_TRACKED string = "Progress is not tracked for resources of type '%s'"
+	ERROR_RESOURCE_PROGRESS_EXCEEDS_TOTAL  string = "Wrong progress. Expected 'progress' not greater than 'progressTotal'"
+
+	ERROR_BIB_ENTRY_IS_INVALID     string = "Wrong bibliography entry: %s"
+	ERROR_BIBTEX_FILE_IS_MISSED    string = "Missed file. Expected multipart form with BibTeX file in field '%s'"
+	ERROR_BIBTEX_FILE_IS_TOO_LARGE string = "BibTeX file is too large. Max size in bytes: %d"
+
+	ERROR_COMMENT_WRONG_REFERENCE string = "Wrong 'linkedCommentId'. Expected id of existing comment of the same note"
+	ERROR_COMMENT_IS_BLOCKED      string = "Comment is blocked by the moderator"
+
+	ERROR_REPORT_OF_OWN_CONTENT       string = "Unable to report own content"
+	ERROR_MODERATION_REASON_IS_MISSED string = "Missed 'reason'. Expected explanation of the blocking"
+	ERROR_CONTENT_IS_BLOCKED_ALREADY  string = "Content is blocked already"
+	ERROR_CONTENT_HAS_NO_OPEN_REPORTS string = "Content has no open reports"
+
+	ERROR_BOOKMARK_FOLDER_WRONG_REFERENCE string = "Wrong 'folderId'. Expected id of existing bookmark folder of the caller"
+
+	ERROR_NOTE_TEMPLATE_WRONG_REFERENCE string = "Wrong 'template'. Expected id of existing note template of the caller or shared one"
+	ERROR_NOTE_TEMPLATE_TOPIC_IS_MISSED string = "Missed 'topic'. The template has no topic, so it is expected in the request"
+
+	ERROR_NOTEBOOK_WRONG_REFERENCE  string = "Wrong '%s'. Expected id of existing notebook of the caller"
+	ERROR_NOTEBOOK_MOVE_MAKES_CYCLE string = "Wrong 'parentId'. Expected notebook outside of the moved one and its descendants"
 )
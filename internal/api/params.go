@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parses path param as ID, sends 400 and returns false in case of wrong format
+func ParseIdParam(c *gin.Context, name string) (int, bool) {
+	idStr := c.Param(name)
+
+	if idStr == "" {
+		c.JSON(http.StatusBadRequest, "Missed ID")
+		return -1, false
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ERROR_ID_WRONG_FORMAT)
+		return -1, false
+	}
+
+	return id, true
+}
+
+func ParseLimitAndOffset(c *gin.Context) (int, int) {
+	limitStr := c.DefaultQuery("limit", "50")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+		offset = 0
+	}
+
+	return limit, offset
+}
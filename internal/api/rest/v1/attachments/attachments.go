@@ -0,0 +1,332 @@
+package attachments
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FORM_FILE_FIELD_NAME       string = "file"
+	MULTIPART_OVERHEAD_BYTES   int64  = 1 << 20
+	DEFAULT_MAX_SIZE_IN_BYTES  string = "10485760" // 10 MB
+	DEFAULT_ALLOWED_MIME_TYPES string = "image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain,application/zip"
+)
+
+var maxSizeInBytes int64
+var allowedMimeTypes []string
+var once sync.Once
+
+func Setup() {
+	once.Do(func() {
+		size, err := strconv.ParseInt(utils.EnvVarDefault("ATTACHMENT_MAX_SIZE_IN_BYTES", DEFAULT_MAX_SIZE_IN_BYTES), 10, 64)
+		if err != nil {
+			log.Fatalf("Wrong value of environment variable: ATTACHMENT_MAX_SIZE_IN_BYTES. It should be integer number")
+		}
+		maxSizeInBytes = size
+		for _, mimeType := range strings.Split(utils.EnvVarDefault("ATTACHMENT_ALLOWED_MIME_TYPES", DEFAULT_ALLOWED_MIME_TYPES), ",") {
+			allowedMimeTypes = append(allowedMimeTypes, strings.TrimSpace(mimeType))
+		}
+	})
+}
+
+type AttachmentDTO struct {
+	Id         int
+	NoteId     int
+	UserId     int
+	FileName   string
+	MimeType   string
+	Size       int64
+	Checksum   string
+	CreateDate time.Time
+}
+
+type AttachmentListDTO struct {
+	Count int
+	Data  []AttachmentDTO
+}
+
+func convertAttachments(attachments []entities.Attachment) []AttachmentDTO {
+	if attachments == nil {
+		return make([]AttachmentDTO, 0)
+	}
+	var result []AttachmentDTO
+	for _, attachment := range attachments {
+		result = append(result, convertAttachment(attachment))
+	}
+	return result
+}
+
+func convertAttachment(attachment entities.Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		Id:         attachment.Id,
+		NoteId:     attachment.NoteId,
+		UserId:     attachment.UserId,
+		FileName:   attachment.FileName,
+		MimeType:   attachment.MimeType,
+		Size:       attachment.Size,
+		Checksum:   attachment.Checksum,
+		CreateDate: attachment.CreateDate,
+	}
+}
+
+func GetAttachments(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		attachments, err := queries.GetAttachments(tx, ctx, noteId)
+		return attachments, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get attachments")
+		log.Printf("Unable to get to attachments : %s", err)
+		return
+	}
+
+	attachments, ok := data.([]entities.Attachment)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get attachments")
+		log.Printf("Unable to get to attachments : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &AttachmentListDTO{Data: convertAttachments(attachments), Count: len(attachments)}
+	c.JSON(http.StatusOK, result)
+}
+
+func CreateAttachment(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSizeInBytes+MULTIPART_OVERHEAD_BYTES)
+	fileHeader, err := c.FormFile(FORM_FILE_FIELD_NAME)
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_ATTACHMENT_IS_TOO_LARGE, maxSizeInBytes))
+			return
+		}
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_ATTACHMENT_FILE_IS_MISSED, FORM_FILE_FIELD_NAME))
+		return
+	}
+	if fileHeader.Size > maxSizeInBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_ATTACHMENT_IS_TOO_LARGE, maxSizeInBytes))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to create attachment")
+		log.Printf("Unable to create attachment : %s", err)
+		return
+	}
+	defer file.Close()
+
+	mimeType, checksum, err := inspect(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to create attachment")
+		log.Printf("Unable to create attachment : %s", err)
+		return
+	}
+	if !utils.Contains(allowedMimeTypes, mimeType) {
+		c.JSON(http.StatusUnsupportedMediaType, fmt.Sprintf(api.ERROR_ATTACHMENT_MIME_TYPE_IS_NOT_ALLOWED, mimeType, allowedMimeTypes))
+		return
+	}
+
+	// the blob key is the checksum of the content, so the same file uploaded twice is stored once. The blob is stored before
+	// the attachment under the lock of the checksum, so the orphan sweep of the same blob can't delete it in between
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := queries.LockAttachmentChecksum(tx, ctx, checksum)
+		if err != nil {
+			return -1, err
+		}
+		blobs := storage.GetInstance()
+		exists, err := blobs.Exists(c.Request.Context(), checksum)
+		if err == nil && !exists {
+			err = blobs.Put(c.Request.Context(), checksum, file, fileHeader.Size, mimeType)
+		}
+		if err != nil {
+			return -1, err
+		}
+		result, err := queries.CreateAttachment(tx, ctx, noteId, userId, filepath.Base(fileHeader.Filename), mimeType, fileHeader.Size, checksum)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create attachment")
+		log.Printf("Unable to create attachment : %s", err)
+		DeleteOrphanBlobs([]string{checksum})
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func DownloadAttachment(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	attachmentId, ok := api.ParseIdParam(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		attachment, err := queries.GetAttachment(tx, ctx, noteId, attachmentId)
+		return attachment, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get attachment")
+			log.Printf("Unable to get to attachment : %s", err)
+		}
+		return
+	}
+
+	attachment, ok := data.(entities.Attachment)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get attachment")
+		log.Printf("Unable to get to attachment : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	blob, err := storage.GetInstance().Open(c.Request.Context(), attachment.Checksum)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get attachment")
+		log.Printf("Unable to get to attachment blob '%s' : %s", attachment.Checksum, err)
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", attachment.MimeType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("ETag", "\""+attachment.Checksum+"\"")
+	// handles 'Range' and 'If-Range' headers
+	http.ServeContent(c.Writer, c.Request, attachment.FileName, attachment.CreateDate, blob)
+}
+
+func DeleteAttachment(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	attachmentId, ok := api.ParseIdParam(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		checksum, err := queries.DeleteAttachment(tx, ctx, noteId, attachmentId)
+		return checksum, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to delete attachment")
+			log.Printf("Unable to delete attachment: %s", err)
+		}
+		return
+	}
+
+	checksum, ok := data.(string)
+	if ok {
+		DeleteOrphanBlobs([]string{checksum})
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// removes blobs from the storage if there are no attachments referring them anymore. The blob is deleted under the lock
+// of its checksum, so the attachment being uploaded at the same time can't miss it
+func DeleteOrphanBlobs(checksums []string) {
+	for _, checksum := range checksums {
+		err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			err := queries.LockAttachmentChecksum(tx, ctx, checksum)
+			if err != nil {
+				return err
+			}
+			inUse, err := queries.IsAttachmentChecksumInUse(tx, ctx, checksum)
+			if err != nil || inUse {
+				return err
+			}
+			return storage.GetInstance().Delete(ctx, checksum)
+		})()
+		if err != nil {
+			log.Printf("Unable to delete attachment blob '%s' : %s", checksum, err)
+		}
+	}
+}
+
+// detects MIME type by the content (not by the name or headers provided by client) and calculates sha256 checksum, then rewinds the file
+func inspect(file io.ReadSeeker) (string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	return mimeType, hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -69,6 +69,8 @@ type UserClaims struct {
 	jwt.RegisteredClaims
 }
 
+const USER_ID_CONTEXT_KEY = "userId"
+
 func Authenicate(c *gin.Context) {
 	var authenicationDTO AuthenicationDTO
 
@@ -226,6 +228,27 @@ func Verify(token string) (*TokenValidationResult, error) {
 	return &TokenValidationResult{IsValid: true, IsExpired: false, token: t}, nil
 }
 
+func (p *TokenValidationResult) GetUserId() (int, bool) {
+	if p.token == nil {
+		return -1, false
+	}
+	claims, ok := p.token.Claims.(*UserClaims)
+	if !ok {
+		return -1, false
+	}
+	return claims.UserId, true
+}
+
+// returns id of the user who made the request, it is put into the context by app.AuthReqired()
+func GetUserId(c *gin.Context) (int, bool) {
+	value, exists := c.Get(USER_ID_CONTEXT_KEY)
+	if !exists {
+		return -1, false
+	}
+	userId, ok := value.(int)
+	return userId, ok
+}
+
 func checkUserCredentials(email string, password string) (CredentialsValidationResult, error) {
 	var result CredentialsValidationResult
 
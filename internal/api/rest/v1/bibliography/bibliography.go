@@ -0,0 +1,298 @@
+package bibliography
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/bibtex"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	BIB_ENTRY_MAX_FIELDS      int = 64
+	BIB_ENTRY_MAX_VALUE_RUNES int = 4096
+)
+
+type BibEntryDTO struct {
+	Id             int
+	Key            string
+	Type           string
+	Label          string // the short author-date label, e.g. "Knuth, 1984"
+	Fields         map[string]string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type BibEntryListDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []BibEntryDTO
+}
+
+type BibEntryEditDTO struct {
+	Key    string            `json:"key" binding:"required,max=256"`
+	Type   string            `json:"type" binding:"required,max=64"`
+	Fields map[string]string `json:"fields"`
+}
+
+func convertBibEntries(entries []entities.BibEntry) []BibEntryDTO {
+	if entries == nil {
+		return make([]BibEntryDTO, 0)
+	}
+	var result []BibEntryDTO
+	for _, entry := range entries {
+		result = append(result, convertBibEntry(entry))
+	}
+	return result
+}
+
+func convertBibEntry(entry entities.BibEntry) BibEntryDTO {
+	result := BibEntryDTO{
+		Id:             entry.Id,
+		Key:            entry.CitationKey,
+		Type:           entry.EntryType,
+		Label:          bibtex.Label(toBibtex(entry)),
+		Fields:         entry.Fields,
+		CreateDate:     entry.CreateDate,
+		LastUpdateDate: entry.LastUpdateDate,
+	}
+	if result.Fields == nil {
+		result.Fields = make(map[string]string)
+	}
+	return result
+}
+
+func toBibtex(entry entities.BibEntry) bibtex.Entry {
+	return bibtex.Entry{Type: entry.EntryType, Key: entry.CitationKey, Fields: entry.Fields}
+}
+
+// checks the key, the type and the fields of the entry, the error describes the first found problem
+func checkEntry(entry bibtex.Entry) error {
+	if !bibtex.IsValidKey(entry.Key) || len(entry.Key) > 256 {
+		return fmt.Errorf("wrong key '%s'. Expected letters, digits and any of '_:.+/-'", entry.Key)
+	}
+	if !bibtex.IsValidName(entry.Type) || len(entry.Type) > 64 {
+		return fmt.Errorf("wrong type '%s' of entry '%s'. Expected lower case name, e.g. 'book'", entry.Type, entry.Key)
+	}
+	if len(entry.Fields) > BIB_ENTRY_MAX_FIELDS {
+		return fmt.Errorf("too many fields of entry '%s'. Max count: %d", entry.Key, BIB_ENTRY_MAX_FIELDS)
+	}
+	for name, value := range entry.Fields {
+		if !bibtex.IsValidName(name) || len(name) > 64 {
+			return fmt.Errorf("wrong field name '%s' of entry '%s'. Expected lower case name, e.g. 'author'", name, entry.Key)
+		}
+		if !bibtex.IsBalanced(value) || len([]rune(value)) > BIB_ENTRY_MAX_VALUE_RUNES {
+			return fmt.Errorf("wrong value of field '%s' of entry '%s'. Expected balanced braces and at most %d characters", name, entry.Key, BIB_ENTRY_MAX_VALUE_RUNES)
+		}
+	}
+	return nil
+}
+
+// validates the entry and converts it, returns false if the response is sent already
+func toBibEntry(c *gin.Context, dto BibEntryEditDTO, userId int) (entities.BibEntry, bool) {
+	if dto.Fields == nil {
+		dto.Fields = make(map[string]string)
+	}
+	err := checkEntry(bibtex.Entry{Type: dto.Type, Key: dto.Key, Fields: dto.Fields})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_BIB_ENTRY_IS_INVALID, err))
+		return entities.BibEntry{}, false
+	}
+	return entities.BibEntry{UserId: userId, CitationKey: dto.Key, EntryType: dto.Type, Fields: dto.Fields}, true
+}
+
+// checks that the entry belongs to the caller and returns the caller id with the entry, the entries of other users are not disclosed
+func checkBibEntryOwner(c *gin.Context, entryId int) (int, entities.BibEntry, bool) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return -1, entities.BibEntry{}, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		entry, err := queries.GetBibEntry(tx, ctx, entryId)
+		return entry, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get bibliography entry")
+			log.Printf("Unable to get bibliography entry : %s", err)
+		}
+		return -1, entities.BibEntry{}, false
+	}
+
+	entry, ok := data.(entities.BibEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get bibliography entry")
+		log.Printf("Unable to get bibliography entry : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, entry, false
+	}
+	if entry.UserId != userId {
+		c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		return -1, entry, false
+	}
+
+	return userId, entry, true
+}
+
+// returns the bibliography of the caller in order of the citation keys
+func GetBibEntries(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		entries, err := queries.GetUserBibEntries(tx, ctx, userId, limit, offset)
+		return entries, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get bibliography")
+		log.Printf("Unable to get bibliography : %s", err)
+		return
+	}
+
+	entries, ok := data.([]entities.BibEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get bibliography")
+		log.Printf("Unable to get bibliography : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &BibEntryListDTO{Data: convertBibEntries(entries), Count: len(entries), Offset: offset, Limit: limit}
+	c.JSON(http.StatusOK, result)
+}
+
+func GetBibEntry(c *gin.Context) {
+	entryId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	_, entry, ok := checkBibEntryOwner(c, entryId)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, convertBibEntry(entry))
+}
+
+func CreateBibEntry(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto BibEntryEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	entry, ok := toBibEntry(c, dto, userId)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateBibEntry(tx, ctx, entry)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == db.ErrorBibEntryDuplicateKey {
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create bibliography entry")
+			log.Printf("Unable to create bibliography entry : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// updates the entry and replaces its fields
+func UpdateBibEntry(c *gin.Context) {
+	entryId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto BibEntryEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, _, ok := checkBibEntryOwner(c, entryId)
+	if !ok {
+		return
+	}
+
+	entry, ok := toBibEntry(c, dto, userId)
+	if !ok {
+		return
+	}
+	entry.Id = entryId
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateBibEntry(tx, ctx, entry)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update bibliography entry")
+}
+
+func DeleteBibEntry(c *gin.Context) {
+	entryId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, _, ok := checkBibEntryOwner(c, entryId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteBibEntry(tx, ctx, entryId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete bibliography entry")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case db.ErrorBibEntryDuplicateKey:
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,192 @@
+package bibliography
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/bibtex"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FORM_FILE_FIELD_NAME     string = "file"
+	MULTIPART_OVERHEAD_BYTES int64  = 1 << 20
+	BIBTEX_MAX_SIZE_IN_BYTES int64  = 5 << 20
+	BIBTEX_CONTENT_TYPE      string = "application/x-bibtex; charset=utf-8"
+	BIBTEX_FILE_NAME         string = "bibliography.bib"
+)
+
+type ImportResultDTO struct {
+	Created int
+	Updated int
+	Skipped int // the existing entries are kept unless 'overwrite' is given
+	Failed  int
+	Errors  []string
+}
+
+// imports the entries of the BibTeX file into the bibliography of the caller in one transaction. The entries are matched by the citation keys,
+// the existing ones are replaced only if 'overwrite=true' is given. The broken entries are skipped and reported
+func ImportBibliography(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	overwrite := false
+	if overwriteStr := c.Query("overwrite"); overwriteStr != "" {
+		value, err := strconv.ParseBool(overwriteStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "Unable to import bibliography. Wrong 'overwrite' value. Possible values: [true false]")
+			return
+		}
+		overwrite = value
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, BIBTEX_MAX_SIZE_IN_BYTES+MULTIPART_OVERHEAD_BYTES)
+	fileHeader, err := c.FormFile(FORM_FILE_FIELD_NAME)
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_BIBTEX_FILE_IS_TOO_LARGE, BIBTEX_MAX_SIZE_IN_BYTES))
+			return
+		}
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_BIBTEX_FILE_IS_MISSED, FORM_FILE_FIELD_NAME))
+		return
+	}
+	if fileHeader.Size > BIBTEX_MAX_SIZE_IN_BYTES {
+		c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_BIBTEX_FILE_IS_TOO_LARGE, BIBTEX_MAX_SIZE_IN_BYTES))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to import bibliography")
+		log.Printf("Unable to import bibliography : %s", err)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, BIBTEX_MAX_SIZE_IN_BYTES))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to import bibliography")
+		log.Printf("Unable to import bibliography : %s", err)
+		return
+	}
+
+	parsed, errs := bibtex.Parse(string(content))
+	result := ImportResultDTO{Failed: len(errs), Errors: make([]string, 0, len(errs))}
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		return importEntries(tx, ctx, userId, parsed, overwrite, result)
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to import bibliography")
+		log.Printf("Unable to import bibliography : %s", err)
+		return
+	}
+
+	result, ok = data.(ImportResultDTO)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to import bibliography")
+		log.Printf("Unable to import bibliography : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func importEntries(tx *sql.Tx, ctx context.Context, userId int, parsed []bibtex.Entry, overwrite bool, result ImportResultDTO) (ImportResultDTO, error) {
+	var keys []string
+	for _, entry := range parsed {
+		keys = append(keys, entry.Key)
+	}
+	existing, err := queries.GetBibEntriesByKeys(tx, ctx, userId, keys)
+	if err != nil {
+		return result, err
+	}
+	existingIds := make(map[string]int)
+	for _, entry := range existing {
+		existingIds[entry.CitationKey] = entry.Id
+	}
+
+	imported := make(map[string]bool)
+	for _, entry := range parsed {
+		if err := checkEntry(entry); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if imported[entry.Key] {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate key '%s'", entry.Key))
+			continue
+		}
+		imported[entry.Key] = true
+
+		bibEntry := entities.BibEntry{UserId: userId, CitationKey: entry.Key, EntryType: entry.Type, Fields: entry.Fields}
+		id, exists := existingIds[entry.Key]
+		switch {
+		case exists && !overwrite:
+			result.Skipped++
+		case exists:
+			bibEntry.Id = id
+			err = queries.UpdateBibEntry(tx, ctx, bibEntry)
+			result.Updated++
+		default:
+			_, err = queries.CreateBibEntry(tx, ctx, bibEntry)
+			result.Created++
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// returns the bibliography of the caller as BibTeX file
+func ExportBibliography(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		entries, err := queries.GetUserBibEntries(tx, ctx, userId, -1, 0)
+		return entries, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to export bibliography")
+		log.Printf("Unable to export bibliography : %s", err)
+		return
+	}
+
+	entries, ok := data.([]entities.BibEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to export bibliography")
+		log.Printf("Unable to export bibliography : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	var result []bibtex.Entry
+	for _, entry := range entries {
+		result = append(result, toBibtex(entry))
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", BIBTEX_FILE_NAME))
+	c.Data(http.StatusOK, BIBTEX_CONTENT_TYPE, []byte(bibtex.Format(result)))
+}
@@ -0,0 +1,142 @@
+package bibliography
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/bibtex"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/gin-gonic/gin"
+)
+
+type NoteReferencesDTO struct {
+	References  []BibEntryDTO // in order of the first citation
+	MissingKeys []string      // the cited keys which are absent in the bibliography of the note owner
+}
+
+type RenderedNoteDTO struct {
+	Id          int
+	Topic       string
+	Text        string // the citations are replaced by their labels, e.g. "(Knuth, 1984, p. 33)"
+	References  []BibEntryDTO
+	MissingKeys []string
+}
+
+// the note with the cited entries of its owner bibliography
+type noteCitations struct {
+	note    entities.Note
+	keys    []string
+	entries map[string]entities.BibEntry
+}
+
+func (n noteCitations) references() NoteReferencesDTO {
+	result := NoteReferencesDTO{References: make([]BibEntryDTO, 0), MissingKeys: make([]string, 0)}
+	for _, key := range n.keys {
+		if entry, ok := n.entries[key]; ok {
+			result.References = append(result.References, convertBibEntry(entry))
+		} else {
+			result.MissingKeys = append(result.MissingKeys, key)
+		}
+	}
+	return result
+}
+
+// returns the bibliography entries cited by the note, the citations are resolved against the bibliography of the note owner
+func GetNoteReferences(c *gin.Context) {
+	citations, ok := loadNoteCitations(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, citations.references())
+}
+
+// returns the text of the note with the resolved citations and its references
+func RenderNote(c *gin.Context) {
+	citations, ok := loadNoteCitations(c)
+	if !ok {
+		return
+	}
+
+	references := citations.references()
+	c.JSON(http.StatusOK, RenderedNoteDTO{
+		Id:          citations.note.Id,
+		Topic:       citations.note.Topic,
+		Text:        markdown.RenderCitations(citations.note.Text, citations.label),
+		References:  references.References,
+		MissingKeys: references.MissingKeys,
+	})
+}
+
+// returns the author-date label of the citation group, e.g. "(Knuth, 1984, p. 33; Lamport, 1994)", the unknown keys are kept as "@key"
+func (n noteCitations) label(citations []markdown.Citation) string {
+	var parts []string
+	for _, citation := range citations {
+		part := "@" + citation.Key
+		if entry, ok := n.entries[citation.Key]; ok {
+			part = bibtex.Label(toBibtex(entry))
+		}
+		if citation.Locator != "" {
+			part += ", " + citation.Locator
+		}
+		parts = append(parts, part)
+	}
+	return "(" + strings.Join(parts, "; ") + ")"
+}
+
+// loads the note readable by the caller with its cited entries, returns false if the response is sent already
+func loadNoteCitations(c *gin.Context) (noteCitations, bool) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return noteCitations{}, false
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ); !ok {
+		return noteCitations{}, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result := noteCitations{entries: make(map[string]entities.BibEntry)}
+		note, err := queries.GetNote(tx, ctx, noteId)
+		if err != nil {
+			return result, err
+		}
+		result.note = note
+		result.keys = markdown.ExtractCitationKeys(note.Text)
+		entries, err := queries.GetBibEntriesByKeys(tx, ctx, note.UserId, result.keys)
+		if err != nil {
+			return result, err
+		}
+		for _, entry := range entries {
+			result.entries[entry.CitationKey] = entry
+		}
+		return result, nil
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get note references")
+			log.Printf("Unable to get note references : %s", err)
+		}
+		return noteCitations{}, false
+	}
+
+	result, ok := data.(noteCitations)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note references")
+		log.Printf("Unable to get note references : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return result, false
+	}
+
+	return result, true
+}
@@ -0,0 +1,305 @@
+package bookmarks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const UNFILED_FOLDER string = "none"
+
+var errorBookmarkFolderNotFound = errors.New("bookmark folder is not found")
+
+type BookmarkDTO struct {
+	NoteId     int
+	NoteTopic  string
+	FolderId   *int `json:",omitempty"`
+	CreateDate time.Time
+}
+
+type BookmarkListDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []BookmarkDTO
+}
+
+type BookmarkEditDTO struct {
+	FolderId int `json:"folderId" binding:"min=0"` // optional, the bookmark is kept without folder if missed
+}
+
+type BookmarkFolderDTO struct {
+	Id         int
+	Name       string
+	CreateDate time.Time
+}
+
+type BookmarkFolderListDTO struct {
+	Count int
+	Data  []BookmarkFolderDTO
+}
+
+type BookmarkFolderEditDTO struct {
+	Name string `json:"name" binding:"required,max=256"`
+}
+
+func convertBookmarks(bookmarks []entities.Bookmark) []BookmarkDTO {
+	if bookmarks == nil {
+		return make([]BookmarkDTO, 0)
+	}
+	var result []BookmarkDTO
+	for _, bookmark := range bookmarks {
+		result = append(result, convertBookmark(bookmark))
+	}
+	return result
+}
+
+func convertBookmark(bookmark entities.Bookmark) BookmarkDTO {
+	result := BookmarkDTO{NoteId: bookmark.NoteId, NoteTopic: bookmark.NoteTopic, CreateDate: bookmark.CreateDate}
+	if bookmark.FolderId.Valid {
+		folderId := int(bookmark.FolderId.Int32)
+		result.FolderId = &folderId
+	}
+	return result
+}
+
+func convertBookmarkFolders(folders []entities.BookmarkFolder) []BookmarkFolderDTO {
+	if folders == nil {
+		return make([]BookmarkFolderDTO, 0)
+	}
+	var result []BookmarkFolderDTO
+	for _, folder := range folders {
+		result = append(result, BookmarkFolderDTO{Id: folder.Id, Name: folder.Name, CreateDate: folder.CreateDate})
+	}
+	return result
+}
+
+// returns the bookmarks of the caller, optionally of the given 'folderId' or without folder if it is 'none'
+func GetBookmarks(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var filter queries.BookmarkFilter
+	if folderIdStr := c.Query("folderId"); folderIdStr == UNFILED_FOLDER {
+		filter.Unfiled = true
+	} else if folderIdStr != "" {
+		folderId, err := strconv.Atoi(folderIdStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "Unable to get bookmarks. Wrong 'folderId' value. Expected number or '"+UNFILED_FOLDER+"'")
+			return
+		}
+		filter.FolderId = sql.NullInt32{Int32: int32(folderId), Valid: true}
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		bookmarks, err := queries.GetUserBookmarks(tx, ctx, userId, filter, limit, offset)
+		return bookmarks, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get bookmarks")
+		log.Printf("Unable to get bookmarks : %s", err)
+		return
+	}
+
+	bookmarks, ok := data.([]entities.Bookmark)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get bookmarks")
+		log.Printf("Unable to get bookmarks : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &BookmarkListDTO{Count: len(bookmarks), Offset: offset, Limit: limit, Data: convertBookmarks(bookmarks)})
+}
+
+// bookmarks the visible note or moves the existing bookmark to the given folder of the caller
+func SetBookmark(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// the body is optional for the bookmark without folder
+	var dto BookmarkEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil && err != io.EOF {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		var folderId sql.NullInt32
+		if dto.FolderId != 0 {
+			_, err := queries.GetBookmarkFolder(tx, ctx, userId, dto.FolderId)
+			if err == sql.ErrNoRows {
+				return errorBookmarkFolderNotFound
+			}
+			if err != nil {
+				return err
+			}
+			folderId = sql.NullInt32{Int32: int32(dto.FolderId), Valid: true}
+		}
+		return queries.SetBookmark(tx, ctx, userId, noteId, folderId)
+	})()
+
+	sendEditResult(c, err, "Unable to set bookmark")
+}
+
+func DeleteBookmark(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteBookmark(tx, ctx, userId, noteId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete bookmark")
+}
+
+func GetBookmarkFolders(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		folders, err := queries.GetBookmarkFolders(tx, ctx, userId)
+		return folders, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get bookmark folders")
+		log.Printf("Unable to get bookmark folders : %s", err)
+		return
+	}
+
+	folders, ok := data.([]entities.BookmarkFolder)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get bookmark folders")
+		log.Printf("Unable to get bookmark folders : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &BookmarkFolderListDTO{Count: len(folders), Data: convertBookmarkFolders(folders)})
+}
+
+func CreateBookmarkFolder(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto BookmarkFolderEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateBookmarkFolder(tx, ctx, userId, dto.Name)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == db.ErrorBookmarkFolderDuplicateKey {
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create bookmark folder")
+			log.Printf("Unable to create bookmark folder : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateBookmarkFolder(c *gin.Context) {
+	folderId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto BookmarkFolderEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.UpdateBookmarkFolder(tx, ctx, userId, folderId, dto.Name)
+	})()
+
+	sendEditResult(c, err, "Unable to update bookmark folder")
+}
+
+// deletes the folder of the caller, its bookmarks are kept without folder
+func DeleteBookmarkFolder(c *gin.Context) {
+	folderId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteBookmarkFolder(tx, ctx, userId, folderId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete bookmark folder")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorBookmarkFolderNotFound:
+			c.JSON(http.StatusBadRequest, api.ERROR_BOOKMARK_FOLDER_WRONG_REFERENCE)
+		case db.ErrorBookmarkFolderDuplicateKey:
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
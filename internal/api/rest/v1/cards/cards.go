@@ -0,0 +1,284 @@
+package cards
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/gin-gonic/gin"
+)
+
+type CardDTO struct {
+	Id             int
+	NoteId         int
+	UserId         int
+	Front          string
+	Back           string
+	Source         string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type CardListDTO struct {
+	Count int
+	Data  []CardDTO
+}
+
+type CardEditDTO struct {
+	Front string `json:"front" binding:"required"`
+	Back  string `json:"back" binding:"required"`
+}
+
+type CardExtractionDTO struct {
+	Created int
+	Updated int
+	Deleted int
+}
+
+func ConvertCards(cards []entities.Card) []CardDTO {
+	if cards == nil {
+		return make([]CardDTO, 0)
+	}
+	var result []CardDTO
+	for _, card := range cards {
+		result = append(result, ConvertCard(card))
+	}
+	return result
+}
+
+func ConvertCard(card entities.Card) CardDTO {
+	return CardDTO{Id: card.Id, NoteId: card.NoteId, UserId: card.UserId, Front: card.Front, Back: card.Back, Source: card.Source, CreateDate: card.CreateDate, LastUpdateDate: card.LastUpdateDate}
+}
+
+func GetNoteCards(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		cards, err := queries.GetNoteCards(tx, ctx, noteId)
+		return cards, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get cards")
+		log.Printf("Unable to get cards : %s", err)
+		return
+	}
+
+	cards, ok := data.([]entities.Card)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get cards")
+		log.Printf("Unable to get cards : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &CardListDTO{Data: ConvertCards(cards), Count: len(cards)}
+	c.JSON(http.StatusOK, result)
+}
+
+func CreateNoteCard(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var card CardEditDTO
+
+	if err := c.ShouldBindJSON(&card); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateCard(tx, ctx, noteId, userId, card.Front, card.Back, entities.CARD_SOURCE_MANUAL)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create card")
+		log.Printf("Unable to create card : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateNoteCard(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	cardId, ok := api.ParseIdParam(c, "cardId")
+	if !ok {
+		return
+	}
+
+	var card CardEditDTO
+
+	if err := c.ShouldBindJSON(&card); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateCard(tx, ctx, noteId, cardId, card.Front, card.Back)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to update card")
+			log.Printf("Unable to update card : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+func DeleteNoteCard(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	cardId, ok := api.ParseIdParam(c, "cardId")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteCard(tx, ctx, noteId, cardId)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to delete card")
+			log.Printf("Unable to delete card: %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// synchronizes the cards extracted from "Q:/A:" blocks of the note text with the text: new blocks are added as cards,
+// the cards of removed blocks are deleted. The blocks are matched with the cards by questions, so review history of unchanged questions is kept
+func ExtractNoteCards(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result := CardExtractionDTO{}
+
+		note, err := queries.GetNote(tx, ctx, noteId)
+		if err != nil {
+			return result, err
+		}
+		cards, err := queries.GetNoteCards(tx, ctx, noteId)
+		if err != nil {
+			return result, err
+		}
+
+		extracted := make(map[string]entities.Card)
+		for _, card := range cards {
+			if card.Source == entities.CARD_SOURCE_NOTE {
+				extracted[card.Front] = card
+			}
+		}
+
+		for _, qa := range markdown.ExtractQuestionAnswers(note.Text) {
+			card, ok := extracted[qa.Question]
+			if !ok {
+				_, err = queries.CreateCard(tx, ctx, noteId, userId, qa.Question, qa.Answer, entities.CARD_SOURCE_NOTE)
+				if err != nil {
+					return result, err
+				}
+				result.Created++
+				// the same question could be repeated in the text
+				extracted[qa.Question] = entities.Card{Id: -1}
+				continue
+			}
+			delete(extracted, qa.Question)
+			if card.Id == -1 || card.Back == qa.Answer {
+				continue
+			}
+			err = queries.UpdateCard(tx, ctx, noteId, card.Id, qa.Question, qa.Answer)
+			if err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
+
+		for _, card := range extracted {
+			if card.Id == -1 {
+				continue
+			}
+			err = queries.DeleteCard(tx, ctx, noteId, card.Id)
+			if err != nil {
+				return result, err
+			}
+			result.Deleted++
+		}
+
+		return result, nil
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to extract cards")
+			log.Printf("Unable to extract cards : %s", err)
+		}
+		return
+	}
+
+	result, ok := data.(CardExtractionDTO)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to extract cards")
+		log.Printf("Unable to extract cards : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
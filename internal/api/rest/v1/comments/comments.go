@@ -0,0 +1,328 @@
+package comments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/moderation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+var errorCommentWrongReference = errors.New("comment replies to missing comment or comment of another note")
+var errorCommentIsBlocked = errors.New("comment is blocked")
+var errorAuthorOnly = errors.New("action is allowed to the author only")
+
+type CommentDTO struct {
+	Id              int
+	NoteId          int
+	UserId          int
+	LinkedCommentId *int `json:",omitempty"`
+	Text            string
+	State           string
+	CreateDate      time.Time
+	LastUpdateDate  time.Time
+}
+
+type CommentListDTO struct {
+	Count int
+	Data  []CommentDTO
+}
+
+type CommentCreateDTO struct {
+	Text            string `json:"text" binding:"required,max=4096"`
+	LinkedCommentId int    `json:"linkedCommentId" binding:"min=0"`
+}
+
+type CommentEditDTO struct {
+	Text string `json:"text" binding:"required,max=4096"`
+}
+
+func convertComments(comments []entities.Comment) []CommentDTO {
+	if comments == nil {
+		return make([]CommentDTO, 0)
+	}
+	var result []CommentDTO
+	for _, comment := range comments {
+		result = append(result, convertComment(comment))
+	}
+	return result
+}
+
+func convertComment(comment entities.Comment) CommentDTO {
+	result := CommentDTO{
+		Id:             comment.Id,
+		NoteId:         comment.NoteId,
+		UserId:         comment.UserId,
+		Text:           comment.Text,
+		State:          comment.State,
+		CreateDate:     comment.CreateDate,
+		LastUpdateDate: comment.LastUpdateDate,
+	}
+	if comment.LinkdedCommentId != 0 {
+		linkedCommentId := comment.LinkdedCommentId
+		result.LinkedCommentId = &linkedCommentId
+	}
+	return result
+}
+
+// the blocked comments are visible to their authors only
+func isCommentVisible(comment entities.Comment, userId int) bool {
+	return comment.State != entities.COMMENT_STATE_BLOCKED || comment.UserId == userId
+}
+
+// returns the comment with the permission of the user for its note, sql.ErrNoRows if the comment is not visible to the user
+func getVisibleComment(tx *sql.Tx, ctx context.Context, commentId int, userId int) (entities.Comment, string, error) {
+	comment, err := queries.GetComment(tx, ctx, commentId)
+	if err != nil {
+		return comment, entities.NOTE_PERMISSION_NONE, err
+	}
+	if !isCommentVisible(comment, userId) {
+		return comment, entities.NOTE_PERMISSION_NONE, sql.ErrNoRows
+	}
+	permission, err := queries.GetNotePermission(tx, ctx, comment.NoteId, userId)
+	if err != nil {
+		return comment, permission, err
+	}
+	if permission == entities.NOTE_PERMISSION_NONE {
+		return comment, permission, sql.ErrNoRows
+	}
+	return comment, permission, nil
+}
+
+// notifies the author of the replied comment and the owner of the note, the owner replied directly gets the reply notification only
+func notifyAboutComment(tx *sql.Tx, ctx context.Context, noteId int, commentId int, userId int, linked entities.Comment, text string) error {
+	note, err := queries.GetNote(tx, ctx, noteId)
+	if err != nil {
+		return err
+	}
+	notification := entities.Notification{
+		ActorId:   sql.NullInt32{Int32: int32(userId), Valid: true},
+		NoteId:    sql.NullInt32{Int32: int32(noteId), Valid: true},
+		CommentId: sql.NullInt32{Int32: int32(commentId), Valid: true},
+		Text:      notifications.Excerpt(text),
+	}
+	if linked.Id != 0 {
+		notification.UserId = linked.UserId
+		notification.Type = entities.NOTIFICATION_TYPE_COMMENT_REPLIED
+		err = notifications.Notify(tx, ctx, notification)
+		if err != nil || linked.UserId == note.UserId {
+			return err
+		}
+	}
+	notification.UserId = note.UserId
+	notification.Type = entities.NOTIFICATION_TYPE_NOTE_COMMENTED
+	return notifications.Notify(tx, ctx, notification)
+}
+
+func GetNoteComments(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		comments, err := queries.GetNoteComments(tx, ctx, noteId)
+		return comments, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get comments")
+		log.Printf("Unable to get comments : %s", err)
+		return
+	}
+
+	comments, ok := data.([]entities.Comment)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get comments")
+		log.Printf("Unable to get comments : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	var visible []entities.Comment
+	for _, comment := range comments {
+		if isCommentVisible(comment, userId) {
+			visible = append(visible, comment)
+		}
+	}
+
+	result := &CommentListDTO{Data: convertComments(visible), Count: len(visible)}
+	c.JSON(http.StatusOK, result)
+}
+
+// every user who is able to read the note is able to comment it, the comment is checked by the word filter
+func CreateComment(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto CommentCreateDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var linked entities.Comment
+		if dto.LinkedCommentId != 0 {
+			var err error
+			linked, err = queries.GetComment(tx, ctx, dto.LinkedCommentId)
+			if err == sql.ErrNoRows || (err == nil && (linked.NoteId != noteId || !isCommentVisible(linked, userId))) {
+				return -1, errorCommentWrongReference
+			}
+			if err != nil {
+				return -1, err
+			}
+		}
+		result, err := queries.CreateComment(tx, ctx, noteId, userId, dto.Text, dto.LinkedCommentId, entities.COMMENT_STATE_NEW)
+		if err != nil {
+			return result, err
+		}
+		err = moderation.FlagContent(tx, ctx, entities.CONTENT_TYPE_COMMENT, result, dto.Text)
+		if err != nil {
+			return result, err
+		}
+		err = mentions.SaveCommentMentions(tx, ctx, noteId, result, userId, dto.Text)
+		if err != nil {
+			return result, err
+		}
+		err = events.PublishCommentEvent(tx, ctx, noteId, result, entities.EVENT_ACTION_CREATED)
+		if err != nil {
+			return result, err
+		}
+		return result, notifyAboutComment(tx, ctx, noteId, result, userId, linked, dto.Text)
+	})()
+
+	if err != nil || data == -1 {
+		if err == errorCommentWrongReference {
+			c.JSON(http.StatusBadRequest, api.ERROR_COMMENT_WRONG_REFERENCE)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create comment")
+			log.Printf("Unable to create comment : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// the comment is editable by its author only until it is blocked, the new text is checked by the word filter
+func UpdateComment(c *gin.Context) {
+	commentId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto CommentEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		comment, _, err := getVisibleComment(tx, ctx, commentId, userId)
+		if err != nil {
+			return err
+		}
+		if comment.UserId != userId {
+			return errorAuthorOnly
+		}
+		if comment.State == entities.COMMENT_STATE_BLOCKED {
+			return errorCommentIsBlocked
+		}
+		err = queries.UpdateCommentText(tx, ctx, commentId, dto.Text)
+		if err != nil {
+			return err
+		}
+		err = moderation.FlagContent(tx, ctx, entities.CONTENT_TYPE_COMMENT, commentId, dto.Text)
+		if err != nil {
+			return err
+		}
+		err = mentions.SaveCommentMentions(tx, ctx, comment.NoteId, commentId, userId, dto.Text)
+		if err != nil {
+			return err
+		}
+		return events.PublishCommentEvent(tx, ctx, comment.NoteId, commentId, entities.EVENT_ACTION_UPDATED)
+	})()
+
+	sendEditResult(c, err, "Unable to update comment")
+}
+
+// the comment is deleted by its author or by the owner of the note
+func DeleteComment(c *gin.Context) {
+	commentId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		comment, permission, err := getVisibleComment(tx, ctx, commentId, userId)
+		if err != nil {
+			return err
+		}
+		if comment.UserId != userId && permission != entities.NOTE_PERMISSION_OWNER {
+			return errorAuthorOnly
+		}
+		err = queries.DeleteComment(tx, ctx, commentId)
+		if err != nil {
+			return err
+		}
+		return events.PublishCommentEvent(tx, ctx, comment.NoteId, commentId, entities.EVENT_ACTION_DELETED)
+	})()
+
+	sendEditResult(c, err, "Unable to delete comment")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorAuthorOnly:
+			c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		case errorCommentIsBlocked:
+			c.JSON(http.StatusConflict, api.ERROR_COMMENT_IS_BLOCKED)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,639 @@
+package courses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	COURSE_ITEM_TYPE_NOTE string = "NOTE"
+	COURSE_ITEM_TYPE_TASK string = "TASK"
+)
+
+var errorCourseItemWrongReference = errors.New("course item references missing note or task")
+var errorCourseOrderMismatch = errors.New("course order does not match")
+
+type CourseDTO struct {
+	Id             int
+	UserId         int
+	Title          string
+	Description    string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type CourseListDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []CourseDTO
+}
+
+type CourseItemDTO struct {
+	Id       int
+	Type     string
+	NoteId   *int `json:",omitempty"`
+	TaskId   *int `json:",omitempty"`
+	Position int
+}
+
+type CourseModuleDTO struct {
+	Id       int
+	Title    string
+	Position int
+	Items    []CourseItemDTO
+}
+
+type CourseDetailsDTO struct {
+	CourseDTO
+	Modules []CourseModuleDTO
+}
+
+type CourseEditDTO struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+}
+
+type CourseModuleEditDTO struct {
+	Title string `json:"title" binding:"required"`
+}
+
+type CourseItemCreateDTO struct {
+	NoteId *int `json:"noteId"`
+	TaskId *int `json:"taskId"`
+}
+
+type CourseOrderDTO struct {
+	Ids []int `json:"ids" binding:"required"`
+}
+
+// the course with its modules and items in order of studying
+type courseDetails struct {
+	course  entities.Course
+	modules []entities.CourseModule
+	items   []entities.CourseItem
+}
+
+func convertCourses(courses []entities.Course) []CourseDTO {
+	if courses == nil {
+		return make([]CourseDTO, 0)
+	}
+	var result []CourseDTO
+	for _, course := range courses {
+		result = append(result, convertCourse(course))
+	}
+	return result
+}
+
+func convertCourse(course entities.Course) CourseDTO {
+	return CourseDTO{Id: course.Id, UserId: course.UserId, Title: course.Title, Description: course.Description, CreateDate: course.CreateDate, LastUpdateDate: course.LastUpdateDate}
+}
+
+func convertCourseItem(item entities.CourseItem) CourseItemDTO {
+	result := CourseItemDTO{Id: item.Id, Position: item.Position}
+	if item.NoteId.Valid {
+		noteId := int(item.NoteId.Int32)
+		result.Type = COURSE_ITEM_TYPE_NOTE
+		result.NoteId = &noteId
+	} else {
+		taskId := int(item.TaskId.Int32)
+		result.Type = COURSE_ITEM_TYPE_TASK
+		result.TaskId = &taskId
+	}
+	return result
+}
+
+func convertCourseDetails(details courseDetails) CourseDetailsDTO {
+	result := CourseDetailsDTO{CourseDTO: convertCourse(details.course), Modules: make([]CourseModuleDTO, 0, len(details.modules))}
+	indexes := make(map[int]int)
+	for i, module := range details.modules {
+		indexes[module.Id] = i
+		result.Modules = append(result.Modules, CourseModuleDTO{Id: module.Id, Title: module.Title, Position: module.Position, Items: make([]CourseItemDTO, 0)})
+	}
+	for _, item := range details.items {
+		i := indexes[item.ModuleId]
+		result.Modules[i].Items = append(result.Modules[i].Items, convertCourseItem(item))
+	}
+	return result
+}
+
+func GetCourses(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "50")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+		offset = 0
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		courses, err := queries.GetCourses(tx, ctx, limit, offset)
+		return courses, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get courses")
+		log.Printf("Unable to get courses : %s", err)
+		return
+	}
+
+	courses, ok := data.([]entities.Course)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get courses")
+		log.Printf("Unable to get courses : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &CourseListDTO{Data: convertCourses(courses), Count: len(courses), Offset: offset, Limit: limit}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the course with its modules and items in order of studying
+func GetCourse(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var details courseDetails
+		var err error
+		details.course, err = queries.GetCourse(tx, ctx, courseId)
+		if err != nil {
+			return details, err
+		}
+		details.modules, err = queries.GetCourseModules(tx, ctx, courseId)
+		if err != nil {
+			return details, err
+		}
+		details.items, err = queries.GetCourseItems(tx, ctx, courseId)
+		return details, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get course")
+			log.Printf("Unable to get course : %s", err)
+		}
+		return
+	}
+
+	details, ok := data.(courseDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get course")
+		log.Printf("Unable to get course : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertCourseDetails(details))
+}
+
+func CreateCourse(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var course CourseEditDTO
+
+	if err := c.ShouldBindJSON(&course); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateCourse(tx, ctx, userId, course.Title, course.Description)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create course")
+		log.Printf("Unable to create course : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateCourse(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var course CourseEditDTO
+
+	if err := c.ShouldBindJSON(&course); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateCourse(tx, ctx, courseId, course.Title, course.Description)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update course")
+}
+
+func DeleteCourse(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteCourse(tx, ctx, courseId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete course")
+}
+
+// adds the module to the end of the course
+func CreateCourseModule(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var module CourseModuleEditDTO
+
+	if err := c.ShouldBindJSON(&module); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateCourseModule(tx, ctx, courseId, module.Title)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create course module")
+		log.Printf("Unable to create course module : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateCourseModule(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	moduleId, ok := api.ParseIdParam(c, "moduleId")
+	if !ok {
+		return
+	}
+
+	var module CourseModuleEditDTO
+
+	if err := c.ShouldBindJSON(&module); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateCourseModule(tx, ctx, courseId, moduleId, module.Title)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update course module")
+}
+
+// deletes the module with its items
+func DeleteCourseModule(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	moduleId, ok := api.ParseIdParam(c, "moduleId")
+	if !ok {
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteCourseModule(tx, ctx, courseId, moduleId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete course module")
+}
+
+// reorders the modules of the course, the body should contain ids of all modules of the course in the new order
+func OrderCourseModules(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var order CourseOrderDTO
+
+	if err := c.ShouldBindJSON(&order); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		modules, err := queries.GetCourseModules(tx, ctx, courseId)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for _, module := range modules {
+			ids = append(ids, module.Id)
+		}
+		if !isPermutation(ids, order.Ids) {
+			return errorCourseOrderMismatch
+		}
+		return queries.SetCourseModulePositions(tx, ctx, courseId, order.Ids)
+	})()
+
+	if err == errorCourseOrderMismatch {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_COURSE_ORDER_MISMATCH, "modules of the course"))
+		return
+	}
+	sendEditResult(c, err, "Unable to order course modules")
+}
+
+// adds the note or the task to the end of the module. The note should be readable by the caller
+func CreateCourseItem(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	moduleId, ok := api.ParseIdParam(c, "moduleId")
+	if !ok {
+		return
+	}
+
+	var item CourseItemCreateDTO
+
+	if err := c.ShouldBindJSON(&item); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+	if (item.NoteId == nil) == (item.TaskId == nil) {
+		c.JSON(http.StatusBadRequest, api.ERROR_COURSE_ITEM_WRONG_REFERENCE)
+		return
+	}
+
+	userId, ok := checkCourseOwner(c, courseId)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := checkModule(tx, ctx, courseId, moduleId)
+		if err != nil {
+			return -1, err
+		}
+
+		var noteId, taskId sql.NullInt32
+		if item.NoteId != nil {
+			permission, err := queries.GetNotePermission(tx, ctx, *item.NoteId, userId)
+			if err == sql.ErrNoRows || (err == nil && permission == entities.NOTE_PERMISSION_NONE) {
+				return -1, errorCourseItemWrongReference
+			}
+			if err != nil {
+				return -1, err
+			}
+			noteId = sql.NullInt32{Int32: int32(*item.NoteId), Valid: true}
+		} else {
+			_, err := queries.GetTask(tx, ctx, *item.TaskId)
+			if err == sql.ErrNoRows {
+				return -1, errorCourseItemWrongReference
+			}
+			if err != nil {
+				return -1, err
+			}
+			taskId = sql.NullInt32{Int32: int32(*item.TaskId), Valid: true}
+		}
+
+		result, err := queries.CreateCourseItem(tx, ctx, moduleId, noteId, taskId)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorCourseItemWrongReference:
+			c.JSON(http.StatusBadRequest, api.ERROR_COURSE_ITEM_WRONG_REFERENCE)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to create course item")
+			log.Printf("Unable to create course item : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func DeleteCourseItem(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	moduleId, ok := api.ParseIdParam(c, "moduleId")
+	if !ok {
+		return
+	}
+	itemId, ok := api.ParseIdParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := checkModule(tx, ctx, courseId, moduleId)
+		if err != nil {
+			return err
+		}
+		return queries.DeleteCourseItem(tx, ctx, moduleId, itemId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete course item")
+}
+
+// reorders the items of the module, the body should contain ids of all items of the module in the new order
+func OrderCourseItems(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	moduleId, ok := api.ParseIdParam(c, "moduleId")
+	if !ok {
+		return
+	}
+
+	var order CourseOrderDTO
+
+	if err := c.ShouldBindJSON(&order); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, ok := checkCourseOwner(c, courseId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := checkModule(tx, ctx, courseId, moduleId)
+		if err != nil {
+			return err
+		}
+		items, err := queries.GetCourseItems(tx, ctx, courseId)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for _, item := range items {
+			if item.ModuleId == moduleId {
+				ids = append(ids, item.Id)
+			}
+		}
+		if !isPermutation(ids, order.Ids) {
+			return errorCourseOrderMismatch
+		}
+		return queries.SetCourseItemPositions(tx, ctx, moduleId, order.Ids)
+	})()
+
+	if err == errorCourseOrderMismatch {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_COURSE_ORDER_MISMATCH, "items of the module"))
+		return
+	}
+	sendEditResult(c, err, "Unable to order course items")
+}
+
+// checks that the course exists and the caller is its author, returns the caller id
+func checkCourseOwner(c *gin.Context, courseId int) (int, bool) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return -1, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		course, err := queries.GetCourse(tx, ctx, courseId)
+		return course, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to check course permission")
+			log.Printf("Unable to check course permission : %s", err)
+		}
+		return -1, false
+	}
+
+	course, ok := data.(entities.Course)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to check course permission")
+		log.Printf("Unable to check course permission : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, false
+	}
+
+	if course.UserId != userId {
+		c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		return -1, false
+	}
+
+	return userId, true
+}
+
+// returns sql.ErrNoRows if the module does not belong to the course
+func checkModule(tx *sql.Tx, ctx context.Context, courseId int, moduleId int) error {
+	modules, err := queries.GetCourseModules(tx, ctx, courseId)
+	if err != nil {
+		return err
+	}
+	for _, module := range modules {
+		if module.Id == moduleId {
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func isPermutation(expected []int, actual []int) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	counts := make(map[int]int)
+	for _, id := range expected {
+		counts[id]++
+	}
+	for _, id := range actual {
+		if counts[id] == 0 {
+			return false
+		}
+		counts[id]--
+	}
+	return true
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,314 @@
+package courses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+var errorCourseIsNotEnrolled = errors.New("user is not enrolled into the course")
+
+type CourseProgressDTO struct {
+	CourseId         int
+	ItemsTotal       int
+	ItemsCompleted   int
+	Percentage       int
+	CompletedItemIds []int
+	LastItemId       *int `json:",omitempty"`
+	EnrollDate       time.Time
+	LastUpdateDate   time.Time
+}
+
+type UserCourseDTO struct {
+	CourseDTO
+	Progress CourseProgressDTO
+}
+
+type UserCourseListDTO struct {
+	Count int
+	Data  []UserCourseDTO
+}
+
+type CoursePositionDTO struct {
+	ItemId int `json:"itemId" binding:"required"`
+}
+
+type userCourse struct {
+	course   entities.Course
+	progress entities.CourseProgress
+}
+
+func convertCourseProgress(progress entities.CourseProgress) CourseProgressDTO {
+	result := CourseProgressDTO{
+		CourseId:         progress.Enrollment.CourseId,
+		ItemsTotal:       progress.ItemsTotal,
+		ItemsCompleted:   len(progress.CompletedItemIds),
+		CompletedItemIds: progress.CompletedItemIds,
+		EnrollDate:       progress.Enrollment.EnrollDate,
+		LastUpdateDate:   progress.Enrollment.LastUpdateDate,
+	}
+	if result.CompletedItemIds == nil {
+		result.CompletedItemIds = make([]int, 0)
+	}
+	if progress.ItemsTotal > 0 {
+		result.Percentage = result.ItemsCompleted * 100 / progress.ItemsTotal
+	}
+	if progress.Enrollment.LastItemId.Valid {
+		lastItemId := int(progress.Enrollment.LastItemId.Int32)
+		result.LastItemId = &lastItemId
+	}
+	return result
+}
+
+func convertUserCourses(courses []userCourse) []UserCourseDTO {
+	result := make([]UserCourseDTO, 0, len(courses))
+	for _, course := range courses {
+		result = append(result, UserCourseDTO{CourseDTO: convertCourse(course.course), Progress: convertCourseProgress(course.progress)})
+	}
+	return result
+}
+
+// returns the courses which the caller is enrolled in with the progress, the recently studied ones go first
+func GetUserCourses(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result []userCourse
+		courses, err := queries.GetUserCourses(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+		for _, course := range courses {
+			progress, err := queries.GetCourseProgress(tx, ctx, course.Id, userId)
+			if err != nil {
+				return result, err
+			}
+			result = append(result, userCourse{course: course, progress: progress})
+		}
+		return result, nil
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get user courses")
+		log.Printf("Unable to get user courses : %s", err)
+		return
+	}
+
+	courses, ok := data.([]userCourse)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get user courses")
+		log.Printf("Unable to get user courses : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &UserCourseListDTO{Data: convertUserCourses(courses), Count: len(courses)}
+	c.JSON(http.StatusOK, result)
+}
+
+// enrolls the caller into the course, the repeated enrollment keeps the progress
+func EnrollCourse(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetCourse(tx, ctx, courseId)
+		if err != nil {
+			return err
+		}
+		return queries.EnrollCourse(tx, ctx, courseId, userId)
+	})()
+
+	sendEditResult(c, err, "Unable to enroll course")
+}
+
+// removes the enrollment of the caller together with the progress
+func LeaveCourse(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.LeaveCourse(tx, ctx, courseId, userId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to leave course")
+}
+
+func GetCourseProgress(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetCourse(tx, ctx, courseId)
+		if err != nil {
+			return nil, err
+		}
+		progress, err := queries.GetCourseProgress(tx, ctx, courseId, userId)
+		if err == sql.ErrNoRows {
+			return nil, errorCourseIsNotEnrolled
+		}
+		return progress, err
+	})()
+
+	if err != nil {
+		sendProgressError(c, err, "Unable to get course progress")
+		return
+	}
+
+	progress, ok := data.(entities.CourseProgress)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get course progress")
+		log.Printf("Unable to get course progress : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertCourseProgress(progress))
+}
+
+// sets the item which the caller studies now
+func SetCoursePosition(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var position CoursePositionDTO
+
+	if err := c.ShouldBindJSON(&position); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return updateProgress(tx, ctx, courseId, position.ItemId, userId, nil)
+	})()
+
+	sendProgressResult(c, err, "Unable to set course position")
+}
+
+// marks the item as completed by the caller, the item becomes the last position in the course too
+func CompleteCourseItem(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	itemId, ok := api.ParseIdParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return updateProgress(tx, ctx, courseId, itemId, userId, queries.CompleteCourseItem)
+	})()
+
+	sendProgressResult(c, err, "Unable to complete course item")
+}
+
+func UncompleteCourseItem(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	itemId, ok := api.ParseIdParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return updateProgress(tx, ctx, courseId, itemId, userId, queries.UncompleteCourseItem)
+	})()
+
+	sendProgressResult(c, err, "Unable to uncomplete course item")
+}
+
+// moves the position of the enrolled user to the item of the course and applies the completion change if it is given
+func updateProgress(tx *sql.Tx, ctx context.Context, courseId int, itemId int, userId int,
+	change func(tx *sql.Tx, ctx context.Context, itemId int, userId int) error) error {
+	_, err := queries.GetCourse(tx, ctx, courseId)
+	if err != nil {
+		return err
+	}
+	_, err = queries.GetCourseItem(tx, ctx, courseId, itemId)
+	if err != nil {
+		return err
+	}
+	err = queries.SetCourseLastItem(tx, ctx, courseId, userId, itemId)
+	if err == sql.ErrNoRows {
+		return errorCourseIsNotEnrolled
+	}
+	if err != nil || change == nil {
+		return err
+	}
+	return change(tx, ctx, itemId, userId)
+}
+
+func sendProgressResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		sendProgressError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+func sendProgressError(c *gin.Context, err error, message string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+	case errorCourseIsNotEnrolled:
+		c.JSON(http.StatusConflict, api.ERROR_COURSE_IS_NOT_ENROLLED)
+	default:
+		c.JSON(http.StatusInternalServerError, message)
+		log.Printf("%s : %s", message, err)
+	}
+}
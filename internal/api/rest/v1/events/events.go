@@ -0,0 +1,416 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/eventhub"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	EVENTS_CHANNEL                        string = "events"
+	DEFAULT_HEARTBEAT_INTERVAL_IN_SECONDS string = "15"
+	DEFAULT_RETENTION_IN_HOURS            string = "24"
+	SUBSCRIBER_BUFFER_SIZE                int    = 64
+	REPLAY_PAGE_SIZE                      int    = 1000
+	LAST_EVENT_ID_HEADER                  string = "Last-Event-ID"
+	// the allowed difference between the clocks of the instances publishing the events
+	REPLAY_CLOCK_SKEW time.Duration = 10 * time.Second
+)
+
+var hub *eventhub.Hub
+var heartbeatInterval time.Duration
+var retention time.Duration
+var once sync.Once
+var listeningOnce sync.Once
+var listeners []func(event *entities.Event)
+var allowedOrigins map[string]bool
+
+func Setup() {
+	once.Do(func() {
+		seconds, err := strconv.Atoi(utils.EnvVarDefault("EVENTS_HEARTBEAT_INTERVAL_IN_SECONDS", DEFAULT_HEARTBEAT_INTERVAL_IN_SECONDS))
+		if err != nil || seconds <= 0 {
+			log.Fatalf("Wrong value of environment variable: EVENTS_HEARTBEAT_INTERVAL_IN_SECONDS. It should be positive integer number")
+		}
+		hours, err := strconv.Atoi(utils.EnvVarDefault("EVENTS_RETENTION_IN_HOURS", DEFAULT_RETENTION_IN_HOURS))
+		if err != nil || hours <= 0 {
+			log.Fatalf("Wrong value of environment variable: EVENTS_RETENTION_IN_HOURS. It should be positive integer number")
+		}
+		allowedOrigins = make(map[string]bool)
+		for _, origin := range strings.Split(utils.EnvVarDefault("EVENTS_ALLOWED_ORIGINS", ""), ",") {
+			origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+			if origin != "" {
+				allowedOrigins[origin] = true
+			}
+		}
+		heartbeatInterval = time.Duration(seconds) * time.Second
+		retention = time.Duration(hours) * time.Hour
+		hub = eventhub.NewHub(SUBSCRIBER_BUFFER_SIZE)
+	})
+}
+
+// starts receiving of the events published by all instances of the app and purging of the outdated ones in background.
+// If the connection to the database is lost, the streams are closed, so the clients resume them from the last received event
+func StartListening() {
+	listeningOnce.Do(func() {
+		listener := pq.NewListener(db.ConnectionString(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Events listener error : %s", err)
+			}
+		})
+		go func() {
+			// blocks until the connection is established
+			if err := listener.Listen(EVENTS_CHANNEL); err != nil {
+				log.Printf("Unable to listen to events channel : %s", err)
+				return
+			}
+			pingTicker := time.NewTicker(90 * time.Second)
+			defer pingTicker.Stop()
+			for {
+				select {
+				case notification := <-listener.Notify:
+					if notification == nil {
+						hub.CloseAll()
+						notifyListeners(nil)
+						continue
+					}
+					broadcast(notification.Extra)
+				case <-pingTicker.C:
+					go listener.Ping()
+				}
+			}
+		}()
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				purgeEvents()
+			}
+		}()
+	})
+}
+
+// registers the function called for every event published by any instance, e.g. for invalidation of the caches of the instance.
+// The nil event means that the events could be missed. The listeners are registered on setup before the listening is started and they should not block
+func AddListener(listener func(event *entities.Event)) {
+	listeners = append(listeners, listener)
+}
+
+func notifyListeners(event *entities.Event) {
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// closes the streams, so the graceful shutdown does not wait for them
+func Shutdown() {
+	hub.CloseAll()
+}
+
+func broadcast(payload string) {
+	eventId, err := strconv.Atoi(payload)
+	if err != nil {
+		log.Printf("Unable to broadcast event '%s' : %s", payload, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		event, err := queries.GetEvent(tx, ctx, eventId)
+		return event, err
+	})()
+
+	if err != nil {
+		log.Printf("Unable to broadcast event '%d' : %s", eventId, err)
+		return
+	}
+
+	event, ok := data.(entities.Event)
+	if !ok {
+		log.Printf("Unable to broadcast event '%d' : %s", eventId, api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+	hub.Broadcast(event)
+	notifyListeners(&event)
+}
+
+func purgeEvents() {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		count, err := queries.DeleteEventsBefore(tx, ctx, time.Now().Add(-retention))
+		return count, err
+	})()
+
+	if err != nil {
+		log.Printf("Unable to purge events : %s", err)
+		return
+	}
+	if count, ok := data.(int); ok && count > 0 {
+		log.Printf("Outdated events are purged: %d", count)
+	}
+}
+
+// stores the event within the transaction of the change and notifies the instances, the event is delivered only if the transaction is committed
+func Publish(tx *sql.Tx, ctx context.Context, event entities.Event) error {
+	eventId, err := queries.CreateEvent(tx, ctx, event)
+	if err != nil {
+		return err
+	}
+	return queries.NotifyChannel(tx, ctx, EVENTS_CHANNEL, strconv.Itoa(eventId))
+}
+
+// the event is visible to the audience of the note at the moment of the change
+func PublishNoteEvent(tx *sql.Tx, ctx context.Context, noteId int, action string) error {
+	public, userIds, err := queries.GetNoteAudience(tx, ctx, noteId)
+	if err != nil {
+		return err
+	}
+	return Publish(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_NOTE, ResourceId: noteId, Action: action, Public: public, UserIds: userIds})
+}
+
+// the comment is visible to the audience of its note
+func PublishCommentEvent(tx *sql.Tx, ctx context.Context, noteId int, commentId int, action string) error {
+	public, userIds, err := queries.GetNoteAudience(tx, ctx, noteId)
+	if err != nil {
+		return err
+	}
+	return Publish(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_COMMENT, ResourceId: commentId, Action: action, Public: public, UserIds: userIds})
+}
+
+// the tasks are shared by all users
+func PublishTaskEvent(tx *sql.Tx, ctx context.Context, taskId int, action string) error {
+	return Publish(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_TASK, ResourceId: taskId, Action: action, Public: true})
+}
+
+// the tags are shared by all users
+func PublishTagEvent(tx *sql.Tx, ctx context.Context, tagId int, action string) error {
+	return Publish(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_TAG, ResourceId: tagId, Action: action, Public: true})
+}
+
+// streams the events visible to the caller as Server-Sent Events. The stream is resumed after the event given by 'Last-Event-ID' header or 'lastEventId' param,
+// the events published shortly before it could be sent again, so the client should skip the already received ids
+func GetEvents(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	lastEventId, ok := parseLastEventId(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := stream(c.Request.Context(), userId, lastEventId, func(event *entities.Event) error {
+		frame := ": heartbeat\n\n"
+		if event != nil {
+			var err error
+			frame, err = eventhub.FormatSSE(*event)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(c.Writer, frame); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Unable to stream events : %s", err)
+	}
+}
+
+// streams the events visible to the caller over WebSocket as JSON messages, the heartbeats are sent as ping frames
+func GetEventsWebSocket(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	lastEventId, ok := parseLastEventId(c)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			go func() {
+				// the client messages are ignored, the reading handles the control frames and detects closing of the connection
+				io.Copy(io.Discard, ws)
+				cancel()
+			}()
+
+			err := stream(ctx, userId, lastEventId, func(event *entities.Event) error {
+				if event == nil {
+					ws.PayloadType = websocket.PingFrame
+					_, err := ws.Write(nil)
+					ws.PayloadType = websocket.TextFrame
+					return err
+				}
+				return websocket.JSON.Send(ws, eventhub.ToMessage(*event))
+			})
+
+			if err != nil {
+				log.Printf("Unable to stream events : %s", err)
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// the browsers send the cookies and the stored credentials with the cross-site WebSocket requests, so the connections are accepted
+// from the same origin and from the ones given by EVENTS_ALLOWED_ORIGINS only
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return fmt.Errorf("null origin")
+	}
+	if origin.Host != r.Host && !allowedOrigins[origin.Scheme+"://"+origin.Host] {
+		return fmt.Errorf("origin '%s' is not allowed", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+// replays the missed events and then writes the new ones until the context is done or the subscription is closed.
+// The write is called with nil for the heartbeat
+func stream(ctx context.Context, userId int, lastEventId int, write func(event *entities.Event) error) error {
+	// subscribes before the replay, so the events published during it are not missed
+	subscription := hub.Subscribe(userId)
+	defer subscription.Close()
+
+	replayed := make(map[int]bool)
+	if lastEventId > 0 {
+		afterDate, err := getReplayStart(lastEventId)
+		if err != nil {
+			return err
+		}
+		afterId := 0
+		for {
+			events, err := getEventsAfter(afterDate, afterId)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				afterDate, afterId = event.CreateDate, event.Id
+				if event.Id == lastEventId || replayed[event.Id] || !event.IsVisibleTo(userId) {
+					continue
+				}
+				replayed[event.Id] = true
+				if err := write(&event); err != nil {
+					return err
+				}
+			}
+			if len(events) < REPLAY_PAGE_SIZE {
+				break
+			}
+		}
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.C:
+			if !ok {
+				return nil
+			}
+			if replayed[event.Id] {
+				continue
+			}
+			if err := write(&event); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := write(nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// the ids are assigned before the commits, so the event committed after the last received one could have a lower id.
+// The replay starts before the last received event by the longest transaction, the client deduplicates the replayed events by id
+func getReplayStart(lastEventId int) (time.Time, error) {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		date, err := queries.GetEventDate(tx, ctx, lastEventId)
+		return date, err
+	})()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, ok := data.(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf(api.ERROR_ASSERT_RESULT_TYPE)
+	}
+	if date.IsZero() {
+		return date, nil
+	}
+	return date.Add(-db.GetInstance().GetTimeout() - REPLAY_CLOCK_SKEW), nil
+}
+
+func getEventsAfter(afterDate time.Time, afterId int) ([]entities.Event, error) {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		events, err := queries.GetEventsAfter(tx, ctx, afterDate, afterId, REPLAY_PAGE_SIZE)
+		return events, err
+	})()
+
+	if err != nil {
+		return nil, err
+	}
+
+	events, ok := data.([]entities.Event)
+	if !ok {
+		return nil, fmt.Errorf(api.ERROR_ASSERT_RESULT_TYPE)
+	}
+	return events, nil
+}
+
+func parseLastEventId(c *gin.Context) (int, bool) {
+	lastEventIdStr := c.GetHeader(LAST_EVENT_ID_HEADER)
+	if lastEventIdStr == "" {
+		lastEventIdStr = c.Query("lastEventId")
+	}
+	if lastEventIdStr == "" {
+		return 0, true
+	}
+	lastEventId, err := strconv.Atoi(lastEventIdStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ERROR_ID_WRONG_FORMAT)
+		return -1, false
+	}
+	return lastEventId, true
+}
@@ -0,0 +1,227 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EXPORT_PAGE_SIZE          int    = 50
+	EXPORT_ATTACHMENTS_FOLDER string = "attachments"
+	EXPORT_COMMENTS_SUFFIX    string = ".comments.json"
+)
+
+type CommentDTO struct {
+	Id              int
+	UserId          int
+	LinkedCommentId int `json:",omitempty"`
+	Text            string
+	State           string
+	CreateDate      time.Time
+	LastUpdateDate  time.Time
+}
+
+// the page of notes with everything required to write them into the archive
+type notesPage struct {
+	notes       []entities.Note
+	attachments map[int][]entities.Attachment
+	comments    map[int][]entities.Comment
+	tags        map[int][]string
+}
+
+// streams zip archive with all notes of the caller. Notes are loaded page by page and attachments are copied from the storage directly into the response,
+// so the whole archive is never kept in memory
+func ExportNotes(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	page, err := loadNotesPage(userId, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to export notes")
+		log.Printf("Unable to export notes : %s", err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"notes-%s.zip\"", time.Now().Format("2006-01-02")))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	for len(page.notes) > 0 {
+		for _, note := range page.notes {
+			err = writeNote(c.Request.Context(), archive, note, page.tags[note.Id], page.attachments[note.Id], page.comments[note.Id])
+			if err != nil {
+				// the response is already started, so the archive is left unfinished to let the client know that it is broken
+				log.Printf("Unable to export notes : %s", err)
+				c.Abort()
+				return
+			}
+		}
+		if len(page.notes) < EXPORT_PAGE_SIZE {
+			break
+		}
+		page, err = loadNotesPage(userId, page.notes[len(page.notes)-1].Id)
+		if err != nil {
+			log.Printf("Unable to export notes : %s", err)
+			c.Abort()
+			return
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		log.Printf("Unable to export notes : %s", err)
+	}
+}
+
+// loads the next page of notes after the note with the given id
+func loadNotesPage(userId int, afterId int) (notesPage, error) {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		page := notesPage{attachments: make(map[int][]entities.Attachment), comments: make(map[int][]entities.Comment), tags: make(map[int][]string)}
+
+		notes, err := queries.GetUserNotes(tx, ctx, userId, afterId, EXPORT_PAGE_SIZE)
+		if err != nil {
+			return page, err
+		}
+		page.notes = notes
+
+		for _, note := range notes {
+			page.tags[note.Id], err = queries.GetNoteTagNames(tx, ctx, note.Id)
+			if err != nil {
+				return page, err
+			}
+			page.attachments[note.Id], err = queries.GetAttachments(tx, ctx, note.Id)
+			if err != nil {
+				return page, err
+			}
+			page.comments[note.Id], err = queries.GetNoteComments(tx, ctx, note.Id)
+			if err != nil {
+				return page, err
+			}
+		}
+		return page, nil
+	})()
+
+	if err != nil {
+		return notesPage{}, err
+	}
+
+	page, ok := data.(notesPage)
+	if !ok {
+		return notesPage{}, fmt.Errorf(api.ERROR_ASSERT_RESULT_TYPE)
+	}
+	return page, nil
+}
+
+func writeNote(ctx context.Context, archive *zip.Writer, note entities.Note, tags []string, attachments []entities.Attachment, comments []entities.Comment) error {
+	baseName := fmt.Sprintf("%d-%s", note.Id, markdown.Slugify(note.Topic))
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	attachmentPaths := []string{}
+	for _, attachment := range attachments {
+		attachmentPaths = append(attachmentPaths, attachmentPath(baseName, attachment))
+	}
+
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: baseName + ".md", Method: zip.Deflate, Modified: note.LastUpdateDate})
+	if err != nil {
+		return fmt.Errorf("unable to add note '%d' to archive: %s", note.Id, err)
+	}
+	err = markdown.WriteDocument(w, []markdown.Field{
+		{Key: "id", Value: note.Id},
+		{Key: "topic", Value: note.Topic},
+		{Key: "tags", Value: tags},
+		{Key: "state", Value: note.State},
+		{Key: "visibility", Value: note.Visibility},
+		{Key: "created", Value: note.CreateDate},
+		{Key: "updated", Value: note.LastUpdateDate},
+		{Key: "attachments", Value: attachmentPaths},
+	}, note.Text)
+	if err != nil {
+		return fmt.Errorf("unable to write note '%d' to archive: %s", note.Id, err)
+	}
+
+	if len(comments) > 0 {
+		w, err = archive.CreateHeader(&zip.FileHeader{Name: baseName + EXPORT_COMMENTS_SUFFIX, Method: zip.Deflate, Modified: note.LastUpdateDate})
+		if err != nil {
+			return fmt.Errorf("unable to add comments of note '%d' to archive: %s", note.Id, err)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(convertComments(comments))
+		if err != nil {
+			return fmt.Errorf("unable to write comments of note '%d' to archive: %s", note.Id, err)
+		}
+	}
+
+	for i, attachment := range attachments {
+		err = writeAttachment(ctx, archive, attachmentPaths[i], attachment)
+		if err != nil {
+			return fmt.Errorf("unable to write attachment '%d' of note '%d' to archive: %s", attachment.Id, note.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func writeAttachment(ctx context.Context, archive *zip.Writer, name string, attachment entities.Attachment) error {
+	blob, err := storage.GetInstance().Open(ctx, attachment.Checksum)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: attachment.CreateDate})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, blob)
+	return err
+}
+
+// attachments are placed into the folder named as the note file, the id prefix makes the names unique
+func attachmentPath(baseName string, attachment entities.Attachment) string {
+	fileName := path.Base(strings.ReplaceAll(attachment.FileName, "\\", "/"))
+	if fileName == "." || fileName == "/" || fileName == ".." {
+		fileName = "file"
+	}
+	return path.Join(EXPORT_ATTACHMENTS_FOLDER, baseName, fmt.Sprintf("%d-%s", attachment.Id, fileName))
+}
+
+func convertComments(comments []entities.Comment) []CommentDTO {
+	var result []CommentDTO
+	for _, comment := range comments {
+		result = append(result, CommentDTO{
+			Id:              comment.Id,
+			UserId:          comment.UserId,
+			LinkedCommentId: comment.LinkdedCommentId,
+			Text:            comment.Text,
+			State:           comment.State,
+			CreateDate:      comment.CreateDate,
+			LastUpdateDate:  comment.LastUpdateDate,
+		})
+	}
+	return result
+}
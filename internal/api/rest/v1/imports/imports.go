@@ -0,0 +1,340 @@
+package imports
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/moderation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FORM_FILE_FIELD_NAME      string = "file"
+	MULTIPART_OVERHEAD_BYTES  int64  = 1 << 20
+	DEFAULT_MAX_SIZE_IN_BYTES string = "52428800" // 50 MB
+	NOTE_MAX_SIZE_IN_BYTES    int64  = 5 << 20
+	MARKDOWN_FILE_EXTENSION   string = ".md"
+)
+
+const (
+	IMPORT_FILE_STATUS_CREATED   string = "CREATED"
+	IMPORT_FILE_STATUS_UPDATED   string = "UPDATED"
+	IMPORT_FILE_STATUS_UNCHANGED string = "UNCHANGED"
+	IMPORT_FILE_STATUS_FAILED    string = "FAILED"
+)
+
+var errorNoteIsNotEditable = errors.New("note is not editable by its author")
+
+var maxSizeInBytes int64
+var once sync.Once
+
+func Setup() {
+	once.Do(func() {
+		size, err := strconv.ParseInt(utils.EnvVarDefault("IMPORT_MAX_SIZE_IN_BYTES", DEFAULT_MAX_SIZE_IN_BYTES), 10, 64)
+		if err != nil {
+			log.Fatalf("Wrong value of environment variable: IMPORT_MAX_SIZE_IN_BYTES. It should be integer number")
+		}
+		maxSizeInBytes = size
+	})
+}
+
+type ImportFileDTO struct {
+	Path   string
+	Status string
+	NoteId int    `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+type ImportResultDTO struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    int
+	Files     []ImportFileDTO
+}
+
+// imports markdown files of zip archive (e.g. Obsidian vault) as notes of the caller. Every file is imported in its own transaction,
+// so the broken files do not prevent importing the others. The files are matched with the notes by their paths, so the archive could be imported again:
+// changed files update their notes and unchanged ones are skipped
+func ImportNotes(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSizeInBytes+MULTIPART_OVERHEAD_BYTES)
+	fileHeader, err := c.FormFile(FORM_FILE_FIELD_NAME)
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_IMPORT_ARCHIVE_IS_TOO_LARGE, maxSizeInBytes))
+			return
+		}
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_IMPORT_ARCHIVE_IS_MISSED, FORM_FILE_FIELD_NAME))
+		return
+	}
+	if fileHeader.Size > maxSizeInBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, fmt.Sprintf(api.ERROR_IMPORT_ARCHIVE_IS_TOO_LARGE, maxSizeInBytes))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to import notes")
+		log.Printf("Unable to import notes : %s", err)
+		return
+	}
+	defer file.Close()
+
+	archive, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ERROR_IMPORT_ARCHIVE_HAS_WRONG_FORMAT)
+		return
+	}
+
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, f := range archive.File {
+		if isNoteFile(f) {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	result := &ImportResultDTO{Files: make([]ImportFileDTO, 0, len(files))}
+	for _, f := range files {
+		fileResult := importFile(userId, f)
+		switch fileResult.Status {
+		case IMPORT_FILE_STATUS_CREATED:
+			result.Created++
+		case IMPORT_FILE_STATUS_UPDATED:
+			result.Updated++
+		case IMPORT_FILE_STATUS_UNCHANGED:
+			result.Unchanged++
+		default:
+			result.Failed++
+		}
+		result.Files = append(result.Files, fileResult)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// markdown files only, hidden folders (e.g. '.obsidian', '.trash') and macOS metadata are skipped
+func isNoteFile(f *zip.File) bool {
+	if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), MARKDOWN_FILE_EXTENSION) {
+		return false
+	}
+	for _, segment := range strings.Split(f.Name, "/") {
+		if strings.HasPrefix(segment, ".") || segment == "__MACOSX" {
+			return false
+		}
+	}
+	return true
+}
+
+func importFile(userId int, f *zip.File) ImportFileDTO {
+	sourcePath := path.Clean(strings.ReplaceAll(f.Name, "\\", "/"))
+	result := ImportFileDTO{Path: sourcePath, Status: IMPORT_FILE_STATUS_FAILED}
+
+	if path.IsAbs(sourcePath) || strings.HasPrefix(sourcePath, "../") {
+		result.Error = "wrong file path"
+		return result
+	}
+	if f.UncompressedSize64 > uint64(NOTE_MAX_SIZE_IN_BYTES) {
+		result.Error = fmt.Sprintf("file is too large. Max size in bytes: %d", NOTE_MAX_SIZE_IN_BYTES)
+		return result
+	}
+
+	content, err := readFile(f)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	hash := sha256.Sum256(content)
+	contentHash := hex.EncodeToString(hash[:])
+
+	note, err := ParseNoteFile(sourcePath, content, f.Modified)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		return saveNote(tx, ctx, userId, sourcePath, contentHash, note)
+	})()
+
+	if err != nil {
+		if err == db.ErrorTagDuplicateKey {
+			result.Error = "tag was created concurrently, please try again"
+		} else if err == errorNoteIsNotEditable {
+			result.Error = "note is blocked by the moderator"
+		} else {
+			result.Error = "unable to save note"
+			log.Printf("Unable to import note from '%s' : %s", sourcePath, err)
+		}
+		return result
+	}
+
+	saved, ok := data.(ImportFileDTO)
+	if !ok {
+		result.Error = "unable to save note"
+		log.Printf("Unable to import note from '%s' : %s", sourcePath, api.ERROR_ASSERT_RESULT_TYPE)
+		return result
+	}
+	saved.Path = sourcePath
+	return saved
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %s", err)
+	}
+	defer r.Close()
+
+	// the declared size could be wrong, so the reading is limited too
+	content, err := io.ReadAll(io.LimitReader(r, NOTE_MAX_SIZE_IN_BYTES+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %s", err)
+	}
+	if int64(len(content)) > NOTE_MAX_SIZE_IN_BYTES {
+		return nil, fmt.Errorf("file is too large. Max size in bytes: %d", NOTE_MAX_SIZE_IN_BYTES)
+	}
+	return content, nil
+}
+
+// creates the note or updates the one imported from the same path before
+func saveNote(tx *sql.Tx, ctx context.Context, userId int, sourcePath string, contentHash string, note ImportedNote) (ImportFileDTO, error) {
+	result := ImportFileDTO{}
+
+	existingNoteId := -1
+	existingState := ""
+	noteImport, err := queries.GetNoteImport(tx, ctx, userId, sourcePath)
+	if err != nil && err != sql.ErrNoRows {
+		return result, err
+	}
+	if err == nil {
+		existingNote, err := queries.GetNote(tx, ctx, noteImport.NoteId)
+		if err != nil && err != sql.ErrNoRows {
+			return result, err
+		}
+		// the deleted notes and the notes given to other users are imported again as new ones
+		if err == nil && existingNote.UserId == userId {
+			existingNoteId = existingNote.Id
+			existingState = existingNote.State
+		}
+	}
+
+	if existingNoteId != -1 && noteImport.ContentHash == contentHash {
+		return ImportFileDTO{Status: IMPORT_FILE_STATUS_UNCHANGED, NoteId: existingNoteId}, nil
+	}
+
+	tagIds, err := getOrCreateTags(tx, ctx, note.Tags)
+	if err != nil {
+		return result, err
+	}
+
+	noteId := existingNoteId
+	if noteId != -1 {
+		result.Status = IMPORT_FILE_STATUS_UPDATED
+		// the import follows the workflow too: the note keeps its state if the new one is not reachable and the blocked note is not changed at all
+		if !entities.IsNoteStateTransitionAllowed(existingState, note.State) {
+			if !entities.IsNoteStateTransitionAllowed(existingState, existingState) {
+				return result, errorNoteIsNotEditable
+			}
+			note.State = existingState
+		}
+		err = queries.UpdateNote(tx, ctx, noteId, note.Text, note.Topic, tagIds[0], userId, note.State)
+		if err != nil {
+			return result, err
+		}
+		err = queries.SetNoteTags(tx, ctx, noteId, tagIds[1:])
+	} else {
+		result.Status = IMPORT_FILE_STATUS_CREATED
+		noteId, err = queries.CreateNote(tx, ctx, note.Text, note.Topic, tagIds[0], userId, note.State)
+		if err != nil {
+			return result, err
+		}
+		err = queries.AddNoteTags(tx, ctx, noteId, tagIds[1:])
+	}
+	if err != nil {
+		return result, err
+	}
+
+	err = queries.UpdateNoteVisibility(tx, ctx, noteId, note.Visibility)
+	if err != nil {
+		return result, err
+	}
+	err = queries.UpdateNoteDates(tx, ctx, noteId, note.CreateDate, note.LastUpdateDate)
+	if err != nil {
+		return result, err
+	}
+	err = links.SaveNoteLinks(tx, ctx, noteId, userId, note.Topic, note.Text)
+	if err != nil {
+		return result, err
+	}
+	err = moderation.FlagContent(tx, ctx, entities.CONTENT_TYPE_NOTE, noteId, note.Topic, note.Text)
+	if err != nil {
+		return result, err
+	}
+	err = mentions.SaveNoteMentions(tx, ctx, noteId, userId, note.Topic, note.Text)
+	if err != nil {
+		return result, err
+	}
+	action := entities.EVENT_ACTION_CREATED
+	if result.Status == IMPORT_FILE_STATUS_UPDATED {
+		action = entities.EVENT_ACTION_UPDATED
+	}
+	err = events.PublishNoteEvent(tx, ctx, noteId, action)
+	if err != nil {
+		return result, err
+	}
+	err = queries.SaveNoteImport(tx, ctx, userId, sourcePath, contentHash, noteId)
+	if err != nil {
+		return result, err
+	}
+
+	result.NoteId = noteId
+	return result, nil
+}
+
+func getOrCreateTags(tx *sql.Tx, ctx context.Context, names []string) ([]int, error) {
+	var result []int
+	for _, name := range names {
+		tag, err := queries.GetTagByName(tx, ctx, name)
+		if err == nil {
+			result = append(result, tag.Id)
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+		tagId, err := queries.CreateTag(tx, ctx, name, entities.TAG_STATE_NEW)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tagId)
+	}
+	return result, nil
+}
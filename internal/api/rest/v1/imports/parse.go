@@ -0,0 +1,149 @@
+package imports
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+)
+
+const (
+	TOPIC_MAX_LENGTH    int    = 512
+	TAG_NAME_MAX_LENGTH int    = 256
+	DEFAULT_TAG_NAME    string = "imported"
+)
+
+// front matter keys in order of priority, the keys of Obsidian and the keys of our export are supported
+var topicKeys = []string{"topic", "title"}
+var tagsKeys = []string{"tags", "tag"}
+var createDateKeys = []string{"created", "date", "created_at", "creation_date"}
+var lastUpdateDateKeys = []string{"updated", "modified", "updated_at", "last_modified"}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+type ImportedNote struct {
+	Topic          string
+	Text           string
+	Tags           []string // the first one is the primary tag of the note
+	State          string
+	Visibility     string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+// converts markdown file to the note. The file name is used as topic if the front matter has no one,
+// the modification time of the file is used if the front matter has no dates
+func ParseNoteFile(sourcePath string, content []byte, modified time.Time) (ImportedNote, error) {
+	var note ImportedNote
+
+	if !utf8.Valid(content) {
+		return note, fmt.Errorf("file is not UTF-8 encoded text")
+	}
+
+	frontMatter, body, err := markdown.ParseDocument(string(content))
+	if err != nil {
+		return note, fmt.Errorf("wrong front matter: %s", err)
+	}
+
+	note.Text = body
+	if strings.TrimSpace(note.Text) == "" {
+		return note, fmt.Errorf("note text is empty")
+	}
+
+	note.Topic = firstString(frontMatter, topicKeys)
+	if note.Topic == "" {
+		note.Topic = strings.TrimSuffix(path.Base(sourcePath), path.Ext(sourcePath))
+	}
+	note.Topic = truncate(note.Topic, TOPIC_MAX_LENGTH)
+
+	var tags []string
+	for _, key := range tagsKeys {
+		for _, value := range frontMatter.List(key) {
+			// Obsidian allows to list tags in one string separated by commas or spaces
+			tags = append(tags, strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })...)
+		}
+	}
+	tags = append(tags, markdown.ExtractHashtags(body)...)
+	note.Tags = normalizeTags(tags)
+	if len(note.Tags) == 0 {
+		note.Tags = []string{DEFAULT_TAG_NAME}
+	}
+
+	// the other states, e.g. NEW of the older exports, are imported as published
+	note.State = entities.NOTE_STATE_PUBLISHED
+	if state, _ := frontMatter.String("state"); utils.Contains(entities.GetPossibleNoteWorkflowStates(), state) {
+		note.State = state
+	}
+
+	// the imported notes are private unless the visibility is set explicitly
+	note.Visibility = entities.NOTE_VISIBILITY_PRIVATE
+	if visibility, _ := frontMatter.String("visibility"); utils.Contains(entities.GetPossibleNoteVisibilities(), visibility) {
+		note.Visibility = visibility
+	}
+
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	note.CreateDate, err = parseDate(firstString(frontMatter, createDateKeys), modified)
+	if err != nil {
+		return note, err
+	}
+	note.LastUpdateDate, err = parseDate(firstString(frontMatter, lastUpdateDateKeys), modified)
+	if err != nil {
+		return note, err
+	}
+	if note.LastUpdateDate.Before(note.CreateDate) {
+		note.LastUpdateDate = note.CreateDate
+	}
+
+	return note, nil
+}
+
+func firstString(frontMatter markdown.FrontMatter, keys []string) string {
+	for _, key := range keys {
+		if value, ok := frontMatter.String(key); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func normalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = truncate(strings.TrimPrefix(strings.TrimSpace(tag), "#"), TAG_NAME_MAX_LENGTH)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func parseDate(value string, defaultValue time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	for _, layout := range dateLayouts {
+		date, err := time.Parse(layout, value)
+		if err == nil {
+			return date, nil
+		}
+	}
+	return defaultValue, fmt.Errorf("wrong date format: '%s'", value)
+}
+
+func truncate(str string, maxLength int) string {
+	runes := []rune(str)
+	if len(runes) <= maxLength {
+		return str
+	}
+	return string(runes[:maxLength])
+}
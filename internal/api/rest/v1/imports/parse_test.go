@@ -0,0 +1,57 @@
+//go:build unit
+// +build unit
+
+package imports_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/imports"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNoteFile(t *testing.T) {
+	content := "---\n" +
+		"title: Dijkstra\n" +
+		"tags: algorithms, graphs\n" +
+		"created: 2021-03-01\n" +
+		"updated: 2021-03-05T10:00:00Z\n" +
+		"---\n" +
+		"Shortest paths #graphs #weighted\n"
+
+	note, err := imports.ParseNoteFile("vault/algo/Dijkstra.md", []byte(content), time.Time{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Dijkstra", note.Topic)
+	assert.Equal(t, "Shortest paths #graphs #weighted\n", note.Text)
+	assert.Equal(t, []string{"algorithms", "graphs", "weighted"}, note.Tags)
+	assert.Equal(t, entities.NOTE_STATE_PUBLISHED, note.State)
+	assert.Equal(t, entities.NOTE_VISIBILITY_PRIVATE, note.Visibility)
+	assert.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), note.CreateDate)
+	assert.Equal(t, time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC), note.LastUpdateDate)
+}
+
+func TestParseNoteFileWithoutFrontMatter(t *testing.T) {
+	modified := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	note, err := imports.ParseNoteFile("Linear algebra.md", []byte("Vectors"), modified)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Linear algebra", note.Topic)
+	assert.Equal(t, []string{imports.DEFAULT_TAG_NAME}, note.Tags)
+	assert.Equal(t, modified, note.CreateDate)
+	assert.Equal(t, modified, note.LastUpdateDate)
+}
+
+func TestParseNoteFileErrors(t *testing.T) {
+	_, err := imports.ParseNoteFile("a.md", []byte{0xff, 0xfe}, time.Time{})
+	assert.NotNil(t, err)
+
+	_, err = imports.ParseNoteFile("a.md", []byte("---\ntitle: a\n---\n"), time.Time{})
+	assert.NotNil(t, err)
+
+	_, err = imports.ParseNoteFile("a.md", []byte("---\ncreated: yesterday\n---\ntext"), time.Time{})
+	assert.NotNil(t, err)
+}
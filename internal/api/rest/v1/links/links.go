@@ -0,0 +1,222 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/gin-gonic/gin"
+)
+
+// the same as max length of the note topic, the longer links could not point to any note
+const LINK_TARGET_MAX_LENGTH int = 512
+
+type NoteLinkDTO struct {
+	Target       string
+	TargetNoteId *int
+	Dangling     bool
+}
+
+type NoteLinkListDTO struct {
+	Count int
+	Data  []NoteLinkDTO
+}
+
+type BacklinkDTO struct {
+	NoteId int
+	Topic  string
+	UserId int
+}
+
+type BacklinkListDTO struct {
+	Count int
+	Data  []BacklinkDTO
+}
+
+type GraphNodeDTO struct {
+	Id    int
+	Topic string
+	TagId int
+}
+
+type GraphEdgeDTO struct {
+	Source int
+	Target int
+}
+
+type GraphDTO struct {
+	Nodes []GraphNodeDTO
+	Edges []GraphEdgeDTO
+}
+
+type graph struct {
+	nodes []entities.NoteGraphNode
+	edges []entities.NoteLink
+}
+
+// saves [[wiki links]] of the note text and links the dangling links of the user's notes to this note by its topic.
+// It should be called within the transaction which creates or updates the note
+func SaveNoteLinks(tx *sql.Tx, ctx context.Context, noteId int, userId int, topic string, text string) error {
+	var targets []string
+	for _, target := range markdown.ExtractWikiLinks(text) {
+		if utf8.RuneCountInString(target) <= LINK_TARGET_MAX_LENGTH {
+			targets = append(targets, target)
+		}
+	}
+	err := queries.ReplaceNoteLinks(tx, ctx, noteId, userId, targets)
+	if err != nil {
+		return err
+	}
+	return queries.ResolveNoteLinks(tx, ctx, noteId, userId, topic)
+}
+
+func convertNoteLinks(links []entities.NoteLink) []NoteLinkDTO {
+	if links == nil {
+		return make([]NoteLinkDTO, 0)
+	}
+	var result []NoteLinkDTO
+	for _, link := range links {
+		dto := NoteLinkDTO{Target: link.Target, Dangling: !link.TargetNoteId.Valid}
+		if link.TargetNoteId.Valid {
+			targetNoteId := int(link.TargetNoteId.Int32)
+			dto.TargetNoteId = &targetNoteId
+		}
+		result = append(result, dto)
+	}
+	return result
+}
+
+func convertBacklinks(notes []entities.Note) []BacklinkDTO {
+	if notes == nil {
+		return make([]BacklinkDTO, 0)
+	}
+	var result []BacklinkDTO
+	for _, note := range notes {
+		result = append(result, BacklinkDTO{NoteId: note.Id, Topic: note.Topic, UserId: note.UserId})
+	}
+	return result
+}
+
+func convertGraph(g graph) GraphDTO {
+	result := GraphDTO{Nodes: make([]GraphNodeDTO, 0, len(g.nodes)), Edges: make([]GraphEdgeDTO, 0, len(g.edges))}
+	for _, node := range g.nodes {
+		result.Nodes = append(result.Nodes, GraphNodeDTO{Id: node.Id, Topic: node.Topic, TagId: node.TagId})
+	}
+	for _, edge := range g.edges {
+		result.Edges = append(result.Edges, GraphEdgeDTO{Source: edge.SourceNoteId, Target: int(edge.TargetNoteId.Int32)})
+	}
+	return result
+}
+
+// returns outgoing links of the note, the links to missing notes and to notes which are not visible to the caller are marked as dangling
+func GetNoteLinks(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		links, err := queries.GetNoteLinks(tx, ctx, noteId, userId)
+		return links, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get note links")
+		log.Printf("Unable to get note links : %s", err)
+		return
+	}
+
+	links, ok := data.([]entities.NoteLink)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note links")
+		log.Printf("Unable to get note links : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &NoteLinkListDTO{Data: convertNoteLinks(links), Count: len(links)}
+	c.JSON(http.StatusOK, result)
+}
+
+func GetNoteBacklinks(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		notes, err := queries.GetNoteBacklinks(tx, ctx, noteId, userId)
+		return notes, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get note backlinks")
+		log.Printf("Unable to get note backlinks : %s", err)
+		return
+	}
+
+	notes, ok := data.([]entities.Note)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note backlinks")
+		log.Printf("Unable to get note backlinks : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &BacklinkListDTO{Data: convertBacklinks(notes), Count: len(notes)}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the graph of notes visible to the caller, the optional query parameter 'tagId' limits the graph by notes with the tag
+func GetGraph(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	tagId := 0
+	if tagIdStr := c.Query("tagId"); tagIdStr != "" {
+		var err error
+		if tagId, err = strconv.Atoi(tagIdStr); err != nil {
+			c.JSON(http.StatusBadRequest, api.ERROR_ID_WRONG_FORMAT)
+			return
+		}
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		nodes, edges, err := queries.GetNotesGraph(tx, ctx, userId, tagId)
+		return graph{nodes: nodes, edges: edges}, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get notes graph")
+		log.Printf("Unable to get notes graph : %s", err)
+		return
+	}
+
+	g, ok := data.(graph)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notes graph")
+		log.Printf("Unable to get notes graph : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertGraph(g))
+}
@@ -0,0 +1,202 @@
+package mentions
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DEFAULT_MAX_MENTIONS_PER_TEXT string = "10"
+	DEFAULT_MAX_MENTIONS_PER_HOUR string = "50"
+)
+
+var maxMentionsPerText int
+var maxMentionsPerHour int
+var once sync.Once
+
+func Setup() {
+	once.Do(func() {
+		var err error
+		maxMentionsPerText, err = strconv.Atoi(utils.EnvVarDefault("MENTIONS_MAX_PER_TEXT", DEFAULT_MAX_MENTIONS_PER_TEXT))
+		if err != nil || maxMentionsPerText <= 0 {
+			log.Fatalf("Wrong value of environment variable: MENTIONS_MAX_PER_TEXT. It should be positive integer number")
+		}
+		maxMentionsPerHour, err = strconv.Atoi(utils.EnvVarDefault("MENTIONS_MAX_PER_HOUR", DEFAULT_MAX_MENTIONS_PER_HOUR))
+		if err != nil || maxMentionsPerHour <= 0 {
+			log.Fatalf("Wrong value of environment variable: MENTIONS_MAX_PER_HOUR. It should be positive integer number")
+		}
+	})
+}
+
+type MentionDTO struct {
+	Id         int
+	ActorId    int
+	NoteId     int
+	CommentId  *int `json:",omitempty"`
+	NoteTopic  string
+	CreateDate time.Time
+}
+
+type MentionListDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []MentionDTO
+}
+
+func convertMentions(mentions []entities.Mention) []MentionDTO {
+	if mentions == nil {
+		return make([]MentionDTO, 0)
+	}
+	var result []MentionDTO
+	for _, mention := range mentions {
+		result = append(result, convertMention(mention))
+	}
+	return result
+}
+
+func convertMention(mention entities.Mention) MentionDTO {
+	result := MentionDTO{Id: mention.Id, ActorId: mention.ActorId, NoteId: mention.NoteId, NoteTopic: mention.NoteTopic, CreateDate: mention.CreateDate}
+	if mention.CommentId.Valid {
+		commentId := int(mention.CommentId.Int32)
+		result.CommentId = &commentId
+	}
+	return result
+}
+
+// saves the mentions of the note text within the transaction of the change and notifies the newly mentioned users
+func SaveNoteMentions(tx *sql.Tx, ctx context.Context, noteId int, actorId int, topic string, text string) error {
+	return saveMentions(tx, ctx, noteId, sql.NullInt32{}, actorId, text, topic)
+}
+
+// saves the mentions of the comment text within the transaction of the change and notifies the newly mentioned users
+func SaveCommentMentions(tx *sql.Tx, ctx context.Context, noteId int, commentId int, actorId int, text string) error {
+	return saveMentions(tx, ctx, noteId, sql.NullInt32{Int32: int32(commentId), Valid: true}, actorId, text, notifications.Excerpt(text))
+}
+
+// replaces the mentions of the text. Against the spam only the first logins of the text are taken, the users who are not able to see the note
+// are not mentioned, the users who are mentioned already are not notified again and the new mentions of the actor are limited per hour
+func saveMentions(tx *sql.Tx, ctx context.Context, noteId int, commentId sql.NullInt32, actorId int, text string, notificationText string) error {
+	logins := markdown.ExtractMentions(text)
+	if len(logins) > maxMentionsPerText {
+		logins = logins[:maxMentionsPerText]
+	}
+
+	var userIds []int
+	if len(logins) > 0 {
+		candidateIds, err := queries.GetUserIdsByLogins(tx, ctx, logins)
+		if err != nil {
+			return err
+		}
+		for _, userId := range candidateIds {
+			if userId == actorId {
+				continue
+			}
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, userId)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if permission != entities.NOTE_PERMISSION_NONE {
+				userIds = append(userIds, userId)
+			}
+		}
+	}
+
+	err := queries.DeleteMentionsExcept(tx, ctx, noteId, commentId, userIds)
+	if err != nil {
+		return err
+	}
+	mentionedIds, err := queries.GetMentionedUserIds(tx, ctx, noteId, commentId)
+	if err != nil {
+		return err
+	}
+	mentioned := make(map[int]bool)
+	for _, userId := range mentionedIds {
+		mentioned[userId] = true
+	}
+	var newIds []int
+	for _, userId := range userIds {
+		if !mentioned[userId] {
+			newIds = append(newIds, userId)
+		}
+	}
+	if len(newIds) == 0 {
+		return nil
+	}
+
+	count, err := queries.CountMentionsByActorSince(tx, ctx, actorId, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	allowed := maxMentionsPerHour - count
+	if allowed <= 0 {
+		return nil
+	}
+	if len(newIds) > allowed {
+		newIds = newIds[:allowed]
+	}
+
+	for _, userId := range newIds {
+		_, err = queries.CreateMention(tx, ctx, entities.Mention{UserId: userId, ActorId: actorId, NoteId: noteId, CommentId: commentId})
+		if err != nil {
+			return err
+		}
+		err = notifications.Notify(tx, ctx, entities.Notification{
+			UserId:    userId,
+			Type:      entities.NOTIFICATION_TYPE_MENTIONED,
+			ActorId:   sql.NullInt32{Int32: int32(actorId), Valid: true},
+			NoteId:    sql.NullInt32{Int32: int32(noteId), Valid: true},
+			CommentId: commentId,
+			Text:      notificationText,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the mentions of the caller in the notes and the comments which are visible to the caller
+func GetMentions(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		mentions, err := queries.GetUserMentions(tx, ctx, userId, limit, offset)
+		return mentions, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get mentions")
+		log.Printf("Unable to get mentions : %s", err)
+		return
+	}
+
+	mentions, ok := data.([]entities.Mention)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get mentions")
+		log.Printf("Unable to get mentions : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &MentionListDTO{Count: len(mentions), Offset: offset, Limit: limit, Data: convertMentions(mentions)})
+}
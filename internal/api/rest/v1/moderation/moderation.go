@@ -0,0 +1,425 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/wordfilter"
+	"github.com/gin-gonic/gin"
+)
+
+var errorContentIsBlocked = errors.New("content is blocked already")
+var errorContentHasNoOpenReports = errors.New("content has no open reports")
+
+var wordFilter *wordfilter.Filter
+var once sync.Once
+
+// the filter is turned off if the word list is empty
+func Setup() {
+	once.Do(func() {
+		wordFilter = wordfilter.New(wordfilter.ParseList(utils.EnvVarDefault("MODERATION_WORD_LIST", "")))
+	})
+}
+
+type ModerationQueueItemDTO struct {
+	ContentType     string
+	ContentId       int
+	AuthorId        int
+	State           string
+	Preview         string
+	ReportsCount    int
+	Reasons         []string
+	Flagged         bool
+	FirstReportDate time.Time
+	LastReportDate  time.Time
+}
+
+type ModerationQueueDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []ModerationQueueItemDTO
+}
+
+type ModerationLogEntryDTO struct {
+	Id            int
+	ModeratorId   int
+	ContentType   string
+	ContentId     int
+	Action        string
+	Reason        string
+	PreviousState string
+	ReportsCount  int
+	CreateDate    time.Time
+}
+
+type ModerationLogDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []ModerationLogEntryDTO
+}
+
+// the reason is required for the blocking only
+type ModerationActionDTO struct {
+	Reason string `json:"reason" binding:"max=1024"`
+}
+
+func convertQueueItems(items []entities.ModerationQueueItem) []ModerationQueueItemDTO {
+	if items == nil {
+		return make([]ModerationQueueItemDTO, 0)
+	}
+	var result []ModerationQueueItemDTO
+	for _, item := range items {
+		result = append(result, ModerationQueueItemDTO{
+			ContentType:     item.ContentType,
+			ContentId:       item.ContentId,
+			AuthorId:        item.AuthorId,
+			State:           item.State,
+			Preview:         notifications.Excerpt(item.Text),
+			ReportsCount:    item.ReportsCount,
+			Reasons:         item.Reasons,
+			Flagged:         item.Flagged,
+			FirstReportDate: item.FirstReportDate,
+			LastReportDate:  item.LastReportDate,
+		})
+	}
+	return result
+}
+
+func convertLogEntries(entries []entities.ModerationLogEntry) []ModerationLogEntryDTO {
+	if entries == nil {
+		return make([]ModerationLogEntryDTO, 0)
+	}
+	var result []ModerationLogEntryDTO
+	for _, entry := range entries {
+		result = append(result, ModerationLogEntryDTO(entry))
+	}
+	return result
+}
+
+// flags the content if it contains any word of the list, the flagged content waits for the review in the moderation queue
+func FlagContent(tx *sql.Tx, ctx context.Context, contentType string, contentId int, texts ...string) error {
+	if wordFilter == nil || wordFilter.IsEmpty() {
+		return nil
+	}
+	words := wordFilter.Match(texts...)
+	if len(words) == 0 {
+		return nil
+	}
+	return queries.FlagContent(tx, ctx, contentType, contentId, "Matched words: "+strings.Join(words, ", "))
+}
+
+// returns the content type by the name of the resource in the path, e.g. 'notes'
+func parseContentType(c *gin.Context) (string, bool) {
+	switch c.Param("type") {
+	case "notes":
+		return entities.CONTENT_TYPE_NOTE, true
+	case "comments":
+		return entities.CONTENT_TYPE_COMMENT, true
+	}
+	c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to moderate content. Wrong 'type' value. Possible values: %v", []string{"notes", "comments"}))
+	return "", false
+}
+
+func parseContentTypeQuery(c *gin.Context, message string) (string, bool) {
+	contentType := c.Query("type")
+	if contentType != "" && !utils.Contains(entities.GetPossibleContentTypes(), contentType) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("%s. Wrong 'type' value. Possible values: %v", message, entities.GetPossibleContentTypes()))
+		return "", false
+	}
+	return contentType, true
+}
+
+// the moderated note or comment
+type content struct {
+	state     string
+	authorId  int
+	noteId    int
+	commentId int
+}
+
+func getContent(tx *sql.Tx, ctx context.Context, contentType string, contentId int) (content, error) {
+	if contentType == entities.CONTENT_TYPE_NOTE {
+		note, err := queries.GetNote(tx, ctx, contentId)
+		return content{state: note.State, authorId: note.UserId, noteId: note.Id}, err
+	}
+	comment, err := queries.GetComment(tx, ctx, contentId)
+	return content{state: comment.State, authorId: comment.UserId, noteId: comment.NoteId, commentId: comment.Id}, err
+}
+
+// notifies the author about the blocking or the unblocking of the content, the moderator is not disclosed
+func notifyAuthor(tx *sql.Tx, ctx context.Context, moderated content, notificationType string, reason string) error {
+	notification := entities.Notification{
+		UserId: moderated.authorId,
+		Type:   notificationType,
+		NoteId: sql.NullInt32{Int32: int32(moderated.noteId), Valid: true},
+		Text:   reason,
+	}
+	if moderated.commentId != 0 {
+		notification.CommentId = sql.NullInt32{Int32: int32(moderated.commentId), Valid: true}
+	}
+	return notifications.Notify(tx, ctx, notification)
+}
+
+func setContentState(tx *sql.Tx, ctx context.Context, contentType string, contentId int, state string) error {
+	if contentType == entities.CONTENT_TYPE_NOTE {
+		return queries.UpdateNoteState(tx, ctx, contentId, state)
+	}
+	return queries.UpdateCommentState(tx, ctx, contentId, state)
+}
+
+// the blocked note is not public, so the event of blocking is published before it and the event of unblocking after it
+func publishContentEvent(tx *sql.Tx, ctx context.Context, contentType string, moderated content) error {
+	if contentType == entities.CONTENT_TYPE_NOTE {
+		return events.PublishNoteEvent(tx, ctx, moderated.noteId, entities.EVENT_ACTION_UPDATED)
+	}
+	return events.PublishCommentEvent(tx, ctx, moderated.noteId, moderated.commentId, entities.EVENT_ACTION_UPDATED)
+}
+
+func getBlockedState(contentType string) string {
+	if contentType == entities.CONTENT_TYPE_NOTE {
+		return entities.NOTE_STATE_BLOCKED
+	}
+	return entities.COMMENT_STATE_BLOCKED
+}
+
+// returns the state of the approved content which was blocked, the note is archived if its state before the blocking is unknown, so the author decides whether to publish it again
+func getApprovedState(tx *sql.Tx, ctx context.Context, contentType string, contentId int) (string, error) {
+	state, err := queries.GetStateBeforeBlocking(tx, ctx, contentType, contentId)
+	if err == sql.ErrNoRows {
+		if contentType == entities.CONTENT_TYPE_NOTE {
+			return entities.NOTE_STATE_ARCHIVED, nil
+		}
+		return entities.COMMENT_STATE_NEW, nil
+	}
+	return state, err
+}
+
+// returns the open reports grouped by content, optionally filtered by content 'type'
+func GetModerationQueue(c *gin.Context) {
+	if _, ok := access.CheckRole(c, entities.GetPossibleModeratorRoles()); !ok {
+		return
+	}
+
+	contentType, ok := parseContentTypeQuery(c, "Unable to get moderation queue")
+	if !ok {
+		return
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		items, err := queries.GetModerationQueue(tx, ctx, contentType, limit, offset)
+		return items, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get moderation queue")
+		log.Printf("Unable to get moderation queue : %s", err)
+		return
+	}
+
+	items, ok := data.([]entities.ModerationQueueItem)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get moderation queue")
+		log.Printf("Unable to get moderation queue : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &ModerationQueueDTO{Data: convertQueueItems(items), Count: len(items), Offset: offset, Limit: limit}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the decisions of the moderators, optionally filtered by content 'type' and 'contentId'
+func GetModerationLog(c *gin.Context) {
+	if _, ok := access.CheckRole(c, entities.GetPossibleModeratorRoles()); !ok {
+		return
+	}
+
+	contentType, ok := parseContentTypeQuery(c, "Unable to get moderation log")
+	if !ok {
+		return
+	}
+	contentId := 0
+	if contentIdStr := c.Query("contentId"); contentIdStr != "" {
+		var err error
+		contentId, err = strconv.Atoi(contentIdStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, api.ERROR_ID_WRONG_FORMAT)
+			return
+		}
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		entries, err := queries.GetModerationLog(tx, ctx, contentType, contentId, limit, offset)
+		return entries, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get moderation log")
+		log.Printf("Unable to get moderation log : %s", err)
+		return
+	}
+
+	entries, ok := data.([]entities.ModerationLogEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get moderation log")
+		log.Printf("Unable to get moderation log : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &ModerationLogDTO{Data: convertLogEntries(entries), Count: len(entries), Offset: offset, Limit: limit}
+	c.JSON(http.StatusOK, result)
+}
+
+// closes the open reports of the content as unfounded and restores the content if it is blocked
+func ApproveContent(c *gin.Context) {
+	moderate(c, entities.MODERATION_ACTION_APPROVE)
+}
+
+// blocks the content and closes its open reports, the content could be blocked without any report
+func BlockContent(c *gin.Context) {
+	moderate(c, entities.MODERATION_ACTION_BLOCK)
+}
+
+// closes the open reports of the content without any decision about the content itself, e.g. the reports are irrelevant
+func DismissContent(c *gin.Context) {
+	moderate(c, entities.MODERATION_ACTION_DISMISS)
+}
+
+func moderate(c *gin.Context, action string) {
+	contentType, ok := parseContentType(c)
+	if !ok {
+		return
+	}
+	contentId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// the body is optional for the actions which do not require the reason
+	var dto ModerationActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil && err != io.EOF {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+	dto.Reason = strings.TrimSpace(dto.Reason)
+	if action == entities.MODERATION_ACTION_BLOCK && dto.Reason == "" {
+		c.JSON(http.StatusBadRequest, api.ERROR_MODERATION_REASON_IS_MISSED)
+		return
+	}
+
+	moderatorId, ok := access.CheckRole(c, entities.GetPossibleModeratorRoles())
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		moderated, err := getContent(tx, ctx, contentType, contentId)
+		if err != nil {
+			return err
+		}
+		isBlocked := moderated.state == getBlockedState(contentType)
+		if action == entities.MODERATION_ACTION_BLOCK && isBlocked {
+			return errorContentIsBlocked
+		}
+
+		reportsCount, err := queries.CloseContentReports(tx, ctx, contentType, contentId, entities.GetReportStatusByModerationAction(action))
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case entities.MODERATION_ACTION_BLOCK:
+			err = publishContentEvent(tx, ctx, contentType, moderated)
+			if err != nil {
+				return err
+			}
+			err = setContentState(tx, ctx, contentType, contentId, getBlockedState(contentType))
+			if err != nil {
+				return err
+			}
+			err = notifyAuthor(tx, ctx, moderated, entities.NOTIFICATION_TYPE_CONTENT_BLOCKED, dto.Reason)
+		case entities.MODERATION_ACTION_APPROVE:
+			if !isBlocked && reportsCount == 0 {
+				return errorContentHasNoOpenReports
+			}
+			if isBlocked {
+				approvedState, err := getApprovedState(tx, ctx, contentType, contentId)
+				if err != nil {
+					return err
+				}
+				err = setContentState(tx, ctx, contentType, contentId, approvedState)
+				if err != nil {
+					return err
+				}
+				err = publishContentEvent(tx, ctx, contentType, moderated)
+				if err != nil {
+					return err
+				}
+				err = notifyAuthor(tx, ctx, moderated, entities.NOTIFICATION_TYPE_CONTENT_UNBLOCKED, dto.Reason)
+				if err != nil {
+					return err
+				}
+			}
+		case entities.MODERATION_ACTION_DISMISS:
+			if reportsCount == 0 {
+				return errorContentHasNoOpenReports
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = queries.CreateModerationLogEntry(tx, ctx, entities.ModerationLogEntry{
+			ModeratorId:   moderatorId,
+			ContentType:   contentType,
+			ContentId:     contentId,
+			Action:        action,
+			Reason:        dto.Reason,
+			PreviousState: moderated.state,
+			ReportsCount:  reportsCount,
+		})
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to moderate content")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorContentIsBlocked:
+			c.JSON(http.StatusConflict, api.ERROR_CONTENT_IS_BLOCKED_ALREADY)
+		case errorContentHasNoOpenReports:
+			c.JSON(http.StatusConflict, api.ERROR_CONTENT_HAS_NO_OPEN_REPORTS)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,144 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+var errorReportOfOwnContent = errors.New("report of own content")
+
+type ContentReportCreateDTO struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details" binding:"max=4096"`
+}
+
+// reports the note visible to the caller
+func ReportNote(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	dto, ok := bindReport(c)
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		note, err := queries.GetNote(tx, ctx, noteId)
+		if err != nil {
+			return -1, err
+		}
+		if note.UserId == userId {
+			return -1, errorReportOfOwnContent
+		}
+		return createReport(tx, ctx, entities.CONTENT_TYPE_NOTE, noteId, userId, dto)
+	})()
+
+	sendCreateReportResult(c, data, err)
+}
+
+// reports the comment of the note visible to the caller, the blocked comments are visible to their authors only
+func ReportComment(c *gin.Context) {
+	commentId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	dto, ok := bindReport(c)
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		comment, err := queries.GetComment(tx, ctx, commentId)
+		if err != nil {
+			return -1, err
+		}
+		if comment.UserId == userId {
+			return -1, errorReportOfOwnContent
+		}
+		if comment.State == entities.COMMENT_STATE_BLOCKED {
+			return -1, sql.ErrNoRows
+		}
+		permission, err := queries.GetNotePermission(tx, ctx, comment.NoteId, userId)
+		if err != nil {
+			return -1, err
+		}
+		if permission == entities.NOTE_PERMISSION_NONE {
+			return -1, sql.ErrNoRows
+		}
+		return createReport(tx, ctx, entities.CONTENT_TYPE_COMMENT, commentId, userId, dto)
+	})()
+
+	sendCreateReportResult(c, data, err)
+}
+
+func bindReport(c *gin.Context) (ContentReportCreateDTO, bool) {
+	var dto ContentReportCreateDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return dto, false
+	}
+
+	possibleReportReasons := entities.GetPossibleReportReasons()
+	if !utils.Contains(possibleReportReasons, dto.Reason) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to create report. Wrong 'Reason' value. Possible values: %v", possibleReportReasons))
+		return dto, false
+	}
+
+	return dto, true
+}
+
+func createReport(tx *sql.Tx, ctx context.Context, contentType string, contentId int, userId int, dto ContentReportCreateDTO) (int, error) {
+	return queries.CreateContentReport(tx, ctx, entities.ContentReport{
+		ContentType: contentType,
+		ContentId:   contentId,
+		ReporterId:  sql.NullInt32{Int32: int32(userId), Valid: true},
+		Reason:      dto.Reason,
+		Details:     dto.Details,
+	})
+}
+
+func sendCreateReportResult(c *gin.Context, data any, err error) {
+	if err != nil || data == -1 {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorReportOfOwnContent:
+			c.JSON(http.StatusBadRequest, api.ERROR_REPORT_OF_OWN_CONTENT)
+		case db.ErrorContentReportDuplicateKey:
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to create report")
+			log.Printf("Unable to create report : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
@@ -0,0 +1,520 @@
+package notebooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+var errorParentNotFound = errors.New("parent notebook is not found")
+var errorNotebookNotFound = errors.New("notebook is not found")
+var errorNotebookMoveMakesCycle = errors.New("notebook move makes cycle")
+var errorNotebookOrderMismatch = errors.New("notebook order does not match")
+
+type NotebookDTO struct {
+	Id             int
+	ParentId       *int `json:",omitempty"`
+	Name           string
+	Position       int
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type NotebookListDTO struct {
+	Count int
+	Data  []NotebookDTO
+}
+
+type NotebookCreateDTO struct {
+	Name     string `json:"name" binding:"required,max=256"`
+	ParentId int    `json:"parentId" binding:"min=0"` // optional, the root notebook is created if missed
+}
+
+type NotebookEditDTO struct {
+	Name string `json:"name" binding:"required,max=256"`
+}
+
+type NotebookMoveDTO struct {
+	ParentId int `json:"parentId" binding:"min=0"` // the notebook is moved to the roots if it is 0
+}
+
+type NotebookOrderDTO struct {
+	Ids []int `json:"ids" binding:"required"`
+}
+
+type NoteNotebookDTO struct {
+	NotebookId int `json:"notebookId" binding:"required,min=1"`
+}
+
+func convertNotebooks(notebooks []entities.Notebook) []NotebookDTO {
+	if notebooks == nil {
+		return make([]NotebookDTO, 0)
+	}
+	var result []NotebookDTO
+	for _, notebook := range notebooks {
+		result = append(result, convertNotebook(notebook))
+	}
+	return result
+}
+
+func convertNotebook(notebook entities.Notebook) NotebookDTO {
+	result := NotebookDTO{Id: notebook.Id, Name: notebook.Name, Position: notebook.Position, CreateDate: notebook.CreateDate, LastUpdateDate: notebook.LastUpdateDate}
+	if notebook.ParentId.Valid {
+		parentId := int(notebook.ParentId.Int32)
+		result.ParentId = &parentId
+	}
+	return result
+}
+
+// returns the children of the notebook 'parentId' of the caller in their order, the root notebooks if it is missed
+func GetNotebooks(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var parentId sql.NullInt32
+	if parentIdStr := c.Query("parentId"); parentIdStr != "" {
+		id, err := strconv.Atoi(parentIdStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "Unable to get notebooks. Wrong 'parentId' value. Expected number")
+			return
+		}
+		parentId = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		notebooks, err := queries.GetNotebooks(tx, ctx, userId, parentId)
+		return notebooks, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get notebooks")
+		log.Printf("Unable to get notebooks : %s", err)
+		return
+	}
+
+	notebooks, ok := data.([]entities.Notebook)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notebooks")
+		log.Printf("Unable to get notebooks : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &NotebookListDTO{Count: len(notebooks), Data: convertNotebooks(notebooks)})
+}
+
+func GetNotebook(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		notebook, err := queries.GetNotebook(tx, ctx, userId, notebookId)
+		return notebook, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get notebook")
+			log.Printf("Unable to get notebook : %s", err)
+		}
+		return
+	}
+
+	notebook, ok := data.(entities.Notebook)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notebook")
+		log.Printf("Unable to get notebook : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertNotebook(notebook))
+}
+
+// returns the notebook with all its descendants and their notes as the nested tree, the subtree is loaded by one recursive query
+func GetNotebookTree(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		subtree, err := queries.GetNotebookSubtree(tx, ctx, userId, notebookId)
+		if err != nil {
+			return nil, err
+		}
+		if len(subtree) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		var ids []int
+		for _, notebook := range subtree {
+			ids = append(ids, notebook.Id)
+		}
+		notes, err := queries.GetNotebookNotes(tx, ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		return BuildNotebookTree(subtree, notes), nil
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get notebook tree")
+			log.Printf("Unable to get notebook tree : %s", err)
+		}
+		return
+	}
+
+	tree, ok := data.(NotebookTreeDTO)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notebook tree")
+		log.Printf("Unable to get notebook tree : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// creates the notebook of the caller at the end of the children of the parent
+func CreateNotebook(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NotebookCreateDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		parentId, err := checkParent(tx, ctx, userId, dto.ParentId)
+		if err != nil {
+			return -1, err
+		}
+		result, err := queries.CreateNotebook(tx, ctx, userId, parentId, dto.Name)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == errorParentNotFound {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_NOTEBOOK_WRONG_REFERENCE, "parentId"))
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create notebook")
+			log.Printf("Unable to create notebook : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateNotebook(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NotebookEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.UpdateNotebookName(tx, ctx, userId, notebookId, dto.Name)
+	})()
+
+	sendEditResult(c, err, "Unable to update notebook")
+}
+
+// moves the notebook with all its descendants and notes under the new parent, the parent can not be inside the moved subtree
+func MoveNotebook(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NotebookMoveDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		subtree, err := queries.GetNotebookSubtree(tx, ctx, userId, notebookId)
+		if err != nil {
+			return err
+		}
+		if len(subtree) == 0 {
+			return sql.ErrNoRows
+		}
+		for _, notebook := range subtree {
+			if notebook.Id == dto.ParentId {
+				return errorNotebookMoveMakesCycle
+			}
+		}
+		parentId, err := checkParent(tx, ctx, userId, dto.ParentId)
+		if err != nil {
+			return err
+		}
+		return queries.MoveNotebook(tx, ctx, userId, notebookId, parentId)
+	})()
+
+	sendEditResult(c, err, "Unable to move notebook")
+}
+
+// deletes the notebook with all its descendants, their notes are kept without notebook
+func DeleteNotebook(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteNotebookSubtree(tx, ctx, userId, notebookId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete notebook")
+}
+
+// sets the order of the children of the notebook, all children are expected exactly once
+func OrderNotebookChildren(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var order NotebookOrderDTO
+
+	if err := c.ShouldBindJSON(&order); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetNotebook(tx, ctx, userId, notebookId)
+		if err != nil {
+			return err
+		}
+		children, err := queries.GetNotebooks(tx, ctx, userId, sql.NullInt32{Int32: int32(notebookId), Valid: true})
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for _, child := range children {
+			ids = append(ids, child.Id)
+		}
+		if !isPermutation(ids, order.Ids) {
+			return errorNotebookOrderMismatch
+		}
+		return queries.SetNotebookPositions(tx, ctx, userId, order.Ids)
+	})()
+
+	if err == errorNotebookOrderMismatch {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_COURSE_ORDER_MISMATCH, "children of the notebook"))
+		return
+	}
+	sendEditResult(c, err, "Unable to order notebooks")
+}
+
+// sets the order of the notes of the notebook, all its notes are expected exactly once
+func OrderNotebookNotes(c *gin.Context) {
+	notebookId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var order NotebookOrderDTO
+
+	if err := c.ShouldBindJSON(&order); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetNotebook(tx, ctx, userId, notebookId)
+		if err != nil {
+			return err
+		}
+		notes, err := queries.GetNotebookNotes(tx, ctx, []int{notebookId})
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for _, note := range notes {
+			ids = append(ids, note.NoteId)
+		}
+		if !isPermutation(ids, order.Ids) {
+			return errorNotebookOrderMismatch
+		}
+		return queries.SetNotebookNotePositions(tx, ctx, notebookId, order.Ids)
+	})()
+
+	if err == errorNotebookOrderMismatch {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_COURSE_ORDER_MISMATCH, "notes of the notebook"))
+		return
+	}
+	sendEditResult(c, err, "Unable to order notes of notebook")
+}
+
+// puts the own note of the caller to the end of the notebook, the note is moved out of its previous notebook
+func SetNoteNotebook(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto NoteNotebookDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetNotebook(tx, ctx, userId, dto.NotebookId)
+		if err == sql.ErrNoRows {
+			return errorNotebookNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return queries.SetNoteNotebook(tx, ctx, noteId, dto.NotebookId)
+	})()
+
+	sendEditResult(c, err, "Unable to set notebook of note")
+}
+
+func DeleteNoteNotebook(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteNoteNotebook(tx, ctx, noteId)
+	})()
+
+	sendEditResult(c, err, "Unable to remove note from notebook")
+}
+
+// returns the parent notebook of the user or the unset one for the roots if the id is 0
+func checkParent(tx *sql.Tx, ctx context.Context, userId int, parentId int) (sql.NullInt32, error) {
+	if parentId == 0 {
+		return sql.NullInt32{}, nil
+	}
+	_, err := queries.GetNotebook(tx, ctx, userId, parentId)
+	if err == sql.ErrNoRows {
+		return sql.NullInt32{}, errorParentNotFound
+	}
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	return sql.NullInt32{Int32: int32(parentId), Valid: true}, nil
+}
+
+func isPermutation(expected []int, actual []int) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	counts := make(map[int]int)
+	for _, id := range expected {
+		counts[id]++
+	}
+	for _, id := range actual {
+		if counts[id] == 0 {
+			return false
+		}
+		counts[id]--
+	}
+	return true
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorParentNotFound:
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_NOTEBOOK_WRONG_REFERENCE, "parentId"))
+		case errorNotebookNotFound:
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_NOTEBOOK_WRONG_REFERENCE, "notebookId"))
+		case errorNotebookMoveMakesCycle:
+			c.JSON(http.StatusBadRequest, api.ERROR_NOTEBOOK_MOVE_MAKES_CYCLE)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,53 @@
+package notebooks
+
+import (
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+type NotebookTreeDTO struct {
+	Id       int
+	Name     string
+	Position int
+	Notes    []NotebookNoteDTO
+	Children []NotebookTreeDTO
+}
+
+type NotebookNoteDTO struct {
+	NoteId    int
+	NoteTopic string
+	Position  int
+}
+
+// builds the nested tree from the notebooks of the subtree, the first one is the root. The notebooks and the notes are expected in their order,
+// the notebooks and the notes which are not linked to the root are skipped
+func BuildNotebookTree(notebooks []entities.Notebook, notes []entities.NotebookNote) NotebookTreeDTO {
+	if len(notebooks) == 0 {
+		return NotebookTreeDTO{Notes: make([]NotebookNoteDTO, 0), Children: make([]NotebookTreeDTO, 0)}
+	}
+
+	children := make(map[int][]entities.Notebook)
+	for _, notebook := range notebooks[1:] {
+		if notebook.ParentId.Valid {
+			parentId := int(notebook.ParentId.Int32)
+			children[parentId] = append(children[parentId], notebook)
+		}
+	}
+	notebookNotes := make(map[int][]NotebookNoteDTO)
+	for _, note := range notes {
+		notebookNotes[note.NotebookId] = append(notebookNotes[note.NotebookId], NotebookNoteDTO{NoteId: note.NoteId, NoteTopic: note.NoteTopic, Position: note.Position})
+	}
+
+	var build func(notebook entities.Notebook) NotebookTreeDTO
+	build = func(notebook entities.Notebook) NotebookTreeDTO {
+		result := NotebookTreeDTO{Id: notebook.Id, Name: notebook.Name, Position: notebook.Position, Notes: notebookNotes[notebook.Id], Children: make([]NotebookTreeDTO, 0)}
+		if result.Notes == nil {
+			result.Notes = make([]NotebookNoteDTO, 0)
+		}
+		for _, child := range children[notebook.Id] {
+			result.Children = append(result.Children, build(child))
+		}
+		return result
+	}
+
+	return build(notebooks[0])
+}
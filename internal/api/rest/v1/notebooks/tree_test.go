@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package notebooks_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notebooks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func parent(id int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(id), Valid: true}
+}
+
+func TestBuildNotebookTree(t *testing.T) {
+	subtree := []entities.Notebook{
+		{Id: 1, Name: "Algorithms", ParentId: parent(7), Position: 3},
+		{Id: 2, Name: "Graphs", ParentId: parent(1), Position: 0, Depth: 1},
+		{Id: 3, Name: "Sorting", ParentId: parent(1), Position: 1, Depth: 1},
+		{Id: 4, Name: "Shortest paths", ParentId: parent(2), Position: 0, Depth: 2},
+	}
+	notes := []entities.NotebookNote{
+		{NoteId: 10, NotebookId: 1, Position: 0, NoteTopic: "Overview"},
+		{NoteId: 11, NotebookId: 4, Position: 0, NoteTopic: "Dijkstra"},
+		{NoteId: 12, NotebookId: 4, Position: 1, NoteTopic: "Bellman-Ford"},
+	}
+
+	tree := notebooks.BuildNotebookTree(subtree, notes)
+
+	assert.Equal(t, notebooks.NotebookTreeDTO{
+		Id: 1, Name: "Algorithms", Position: 3,
+		Notes: []notebooks.NotebookNoteDTO{{NoteId: 10, NoteTopic: "Overview", Position: 0}},
+		Children: []notebooks.NotebookTreeDTO{
+			{
+				Id: 2, Name: "Graphs", Position: 0,
+				Notes: []notebooks.NotebookNoteDTO{},
+				Children: []notebooks.NotebookTreeDTO{
+					{
+						Id: 4, Name: "Shortest paths", Position: 0,
+						Notes: []notebooks.NotebookNoteDTO{
+							{NoteId: 11, NoteTopic: "Dijkstra", Position: 0},
+							{NoteId: 12, NoteTopic: "Bellman-Ford", Position: 1},
+						},
+						Children: []notebooks.NotebookTreeDTO{},
+					},
+				},
+			},
+			{Id: 3, Name: "Sorting", Position: 1, Notes: []notebooks.NotebookNoteDTO{}, Children: []notebooks.NotebookTreeDTO{}},
+		},
+	}, tree)
+}
+
+func TestBuildNotebookTreeOfEmptySubtree(t *testing.T) {
+	tree := notebooks.BuildNotebookTree(nil, nil)
+
+	assert.Equal(t, 0, tree.Id)
+	assert.Equal(t, 0, len(tree.Notes))
+	assert.Equal(t, 0, len(tree.Children))
+}
@@ -3,12 +3,19 @@ package notes
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/moderation"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
@@ -18,12 +25,17 @@ import (
 )
 
 type NoteDTO struct {
-	Id     int
-	Text   string
-	Topic  string
-	TagId  int
-	UserId int
-	State  string
+	Id          int
+	Text        string
+	Topic       string
+	TagId       int
+	UserId      int
+	State       string
+	Visibility  string
+	PublishedAt *time.Time     `json:",omitempty"`
+	PublishAt   *time.Time     `json:",omitempty"`
+	Reactions   map[string]int `json:",omitempty"` // the counts of the reactions by type
+	MyReactions []string       `json:",omitempty"` // the reactions of the caller
 }
 
 type NoteListDTO struct {
@@ -34,37 +46,79 @@ type NoteListDTO struct {
 }
 
 type NoteEditDTO struct {
-	Text   string `json:"text" binding:"required"`
-	Topic  string `json:"topic" binding:"required"`
-	TagId  int    `json:"tagId" binding:"required"`
-	UserId int    `json:"userId" binding:"required"`
-	State  string `json:"state" binding:"required"`
+	Text       string     `json:"text" binding:"required"`
+	Topic      string     `json:"topic" binding:"required"`
+	TagId      int        `json:"tagId" binding:"required"`
+	UserId     int        `json:"userId" binding:"required"`
+	State      string     `json:"state" binding:"required"`
+	Visibility string     `json:"visibility"` // optional, the current one is kept if missed
+	PublishAt  *time.Time `json:"publishAt"`  // optional, schedules the publication of the draft, the current schedule is kept if missed
 }
 
 type NoteCreateDTO struct {
-	Text   string `json:"text" binding:"required"`
-	Topic  string `json:"topic" binding:"required"`
-	TagId  int    `json:"tagId" binding:"required"`
-	UserId int    `json:"userId" binding:"required"`
-	State  string `json:"state" binding:"required"`
+	Text       string     `json:"text" binding:"required"`
+	Topic      string     `json:"topic" binding:"required"`
+	TagId      int        `json:"tagId" binding:"required"`
+	UserId     int        `json:"userId" binding:"required"`
+	State      string     `json:"state" binding:"required"`
+	Visibility string     `json:"visibility"` // optional, PUBLIC by default
+	PublishAt  *time.Time `json:"publishAt"`  // optional, schedules the publication of the draft
 }
 
-func convertNotes(notes []entities.Note) []NoteDTO {
-	if notes == nil {
+var errorOwnerOnly = errors.New("the action is allowed to the note owner only")
+var errorNoteStateTransition = errors.New("the note state transition is not allowed")
+
+// the notes with the reactions to them
+type notesWithReactions struct {
+	notes          []entities.Note
+	reactionCounts map[int]map[string]int
+	userReactions  map[int][]string
+}
+
+func convertNotes(data notesWithReactions) []NoteDTO {
+	if data.notes == nil {
 		return make([]NoteDTO, 0)
 	}
 	var result []NoteDTO
-	for _, note := range notes {
-		result = append(result, convertNote(note))
+	for _, note := range data.notes {
+		result = append(result, convertNote(note, data.reactionCounts[note.Id], data.userReactions[note.Id]))
 	}
 	return result
 }
 
-func convertNote(note entities.Note) NoteDTO {
-	return NoteDTO{Id: note.Id, Text: note.Text, Topic: note.Topic, TagId: note.TagId, UserId: note.UserId, State: note.State}
+func convertNote(note entities.Note, reactionCounts map[string]int, userReactions []string) NoteDTO {
+	result := NoteDTO{Id: note.Id, Text: note.Text, Topic: note.Topic, TagId: note.TagId, UserId: note.UserId, State: note.State, Visibility: note.Visibility,
+		Reactions: reactionCounts, MyReactions: userReactions}
+	if note.PublishedAt.Valid {
+		result.PublishedAt = &note.PublishedAt.Time
+	}
+	if note.PublishAt.Valid {
+		result.PublishAt = &note.PublishAt.Time
+	}
+	return result
+}
+
+func loadReactions(tx *sql.Tx, ctx context.Context, userId int, notes []entities.Note) (notesWithReactions, error) {
+	result := notesWithReactions{notes: notes}
+	noteIds := make([]int, 0, len(notes))
+	for _, note := range notes {
+		noteIds = append(noteIds, note.Id)
+	}
+	var err error
+	result.reactionCounts, err = queries.GetNotesReactionCounts(tx, ctx, noteIds)
+	if err != nil {
+		return result, err
+	}
+	result.userReactions, err = queries.GetUserNotesReactions(tx, ctx, userId, noteIds)
+	return result, err
 }
 
 func GetNotes(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
 	limitStr := c.DefaultQuery("limit", "50")
 	offsetStr := c.DefaultQuery("offset", "0")
 
@@ -79,8 +133,11 @@ func GetNotes(c *gin.Context) {
 	}
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
-		notes, err := queries.GetNotes(tx, ctx, limit, offset)
-		return notes, err
+		notes, err := queries.GetVisibleNotes(tx, ctx, userId, limit, offset)
+		if err != nil {
+			return notesWithReactions{}, err
+		}
+		return loadReactions(tx, ctx, userId, notes)
 	})()
 
 	if err != nil {
@@ -89,18 +146,23 @@ func GetNotes(c *gin.Context) {
 		return
 	}
 
-	notes, ok := data.([]entities.Note)
+	notes, ok := data.(notesWithReactions)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, "Unable to get notes")
 		log.Printf("Unable to get to notes : %s", api.ERROR_ASSERT_RESULT_TYPE)
 		return
 	}
 
-	result := &NoteListDTO{Data: convertNotes(notes), Count: len(notes), Offset: offset, Limit: limit}
+	result := &NoteListDTO{Data: convertNotes(notes), Count: len(notes.notes), Offset: offset, Limit: limit}
 	c.JSON(http.StatusOK, result)
 }
 
 func GetNote(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
 	noteIdStr := c.Param("id")
 
 	if noteIdStr == "" {
@@ -116,8 +178,11 @@ func GetNote(c *gin.Context) {
 	}
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
-		note, err := queries.GetNote(tx, ctx, noteId)
-		return note, err
+		note, err := queries.GetVisibleNote(tx, ctx, noteId, userId)
+		if err != nil {
+			return notesWithReactions{}, err
+		}
+		return loadReactions(tx, ctx, userId, []entities.Note{note})
 	})()
 
 	if err != nil {
@@ -130,27 +195,38 @@ func GetNote(c *gin.Context) {
 		return
 	}
 
-	note, ok := data.(entities.Note)
+	notes, ok := data.(notesWithReactions)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, "Unable to get note")
 		log.Printf("Unable to get to note : %s", api.ERROR_ASSERT_RESULT_TYPE)
 		return
 	}
 
-	c.JSON(http.StatusOK, convertNote(note))
+	note := notes.notes[0]
+	c.JSON(http.StatusOK, convertNote(note, notes.reactionCounts[note.Id], notes.userReactions[note.Id]))
 }
 
+// creates the note, the topic and the text are pre-filled from the 'template' if it is set
 func CreateNote(c *gin.Context) {
 	var note NoteCreateDTO
 
-	if err := c.ShouldBindJSON(&note); err != nil {
+	if templateIdStr := c.Query("template"); templateIdStr != "" {
+		var ok bool
+		if note, ok = bindNoteFromTemplate(c, templateIdStr); !ok {
+			return
+		}
+	} else if err := c.ShouldBindJSON(&note); err != nil {
 		validation.ProcessAndSendValidationErrorMessage(c, err)
 		return
 	}
 
-	possibleNoteStates := entities.GetPossibleNoteStates()
-	if !utils.Contains(possibleNoteStates, note.State) {
-		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to create note. Wrong 'State' value. Possible values: %v", possibleNoteStates))
+	// the note is created on behalf of the caller only
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+	if note.UserId != userId {
+		c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
 		return
 	}
 
@@ -159,8 +235,59 @@ func CreateNote(c *gin.Context) {
 		return
 	}
 
+	possibleNoteStates := entities.GetPossibleNoteInitialStates()
+	if !utils.Contains(possibleNoteStates, note.State) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to create note. Wrong 'State' value. Possible values: %v", possibleNoteStates))
+		return
+	}
+
+	if note.PublishAt != nil && !checkPublishAt(c, note.State, *note.PublishAt) {
+		return
+	}
+
+	if note.Visibility == "" {
+		note.Visibility = entities.NOTE_VISIBILITY_PUBLIC
+	}
+	possibleNoteVisibilities := entities.GetPossibleNoteVisibilities()
+	if !utils.Contains(possibleNoteVisibilities, note.Visibility) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to create note. Wrong 'Visibility' value. Possible values: %v", possibleNoteVisibilities))
+		return
+	}
+
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
 		result, err := queries.CreateNote(tx, ctx, note.Text, note.Topic, note.TagId, note.UserId, note.State)
+		if err != nil {
+			return result, err
+		}
+		if note.Visibility != entities.NOTE_VISIBILITY_PUBLIC {
+			err = queries.UpdateNoteVisibility(tx, ctx, result, note.Visibility)
+			if err != nil {
+				return result, err
+			}
+		}
+		if note.PublishAt != nil {
+			err = queries.UpdateNotePublishAt(tx, ctx, result, sql.NullTime{Time: toServerTime(*note.PublishAt), Valid: true})
+			if err != nil {
+				return result, err
+			}
+		}
+		err = links.SaveNoteLinks(tx, ctx, result, note.UserId, note.Topic, note.Text)
+		if err != nil {
+			return result, err
+		}
+		err = moderation.FlagContent(tx, ctx, entities.CONTENT_TYPE_NOTE, result, note.Topic, note.Text)
+		if err != nil {
+			return result, err
+		}
+		err = mentions.SaveNoteMentions(tx, ctx, result, note.UserId, note.Topic, note.Text)
+		if err != nil {
+			return result, err
+		}
+		err = events.PublishNoteEvent(tx, ctx, result, entities.EVENT_ACTION_CREATED)
+		if err != nil {
+			return result, err
+		}
+		err = queries.AddUserActivity(tx, ctx, note.UserId, entities.ACTIVITY_TYPE_NOTE_EDIT, result)
 		return result, err
 	})()
 
@@ -196,25 +323,84 @@ func UpdateNote(c *gin.Context) {
 		return
 	}
 
-	if note.State == entities.TASK_STATE_DELETED {
-		c.JSON(http.StatusBadRequest, api.DELETE_VIA_PUT_REQUEST_IS_FODBIDDEN)
-		return
-	}
-
 	possibleNoteStates := entities.GetPossibleNoteStates()
 	if !utils.Contains(possibleNoteStates, note.State) {
 		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to update note. Wrong 'State' value. Possible values: %v", possibleNoteStates))
 		return
 	}
 
-	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.UpdateNote(tx, ctx, noteId, note.Text, note.Topic, note.TagId, note.UserId, note.State)
-		return err
+	possibleNoteVisibilities := entities.GetPossibleNoteVisibilities()
+	if note.Visibility != "" && !utils.Contains(possibleNoteVisibilities, note.Visibility) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to update note. Wrong 'Visibility' value. Possible values: %v", possibleNoteVisibilities))
+		return
+	}
+
+	if note.PublishAt != nil && !checkPublishAt(c, note.State, *note.PublishAt) {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT)
+	if !ok {
+		return
+	}
+
+	// the current state is returned for reporting the not allowed transition
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		current, err := queries.GetNote(tx, ctx, noteId)
+		if err != nil {
+			return "", err
+		}
+		if !entities.IsNoteStateTransitionAllowed(current.State, note.State) {
+			return current.State, errorNoteStateTransition
+		}
+		// only the owner is allowed to hand over the note or change who can see it
+		isOwner := current.UserId == userId
+		if !isOwner && (note.UserId != current.UserId || (note.Visibility != "" && note.Visibility != current.Visibility)) {
+			return current.State, errorOwnerOnly
+		}
+		err = queries.UpdateNote(tx, ctx, noteId, note.Text, note.Topic, note.TagId, note.UserId, note.State)
+		if err != nil {
+			return current.State, err
+		}
+		if note.Visibility != "" && note.Visibility != current.Visibility {
+			err = queries.UpdateNoteVisibility(tx, ctx, noteId, note.Visibility)
+			if err != nil {
+				return current.State, err
+			}
+		}
+		if note.PublishAt != nil {
+			err = queries.UpdateNotePublishAt(tx, ctx, noteId, sql.NullTime{Time: toServerTime(*note.PublishAt), Valid: true})
+			if err != nil {
+				return current.State, err
+			}
+		}
+		err = links.SaveNoteLinks(tx, ctx, noteId, note.UserId, note.Topic, note.Text)
+		if err != nil {
+			return current.State, err
+		}
+		err = moderation.FlagContent(tx, ctx, entities.CONTENT_TYPE_NOTE, noteId, note.Topic, note.Text)
+		if err != nil {
+			return current.State, err
+		}
+		err = mentions.SaveNoteMentions(tx, ctx, noteId, userId, note.Topic, note.Text)
+		if err != nil {
+			return current.State, err
+		}
+		err = events.PublishNoteEvent(tx, ctx, noteId, entities.EVENT_ACTION_UPDATED)
+		if err != nil {
+			return current.State, err
+		}
+		return current.State, queries.AddUserActivity(tx, ctx, userId, entities.ACTIVITY_TYPE_NOTE_EDIT, noteId)
 	})()
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else if err == errorOwnerOnly {
+			c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		} else if err == errorNoteStateTransition {
+			currentState, _ := data.(string)
+			c.JSON(http.StatusConflict, fmt.Sprintf(api.ERROR_NOTE_STATE_TRANSITION_IS_NOT_ALLOWED, currentState, note.State, entities.GetPossibleNoteStateTransitions(currentState)))
 		} else {
 			c.JSON(http.StatusInternalServerError, "Unable to update note")
 			log.Printf("Unable to update note : %s", err)
@@ -240,9 +426,19 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
+	userId, ok := access.CheckNotePermission(c, id, entities.NOTE_PERMISSION_OWNER)
+	if !ok {
+		return
+	}
+
+	// the attachments are kept until the note is purged from the trash
 	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.DeleteNote(tx, ctx, id)
-		return err
+		// the deleted note is not public anymore, so the event is published before deleting
+		err := events.PublishNoteEvent(tx, ctx, id, entities.EVENT_ACTION_DELETED)
+		if err != nil {
+			return err
+		}
+		return queries.DeleteNote(tx, ctx, id, userId)
 	})()
 
 	if err != nil {
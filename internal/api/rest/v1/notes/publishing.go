@@ -0,0 +1,107 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const DEFAULT_PUBLISHING_INTERVAL_IN_SECONDS string = "60"
+
+var publishingOnce sync.Once
+
+// starts publishing of the scheduled drafts in background. Every instance of the app could run it, the drafts are published by a single update
+func StartScheduledPublishing() {
+	publishingOnce.Do(func() {
+		seconds, err := strconv.Atoi(utils.EnvVarDefault("NOTES_PUBLISHING_INTERVAL_IN_SECONDS", DEFAULT_PUBLISHING_INTERVAL_IN_SECONDS))
+		if err != nil || seconds <= 0 {
+			log.Fatalf("Wrong value of environment variable: NOTES_PUBLISHING_INTERVAL_IN_SECONDS. It should be positive integer number")
+		}
+		go func() {
+			ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				publishScheduledNotes()
+			}
+		}()
+	})
+}
+
+func publishScheduledNotes() {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		ids, err := queries.PublishScheduledNotes(tx, ctx, time.Now())
+		if err != nil {
+			return ids, err
+		}
+		for _, id := range ids {
+			err = events.PublishNoteEvent(tx, ctx, id, entities.EVENT_ACTION_UPDATED)
+			if err != nil {
+				return ids, err
+			}
+		}
+		return ids, nil
+	})()
+
+	if err != nil {
+		log.Printf("Unable to publish scheduled notes : %s", err)
+		return
+	}
+	if ids, ok := data.([]int); ok && len(ids) > 0 {
+		log.Printf("Scheduled notes are published: %v", ids)
+	}
+}
+
+// the publication could be scheduled for the drafts only and in the future
+func checkPublishAt(c *gin.Context, state string, publishAt time.Time) bool {
+	if state != entities.NOTE_STATE_DRAFT || !publishAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, api.ERROR_NOTE_PUBLISH_AT_IS_WRONG)
+		return false
+	}
+	return true
+}
+
+// the dates of db are the wall clock of the server
+func toServerTime(date time.Time) time.Time {
+	return date.In(time.Local)
+}
+
+// cancels the scheduled publication of the draft, the note stays the draft
+func CancelNotePublication(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_EDIT); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.UpdateNotePublishAt(tx, ctx, noteId, sql.NullTime{})
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, api.ERROR_NOTE_IS_NOT_DRAFT)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to cancel note publication")
+			log.Printf("Unable to cancel note publication : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,103 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/notetemplate"
+	"github.com/gin-gonic/gin"
+)
+
+// the note created from the template, the text is rendered from the template and the fields are the values of its custom placeholders
+type NoteFromTemplateDTO struct {
+	Topic      string            `json:"topic" binding:"max=512"` // optional, the rendered topic of the template by default
+	TagId      int               `json:"tagId" binding:"required"`
+	UserId     int               `json:"userId" binding:"required"`
+	State      string            `json:"state" binding:"required"`
+	Visibility string            `json:"visibility"` // optional, PUBLIC by default
+	PublishAt  *time.Time        `json:"publishAt"`  // optional, schedules the publication of the draft
+	Fields     map[string]string `json:"fields"`     // optional, the placeholders without values are kept as is
+}
+
+// binds the body and renders the template visible to the caller into the note, '{{topic}}' of the text is the topic of the note
+func bindNoteFromTemplate(c *gin.Context, templateIdStr string) (NoteCreateDTO, bool) {
+	var note NoteCreateDTO
+
+	templateId, err := strconv.Atoi(templateIdStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ERROR_NOTE_TEMPLATE_WRONG_REFERENCE)
+		return note, false
+	}
+
+	var dto NoteFromTemplateDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return note, false
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return note, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		template, err := queries.GetNoteTemplate(tx, ctx, userId, templateId)
+		return template, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, api.ERROR_NOTE_TEMPLATE_WRONG_REFERENCE)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create note")
+			log.Printf("Unable to create note : %s", err)
+		}
+		return note, false
+	}
+
+	template, ok := data.(entities.NoteTemplate)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to create note")
+		log.Printf("Unable to create note : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return note, false
+	}
+
+	now := time.Now()
+	values := make(map[string]string)
+	for name, value := range dto.Fields {
+		values[name] = value
+	}
+	delete(values, notetemplate.PLACEHOLDER_TOPIC)
+
+	topic := dto.Topic
+	if topic == "" {
+		topic = notetemplate.Render(template.Topic, values, now)
+	}
+	if topic == "" {
+		c.JSON(http.StatusBadRequest, api.ERROR_NOTE_TEMPLATE_TOPIC_IS_MISSED)
+		return note, false
+	}
+	values[notetemplate.PLACEHOLDER_TOPIC] = topic
+
+	note = NoteCreateDTO{
+		Text:       notetemplate.Render(template.Text, values, now),
+		Topic:      topic,
+		TagId:      dto.TagId,
+		UserId:     dto.UserId,
+		State:      dto.State,
+		Visibility: dto.Visibility,
+		PublishAt:  dto.PublishAt,
+	}
+	return note, true
+}
@@ -0,0 +1,336 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EXCERPT_LENGTH_IN_RUNES int = 280
+)
+
+type NotificationDTO struct {
+	Id         int
+	Type       string
+	ActorId    *int `json:",omitempty"`
+	NoteId     *int `json:",omitempty"`
+	CommentId  *int `json:",omitempty"`
+	Text       string
+	Read       bool
+	ReadAt     *time.Time `json:",omitempty"`
+	CreateDate time.Time
+}
+
+// the unread counts are given for all notifications of the caller regardless of the filter
+type NotificationListDTO struct {
+	Count        int
+	Offset       int
+	Limit        int
+	UnreadCount  int
+	UnreadCounts map[string]int
+	Data         []NotificationDTO
+}
+
+type NotificationPreferenceDTO struct {
+	Type    string
+	Enabled bool
+}
+
+type NotificationPreferenceListDTO struct {
+	Count int
+	Data  []NotificationPreferenceDTO
+}
+
+type NotificationPreferenceEditDTO struct {
+	Type    string `json:"type" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// the missed types are kept as they are
+type NotificationPreferencesEditDTO struct {
+	Preferences []NotificationPreferenceEditDTO `json:"preferences" binding:"dive"`
+}
+
+type userNotifications struct {
+	notifications []entities.Notification
+	unreadCounts  map[string]int
+}
+
+func convertNotifications(notifications []entities.Notification) []NotificationDTO {
+	if notifications == nil {
+		return make([]NotificationDTO, 0)
+	}
+	var result []NotificationDTO
+	for _, notification := range notifications {
+		result = append(result, convertNotification(notification))
+	}
+	return result
+}
+
+func convertNotification(notification entities.Notification) NotificationDTO {
+	result := NotificationDTO{
+		Id:         notification.Id,
+		Type:       notification.Type,
+		ActorId:    nullableInt(notification.ActorId),
+		NoteId:     nullableInt(notification.NoteId),
+		CommentId:  nullableInt(notification.CommentId),
+		Text:       notification.Text,
+		Read:       notification.ReadAt.Valid,
+		CreateDate: notification.CreateDate,
+	}
+	if notification.ReadAt.Valid {
+		result.ReadAt = &notification.ReadAt.Time
+	}
+	return result
+}
+
+func nullableInt(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int32)
+	return &result
+}
+
+// returns the beginning of the text for the notification, e.g. of the comment
+func Excerpt(text string) string {
+	if utf8.RuneCountInString(text) <= EXCERPT_LENGTH_IN_RUNES {
+		return text
+	}
+	return string([]rune(text)[:EXCERPT_LENGTH_IN_RUNES]) + "…"
+}
+
+// creates the notification within the transaction of the event, so it is not sent for the rolled back changes.
+// The notification is skipped if the user is the actor or the user turned the type off
+func Notify(tx *sql.Tx, ctx context.Context, notification entities.Notification) error {
+	if notification.ActorId.Valid && int(notification.ActorId.Int32) == notification.UserId {
+		return nil
+	}
+	enabled, err := queries.IsNotificationTypeEnabled(tx, ctx, notification.UserId, notification.Type)
+	if err != nil || !enabled {
+		return err
+	}
+	_, err = queries.CreateNotification(tx, ctx, notification)
+	return err
+}
+
+// returns the notifications of the caller filtered by 'type' and 'unread'
+func GetNotifications(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseFilter(c, "Unable to get notifications")
+	if !ok {
+		return
+	}
+	if unreadStr := c.Query("unread"); unreadStr != "" {
+		unread, err := strconv.ParseBool(unreadStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "Unable to get notifications. Wrong 'unread' value. Expected boolean")
+			return
+		}
+		filter.UnreadOnly = unread
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result userNotifications
+		var err error
+		result.notifications, err = queries.GetUserNotifications(tx, ctx, userId, filter, limit, offset)
+		if err != nil {
+			return result, err
+		}
+		result.unreadCounts, err = queries.GetUnreadNotificationCounts(tx, ctx, userId)
+		return result, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get notifications")
+		log.Printf("Unable to get notifications : %s", err)
+		return
+	}
+
+	result, ok := data.(userNotifications)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notifications")
+		log.Printf("Unable to get notifications : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	unreadCount := 0
+	for _, count := range result.unreadCounts {
+		unreadCount += count
+	}
+
+	c.JSON(http.StatusOK, &NotificationListDTO{
+		Count:        len(result.notifications),
+		Offset:       offset,
+		Limit:        limit,
+		UnreadCount:  unreadCount,
+		UnreadCounts: result.unreadCounts,
+		Data:         convertNotifications(result.notifications),
+	})
+}
+
+func MarkNotificationRead(c *gin.Context) {
+	notificationId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.MarkNotificationRead(tx, ctx, userId, notificationId)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to mark notification as read")
+			log.Printf("Unable to mark notification as read : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// marks all unread notifications of the caller as read, optionally of the given 'type' only. Returns the count of the marked ones
+func MarkAllNotificationsRead(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseFilter(c, "Unable to mark notifications as read")
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		count, err := queries.MarkAllNotificationsRead(tx, ctx, userId, filter.Type)
+		return count, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to mark notifications as read")
+		log.Printf("Unable to mark notifications as read : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// returns the preferences of the caller for all notification types
+func GetNotificationPreferences(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		preferences, err := queries.GetNotificationPreferences(tx, ctx, userId)
+		return preferences, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get notification preferences")
+		log.Printf("Unable to get notification preferences : %s", err)
+		return
+	}
+
+	preferences, ok := data.([]entities.NotificationPreference)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get notification preferences")
+		log.Printf("Unable to get notification preferences : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	enabled := make(map[string]bool)
+	for _, preference := range preferences {
+		enabled[preference.Type] = preference.Enabled
+	}
+	var result []NotificationPreferenceDTO
+	for _, notificationType := range entities.GetPossibleNotificationTypes() {
+		isEnabled, isSet := enabled[notificationType]
+		result = append(result, NotificationPreferenceDTO{Type: notificationType, Enabled: isEnabled || !isSet})
+	}
+
+	c.JSON(http.StatusOK, &NotificationPreferenceListDTO{Count: len(result), Data: result})
+}
+
+// sets the preferences of the caller for the given notification types
+func SetNotificationPreferences(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NotificationPreferencesEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	var preferences []entities.NotificationPreference
+	seen := make(map[string]bool)
+	possibleNotificationTypes := entities.GetPossibleNotificationTypes()
+	for _, preference := range dto.Preferences {
+		if !utils.Contains(possibleNotificationTypes, preference.Type) {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to set notification preferences. Wrong 'Type' value. Possible values: %v", possibleNotificationTypes))
+			return
+		}
+		if seen[preference.Type] {
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+			return
+		}
+		seen[preference.Type] = true
+		preferences = append(preferences, entities.NotificationPreference{UserId: userId, Type: preference.Type, Enabled: *preference.Enabled})
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.SetNotificationPreferences(tx, ctx, userId, preferences)
+		return err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to set notification preferences")
+		log.Printf("Unable to set notification preferences : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+func parseFilter(c *gin.Context, message string) (queries.NotificationFilter, bool) {
+	filter := queries.NotificationFilter{Type: c.Query("type")}
+	if filter.Type != "" && !utils.Contains(entities.GetPossibleNotificationTypes(), filter.Type) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("%s. Wrong 'type' value. Possible values: %v", message, entities.GetPossibleNotificationTypes()))
+		return filter, false
+	}
+	return filter, true
+}
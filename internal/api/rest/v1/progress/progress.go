@@ -0,0 +1,254 @@
+package progress
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/activity"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+type WeekDTO struct {
+	Start      string
+	Counts     map[string]int
+	ActiveDays int
+}
+
+type GoalDTO struct {
+	Type     string
+	Target   int
+	Current  int
+	Achieved bool
+}
+
+// the award date is missed if the achievement is not earned yet
+type AchievementDTO struct {
+	Code        string
+	Title       string
+	Description string
+	AwardDate   *time.Time `json:",omitempty"`
+}
+
+type ProgressDTO struct {
+	Timezone      string
+	Today         string
+	ActiveToday   bool
+	CurrentStreak int
+	LongestStreak int
+	ActiveDays    int
+	Totals        map[string]int
+	Week          WeekDTO
+	Goals         []GoalDTO
+	Achievements  []AchievementDTO
+}
+
+type GoalEditDTO struct {
+	Type   string `json:"type" binding:"required"`
+	Target int    `json:"target" binding:"required,min=1"`
+}
+
+type GoalsEditDTO struct {
+	Goals []GoalEditDTO `json:"goals" binding:"dive"`
+}
+
+type TimezoneEditDTO struct {
+	Timezone string `json:"timezone" binding:"required"`
+}
+
+type userProgress struct {
+	timezone     string
+	summary      activity.Summary
+	goals        []activity.GoalProgress
+	achievements []entities.UserAchievement
+}
+
+func convertProgress(p userProgress) ProgressDTO {
+	result := ProgressDTO{
+		Timezone:      p.timezone,
+		Today:         p.summary.Today,
+		ActiveToday:   p.summary.ActiveToday,
+		CurrentStreak: p.summary.CurrentStreak,
+		LongestStreak: p.summary.LongestStreak,
+		ActiveDays:    p.summary.ActiveDays,
+		Totals:        make(map[string]int),
+		Week:          WeekDTO{Start: p.summary.WeekStart, Counts: make(map[string]int), ActiveDays: p.summary.WeekActiveDays},
+		Goals:         make([]GoalDTO, 0, len(p.goals)),
+		Achievements:  make([]AchievementDTO, 0, len(activity.Achievements())),
+	}
+	// all activity types are given, so the clients do not need to handle missed ones
+	for _, activityType := range []string{entities.ACTIVITY_TYPE_NOTE_EDIT, entities.ACTIVITY_TYPE_CARD_REVIEW, entities.ACTIVITY_TYPE_TASK_DONE} {
+		result.Totals[activityType] = p.summary.Totals[activityType]
+		result.Week.Counts[activityType] = p.summary.WeekCounts[activityType]
+	}
+	for _, goal := range p.goals {
+		result.Goals = append(result.Goals, GoalDTO{Type: goal.Type, Target: goal.Target, Current: goal.Current, Achieved: goal.Achieved})
+	}
+	awarded := make(map[string]time.Time)
+	for _, achievement := range p.achievements {
+		awarded[achievement.Code] = achievement.AwardDate
+	}
+	for _, achievement := range activity.Achievements() {
+		dto := AchievementDTO{Code: achievement.Code, Title: achievement.Title, Description: achievement.Description}
+		if awardDate, ok := awarded[achievement.Code]; ok {
+			dto.AwardDate = &awardDate
+		}
+		result.Achievements = append(result.Achievements, dto)
+	}
+	return result
+}
+
+// returns streaks, weekly goals and achievements of the caller, the days are counted in the caller's timezone.
+// The achievements earned since the last request are awarded here
+func GetProgress(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result userProgress
+		var err error
+		result.timezone, err = queries.GetUserTimezone(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+		loc, err := time.LoadLocation(result.timezone)
+		if err != nil {
+			return result, fmt.Errorf("wrong timezone of user '%d': %s", userId, err)
+		}
+
+		buckets, err := queries.GetUserActivityBuckets(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+		goals, err := queries.GetUserGoals(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+
+		now := time.Now()
+		result.summary = activity.Summarize(buckets, now, loc)
+		result.goals = activity.Goals(goals, result.summary)
+
+		for _, code := range activity.Earned(result.summary, result.goals) {
+			_, err = queries.AwardUserAchievement(tx, ctx, userId, code, now)
+			if err != nil {
+				return result, err
+			}
+		}
+		result.achievements, err = queries.GetUserAchievements(tx, ctx, userId)
+		return result, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get progress")
+			log.Printf("Unable to get progress : %s", err)
+		}
+		return
+	}
+
+	result, ok := data.(userProgress)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get progress")
+		log.Printf("Unable to get progress : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertProgress(result))
+}
+
+// replaces the weekly goals of the caller, the empty list removes all goals
+func SetGoals(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto GoalsEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	var goals []entities.UserGoal
+	seen := make(map[string]bool)
+	possibleGoalTypes := entities.GetPossibleGoalTypes()
+	for _, goal := range dto.Goals {
+		if !utils.Contains(possibleGoalTypes, goal.Type) {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to set goals. Wrong 'Type' value. Possible values: %v", possibleGoalTypes))
+			return
+		}
+		if seen[goal.Type] {
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+			return
+		}
+		seen[goal.Type] = true
+		goals = append(goals, entities.UserGoal{UserId: userId, Type: goal.Type, Target: goal.Target})
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.SetUserGoals(tx, ctx, userId, goals)
+		return err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to set goals")
+		log.Printf("Unable to set goals : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// sets the IANA timezone of the caller (e.g. 'Europe/Berlin'), the days of streaks and goals are counted in it
+func SetTimezone(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto TimezoneEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	// the empty name and 'Local' are valid for time package, but they mean the server timezone
+	if _, err := time.LoadLocation(dto.Timezone); err != nil || dto.Timezone == "Local" {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TIMEZONE_IS_UNKNOWN, dto.Timezone))
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateUserTimezone(tx, ctx, userId, dto.Timezone)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to set timezone")
+			log.Printf("Unable to set timezone : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
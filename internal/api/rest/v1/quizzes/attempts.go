@@ -0,0 +1,349 @@
+package quizzes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/quiz"
+	"github.com/gin-gonic/gin"
+)
+
+// the answers are accepted a bit later than the deadline because of network delays
+const ATTEMPT_DEADLINE_GRACE_PERIOD time.Duration = 5 * time.Second
+
+var errorQuizHasNoQuestions = errors.New("quiz has no questions")
+var errorQuizAttemptIsFinished = errors.New("quiz attempt is finished already")
+
+type QuizAttemptDTO struct {
+	Id         int
+	QuizId     int
+	State      string
+	Score      int
+	MaxScore   int
+	StartDate  time.Time
+	Deadline   *time.Time `json:",omitempty"`
+	FinishDate *time.Time `json:",omitempty"`
+}
+
+type QuizAttemptListDTO struct {
+	Count int
+	Data  []QuizAttemptDTO
+}
+
+type QuizAnswerDTO struct {
+	QuestionId int
+	OptionIds  []int
+	Text       string
+	Correct    bool
+	Points     int
+}
+
+type QuizAttemptDetailsDTO struct {
+	QuizAttemptDTO
+	Answers []QuizAnswerDTO
+}
+
+type QuizAnswerEditDTO struct {
+	QuestionId int    `json:"questionId" binding:"required"`
+	OptionIds  []int  `json:"optionIds"`
+	Text       string `json:"text"`
+}
+
+type QuizAttemptSubmitDTO struct {
+	Answers []QuizAnswerEditDTO `json:"answers" binding:"dive"`
+}
+
+type quizAttemptDetails struct {
+	attempt entities.QuizAttempt
+	answers []entities.QuizAnswer
+}
+
+func convertQuizAttempts(attempts []entities.QuizAttempt) []QuizAttemptDTO {
+	if attempts == nil {
+		return make([]QuizAttemptDTO, 0)
+	}
+	var result []QuizAttemptDTO
+	for _, attempt := range attempts {
+		result = append(result, convertQuizAttempt(attempt))
+	}
+	return result
+}
+
+func convertQuizAttempt(attempt entities.QuizAttempt) QuizAttemptDTO {
+	result := QuizAttemptDTO{Id: attempt.Id, QuizId: attempt.QuizId, State: attempt.State, Score: attempt.Score, MaxScore: attempt.MaxScore, StartDate: attempt.StartDate}
+	if attempt.Deadline.Valid {
+		result.Deadline = &attempt.Deadline.Time
+	}
+	if attempt.FinishDate.Valid {
+		result.FinishDate = &attempt.FinishDate.Time
+	}
+	return result
+}
+
+func convertQuizAttemptDetails(details quizAttemptDetails) QuizAttemptDetailsDTO {
+	result := QuizAttemptDetailsDTO{QuizAttemptDTO: convertQuizAttempt(details.attempt), Answers: make([]QuizAnswerDTO, 0, len(details.answers))}
+	for _, answer := range details.answers {
+		optionIds := answer.OptionIds
+		if optionIds == nil {
+			optionIds = make([]int, 0)
+		}
+		result.Answers = append(result.Answers, QuizAnswerDTO{QuestionId: answer.QuestionId, OptionIds: optionIds, Text: answer.Text, Correct: answer.Correct, Points: answer.Points})
+	}
+	return result
+}
+
+// starts the attempt of the caller, the attempt of the timed quiz gets the deadline
+func StartQuizAttempt(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, quiz, ok := checkQuizAccess(c, quizId, false)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		questions, err := queries.GetQuizQuestions(tx, ctx, quizId)
+		if err != nil {
+			return nil, err
+		}
+		if len(questions) == 0 {
+			return nil, errorQuizHasNoQuestions
+		}
+		maxScore := 0
+		for _, question := range questions {
+			maxScore += question.Points
+		}
+
+		startDate := time.Now()
+		var deadline sql.NullTime
+		if quiz.TimeLimitSeconds.Valid {
+			deadline = sql.NullTime{Time: startDate.Add(time.Duration(quiz.TimeLimitSeconds.Int32) * time.Second), Valid: true}
+		}
+		attemptId, err := queries.CreateQuizAttempt(tx, ctx, quizId, userId, maxScore, startDate, deadline)
+		if err != nil {
+			return nil, err
+		}
+		return queries.GetQuizAttempt(tx, ctx, quizId, attemptId)
+	})()
+
+	if err != nil {
+		if err == errorQuizHasNoQuestions {
+			c.JSON(http.StatusBadRequest, api.ERROR_QUIZ_HAS_NO_QUESTIONS)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to start quiz attempt")
+			log.Printf("Unable to start quiz attempt : %s", err)
+		}
+		return
+	}
+
+	attempt, ok := data.(entities.QuizAttempt)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to start quiz attempt")
+		log.Printf("Unable to start quiz attempt : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertQuizAttempt(attempt))
+}
+
+// scores the answers and finishes the attempt. The questions without answers are considered as answered wrong,
+// the answers submitted after the deadline are not scored and the attempt becomes expired
+func SubmitQuizAttempt(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	attemptId, ok := api.ParseIdParam(c, "attemptId")
+	if !ok {
+		return
+	}
+
+	var submit QuizAttemptSubmitDTO
+
+	if err := c.ShouldBindJSON(&submit); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, _, ok := checkQuizAccess(c, quizId, false)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result quizAttemptDetails
+		attempt, err := queries.GetQuizAttempt(tx, ctx, quizId, attemptId)
+		if err != nil {
+			return result, err
+		}
+		if attempt.UserId != userId {
+			return result, sql.ErrNoRows
+		}
+		if attempt.State != entities.QUIZ_ATTEMPT_STATE_IN_PROGRESS {
+			return result, errorQuizAttemptIsFinished
+		}
+
+		finishDate := time.Now()
+		if attempt.Deadline.Valid && finishDate.After(attempt.Deadline.Time.Add(ATTEMPT_DEADLINE_GRACE_PERIOD)) {
+			attempt.State = entities.QUIZ_ATTEMPT_STATE_EXPIRED
+		} else {
+			attempt.State = entities.QUIZ_ATTEMPT_STATE_FINISHED
+			questions, err := queries.GetQuizQuestions(tx, ctx, quizId)
+			if err != nil {
+				return result, err
+			}
+			result.answers = scoreAnswers(attemptId, questions, submit.Answers)
+			for _, answer := range result.answers {
+				attempt.Score += answer.Points
+			}
+		}
+		attempt.FinishDate = sql.NullTime{Time: finishDate, Valid: true}
+
+		err = queries.FinishQuizAttempt(tx, ctx, attemptId, attempt.State, attempt.Score, finishDate)
+		if err == sql.ErrNoRows {
+			// the attempt is submitted concurrently
+			return result, errorQuizAttemptIsFinished
+		}
+		if err != nil {
+			return result, err
+		}
+		err = queries.SaveQuizAnswers(tx, ctx, result.answers)
+		result.attempt = attempt
+		return result, err
+	})()
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorQuizAttemptIsFinished:
+			c.JSON(http.StatusConflict, api.ERROR_QUIZ_ATTEMPT_IS_FINISHED)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to submit quiz attempt")
+			log.Printf("Unable to submit quiz attempt : %s", err)
+		}
+		return
+	}
+
+	details, ok := data.(quizAttemptDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to submit quiz attempt")
+		log.Printf("Unable to submit quiz attempt : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertQuizAttemptDetails(details))
+}
+
+// returns the answers to all questions of the quiz in order of questions, the answers to unknown questions are skipped
+func scoreAnswers(attemptId int, questions []entities.QuizQuestion, submitted []QuizAnswerEditDTO) []entities.QuizAnswer {
+	byQuestion := make(map[int]QuizAnswerEditDTO)
+	for _, answer := range submitted {
+		byQuestion[answer.QuestionId] = answer
+	}
+
+	var result []entities.QuizAnswer
+	for _, question := range questions {
+		submittedAnswer := byQuestion[question.Id]
+		answer := entities.QuizAnswer{AttemptId: attemptId, QuestionId: question.Id, OptionIds: submittedAnswer.OptionIds, Text: submittedAnswer.Text}
+		answer.Correct, answer.Points = quiz.Score(question, answer)
+		result = append(result, answer)
+	}
+	return result
+}
+
+// returns the attempts of the caller in the quiz, the recent ones go first
+func GetQuizAttempts(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, _, ok := checkQuizAccess(c, quizId, false)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		attempts, err := queries.GetUserQuizAttempts(tx, ctx, quizId, userId)
+		return attempts, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz attempts")
+		log.Printf("Unable to get quiz attempts : %s", err)
+		return
+	}
+
+	attempts, ok := data.([]entities.QuizAttempt)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz attempts")
+		log.Printf("Unable to get quiz attempts : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &QuizAttemptListDTO{Data: convertQuizAttempts(attempts), Count: len(attempts)}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the attempt of the caller with the answers
+func GetQuizAttempt(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	attemptId, ok := api.ParseIdParam(c, "attemptId")
+	if !ok {
+		return
+	}
+
+	userId, _, ok := checkQuizAccess(c, quizId, false)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result quizAttemptDetails
+		var err error
+		result.attempt, err = queries.GetQuizAttempt(tx, ctx, quizId, attemptId)
+		if err != nil {
+			return result, err
+		}
+		if result.attempt.UserId != userId {
+			return result, sql.ErrNoRows
+		}
+		result.answers, err = queries.GetQuizAnswers(tx, ctx, attemptId)
+		return result, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get quiz attempt")
+			log.Printf("Unable to get quiz attempt : %s", err)
+		}
+		return
+	}
+
+	details, ok := data.(quizAttemptDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz attempt")
+		log.Printf("Unable to get quiz attempt : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertQuizAttemptDetails(details))
+}
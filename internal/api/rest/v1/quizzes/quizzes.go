@@ -0,0 +1,637 @@
+package quizzes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/quiz"
+	"github.com/gin-gonic/gin"
+)
+
+const DEFAULT_QUESTION_POINTS int = 1
+
+var errorQuizWrongReference = errors.New("quiz references missing note or course")
+
+type QuizDTO struct {
+	Id               int
+	UserId           int
+	Title            string
+	NoteId           *int `json:",omitempty"`
+	CourseId         *int `json:",omitempty"`
+	TimeLimitSeconds *int `json:",omitempty"`
+	CreateDate       time.Time
+	LastUpdateDate   time.Time
+}
+
+type QuizListDTO struct {
+	Count int
+	Data  []QuizDTO
+}
+
+// the correct answers are shown to the author of the quiz only
+type QuizOptionDTO struct {
+	Id      int
+	Text    string
+	Correct *bool `json:",omitempty"`
+}
+
+type QuizQuestionDTO struct {
+	Id        int
+	Type      string
+	Text      string
+	Points    int
+	Position  int
+	Options   []QuizOptionDTO
+	Answer    *string `json:",omitempty"`
+	MatchType *string `json:",omitempty"`
+}
+
+type QuizDetailsDTO struct {
+	QuizDTO
+	Questions []QuizQuestionDTO
+}
+
+type QuizCreateDTO struct {
+	Title            string `json:"title" binding:"required"`
+	NoteId           *int   `json:"noteId"`
+	CourseId         *int   `json:"courseId"`
+	TimeLimitSeconds *int   `json:"timeLimitSeconds" binding:"omitempty,min=1"`
+}
+
+type QuizEditDTO struct {
+	Title            string `json:"title" binding:"required"`
+	TimeLimitSeconds *int   `json:"timeLimitSeconds" binding:"omitempty,min=1"`
+}
+
+type QuizOptionEditDTO struct {
+	Text    string `json:"text" binding:"required"`
+	Correct bool   `json:"correct"`
+}
+
+type QuizQuestionEditDTO struct {
+	Type      string              `json:"type" binding:"required"`
+	Text      string              `json:"text" binding:"required"`
+	Points    *int                `json:"points" binding:"omitempty,min=1"`
+	Options   []QuizOptionEditDTO `json:"options" binding:"dive"`
+	Answer    string              `json:"answer"`
+	MatchType string              `json:"matchType"`
+}
+
+type QuizQuestionStatsDTO struct {
+	QuestionId    int
+	Text          string
+	AnswersCount  int
+	CorrectCount  int
+	CorrectRate   float64
+	AveragePoints float64
+}
+
+type QuizStatsDTO struct {
+	QuizId    int
+	Questions []QuizQuestionStatsDTO
+}
+
+type quizDetails struct {
+	quiz      entities.Quiz
+	questions []entities.QuizQuestion
+}
+
+type quizStats struct {
+	questions []entities.QuizQuestion
+	stats     []entities.QuizQuestionStats
+}
+
+func convertQuizzes(quizzes []entities.Quiz) []QuizDTO {
+	if quizzes == nil {
+		return make([]QuizDTO, 0)
+	}
+	var result []QuizDTO
+	for _, quiz := range quizzes {
+		result = append(result, convertQuiz(quiz))
+	}
+	return result
+}
+
+func convertQuiz(quiz entities.Quiz) QuizDTO {
+	return QuizDTO{
+		Id:               quiz.Id,
+		UserId:           quiz.UserId,
+		Title:            quiz.Title,
+		NoteId:           nullableInt(quiz.NoteId),
+		CourseId:         nullableInt(quiz.CourseId),
+		TimeLimitSeconds: nullableInt(quiz.TimeLimitSeconds),
+		CreateDate:       quiz.CreateDate,
+		LastUpdateDate:   quiz.LastUpdateDate,
+	}
+}
+
+func convertQuizDetails(details quizDetails, isAuthor bool) QuizDetailsDTO {
+	result := QuizDetailsDTO{QuizDTO: convertQuiz(details.quiz), Questions: make([]QuizQuestionDTO, 0, len(details.questions))}
+	for _, question := range details.questions {
+		dto := QuizQuestionDTO{Id: question.Id, Type: question.Type, Text: question.Text, Points: question.Points, Position: question.Position, Options: make([]QuizOptionDTO, 0, len(question.Options))}
+		for _, option := range question.Options {
+			optionDTO := QuizOptionDTO{Id: option.Id, Text: option.Text}
+			if isAuthor {
+				correct := option.Correct
+				optionDTO.Correct = &correct
+			}
+			dto.Options = append(dto.Options, optionDTO)
+		}
+		if isAuthor && question.Answer.Valid {
+			answer, matchType := question.Answer.String, question.MatchType.String
+			dto.Answer = &answer
+			dto.MatchType = &matchType
+		}
+		result.Questions = append(result.Questions, dto)
+	}
+	return result
+}
+
+func convertQuizStats(quizId int, s quizStats) QuizStatsDTO {
+	texts := make(map[int]string)
+	for _, question := range s.questions {
+		texts[question.Id] = question.Text
+	}
+	result := QuizStatsDTO{QuizId: quizId, Questions: make([]QuizQuestionStatsDTO, 0, len(s.stats))}
+	for _, stats := range s.stats {
+		dto := QuizQuestionStatsDTO{QuestionId: stats.QuestionId, Text: texts[stats.QuestionId], AnswersCount: stats.AnswersCount, CorrectCount: stats.CorrectCount, AveragePoints: stats.AveragePoints}
+		if stats.AnswersCount > 0 {
+			dto.CorrectRate = float64(stats.CorrectCount) / float64(stats.AnswersCount)
+		}
+		result.Questions = append(result.Questions, dto)
+	}
+	return result
+}
+
+func nullableInt(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int32)
+	return &result
+}
+
+func toNullInt(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
+
+// converts the question and checks that it could be answered: the choice questions need the correct options and the free text ones need the expected answer
+func toQuestion(dto QuizQuestionEditDTO) (entities.QuizQuestion, string) {
+	question := entities.QuizQuestion{Type: dto.Type, Text: dto.Text, Points: DEFAULT_QUESTION_POINTS}
+	if dto.Points != nil {
+		question.Points = *dto.Points
+	}
+
+	if !utils.Contains(entities.GetPossibleQuizQuestionTypes(), dto.Type) {
+		return question, fmt.Sprintf("unknown type. Possible values: %v", entities.GetPossibleQuizQuestionTypes())
+	}
+
+	if dto.Type == entities.QUIZ_QUESTION_TYPE_FREE_TEXT {
+		if len(dto.Options) > 0 {
+			return question, "free text question could not have options"
+		}
+		if strings.TrimSpace(dto.Answer) == "" {
+			return question, "free text question should have the expected answer"
+		}
+		matchType := dto.MatchType
+		if matchType == "" {
+			matchType = entities.QUIZ_MATCH_TYPE_EXACT
+		}
+		if !utils.Contains(entities.GetPossibleQuizMatchTypes(), matchType) {
+			return question, fmt.Sprintf("unknown match type. Possible values: %v", entities.GetPossibleQuizMatchTypes())
+		}
+		if matchType == entities.QUIZ_MATCH_TYPE_REGEX {
+			if _, err := quiz.CompileAnswerPattern(dto.Answer); err != nil {
+				return question, fmt.Sprintf("wrong regular expression: %s", err)
+			}
+		}
+		question.Answer = sql.NullString{String: dto.Answer, Valid: true}
+		question.MatchType = sql.NullString{String: matchType, Valid: true}
+		return question, ""
+	}
+
+	if len(dto.Options) < 2 {
+		return question, "choice question should have at least 2 options"
+	}
+	correctCount := 0
+	for _, option := range dto.Options {
+		question.Options = append(question.Options, entities.QuizQuestionOption{Text: option.Text, Correct: option.Correct})
+		if option.Correct {
+			correctCount++
+		}
+	}
+	if dto.Type == entities.QUIZ_QUESTION_TYPE_SINGLE_CHOICE && correctCount != 1 {
+		return question, "single choice question should have exactly one correct option"
+	}
+	if correctCount == 0 {
+		return question, "multiple choice question should have at least one correct option"
+	}
+	return question, ""
+}
+
+// loads the quiz and checks that it is visible to the caller: the quizzes of notes are visible to the readers of the note, the quizzes of courses are visible to all.
+// Sends 404 if the quiz is not visible and 403 if the author is required, but the caller is not the author
+func checkQuizAccess(c *gin.Context, quizId int, authorRequired bool) (int, entities.Quiz, bool) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return -1, entities.Quiz{}, false
+	}
+
+	var visible bool
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		quiz, err := queries.GetQuiz(tx, ctx, quizId)
+		if err != nil {
+			return quiz, err
+		}
+		visible, err = isQuizTargetVisible(tx, ctx, quiz.NoteId, quiz.CourseId, userId)
+		return quiz, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to check quiz permission")
+			log.Printf("Unable to check quiz permission : %s", err)
+		}
+		return -1, entities.Quiz{}, false
+	}
+
+	quiz, ok := data.(entities.Quiz)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to check quiz permission")
+		log.Printf("Unable to check quiz permission : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, entities.Quiz{}, false
+	}
+
+	if quiz.UserId == userId {
+		return userId, quiz, true
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		return -1, quiz, false
+	}
+	if authorRequired {
+		c.JSON(http.StatusForbidden, api.ACCESS_DENIED)
+		return -1, quiz, false
+	}
+	return userId, quiz, true
+}
+
+func isQuizTargetVisible(tx *sql.Tx, ctx context.Context, noteId sql.NullInt32, courseId sql.NullInt32, userId int) (bool, error) {
+	if noteId.Valid {
+		permission, err := queries.GetNotePermission(tx, ctx, int(noteId.Int32), userId)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return permission != entities.NOTE_PERMISSION_NONE, err
+	}
+	_, err := queries.GetCourse(tx, ctx, int(courseId.Int32))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func GetNoteQuizzes(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		quizzes, err := queries.GetNoteQuizzes(tx, ctx, noteId)
+		return quizzes, err
+	})()
+
+	sendQuizzes(c, data, err)
+}
+
+func GetCourseQuizzes(c *gin.Context) {
+	courseId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetCourse(tx, ctx, courseId)
+		if err != nil {
+			return nil, err
+		}
+		quizzes, err := queries.GetCourseQuizzes(tx, ctx, courseId)
+		return quizzes, err
+	})()
+
+	sendQuizzes(c, data, err)
+}
+
+func sendQuizzes(c *gin.Context, data any, err error) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get quizzes")
+			log.Printf("Unable to get quizzes : %s", err)
+		}
+		return
+	}
+
+	quizzes, ok := data.([]entities.Quiz)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get quizzes")
+		log.Printf("Unable to get quizzes : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &QuizListDTO{Data: convertQuizzes(quizzes), Count: len(quizzes)}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the quiz with its questions, the correct answers are given to the author only
+func GetQuiz(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, quiz, ok := checkQuizAccess(c, quizId, false)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		questions, err := queries.GetQuizQuestions(tx, ctx, quizId)
+		return quizDetails{quiz: quiz, questions: questions}, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz")
+		log.Printf("Unable to get quiz : %s", err)
+		return
+	}
+
+	details, ok := data.(quizDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz")
+		log.Printf("Unable to get quiz : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertQuizDetails(details, quiz.UserId == userId))
+}
+
+// creates the quiz of the note readable by the caller or of the course
+func CreateQuiz(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var quiz QuizCreateDTO
+
+	if err := c.ShouldBindJSON(&quiz); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+	if (quiz.NoteId == nil) == (quiz.CourseId == nil) {
+		c.JSON(http.StatusBadRequest, api.ERROR_QUIZ_WRONG_REFERENCE)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		noteId, courseId := toNullInt(quiz.NoteId), toNullInt(quiz.CourseId)
+		visible, err := isQuizTargetVisible(tx, ctx, noteId, courseId, userId)
+		if err != nil {
+			return -1, err
+		}
+		if !visible {
+			return -1, errorQuizWrongReference
+		}
+		result, err := queries.CreateQuiz(tx, ctx, userId, quiz.Title, noteId, courseId, toNullInt(quiz.TimeLimitSeconds))
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == errorQuizWrongReference {
+			c.JSON(http.StatusBadRequest, api.ERROR_QUIZ_WRONG_REFERENCE)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create quiz")
+			log.Printf("Unable to create quiz : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func UpdateQuiz(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var quiz QuizEditDTO
+
+	if err := c.ShouldBindJSON(&quiz); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateQuiz(tx, ctx, quizId, quiz.Title, toNullInt(quiz.TimeLimitSeconds))
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update quiz")
+}
+
+func DeleteQuiz(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteQuiz(tx, ctx, quizId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete quiz")
+}
+
+// adds the question to the end of the quiz
+func CreateQuizQuestion(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto QuizQuestionEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+	question, message := toQuestion(dto)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_QUIZ_QUESTION_IS_INVALID, message))
+		return
+	}
+	question.QuizId = quizId
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateQuizQuestion(tx, ctx, question)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create quiz question")
+		log.Printf("Unable to create quiz question : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// updates the question and replaces its options
+func UpdateQuizQuestion(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	questionId, ok := api.ParseIdParam(c, "questionId")
+	if !ok {
+		return
+	}
+
+	var dto QuizQuestionEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+	question, message := toQuestion(dto)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_QUIZ_QUESTION_IS_INVALID, message))
+		return
+	}
+	question.Id = questionId
+	question.QuizId = quizId
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateQuizQuestion(tx, ctx, question)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update quiz question")
+}
+
+func DeleteQuizQuestion(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	questionId, ok := api.ParseIdParam(c, "questionId")
+	if !ok {
+		return
+	}
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteQuizQuestion(tx, ctx, quizId, questionId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete quiz question")
+}
+
+// returns the statistics of the finished attempts by questions, so the author could find the questions which are too hard
+func GetQuizStats(c *gin.Context) {
+	quizId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, _, ok := checkQuizAccess(c, quizId, true); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result quizStats
+		var err error
+		result.questions, err = queries.GetQuizQuestions(tx, ctx, quizId)
+		if err != nil {
+			return result, err
+		}
+		result.stats, err = queries.GetQuizQuestionStats(tx, ctx, quizId)
+		return result, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz stats")
+		log.Printf("Unable to get quiz stats : %s", err)
+		return
+	}
+
+	stats, ok := data.(quizStats)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get quiz stats")
+		log.Printf("Unable to get quiz stats : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertQuizStats(quizId, stats))
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,81 @@
+package reactions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+// adds the reaction of the caller to the visible note, the repeated reaction of the same type is ignored
+func AddNoteReaction(c *gin.Context) {
+	noteId, reactionType, ok := parseParams(c, "Unable to add reaction")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.AddNoteReaction(tx, ctx, noteId, userId, reactionType)
+	})()
+
+	sendEditResult(c, err, "Unable to add reaction")
+}
+
+func DeleteNoteReaction(c *gin.Context) {
+	noteId, reactionType, ok := parseParams(c, "Unable to delete reaction")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteNoteReaction(tx, ctx, noteId, userId, reactionType)
+	})()
+
+	sendEditResult(c, err, "Unable to delete reaction")
+}
+
+func parseParams(c *gin.Context, message string) (int, string, bool) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return -1, "", false
+	}
+	reactionType := c.Param("type")
+	if !utils.Contains(entities.GetPossibleReactionTypes(), reactionType) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("%s. Wrong 'type' value. Possible values: %v", message, entities.GetPossibleReactionTypes()))
+		return -1, "", false
+	}
+	return noteId, reactionType, true
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
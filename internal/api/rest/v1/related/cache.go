@@ -0,0 +1,149 @@
+package related
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/cache"
+)
+
+// the notes of the cached ranking, they are kept for the invalidation by the changed notes
+type rankingEntry struct {
+	sourceId  int
+	resultIds []int
+}
+
+// the rankings of the related notes by the note and the caller. The rankings are dropped by the changed notes of their sources or results,
+// all of them are dropped when the cache is full
+type rankingCache struct {
+	mutex      sync.Mutex
+	store      cache.Cache
+	keys       map[string]rankingEntry
+	sourceKeys map[int]map[string]bool
+	resultKeys map[int]map[string]bool
+	maxSize    int
+	generation uint64
+}
+
+func newRankingCache(maxSize int) *rankingCache {
+	return &rankingCache{
+		store:      cache.CreateConcurrentCache(),
+		keys:       make(map[string]rankingEntry),
+		sourceKeys: make(map[int]map[string]bool),
+		resultKeys: make(map[int]map[string]bool),
+		maxSize:    maxSize,
+	}
+}
+
+func (r *rankingCache) get(key string) ([]RelatedNoteDTO, bool) {
+	value, ok := r.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var result []RelatedNoteDTO
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Printf("Unable to read related notes from cache : %s", err)
+		return nil, false
+	}
+	return result, true
+}
+
+// the generation is changed by every change of the notes, so the ranking calculated before it is not stored
+func (r *rankingCache) currentGeneration() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.generation
+}
+
+func (r *rankingCache) nextGeneration() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.generation++
+}
+
+func (r *rankingCache) put(key string, sourceId int, generation uint64, ranking []RelatedNoteDTO) {
+	value, err := json.Marshal(ranking)
+	if err != nil {
+		log.Printf("Unable to write related notes to cache : %s", err)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if generation != r.generation {
+		return
+	}
+	if _, ok := r.keys[key]; ok {
+		r.deleteLocked(key)
+	} else if len(r.keys) >= r.maxSize {
+		r.clearLocked()
+	}
+
+	entry := rankingEntry{sourceId: sourceId, resultIds: make([]int, 0, len(ranking))}
+	for _, related := range ranking {
+		entry.resultIds = append(entry.resultIds, related.NoteId)
+	}
+	r.store.Set(key, string(value))
+	r.keys[key] = entry
+	addKey(r.sourceKeys, sourceId, key)
+	for _, resultId := range entry.resultIds {
+		addKey(r.resultKeys, resultId, key)
+	}
+}
+
+// drops the rankings of the sources and the rankings containing the results
+func (r *rankingCache) invalidate(sourceIds []int, resultIds []int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, sourceId := range sourceIds {
+		for key := range r.sourceKeys[sourceId] {
+			r.deleteLocked(key)
+		}
+	}
+	for _, resultId := range resultIds {
+		for key := range r.resultKeys[resultId] {
+			r.deleteLocked(key)
+		}
+	}
+}
+
+func (r *rankingCache) clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.generation++
+	r.clearLocked()
+}
+
+func (r *rankingCache) clearLocked() {
+	for key := range r.keys {
+		r.store.Delete(key)
+	}
+	r.keys = make(map[string]rankingEntry)
+	r.sourceKeys = make(map[int]map[string]bool)
+	r.resultKeys = make(map[int]map[string]bool)
+}
+
+func (r *rankingCache) deleteLocked(key string) {
+	entry := r.keys[key]
+	r.store.Delete(key)
+	delete(r.keys, key)
+	deleteKey(r.sourceKeys, entry.sourceId, key)
+	for _, resultId := range entry.resultIds {
+		deleteKey(r.resultKeys, resultId, key)
+	}
+}
+
+func addKey(keys map[int]map[string]bool, noteId int, key string) {
+	if keys[noteId] == nil {
+		keys[noteId] = make(map[string]bool)
+	}
+	keys[noteId][key] = true
+}
+
+func deleteKey(keys map[int]map[string]bool, noteId int, key string) {
+	delete(keys[noteId], key)
+	if len(keys[noteId]) == 0 {
+		delete(keys, noteId)
+	}
+}
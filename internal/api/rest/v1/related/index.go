@@ -0,0 +1,213 @@
+package related
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/similarity"
+)
+
+// the index of all not deleted notes, it is loaded on the first use and after the events could be missed.
+// The events only mark the notes and the tags as changed, their notes are reindexed by the next request
+type noteIndex struct {
+	mutex    sync.RWMutex
+	index    *similarity.Index
+	topics   map[int]string
+	rankings *rankingCache
+
+	pendingMutex   sync.Mutex
+	stale          bool
+	pendingNoteIds map[int]bool
+	pendingTagIds  map[int]bool
+}
+
+func newNoteIndex(rankings *rankingCache) *noteIndex {
+	return &noteIndex{index: similarity.NewIndex(), topics: make(map[int]string), rankings: rankings, stale: true,
+		pendingNoteIds: make(map[int]bool), pendingTagIds: make(map[int]bool)}
+}
+
+// the nil event means that the changes could be missed
+func (n *noteIndex) onEvent(event *entities.Event) {
+	n.pendingMutex.Lock()
+	defer n.pendingMutex.Unlock()
+	switch {
+	case event == nil:
+		n.stale = true
+	case event.ResourceType == entities.EVENT_RESOURCE_TYPE_NOTE:
+		n.pendingNoteIds[event.ResourceId] = true
+	case event.ResourceType == entities.EVENT_RESOURCE_TYPE_TAG:
+		n.pendingTagIds[event.ResourceId] = true
+	default:
+		return
+	}
+	n.rankings.nextGeneration()
+}
+
+// reindexes the changed notes and drops the rankings which could be changed by them: the rankings of the changed notes,
+// the ones containing them and the ones of the notes sharing anything with them before or after the change.
+// The notes linking to the changed notes and the notes of the changed tags are reindexed too
+func (n *noteIndex) refresh() error {
+	n.pendingMutex.Lock()
+	idle := !n.stale && len(n.pendingNoteIds) == 0 && len(n.pendingTagIds) == 0
+	n.pendingMutex.Unlock()
+	if idle {
+		return nil
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.pendingMutex.Lock()
+	stale, noteIds, tagIds := n.stale, keys(n.pendingNoteIds), keys(n.pendingTagIds)
+	n.stale, n.pendingNoteIds, n.pendingTagIds = false, make(map[int]bool), make(map[int]bool)
+	n.pendingMutex.Unlock()
+
+	var err error
+	if stale {
+		err = n.reload()
+	} else if len(noteIds) > 0 || len(tagIds) > 0 {
+		err = n.reindex(noteIds, tagIds)
+	}
+	if err != nil {
+		// the changes are applied by the next request
+		n.pendingMutex.Lock()
+		n.stale = n.stale || stale
+		for _, id := range noteIds {
+			n.pendingNoteIds[id] = true
+		}
+		for _, id := range tagIds {
+			n.pendingTagIds[id] = true
+		}
+		n.pendingMutex.Unlock()
+	}
+	return err
+}
+
+func (n *noteIndex) reload() error {
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		documents, err := queries.GetAllNoteDocuments(tx, ctx)
+		return documents, err
+	})()
+
+	if err != nil {
+		return err
+	}
+
+	documents, ok := data.([]entities.NoteDocument)
+	if !ok {
+		return fmt.Errorf(api.ERROR_ASSERT_RESULT_TYPE)
+	}
+
+	n.index = similarity.NewIndex()
+	n.topics = make(map[int]string)
+	for _, document := range documents {
+		n.put(document)
+	}
+	n.rankings.clear()
+	return nil
+}
+
+func (n *noteIndex) reindex(noteIds []int, tagIds []int) error {
+	changed := make(map[int]bool)
+	for _, id := range noteIds {
+		changed[id] = true
+		for _, linkingId := range n.index.LinkingIds(id) {
+			changed[linkingId] = true
+		}
+	}
+	for _, tagId := range tagIds {
+		for _, id := range n.index.TaggedIds(tagId) {
+			changed[id] = true
+		}
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		ids, err := queries.GetLinkingOrTaggedNoteIds(tx, ctx, noteIds, tagIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			changed[id] = true
+		}
+		documents, err := queries.GetNoteDocumentsByIds(tx, ctx, keys(changed))
+		return documents, err
+	})()
+
+	if err != nil {
+		return err
+	}
+
+	documents, ok := data.([]entities.NoteDocument)
+	if !ok {
+		return fmt.Errorf(api.ERROR_ASSERT_RESULT_TYPE)
+	}
+
+	changedIds := keys(changed)
+	sources := make(map[int]bool)
+	addCandidates := func() {
+		for _, id := range changedIds {
+			sources[id] = true
+			for _, candidateId := range n.index.Candidates(id) {
+				sources[candidateId] = true
+			}
+		}
+	}
+
+	addCandidates()
+	for _, id := range changedIds {
+		n.index.Remove(id)
+		delete(n.topics, id)
+	}
+	for _, document := range documents {
+		n.put(document)
+	}
+	addCandidates()
+
+	n.rankings.invalidate(keys(sources), changedIds)
+	return nil
+}
+
+func (n *noteIndex) put(document entities.NoteDocument) {
+	n.index.Put(similarity.Document{Id: document.Id, Text: document.Topic + "\n" + document.Text, TagIds: document.TagIds, LinkedIds: document.LinkedIds})
+	n.topics[document.Id] = document.Topic
+}
+
+// returns the ids of the notes which could be related to the note
+func (n *noteIndex) candidates(noteId int) []int {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.index.Candidates(noteId)
+}
+
+// ranks the given notes by the similarity to the note
+func (n *noteIndex) rank(noteId int, ids []int) []RelatedNoteDTO {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	result := make([]RelatedNoteDTO, 0)
+	for _, match := range n.index.Rank(noteId, ids, MAX_LIMIT) {
+		result = append(result, RelatedNoteDTO{
+			NoteId:    match.Id,
+			Topic:     n.topics[match.Id],
+			Score:     match.Score,
+			TagScore:  match.TagScore,
+			LinkScore: match.LinkScore,
+			TextScore: match.TextScore,
+		})
+	}
+	return result
+}
+
+func keys(set map[int]bool) []int {
+	result := make([]int, 0, len(set))
+	for id := range set {
+		result = append(result, id)
+	}
+	return result
+}
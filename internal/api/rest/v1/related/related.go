@@ -0,0 +1,123 @@
+package related
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DEFAULT_CACHE_SIZE string = "10000"
+	DEFAULT_LIMIT      int    = 10
+	MAX_LIMIT          int    = 50
+)
+
+var rankings *rankingCache
+var notes *noteIndex
+var once sync.Once
+
+// the rankings are invalidated by the note and tag events of all instances, so the events listening should be started after the setup
+func Setup() {
+	once.Do(func() {
+		size, err := strconv.Atoi(utils.EnvVarDefault("RELATED_NOTES_CACHE_SIZE", DEFAULT_CACHE_SIZE))
+		if err != nil || size <= 0 {
+			log.Fatalf("Wrong value of environment variable: RELATED_NOTES_CACHE_SIZE. It should be positive integer number")
+		}
+		rankings = newRankingCache(size)
+		notes = newNoteIndex(rankings)
+		events.AddListener(notes.onEvent)
+	})
+}
+
+// the score is between 0 and 1, the scores of the shared tags, the links and the text explain it
+type RelatedNoteDTO struct {
+	NoteId    int
+	Topic     string
+	Score     float64
+	TagScore  float64
+	LinkScore float64
+	TextScore float64
+}
+
+type RelatedNoteListDTO struct {
+	Count int
+	Data  []RelatedNoteDTO
+}
+
+func parseLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DEFAULT_LIMIT)))
+	if err != nil || limit <= 0 {
+		return DEFAULT_LIMIT
+	}
+	if limit > MAX_LIMIT {
+		return MAX_LIMIT
+	}
+	return limit
+}
+
+// returns the notes similar to the given one by the shared tags, the links between them and TF-IDF of their texts.
+// Only the notes visible to the caller are ranked, the rankings are cached until the notes involved in them are changed
+func GetRelatedNotes(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+	limit := parseLimit(c)
+
+	// the generation is taken before applying the changes, so the ranking is not stored if the notes are changed during the calculation
+	generation := rankings.currentGeneration()
+	if err := notes.refresh(); err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get related notes")
+		log.Printf("Unable to get related notes : %s", err)
+		return
+	}
+
+	key := strconv.Itoa(noteId) + ":" + strconv.Itoa(userId)
+	related, ok := rankings.get(key)
+	if !ok {
+		candidateIds := notes.candidates(noteId)
+
+		data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+			ids, err := queries.GetVisibleNoteIds(tx, ctx, userId, candidateIds)
+			return ids, err
+		})()
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, "Unable to get related notes")
+			log.Printf("Unable to get related notes : %s", err)
+			return
+		}
+
+		visibleIds, ok := data.([]int)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, "Unable to get related notes")
+			log.Printf("Unable to get related notes : %s", api.ERROR_ASSERT_RESULT_TYPE)
+			return
+		}
+
+		related = notes.rank(noteId, visibleIds)
+		rankings.put(key, noteId, generation, related)
+	}
+
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	c.JSON(http.StatusOK, &RelatedNoteListDTO{Count: len(related), Data: related})
+}
@@ -0,0 +1,468 @@
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+var errorResourceWrongReference = errors.New("resource references missing tag or invisible note")
+
+type ResourceDTO struct {
+	Id             int
+	Title          string
+	Url            string
+	Type           string
+	Author         string
+	Status         string
+	Rating         *int `json:",omitempty"`
+	Progress       int
+	ProgressTotal  *int   `json:",omitempty"`
+	ProgressUnit   string `json:",omitempty"`
+	TagIds         []int
+	NoteIds        []int
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type ResourceListDTO struct {
+	Count int
+	Data  []ResourceDTO
+}
+
+// the progress is given in pages for books and papers and in minutes for videos
+type ResourceEditDTO struct {
+	Title         string `json:"title" binding:"required,max=512"`
+	Url           string `json:"url" binding:"omitempty,url,max=2048"`
+	Type          string `json:"type" binding:"required"`
+	Author        string `json:"author" binding:"max=512"`
+	Status        string `json:"status"`
+	Rating        *int   `json:"rating" binding:"omitempty,min=1,max=5"`
+	Progress      int    `json:"progress" binding:"min=0"`
+	ProgressTotal *int   `json:"progressTotal" binding:"omitempty,min=1"`
+	TagIds        []int  `json:"tagIds"`
+	NoteIds       []int  `json:"noteIds"`
+}
+
+// the total is kept if it is missed
+type ResourceProgressEditDTO struct {
+	Progress      *int `json:"progress" binding:"required,min=0"`
+	ProgressTotal *int `json:"progressTotal" binding:"omitempty,min=1"`
+}
+
+func convertResources(resources []entities.Resource) []ResourceDTO {
+	if resources == nil {
+		return make([]ResourceDTO, 0)
+	}
+	var result []ResourceDTO
+	for _, resource := range resources {
+		result = append(result, convertResource(resource))
+	}
+	return result
+}
+
+func convertResource(resource entities.Resource) ResourceDTO {
+	result := ResourceDTO{
+		Id:             resource.Id,
+		Title:          resource.Title,
+		Url:            resource.Url,
+		Type:           resource.Type,
+		Author:         resource.Author,
+		Status:         resource.Status,
+		Rating:         nullableInt(resource.Rating),
+		Progress:       resource.Progress,
+		ProgressTotal:  nullableInt(resource.ProgressTotal),
+		ProgressUnit:   entities.GetResourceProgressUnit(resource.Type),
+		TagIds:         resource.TagIds,
+		NoteIds:        resource.NoteIds,
+		CreateDate:     resource.CreateDate,
+		LastUpdateDate: resource.LastUpdateDate,
+	}
+	if result.TagIds == nil {
+		result.TagIds = make([]int, 0)
+	}
+	if result.NoteIds == nil {
+		result.NoteIds = make([]int, 0)
+	}
+	return result
+}
+
+func nullableInt(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int32)
+	return &result
+}
+
+func toNullInt(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
+
+// validates the type, the status and the progress of the resource and converts it, returns false if the response is sent already
+func toResource(c *gin.Context, dto ResourceEditDTO, userId int) (entities.Resource, bool) {
+	possibleResourceTypes := entities.GetPossibleResourceTypes()
+	if !utils.Contains(possibleResourceTypes, dto.Type) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to save resource. Wrong 'Type' value. Possible values: %v", possibleResourceTypes))
+		return entities.Resource{}, false
+	}
+	if dto.Status == "" {
+		dto.Status = entities.RESOURCE_STATUS_TO_READ
+	}
+	possibleResourceStatuses := entities.GetPossibleResourceStatuses()
+	if !utils.Contains(possibleResourceStatuses, dto.Status) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to save resource. Wrong 'Status' value. Possible values: %v", possibleResourceStatuses))
+		return entities.Resource{}, false
+	}
+	if !checkProgress(c, dto.Type, dto.Progress, dto.ProgressTotal) {
+		return entities.Resource{}, false
+	}
+	return entities.Resource{
+		UserId:        userId,
+		Title:         dto.Title,
+		Url:           dto.Url,
+		Type:          dto.Type,
+		Author:        dto.Author,
+		Status:        dto.Status,
+		Rating:        toNullInt(dto.Rating),
+		Progress:      dto.Progress,
+		ProgressTotal: toNullInt(dto.ProgressTotal),
+		TagIds:        dto.TagIds,
+		NoteIds:       dto.NoteIds,
+	}, true
+}
+
+func checkProgress(c *gin.Context, resourceType string, progress int, progressTotal *int) bool {
+	if entities.GetResourceProgressUnit(resourceType) == "" && (progress > 0 || progressTotal != nil) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_RESOURCE_PROGRESS_IS_NOT_TRACKED, resourceType))
+		return false
+	}
+	if progressTotal != nil && progress > *progressTotal {
+		c.JSON(http.StatusBadRequest, api.ERROR_RESOURCE_PROGRESS_EXCEEDS_TOTAL)
+		return false
+	}
+	return true
+}
+
+// checks that the tags exist and the notes are visible to the user
+func checkLinks(tx *sql.Tx, ctx context.Context, userId int, tagIds []int, noteIds []int) error {
+	if len(tagIds) > 0 {
+		tags, err := queries.GetTagsByIds(tx, ctx, tagIds)
+		if err != nil {
+			return err
+		}
+		if len(tags) != countDistinct(tagIds) {
+			return errorResourceWrongReference
+		}
+	}
+	for _, noteId := range noteIds {
+		permission, err := queries.GetNotePermission(tx, ctx, noteId, userId)
+		if err == sql.ErrNoRows || (err == nil && permission == entities.NOTE_PERMISSION_NONE) {
+			return errorResourceWrongReference
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countDistinct(ids []int) int {
+	distinct := make(map[int]bool)
+	for _, id := range ids {
+		distinct[id] = true
+	}
+	return len(distinct)
+}
+
+// checks that the resource belongs to the caller and returns the caller id with the resource, the resources of other users are not disclosed
+func checkResourceOwner(c *gin.Context, resourceId int) (int, entities.Resource, bool) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return -1, entities.Resource{}, false
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		resource, err := queries.GetResource(tx, ctx, resourceId)
+		return resource, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get resource")
+			log.Printf("Unable to get resource : %s", err)
+		}
+		return -1, entities.Resource{}, false
+	}
+
+	resource, ok := data.(entities.Resource)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get resource")
+		log.Printf("Unable to get resource : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return -1, resource, false
+	}
+	if resource.UserId != userId {
+		c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		return -1, resource, false
+	}
+
+	return userId, resource, true
+}
+
+// returns the reading list of the caller filtered by 'status', 'type' and 'tagId'
+func GetResources(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := api.ParseLimitAndOffset(c)
+	filter := queries.ResourceFilter{Status: c.Query("status"), Type: c.Query("type")}
+	if filter.Status != "" && !utils.Contains(entities.GetPossibleResourceStatuses(), filter.Status) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to get resources. Wrong 'status' value. Possible values: %v", entities.GetPossibleResourceStatuses()))
+		return
+	}
+	if filter.Type != "" && !utils.Contains(entities.GetPossibleResourceTypes(), filter.Type) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to get resources. Wrong 'type' value. Possible values: %v", entities.GetPossibleResourceTypes()))
+		return
+	}
+	if tagIdStr := c.Query("tagId"); tagIdStr != "" {
+		tagId, err := strconv.Atoi(tagIdStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, api.ERROR_ID_WRONG_FORMAT)
+			return
+		}
+		filter.TagId = tagId
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		resources, err := queries.GetUserResources(tx, ctx, userId, filter, limit, offset)
+		return resources, err
+	})()
+
+	sendResources(c, data, err)
+}
+
+// returns the resources of the caller linked to the note
+func GetNoteResources(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		resources, err := queries.GetNoteResources(tx, ctx, noteId, userId)
+		return resources, err
+	})()
+
+	sendResources(c, data, err)
+}
+
+func GetResource(c *gin.Context) {
+	resourceId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	_, resource, ok := checkResourceOwner(c, resourceId)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, convertResource(resource))
+}
+
+func CreateResource(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto ResourceEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	resource, ok := toResource(c, dto, userId)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := checkLinks(tx, ctx, userId, resource.TagIds, resource.NoteIds)
+		if err != nil {
+			return -1, err
+		}
+		result, err := queries.CreateResource(tx, ctx, resource)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == errorResourceWrongReference {
+			c.JSON(http.StatusBadRequest, api.ERROR_RESOURCE_WRONG_REFERENCE)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create resource")
+			log.Printf("Unable to create resource : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// updates the resource and replaces its tags and notes
+func UpdateResource(c *gin.Context) {
+	resourceId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto ResourceEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	userId, _, ok := checkResourceOwner(c, resourceId)
+	if !ok {
+		return
+	}
+
+	resource, ok := toResource(c, dto, userId)
+	if !ok {
+		return
+	}
+	resource.Id = resourceId
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := checkLinks(tx, ctx, userId, resource.TagIds, resource.NoteIds)
+		if err != nil {
+			return err
+		}
+		return queries.UpdateResource(tx, ctx, resource)
+	})()
+
+	sendEditResult(c, err, "Unable to update resource")
+}
+
+// updates the progress of the resource, the status follows it: the started resource is being read and the finished one is done
+func UpdateResourceProgress(c *gin.Context) {
+	resourceId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto ResourceProgressEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	_, resource, ok := checkResourceOwner(c, resourceId)
+	if !ok {
+		return
+	}
+
+	progressTotal := nullableInt(resource.ProgressTotal)
+	if dto.ProgressTotal != nil {
+		progressTotal = dto.ProgressTotal
+	}
+	if !checkProgress(c, resource.Type, *dto.Progress, progressTotal) {
+		return
+	}
+
+	status := resource.Status
+	if progressTotal != nil && *dto.Progress == *progressTotal {
+		status = entities.RESOURCE_STATUS_DONE
+	} else if *dto.Progress > 0 {
+		status = entities.RESOURCE_STATUS_READING
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.UpdateResourceProgress(tx, ctx, resourceId, status, *dto.Progress, toNullInt(progressTotal))
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to update resource progress")
+}
+
+func DeleteResource(c *gin.Context) {
+	resourceId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, _, ok := checkResourceOwner(c, resourceId); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteResource(tx, ctx, resourceId)
+		return err
+	})()
+
+	sendEditResult(c, err, "Unable to delete resource")
+}
+
+func sendResources(c *gin.Context, data any, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get resources")
+		log.Printf("Unable to get resources : %s", err)
+		return
+	}
+
+	resources, ok := data.([]entities.Resource)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get resources")
+		log.Printf("Unable to get resources : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &ResourceListDTO{Data: convertResources(resources), Count: len(resources)}
+	c.JSON(http.StatusOK, result)
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorResourceWrongReference:
+			c.JSON(http.StatusBadRequest, api.ERROR_RESOURCE_WRONG_REFERENCE)
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,249 @@
+package reviews
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/cards"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/srs"
+	"github.com/gin-gonic/gin"
+)
+
+const STATS_PERIOD_DAYS int = 30
+
+type ScheduleDTO struct {
+	Repetitions    int
+	IntervalDays   int
+	EasinessFactor float64
+	DueDate        time.Time
+	LastReviewDate time.Time
+}
+
+type DueCardDTO struct {
+	cards.CardDTO
+	Schedule *ScheduleDTO `json:",omitempty"`
+}
+
+type DueCardListDTO struct {
+	Count int
+	Data  []DueCardDTO
+}
+
+type ReviewDTO struct {
+	Grade *int `json:"grade" binding:"required,min=0,max=5"`
+}
+
+type ReviewsByDayDTO struct {
+	Date  string
+	Count int
+}
+
+type ReviewStatsDTO struct {
+	Cards         int
+	DueCards      int
+	NewCards      int
+	MatureCards   int
+	ReviewsTotal  int
+	ReviewsToday  int
+	AverageGrade  float64
+	RetentionRate float64
+	ReviewsByDay  []ReviewsByDayDTO
+}
+
+func convertSchedule(review entities.CardReview) ScheduleDTO {
+	return ScheduleDTO{Repetitions: review.Repetitions, IntervalDays: review.IntervalDays, EasinessFactor: review.EasinessFactor, DueDate: review.DueDate, LastReviewDate: review.LastReviewDate}
+}
+
+func convertDueCards(dueCards []entities.DueCard) []DueCardDTO {
+	if dueCards == nil {
+		return make([]DueCardDTO, 0)
+	}
+	var result []DueCardDTO
+	for _, card := range dueCards {
+		dto := DueCardDTO{CardDTO: cards.ConvertCard(card.Card)}
+		if card.Review != nil {
+			schedule := convertSchedule(*card.Review)
+			dto.Schedule = &schedule
+		}
+		result = append(result, dto)
+	}
+	return result
+}
+
+func convertStats(stats entities.CardReviewStats) ReviewStatsDTO {
+	result := ReviewStatsDTO{
+		Cards:         stats.Cards,
+		DueCards:      stats.DueCards,
+		NewCards:      stats.NewCards,
+		MatureCards:   stats.MatureCards,
+		ReviewsTotal:  stats.ReviewsTotal,
+		ReviewsToday:  stats.ReviewsToday,
+		AverageGrade:  stats.AverageGrade,
+		RetentionRate: stats.RetentionRate,
+		ReviewsByDay:  make([]ReviewsByDayDTO, 0, len(stats.ReviewsByDay)),
+	}
+	for _, day := range stats.ReviewsByDay {
+		result.ReviewsByDay = append(result.ReviewsByDay, ReviewsByDayDTO{Date: day.Date.Format("2006-01-02"), Count: day.Count})
+	}
+	return result
+}
+
+// returns the cards of the caller to review now, the overdue cards go first, then the new ones
+func GetDueCards(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "50")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 50
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		dueCards, err := queries.GetDueCards(tx, ctx, userId, time.Now(), limit)
+		return dueCards, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get due cards")
+		log.Printf("Unable to get due cards : %s", err)
+		return
+	}
+
+	dueCards, ok := data.([]entities.DueCard)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get due cards")
+		log.Printf("Unable to get due cards : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &DueCardListDTO{Data: convertDueCards(dueCards), Count: len(dueCards)}
+	c.JSON(http.StatusOK, result)
+}
+
+// grades the answer of the caller and schedules the next review of the card
+func ReviewCard(c *gin.Context) {
+	cardId, ok := api.ParseIdParam(c, "cardId")
+	if !ok {
+		return
+	}
+
+	var review ReviewDTO
+
+	if err := c.ShouldBindJSON(&review); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		card, err := queries.GetCard(tx, ctx, cardId)
+		return card, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to review card")
+			log.Printf("Unable to review card : %s", err)
+		}
+		return
+	}
+
+	card, ok := data.(entities.Card)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to review card")
+		log.Printf("Unable to review card : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	userId, ok := access.CheckNotePermission(c, card.NoteId, entities.NOTE_PERMISSION_READ)
+	if !ok {
+		return
+	}
+
+	data, err = db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		schedule := srs.NewSchedule()
+		existing, err := queries.GetCardReview(tx, ctx, cardId, userId)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			schedule = srs.Schedule{Repetitions: existing.Repetitions, IntervalDays: existing.IntervalDays, EasinessFactor: existing.EasinessFactor}
+		}
+
+		now := time.Now()
+		schedule = srs.Review(schedule, *review.Grade)
+		saved := entities.CardReview{
+			CardId:         cardId,
+			UserId:         userId,
+			Repetitions:    schedule.Repetitions,
+			IntervalDays:   schedule.IntervalDays,
+			EasinessFactor: schedule.EasinessFactor,
+			DueDate:        schedule.DueDate(now),
+			LastReviewDate: now,
+		}
+		err = queries.SaveCardReview(tx, ctx, saved, *review.Grade)
+		return saved, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to review card")
+		log.Printf("Unable to review card : %s", err)
+		return
+	}
+
+	saved, ok := data.(entities.CardReview)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to review card")
+		log.Printf("Unable to review card : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertSchedule(saved))
+}
+
+// returns the review statistics of the caller, the reviews by day are given for the last 30 days
+func GetReviewStats(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := todayStart.AddDate(0, 0, -STATS_PERIOD_DAYS+1)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		stats, err := queries.GetCardReviewStats(tx, ctx, userId, now, todayStart, since)
+		return stats, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get review stats")
+		log.Printf("Unable to get review stats : %s", err)
+		return
+	}
+
+	stats, ok := data.(entities.CardReviewStats)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get review stats")
+		log.Printf("Unable to get review stats : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertStats(stats))
+}
@@ -0,0 +1,270 @@
+package sharelinks
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SHARE_LINK_TOKEN_BYTES_COUNT int    = 32
+	SHARE_LINK_PASSWORD_HEADER   string = "X-Share-Password"
+)
+
+var errorShareLinkIsExpired = errors.New("share link is expired")
+var errorShareLinkWrongPassword = errors.New("wrong share link password")
+
+type NoteShareLinkDTO struct {
+	Id          int
+	NoteId      int
+	ExpireAt    *time.Time
+	MaxViews    *int
+	Views       int
+	HasPassword bool
+	CreateDate  time.Time
+}
+
+type NoteShareLinkListDTO struct {
+	Count int
+	Data  []NoteShareLinkDTO
+}
+
+// the token is shown only once, the db keeps its hash
+type NoteShareLinkCreatedDTO struct {
+	Id    int
+	Token string
+}
+
+type NoteShareLinkCreateDTO struct {
+	ExpireAt *time.Time `json:"expireAt"`
+	Password string     `json:"password"`
+	MaxViews *int       `json:"maxViews" binding:"omitempty,min=1"`
+}
+
+type SharedNoteDTO struct {
+	Topic          string
+	Text           string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+func convertNoteShareLinks(links []entities.NoteShareLink) []NoteShareLinkDTO {
+	if links == nil {
+		return make([]NoteShareLinkDTO, 0)
+	}
+	var result []NoteShareLinkDTO
+	for _, link := range links {
+		result = append(result, convertNoteShareLink(link))
+	}
+	return result
+}
+
+func convertNoteShareLink(link entities.NoteShareLink) NoteShareLinkDTO {
+	result := NoteShareLinkDTO{Id: link.Id, NoteId: link.NoteId, Views: link.Views, HasPassword: link.PasswordHash.Valid, CreateDate: link.CreateDate}
+	if link.ExpireAt.Valid {
+		result.ExpireAt = &link.ExpireAt.Time
+	}
+	if link.MaxViews.Valid {
+		maxViews := int(link.MaxViews.Int32)
+		result.MaxViews = &maxViews
+	}
+	return result
+}
+
+func GetNoteShareLinks(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		links, err := queries.GetNoteShareLinks(tx, ctx, noteId)
+		return links, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get note share links")
+		log.Printf("Unable to get to note share links : %s", err)
+		return
+	}
+
+	links, ok := data.([]entities.NoteShareLink)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note share links")
+		log.Printf("Unable to get to note share links : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &NoteShareLinkListDTO{Data: convertNoteShareLinks(links), Count: len(links)}
+	c.JSON(http.StatusOK, result)
+}
+
+func CreateNoteShareLink(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var link NoteShareLinkCreateDTO
+
+	// all fields are optional, so the body could be omitted at all
+	if err := c.ShouldBindJSON(&link); err != nil && !errors.Is(err, io.EOF) {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if link.ExpireAt != nil && !link.ExpireAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, "Unable to create note share link. Expiration date should be in the future")
+		return
+	}
+
+	ownerId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER)
+	if !ok {
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(SHARE_LINK_TOKEN_BYTES_COUNT)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to create note share link")
+		log.Printf("Unable to create note share link : %s", err)
+		return
+	}
+
+	var passwordHash sql.NullString
+	if link.Password != "" {
+		passwordHash = sql.NullString{String: utils.CreateSHA512HashHexEncoded(link.Password), Valid: true}
+	}
+	var expireAt sql.NullTime
+	if link.ExpireAt != nil {
+		expireAt = sql.NullTime{Time: *link.ExpireAt, Valid: true}
+	}
+	var maxViews sql.NullInt32
+	if link.MaxViews != nil {
+		maxViews = sql.NullInt32{Int32: int32(*link.MaxViews), Valid: true}
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateNoteShareLink(tx, ctx, noteId, ownerId, utils.CreateSHA256HashHexEncoded(token), passwordHash, expireAt, maxViews)
+		return result, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to create note share link")
+		log.Printf("Unable to create note share link : %s", err)
+		return
+	}
+
+	linkId, ok := data.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to create note share link")
+		log.Printf("Unable to create note share link : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &NoteShareLinkCreatedDTO{Id: linkId, Token: token})
+}
+
+func RevokeNoteShareLink(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	linkId, ok := api.ParseIdParam(c, "linkId")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.RevokeNoteShareLink(tx, ctx, noteId, linkId)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to revoke note share link")
+			log.Printf("Unable to revoke note share link: %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// public read-only access to the note by the share link token, every successful request is counted as a view
+func GetSharedNote(c *gin.Context) {
+	token := c.Param("token")
+	password := c.GetHeader(SHARE_LINK_PASSWORD_HEADER)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		link, err := queries.GetNoteShareLinkByTokenHash(tx, ctx, utils.CreateSHA256HashHexEncoded(token))
+		if err != nil {
+			return nil, err
+		}
+		if link.ExpireAt.Valid && !link.ExpireAt.Time.After(time.Now()) {
+			return nil, errorShareLinkIsExpired
+		}
+		if link.PasswordHash.Valid {
+			passwordHash := utils.CreateSHA512HashHexEncoded(password)
+			if subtle.ConstantTimeCompare([]byte(passwordHash), []byte(link.PasswordHash.String)) != 1 {
+				return nil, errorShareLinkWrongPassword
+			}
+		}
+		counted, err := queries.IncreaseNoteShareLinkViews(tx, ctx, link.Id)
+		if err != nil {
+			return nil, err
+		}
+		if !counted {
+			return nil, errorShareLinkIsExpired
+		}
+		note, err := queries.GetNote(tx, ctx, link.NoteId)
+		return note, err
+	})()
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorShareLinkIsExpired:
+			c.JSON(http.StatusGone, "Share link is expired")
+		case errorShareLinkWrongPassword:
+			c.JSON(http.StatusUnauthorized, "Wrong share link password")
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to get shared note")
+			log.Printf("Unable to get shared note: %s", err)
+		}
+		return
+	}
+
+	note, ok := data.(entities.Note)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get shared note")
+		log.Printf("Unable to get shared note: %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &SharedNoteDTO{Topic: note.Topic, Text: note.Text, CreateDate: note.CreateDate, LastUpdateDate: note.LastUpdateDate})
+}
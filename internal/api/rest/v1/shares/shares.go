@@ -0,0 +1,190 @@
+package shares
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+type NoteShareDTO struct {
+	NoteId     int
+	UserId     int
+	Permission string
+	CreateDate time.Time
+}
+
+type NoteShareListDTO struct {
+	Count int
+	Data  []NoteShareDTO
+}
+
+type NoteShareCreateDTO struct {
+	UserId     int    `json:"userId" binding:"required"`
+	Permission string `json:"permission" binding:"required"`
+}
+
+func convertNoteShares(shares []entities.NoteShare) []NoteShareDTO {
+	if shares == nil {
+		return make([]NoteShareDTO, 0)
+	}
+	var result []NoteShareDTO
+	for _, share := range shares {
+		result = append(result, convertNoteShare(share))
+	}
+	return result
+}
+
+func convertNoteShare(share entities.NoteShare) NoteShareDTO {
+	return NoteShareDTO{NoteId: share.NoteId, UserId: share.UserId, Permission: share.Permission, CreateDate: share.CreateDate}
+}
+
+func GetNoteShares(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER); !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		shares, err := queries.GetNoteShares(tx, ctx, noteId)
+		return shares, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get note shares")
+		log.Printf("Unable to get to note shares : %s", err)
+		return
+	}
+
+	shares, ok := data.([]entities.NoteShare)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note shares")
+		log.Printf("Unable to get to note shares : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &NoteShareListDTO{Data: convertNoteShares(shares), Count: len(shares)}
+	c.JSON(http.StatusOK, result)
+}
+
+// creates the share or changes permission of the existing one
+func CreateNoteShare(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var share NoteShareCreateDTO
+
+	if err := c.ShouldBindJSON(&share); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	possiblePermissions := entities.GetPossibleNoteSharePermissions()
+	if !utils.Contains(possiblePermissions, share.Permission) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to share note. Wrong 'Permission' value. Possible values: %v", possiblePermissions))
+		return
+	}
+
+	ownerId, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER)
+	if !ok {
+		return
+	}
+
+	if share.UserId == ownerId {
+		c.JSON(http.StatusBadRequest, "Unable to share note. The note owner already has full access")
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetUser(tx, ctx, share.UserId)
+		if err != nil {
+			return err
+		}
+		err = queries.CreateNoteShare(tx, ctx, noteId, share.UserId, share.Permission)
+		if err != nil {
+			return err
+		}
+		note, err := queries.GetNote(tx, ctx, noteId)
+		if err != nil {
+			return err
+		}
+		err = events.PublishNoteEvent(tx, ctx, noteId, entities.EVENT_ACTION_UPDATED)
+		if err != nil {
+			return err
+		}
+		return notifications.Notify(tx, ctx, entities.Notification{
+			UserId:  share.UserId,
+			Type:    entities.NOTIFICATION_TYPE_NOTE_SHARED,
+			ActorId: sql.NullInt32{Int32: int32(ownerId), Valid: true},
+			NoteId:  sql.NullInt32{Int32: int32(noteId), Valid: true},
+			Text:    note.Topic,
+		})
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, "Unable to share note. User not found")
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to share note")
+			log.Printf("Unable to share note : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.DONE)
+}
+
+func DeleteNoteShare(c *gin.Context) {
+	noteId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	userId, ok := api.ParseIdParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if _, ok := access.CheckNotePermission(c, noteId, entities.NOTE_PERMISSION_OWNER); !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		// published before deleting, so the user who loses the access is still in the audience
+		err := events.PublishNoteEvent(tx, ctx, noteId, entities.EVENT_ACTION_UPDATED)
+		if err != nil {
+			return err
+		}
+		return queries.DeleteNoteShare(tx, ctx, noteId, userId)
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to delete note share")
+			log.Printf("Unable to delete note share: %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,285 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/learningpath"
+	"github.com/gin-gonic/gin"
+)
+
+var errorTagParentNotFound = errors.New("parent tag is not found")
+var errorTagParentMakesCycle = errors.New("tag parent makes a cycle")
+var errorTagMergeTargetNotFound = errors.New("merge target tag is not found")
+var errorTagMergeMakesCycle = errors.New("tag merge makes a prerequisite cycle")
+
+type TagParentDTO struct {
+	ParentId int `json:"parentId" binding:"min=0"` // the tag becomes the root if it is 0
+}
+
+type TagAliasCreateDTO struct {
+	Name string `json:"name" binding:"required,max=256"`
+}
+
+type TagMergeDTO struct {
+	TargetId int `json:"targetId" binding:"required,min=1"`
+}
+
+// returns the direct children of the tag
+func GetTagChildren(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return tagsWithDetails{}, err
+		}
+		tags, err := queries.GetTagChildren(tx, ctx, tagId)
+		if err != nil {
+			return tagsWithDetails{}, err
+		}
+		return loadTagDetails(tx, ctx, tags)
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get tag children")
+			log.Printf("Unable to get tag children : %s", err)
+		}
+		return
+	}
+
+	tags, ok := data.(tagsWithDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get tag children")
+		log.Printf("Unable to get tag children : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &TagListDTO{Data: convertTags(tags), Count: len(tags.tags), Offset: 0, Limit: len(tags.tags)}
+	c.JSON(http.StatusOK, result)
+}
+
+// moves the tag under the parent, the parent can not be the tag itself or its descendant
+func SetTagParent(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto TagParentDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return err
+		}
+		var parentId sql.NullInt32
+		if dto.ParentId != 0 {
+			_, err = queries.GetTag(tx, ctx, dto.ParentId)
+			if err == sql.ErrNoRows {
+				return errorTagParentNotFound
+			}
+			if err != nil {
+				return err
+			}
+			ancestorIds, err := queries.GetTagAncestorIds(tx, ctx, dto.ParentId)
+			if err != nil {
+				return err
+			}
+			for _, ancestorId := range ancestorIds {
+				if ancestorId == tagId {
+					return errorTagParentMakesCycle
+				}
+			}
+			parentId = sql.NullInt32{Int32: int32(dto.ParentId), Valid: true}
+		}
+		return queries.SetTagParent(tx, ctx, tagId, parentId)
+	})()
+
+	sendEditResult(c, err, "Unable to set tag parent")
+}
+
+// adds the alternative name of the tag, the name can not be taken by any tag or alias
+func CreateTagAlias(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto TagAliasCreateDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return -1, err
+		}
+		_, err = queries.GetTagByName(tx, ctx, dto.Name)
+		if err == nil {
+			return -1, db.ErrorTagAliasDuplicateKey
+		}
+		if err != sql.ErrNoRows {
+			return -1, err
+		}
+		result, err := queries.CreateTagAlias(tx, ctx, tagId, dto.Name)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case db.ErrorTagAliasDuplicateKey:
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to create tag alias")
+			log.Printf("Unable to create tag alias : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+func DeleteTagAlias(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	aliasId, ok := api.ParseIdParam(c, "aliasId")
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteTagAlias(tx, ctx, tagId, aliasId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete tag alias")
+}
+
+// merges the tag into the target one in a single transaction: the notes, the resources, the prerequisites, the aliases and
+// the children of the tag are moved to the target, the name of the tag becomes the alias of the target and the tag is deleted.
+// The merge which makes a cycle of the prerequisites is rejected
+func MergeTag(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto TagMergeDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if dto.TargetId == tagId {
+		c.JSON(http.StatusBadRequest, api.ERROR_TAG_MERGE_INTO_ITSELF)
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	// the names of the tags of the cycle are returned if the merge makes it
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return nil, err
+		}
+		_, err = queries.GetTag(tx, ctx, dto.TargetId)
+		if err == sql.ErrNoRows {
+			return nil, errorTagMergeTargetNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = queries.LockTagPrerequisites(tx, ctx)
+		if err != nil {
+			return nil, err
+		}
+		edges, err := queries.GetTagPrerequisiteEdges(tx, ctx)
+		if err != nil {
+			return nil, err
+		}
+		cycle := learningpath.NewGraph(edges).CycleWithMerge(tagId, dto.TargetId)
+		if cycle != nil {
+			names, err := getTagNames(tx, ctx, cycle)
+			if err != nil {
+				return nil, err
+			}
+			return names, errorTagMergeMakesCycle
+		}
+		err = queries.MergeTag(tx, ctx, tagId, dto.TargetId)
+		if err != nil {
+			return nil, err
+		}
+		err = queries.DeleteTag(tx, ctx, tagId, userId)
+		if err != nil {
+			return nil, err
+		}
+		err = events.PublishTagEvent(tx, ctx, tagId, entities.EVENT_ACTION_DELETED)
+		if err != nil {
+			return nil, err
+		}
+		return nil, events.PublishTagEvent(tx, ctx, dto.TargetId, entities.EVENT_ACTION_UPDATED)
+	})()
+
+	if err == errorTagMergeMakesCycle {
+		names, _ := data.([]string)
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TAG_PREREQUISITE_MAKES_CYCLE, strings.Join(names, " -> ")))
+		return
+	}
+	sendEditResult(c, err, "Unable to merge tag")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorTagParentNotFound:
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TAG_WRONG_REFERENCE, "parentId"))
+		case errorTagParentMakesCycle:
+			c.JSON(http.StatusBadRequest, api.ERROR_TAG_PARENT_MAKES_CYCLE)
+		case errorTagMergeTargetNotFound:
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TAG_WRONG_REFERENCE, "targetId"))
+		default:
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
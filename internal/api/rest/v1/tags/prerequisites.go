@@ -0,0 +1,272 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/learningpath"
+	"github.com/gin-gonic/gin"
+)
+
+var errorTagPrerequisiteMakesCycle = errors.New("tag prerequisite makes a cycle")
+
+type LearningStepDTO struct {
+	Tag              TagDTO
+	Prerequisites    []int
+	NotesTotal       int
+	NotesCompleted   int
+	RemainingNoteIds []int
+	Done             bool
+	Available        bool
+}
+
+// the next tag is missed if the target is done
+type LearningPathDTO struct {
+	TagId     int
+	NextTagId *int `json:",omitempty"`
+	Steps     []LearningStepDTO
+}
+
+type learningPath struct {
+	steps []learningpath.Step
+	tags  tagsWithDetails
+}
+
+func convertLearningPath(tagId int, path learningPath) LearningPathDTO {
+	result := LearningPathDTO{TagId: tagId, Steps: make([]LearningStepDTO, 0, len(path.steps))}
+	if next, ok := learningpath.Next(path.steps); ok {
+		result.NextTagId = &next
+	}
+	tags := make(map[int]entities.Tag)
+	for _, tag := range path.tags.tags {
+		tags[tag.Id] = tag
+	}
+	for _, step := range path.steps {
+		dto := LearningStepDTO{
+			Tag:              convertTag(tags[step.TagId], path.tags.usageCounts[step.TagId], path.tags.aliases[step.TagId]),
+			Prerequisites:    step.Prerequisites,
+			NotesTotal:       step.NotesTotal,
+			NotesCompleted:   step.NotesCompleted,
+			RemainingNoteIds: step.RemainingNoteIds,
+			Done:             step.Done,
+			Available:        step.Available,
+		}
+		if dto.Prerequisites == nil {
+			dto.Prerequisites = make([]int, 0)
+		}
+		if dto.RemainingNoteIds == nil {
+			dto.RemainingNoteIds = make([]int, 0)
+		}
+		result.Steps = append(result.Steps, dto)
+	}
+	return result
+}
+
+// returns the direct prerequisites of the tag
+func GetTagPrerequisites(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := queries.GetTagPrerequisites(tx, ctx, tagId)
+		if err != nil {
+			return tagsWithDetails{}, err
+		}
+		return loadTagDetails(tx, ctx, tags)
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get tag prerequisites")
+			log.Printf("Unable to get tag prerequisites : %s", err)
+		}
+		return
+	}
+
+	tags, ok := data.(tagsWithDetails)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get tag prerequisites")
+		log.Printf("Unable to get tag prerequisites : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &TagListDTO{Data: convertTags(tags), Count: len(tags.tags), Offset: 0, Limit: len(tags.tags)}
+	c.JSON(http.StatusOK, result)
+}
+
+// makes the topic of the tag require the topic of the prerequisite, the prerequisite which makes a cycle is rejected
+func AddTagPrerequisite(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	prerequisiteId, ok := api.ParseIdParam(c, "prerequisiteId")
+	if !ok {
+		return
+	}
+
+	// the names of the tags of the cycle are returned if the prerequisite makes it
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		for _, id := range []int{tagId, prerequisiteId} {
+			_, err := queries.GetTag(tx, ctx, id)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := queries.LockTagPrerequisites(tx, ctx)
+		if err != nil {
+			return nil, err
+		}
+		edges, err := queries.GetTagPrerequisiteEdges(tx, ctx)
+		if err != nil {
+			return nil, err
+		}
+		cycle := learningpath.NewGraph(edges).CycleWith(tagId, prerequisiteId)
+		if cycle != nil {
+			names, err := getTagNames(tx, ctx, cycle)
+			if err != nil {
+				return nil, err
+			}
+			return names, errorTagPrerequisiteMakesCycle
+		}
+		return nil, queries.AddTagPrerequisite(tx, ctx, tagId, prerequisiteId)
+	})()
+
+	if err != nil {
+		switch err {
+		case errorTagPrerequisiteMakesCycle:
+			names, _ := data.([]string)
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TAG_PREREQUISITE_MAKES_CYCLE, strings.Join(names, " -> ")))
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to add tag prerequisite")
+			log.Printf("Unable to add tag prerequisite : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+func DeleteTagPrerequisite(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	prerequisiteId, ok := api.ParseIdParam(c, "prerequisiteId")
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteTagPrerequisite(tx, ctx, tagId, prerequisiteId)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to delete tag prerequisite")
+			log.Printf("Unable to delete tag prerequisite : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// returns the topics to study before the target one in the topological order. The progress of the topics is taken
+// from the notes visible to the caller: the note is completed if the caller has completed it in any course
+func GetLearningPath(c *gin.Context) {
+	tagId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result learningPath
+		_, err := queries.GetTag(tx, ctx, tagId)
+		if err != nil {
+			return result, err
+		}
+		edges, err := queries.GetTagPrerequisiteEdges(tx, ctx)
+		if err != nil {
+			return result, err
+		}
+		graph := learningpath.NewGraph(edges)
+		order := graph.Order(tagId)
+
+		notes, err := queries.GetTagNotes(tx, ctx, userId, order)
+		if err != nil {
+			return result, err
+		}
+		result.steps = learningpath.Plan(graph, tagId, notes)
+
+		tags, err := queries.GetTagsByIds(tx, ctx, order)
+		if err != nil {
+			return result, err
+		}
+		result.tags, err = loadTagDetails(tx, ctx, tags)
+		return result, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get learning path")
+			log.Printf("Unable to get learning path : %s", err)
+		}
+		return
+	}
+
+	path, ok := data.(learningPath)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get learning path")
+		log.Printf("Unable to get learning path : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertLearningPath(tagId, path))
+}
+
+// returns the names of the tags in the same order, the tag could be repeated
+func getTagNames(tx *sql.Tx, ctx context.Context, ids []int) ([]string, error) {
+	tags, err := queries.GetTagsByIds(tx, ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string)
+	for _, tag := range tags {
+		names[tag.Id] = tag.Name
+	}
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, names[id])
+	}
+	return result, nil
+}
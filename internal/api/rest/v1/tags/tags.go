@@ -9,6 +9,8 @@ import (
 	"strconv"
 
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
@@ -18,9 +20,17 @@ import (
 )
 
 type TagDTO struct {
-	Id    int
-	Name  string
-	State string
+	Id         int
+	Name       string
+	State      string
+	ParentId   *int          `json:",omitempty"`
+	Aliases    []TagAliasDTO `json:",omitempty"`
+	UsageCount int
+}
+
+type TagAliasDTO struct {
+	Id   int
+	Name string
 }
 
 type TagListDTO struct {
@@ -40,19 +50,48 @@ type TagCreateDTO struct {
 	State string `json:"state" binding:"required"`
 }
 
-func convertTags(tags []entities.Tag) []TagDTO {
-	if tags == nil {
+type tagsWithDetails struct {
+	tags        []entities.Tag
+	usageCounts map[int]int
+	aliases     map[int][]entities.TagAlias
+}
+
+func convertTags(data tagsWithDetails) []TagDTO {
+	if data.tags == nil {
 		return make([]TagDTO, 0)
 	}
 	var result []TagDTO
-	for _, tag := range tags {
-		result = append(result, convertTag(tag))
+	for _, tag := range data.tags {
+		result = append(result, convertTag(tag, data.usageCounts[tag.Id], data.aliases[tag.Id]))
 	}
 	return result
 }
 
-func convertTag(tag entities.Tag) TagDTO {
-	return TagDTO{Id: tag.Id, Name: tag.Name, State: tag.State}
+func convertTag(tag entities.Tag, usageCount int, aliases []entities.TagAlias) TagDTO {
+	result := TagDTO{Id: tag.Id, Name: tag.Name, State: tag.State, UsageCount: usageCount}
+	if tag.ParentId.Valid {
+		parentId := int(tag.ParentId.Int32)
+		result.ParentId = &parentId
+	}
+	for _, alias := range aliases {
+		result.Aliases = append(result.Aliases, TagAliasDTO{Id: alias.Id, Name: alias.Name})
+	}
+	return result
+}
+
+func loadTagDetails(tx *sql.Tx, ctx context.Context, tags []entities.Tag) (tagsWithDetails, error) {
+	result := tagsWithDetails{tags: tags}
+	tagIds := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		tagIds = append(tagIds, tag.Id)
+	}
+	var err error
+	result.usageCounts, err = queries.GetTagsUsageCounts(tx, ctx, tagIds)
+	if err != nil {
+		return result, err
+	}
+	result.aliases, err = queries.GetTagsAliases(tx, ctx, tagIds)
+	return result, err
 }
 
 func GetTags(c *gin.Context) {
@@ -71,7 +110,10 @@ func GetTags(c *gin.Context) {
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
 		tags, err := queries.GetTags(tx, ctx, limit, offset)
-		return tags, err
+		if err != nil {
+			return tagsWithDetails{}, err
+		}
+		return loadTagDetails(tx, ctx, tags)
 	})()
 
 	if err != nil {
@@ -80,14 +122,14 @@ func GetTags(c *gin.Context) {
 		return
 	}
 
-	tags, ok := data.([]entities.Tag)
+	tags, ok := data.(tagsWithDetails)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, "Unable to get tags")
 		log.Printf("Unable to get to tags : %s", api.ERROR_ASSERT_RESULT_TYPE)
 		return
 	}
 
-	result := &TagListDTO{Data: convertTags(tags), Count: len(tags), Offset: offset, Limit: limit}
+	result := &TagListDTO{Data: convertTags(tags), Count: len(tags.tags), Offset: offset, Limit: limit}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -108,7 +150,10 @@ func GetTag(c *gin.Context) {
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
 		tag, err := queries.GetTag(tx, ctx, tagId)
-		return tag, err
+		if err != nil {
+			return tagsWithDetails{}, err
+		}
+		return loadTagDetails(tx, ctx, []entities.Tag{tag})
 	})()
 
 	if err != nil {
@@ -121,14 +166,14 @@ func GetTag(c *gin.Context) {
 		return
 	}
 
-	tag, ok := data.(entities.Tag)
+	tags, ok := data.(tagsWithDetails)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, "Unable to get tag")
 		log.Printf("Unable to get to tag : %s", api.ERROR_ASSERT_RESULT_TYPE)
 		return
 	}
 
-	c.JSON(http.StatusOK, convertTag(tag))
+	c.JSON(http.StatusOK, convertTags(tags)[0])
 }
 
 func CreateTag(c *gin.Context) {
@@ -151,7 +196,15 @@ func CreateTag(c *gin.Context) {
 	}
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := checkAliasIsMissed(tx, ctx, tag.Name)
+		if err != nil {
+			return -1, err
+		}
 		result, err := queries.CreateTag(tx, ctx, tag.Name, tag.State)
+		if err != nil {
+			return result, err
+		}
+		err = events.PublishTagEvent(tx, ctx, result, entities.EVENT_ACTION_CREATED)
 		return result, err
 	})()
 
@@ -202,8 +255,15 @@ func UpdateTag(c *gin.Context) {
 	}
 
 	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.UpdateTag(tx, ctx, tagId, tag.Name, tag.State)
-		return err
+		err := checkAliasIsMissed(tx, ctx, tag.Name)
+		if err != nil {
+			return err
+		}
+		err = queries.UpdateTag(tx, ctx, tagId, tag.Name, tag.State)
+		if err != nil {
+			return err
+		}
+		return events.PublishTagEvent(tx, ctx, tagId, entities.EVENT_ACTION_UPDATED)
 	})()
 
 	if err != nil {
@@ -236,9 +296,17 @@ func DeleteTag(c *gin.Context) {
 		return
 	}
 
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
 	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.DeleteTag(tx, ctx, id)
-		return err
+		err := queries.DeleteTag(tx, ctx, id, userId)
+		if err != nil {
+			return err
+		}
+		return events.PublishTagEvent(tx, ctx, id, entities.EVENT_ACTION_DELETED)
 	})()
 
 	if err != nil {
@@ -253,3 +321,15 @@ func DeleteTag(c *gin.Context) {
 
 	c.JSON(http.StatusOK, api.DONE)
 }
+
+// the name of the tag can not be taken by the alias, so it is reported as the duplicate of the tag
+func checkAliasIsMissed(tx *sql.Tx, ctx context.Context, name string) error {
+	_, err := queries.GetTagAliasByName(tx, ctx, name)
+	if err == nil {
+		return db.ErrorTagDuplicateKey
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
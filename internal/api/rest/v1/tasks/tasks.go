@@ -9,6 +9,9 @@ import (
 	"strconv"
 
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/auth"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
@@ -152,6 +155,16 @@ func CreateTask(c *gin.Context) {
 
 	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
 		result, err := queries.CreateTask(tx, ctx, task.Name, task.State)
+		if err != nil {
+			return result, err
+		}
+		if task.State == entities.TASK_STATE_DONE {
+			err = addTaskDoneActivity(c, tx, ctx, result)
+			if err != nil {
+				return result, err
+			}
+		}
+		err = events.PublishTaskEvent(tx, ctx, result, entities.EVENT_ACTION_CREATED)
 		return result, err
 	})()
 
@@ -202,8 +215,21 @@ func UpdateTask(c *gin.Context) {
 	}
 
 	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.UpdateTask(tx, ctx, taskId, task.Name, task.State)
-		return err
+		current, err := queries.GetTask(tx, ctx, taskId)
+		if err != nil {
+			return err
+		}
+		err = queries.UpdateTask(tx, ctx, taskId, task.Name, task.State)
+		if err != nil {
+			return err
+		}
+		if task.State == entities.TASK_STATE_DONE && current.State != entities.TASK_STATE_DONE {
+			err = addTaskDoneActivity(c, tx, ctx, taskId)
+			if err != nil {
+				return err
+			}
+		}
+		return events.PublishTaskEvent(tx, ctx, taskId, entities.EVENT_ACTION_UPDATED)
 	})()
 
 	if err != nil {
@@ -236,9 +262,17 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
 	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-		err := queries.DeleteTask(tx, ctx, id)
-		return err
+		err := queries.DeleteTask(tx, ctx, id, userId)
+		if err != nil {
+			return err
+		}
+		return events.PublishTaskEvent(tx, ctx, id, entities.EVENT_ACTION_DELETED)
 	})()
 
 	if err != nil {
@@ -253,3 +287,12 @@ func DeleteTask(c *gin.Context) {
 
 	c.JSON(http.StatusOK, api.DONE)
 }
+
+// tasks have no owner, so the completion is counted for the user who made it
+func addTaskDoneActivity(c *gin.Context, tx *sql.Tx, ctx context.Context, taskId int) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return nil
+	}
+	return queries.AddUserActivity(tx, ctx, userId, entities.ACTIVITY_TYPE_TASK_DONE, taskId)
+}
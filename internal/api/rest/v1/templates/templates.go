@@ -0,0 +1,224 @@
+package templates
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/notetemplate"
+	"github.com/gin-gonic/gin"
+)
+
+// the fields are the names of the custom placeholders of the topic and the text, their values are expected on the note creation
+type NoteTemplateDTO struct {
+	Id             int
+	UserId         int
+	Name           string
+	Topic          string
+	Text           string
+	Shared         bool
+	Fields         []string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+type NoteTemplateListDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []NoteTemplateDTO
+}
+
+type NoteTemplateEditDTO struct {
+	Name   string `json:"name" binding:"required,max=256"`
+	Topic  string `json:"topic" binding:"max=512"` // optional, the topic is expected on the note creation if it is missed
+	Text   string `json:"text" binding:"required"`
+	Shared bool   `json:"shared"` // optional, the template is personal by default
+}
+
+func convertNoteTemplates(templates []entities.NoteTemplate) []NoteTemplateDTO {
+	if templates == nil {
+		return make([]NoteTemplateDTO, 0)
+	}
+	var result []NoteTemplateDTO
+	for _, template := range templates {
+		result = append(result, convertNoteTemplate(template))
+	}
+	return result
+}
+
+func convertNoteTemplate(template entities.NoteTemplate) NoteTemplateDTO {
+	fields := notetemplate.Fields(template.Topic, template.Text)
+	if fields == nil {
+		fields = make([]string, 0)
+	}
+	return NoteTemplateDTO{
+		Id:             template.Id,
+		UserId:         template.UserId,
+		Name:           template.Name,
+		Topic:          template.Topic,
+		Text:           template.Text,
+		Shared:         template.Shared,
+		Fields:         fields,
+		CreateDate:     template.CreateDate,
+		LastUpdateDate: template.LastUpdateDate,
+	}
+}
+
+// returns the templates of the caller and the shared ones
+func GetNoteTemplates(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		templates, err := queries.GetNoteTemplates(tx, ctx, userId, limit, offset)
+		return templates, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get note templates")
+		log.Printf("Unable to get note templates : %s", err)
+		return
+	}
+
+	templates, ok := data.([]entities.NoteTemplate)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note templates")
+		log.Printf("Unable to get note templates : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, &NoteTemplateListDTO{Count: len(templates), Offset: offset, Limit: limit, Data: convertNoteTemplates(templates)})
+}
+
+func GetNoteTemplate(c *gin.Context) {
+	templateId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		template, err := queries.GetNoteTemplate(tx, ctx, userId, templateId)
+		return template, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get note template")
+			log.Printf("Unable to get note template : %s", err)
+		}
+		return
+	}
+
+	template, ok := data.(entities.NoteTemplate)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get note template")
+		log.Printf("Unable to get note template : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertNoteTemplate(template))
+}
+
+func CreateNoteTemplate(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NoteTemplateEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		result, err := queries.CreateNoteTemplate(tx, ctx, userId, dto.Name, dto.Topic, dto.Text, dto.Shared)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		c.JSON(http.StatusInternalServerError, "Unable to create note template")
+		log.Printf("Unable to create note template : %s", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// updates the template of the caller, the shared templates of the other users are read-only
+func UpdateNoteTemplate(c *gin.Context) {
+	templateId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto NoteTemplateEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.UpdateNoteTemplate(tx, ctx, userId, templateId, dto.Name, dto.Topic, dto.Text, dto.Shared)
+	})()
+
+	sendEditResult(c, err, "Unable to update note template")
+}
+
+func DeleteNoteTemplate(c *gin.Context) {
+	templateId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		return queries.DeleteNoteTemplate(tx, ctx, userId, templateId)
+	})()
+
+	sendEditResult(c, err, "Unable to delete note template")
+}
+
+func sendEditResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, message)
+			log.Printf("%s : %s", message, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -0,0 +1,187 @@
+package timeentries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/activity"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/timetracking"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MAX_REPORT_DAYS     int = 366
+	DEFAULT_REPORT_DAYS int = 7
+)
+
+type TimeReportGroupDTO struct {
+	Key     string
+	Seconds int64
+}
+
+type TimeReportDTO struct {
+	From         string
+	To           string
+	GroupBy      string
+	Timezone     string
+	TotalSeconds int64
+	Groups       []TimeReportGroupDTO
+}
+
+type timeReport struct {
+	timezone string
+	from     time.Time
+	to       time.Time
+	report   timetracking.Report
+}
+
+func convertTimeReport(report timeReport, groupBy string) TimeReportDTO {
+	result := TimeReportDTO{
+		From:         report.from.Format(activity.DAY_LAYOUT),
+		To:           report.to.Format(activity.DAY_LAYOUT),
+		GroupBy:      groupBy,
+		Timezone:     report.timezone,
+		TotalSeconds: report.report.TotalSeconds,
+		Groups:       make([]TimeReportGroupDTO, 0, len(report.report.Groups)),
+	}
+	for _, group := range report.report.Groups {
+		result.Groups = append(result.Groups, TimeReportGroupDTO{Key: group.Key, Seconds: group.Seconds})
+	}
+	return result
+}
+
+// returns the time studied by the caller between the days 'from' and 'to' (inclusive, YYYY-MM-DD in the caller's timezone)
+// grouped by 'day', 'week' or 'tag' of the linked note. The last week is given by default
+func GetTimeReport(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("groupBy", timetracking.GROUP_BY_DAY)
+	possibleGroupings := timetracking.GetPossibleGroupings()
+	if !utils.Contains(possibleGroupings, groupBy) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to get time report. Wrong 'groupBy' value. Possible values: %v", possibleGroupings))
+		return
+	}
+	fromStr, toStr := c.Query("from"), c.Query("to")
+
+	now := time.Now()
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result timeReport
+		loc, err := userLocation(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+		result.timezone = loc.String()
+		result.from, result.to, err = parseReportRange(fromStr, toStr, now, loc)
+		if err != nil {
+			return result, err
+		}
+
+		err = queries.FinishExpiredTimers(tx, ctx, userId, now)
+		if err != nil {
+			return result, err
+		}
+		rangeStart := toServerTime(result.from)
+		rangeEnd := toServerTime(result.to.AddDate(0, 0, 1))
+		entries, err := queries.GetTimeEntriesInRange(tx, ctx, userId, rangeStart, rangeEnd)
+		if err != nil {
+			return result, err
+		}
+		tags, err := queries.GetTimeEntryTagsInRange(tx, ctx, userId, rangeStart, rangeEnd)
+		if err != nil {
+			return result, err
+		}
+
+		result.report = timetracking.Summarize(toSpans(entries, tags, now, loc), result.from, result.to, groupBy, loc)
+		return result, nil
+	})()
+
+	if err != nil {
+		if err == errorReportWrongRange {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_REPORT_WRONG_RANGE, MAX_REPORT_DAYS))
+		} else if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get time report")
+			log.Printf("Unable to get time report : %s", err)
+		}
+		return
+	}
+
+	report, ok := data.(timeReport)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get time report")
+		log.Printf("Unable to get time report : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertTimeReport(report, groupBy))
+}
+
+// returns the beginnings of the days in the location, the missed 'to' is today and the missed 'from' is 'to' minus the default days
+func parseReportRange(fromStr string, toStr string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	to := timetracking.DayStart(now, loc)
+	if toStr != "" {
+		date, err := time.ParseInLocation(activity.DAY_LAYOUT, toStr, loc)
+		if err != nil {
+			return to, to, errorReportWrongRange
+		}
+		to = date
+	}
+	from := to.AddDate(0, 0, 1-DEFAULT_REPORT_DAYS)
+	if fromStr != "" {
+		date, err := time.ParseInLocation(activity.DAY_LAYOUT, fromStr, loc)
+		if err != nil {
+			return from, to, errorReportWrongRange
+		}
+		from = date
+	}
+	if from.After(to) || from.AddDate(0, 0, MAX_REPORT_DAYS-1).Before(to) {
+		return from, to, errorReportWrongRange
+	}
+	return from, to, nil
+}
+
+func toSpans(entries []entities.TimeEntry, tags []entities.TimeEntryTag, now time.Time, loc *time.Location) []timetracking.Span {
+	entryTags := make(map[int][]string)
+	for _, tag := range tags {
+		entryTags[tag.TimeEntryId] = append(entryTags[tag.TimeEntryId], tag.Name)
+	}
+
+	result := make([]timetracking.Span, 0, len(entries))
+	for _, entry := range entries {
+		span := timetracking.Span{Start: activity.ToLocation(entry.StartDate, loc), End: now.In(loc), Tags: entryTags[entry.Id]}
+		// the running entry lasts until now
+		if entry.EndDate.Valid {
+			span.End = activity.ToLocation(entry.EndDate.Time, loc)
+		}
+		result = append(result, span)
+	}
+	return result
+}
+
+// returns the timezone of the user, the days of the reports and the pomodoros are counted in it
+func userLocation(tx *sql.Tx, ctx context.Context, userId int) (*time.Location, error) {
+	timezone, err := queries.GetUserTimezone(tx, ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("wrong timezone of user '%d': %s", userId, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,326 @@
+package timeentries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/activity"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+// the manual entry could not be longer, the forgotten timers should be corrected too
+const MAX_TIME_ENTRY_DURATION time.Duration = 24 * time.Hour
+
+var errorTimeEntryWrongReference = errors.New("time entry references missing note, task or course")
+var errorTimeEntryIsRunning = errors.New("time entry is running")
+var errorReportWrongRange = errors.New("wrong report range")
+
+type TimeEntryDTO struct {
+	Id              int
+	NoteId          *int `json:",omitempty"`
+	TaskId          *int `json:",omitempty"`
+	CourseId        *int `json:",omitempty"`
+	Description     string
+	Kind            string
+	Start           time.Time
+	End             *time.Time `json:",omitempty"`
+	DurationSeconds int64
+	PlannedSeconds  *int `json:",omitempty"`
+}
+
+type TimeEntryListDTO struct {
+	Count int
+	Data  []TimeEntryDTO
+}
+
+type TimeEntryEditDTO struct {
+	NoteId      *int      `json:"noteId"`
+	TaskId      *int      `json:"taskId"`
+	CourseId    *int      `json:"courseId"`
+	Description string    `json:"description" binding:"max=512"`
+	Start       time.Time `json:"start" binding:"required"`
+	End         time.Time `json:"end" binding:"required"`
+}
+
+func convertTimeEntries(entries []entities.TimeEntry, now time.Time) []TimeEntryDTO {
+	if entries == nil {
+		return make([]TimeEntryDTO, 0)
+	}
+	var result []TimeEntryDTO
+	for _, entry := range entries {
+		result = append(result, convertTimeEntry(entry, now))
+	}
+	return result
+}
+
+// the duration of the running entry is counted until now
+func convertTimeEntry(entry entities.TimeEntry, now time.Time) TimeEntryDTO {
+	result := TimeEntryDTO{
+		Id:             entry.Id,
+		NoteId:         nullableInt(entry.NoteId),
+		TaskId:         nullableInt(entry.TaskId),
+		CourseId:       nullableInt(entry.CourseId),
+		Description:    entry.Description,
+		Kind:           entry.Kind,
+		Start:          entry.StartDate,
+		PlannedSeconds: nullableInt(entry.PlannedDuration),
+	}
+	if entry.EndDate.Valid {
+		result.End = &entry.EndDate.Time
+		result.DurationSeconds = int64(entry.EndDate.Time.Sub(entry.StartDate) / time.Second)
+	} else {
+		result.DurationSeconds = int64(now.Sub(activity.ToLocation(entry.StartDate, time.Local)) / time.Second)
+	}
+	return result
+}
+
+func nullableInt(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int32)
+	return &result
+}
+
+func toNullInt(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
+
+// the dates of db are the wall clock of the server
+func toServerTime(date time.Time) time.Time {
+	return date.In(time.Local)
+}
+
+func isWrongReference(noteId *int, taskId *int, courseId *int) bool {
+	count := 0
+	for _, id := range []*int{noteId, taskId, courseId} {
+		if id != nil {
+			count++
+		}
+	}
+	return count > 1
+}
+
+// checks that the linked note is visible to the user and the linked task or course exists
+func checkReference(tx *sql.Tx, ctx context.Context, userId int, noteId *int, taskId *int, courseId *int) error {
+	var err error
+	switch {
+	case noteId != nil:
+		var permission string
+		permission, err = queries.GetNotePermission(tx, ctx, *noteId, userId)
+		if err == nil && permission == entities.NOTE_PERMISSION_NONE {
+			return errorTimeEntryWrongReference
+		}
+	case taskId != nil:
+		_, err = queries.GetTask(tx, ctx, *taskId)
+	case courseId != nil:
+		_, err = queries.GetCourse(tx, ctx, *courseId)
+	}
+	if err == sql.ErrNoRows {
+		return errorTimeEntryWrongReference
+	}
+	return err
+}
+
+// validates the reference and the dates of the manual entry and converts it, returns false if the response is sent already
+func toTimeEntry(c *gin.Context, dto TimeEntryEditDTO, userId int) (entities.TimeEntry, bool) {
+	if isWrongReference(dto.NoteId, dto.TaskId, dto.CourseId) {
+		c.JSON(http.StatusBadRequest, api.ERROR_TIME_ENTRY_WRONG_REFERENCE)
+		return entities.TimeEntry{}, false
+	}
+	if !dto.End.After(dto.Start) || dto.End.After(time.Now()) || dto.End.Sub(dto.Start) > MAX_TIME_ENTRY_DURATION {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf(api.ERROR_TIME_ENTRY_WRONG_DATES, MAX_TIME_ENTRY_DURATION))
+		return entities.TimeEntry{}, false
+	}
+	return entities.TimeEntry{
+		UserId:      userId,
+		NoteId:      toNullInt(dto.NoteId),
+		TaskId:      toNullInt(dto.TaskId),
+		CourseId:    toNullInt(dto.CourseId),
+		Description: dto.Description,
+		Kind:        entities.TIME_ENTRY_KIND_MANUAL,
+		StartDate:   toServerTime(dto.Start),
+		EndDate:     sql.NullTime{Time: toServerTime(dto.End), Valid: true},
+	}, true
+}
+
+// returns the time entries of the caller, the recent ones go first
+func GetTimeEntries(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := api.ParseLimitAndOffset(c)
+	now := time.Now()
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := queries.FinishExpiredTimers(tx, ctx, userId, now)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := queries.GetTimeEntries(tx, ctx, userId, limit, offset)
+		return entries, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get time entries")
+		log.Printf("Unable to get time entries : %s", err)
+		return
+	}
+
+	entries, ok := data.([]entities.TimeEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get time entries")
+		log.Printf("Unable to get time entries : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &TimeEntryListDTO{Data: convertTimeEntries(entries, now), Count: len(entries)}
+	c.JSON(http.StatusOK, result)
+}
+
+// creates the finished entry, e.g. for the time studied without the timer
+func CreateTimeEntry(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto TimeEntryEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	entry, ok := toTimeEntry(c, dto, userId)
+	if !ok {
+		return
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := checkReference(tx, ctx, userId, dto.NoteId, dto.TaskId, dto.CourseId)
+		if err != nil {
+			return -1, err
+		}
+		result, err := queries.CreateTimeEntry(tx, ctx, entry)
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		if err == errorTimeEntryWrongReference {
+			c.JSON(http.StatusBadRequest, api.ERROR_TIME_ENTRY_WRONG_REFERENCE)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to create time entry")
+			log.Printf("Unable to create time entry : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// updates the finished entry, the kind of the entry is kept
+func UpdateTimeEntry(c *gin.Context) {
+	entryId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto TimeEntryEditDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	entry, ok := toTimeEntry(c, dto, userId)
+	if !ok {
+		return
+	}
+	entry.Id = entryId
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.FinishExpiredTimers(tx, ctx, userId, time.Now())
+		if err != nil {
+			return err
+		}
+		current, err := queries.GetTimeEntry(tx, ctx, userId, entryId)
+		if err != nil {
+			return err
+		}
+		if !current.EndDate.Valid {
+			return errorTimeEntryIsRunning
+		}
+		err = checkReference(tx, ctx, userId, dto.NoteId, dto.TaskId, dto.CourseId)
+		if err != nil {
+			return err
+		}
+		return queries.UpdateTimeEntry(tx, ctx, entry)
+	})()
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case errorTimeEntryIsRunning:
+			c.JSON(http.StatusConflict, api.ERROR_TIME_ENTRY_IS_RUNNING)
+		case errorTimeEntryWrongReference:
+			c.JSON(http.StatusBadRequest, api.ERROR_TIME_ENTRY_WRONG_REFERENCE)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to update time entry")
+			log.Printf("Unable to update time entry : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
+
+// deletes the entry, the running one is discarded
+func DeleteTimeEntry(c *gin.Context) {
+	entryId, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		err := queries.DeleteTimeEntry(tx, ctx, userId, entryId)
+		return err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to delete time entry")
+			log.Printf("Unable to delete time entry : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
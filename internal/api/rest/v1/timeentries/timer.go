@@ -0,0 +1,242 @@
+package timeentries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/validation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/timetracking"
+	"github.com/gin-gonic/gin"
+)
+
+var errorTimerIsRunning = errors.New("timer is running already")
+
+// the running timer is missed if it is stopped. The break is suggested after the pomodoros finished today
+type TimerDTO struct {
+	Running          *TimeEntryDTO `json:",omitempty"`
+	RemainingSeconds *int64        `json:",omitempty"`
+	PomodorosToday   int
+	NextBreakMinutes int
+}
+
+// the timer is stopped automatically after the minutes if they are given, the pomodoro lasts 25 minutes by default
+type TimerStartDTO struct {
+	NoteId      *int   `json:"noteId"`
+	TaskId      *int   `json:"taskId"`
+	CourseId    *int   `json:"courseId"`
+	Description string `json:"description" binding:"max=512"`
+	Kind        string `json:"kind"`
+	Minutes     *int   `json:"minutes" binding:"omitempty,min=1,max=240"`
+}
+
+type timerState struct {
+	running        *entities.TimeEntry
+	pomodorosToday int
+}
+
+func convertTimer(state timerState, now time.Time) TimerDTO {
+	result := TimerDTO{
+		PomodorosToday:   state.pomodorosToday,
+		NextBreakMinutes: timetracking.NextBreakMinutes(state.pomodorosToday),
+	}
+	if state.running != nil {
+		running := convertTimeEntry(*state.running, now)
+		result.Running = &running
+		if running.PlannedSeconds != nil {
+			remaining := int64(*running.PlannedSeconds) - running.DurationSeconds
+			if remaining < 0 {
+				remaining = 0
+			}
+			result.RemainingSeconds = &remaining
+		}
+	}
+	return result
+}
+
+// returns the running timer of the caller and the count of the pomodoros finished today in the caller's timezone
+func GetTimer(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result timerState
+		err := queries.FinishExpiredTimers(tx, ctx, userId, now)
+		if err != nil {
+			return result, err
+		}
+		running, err := queries.GetRunningTimeEntry(tx, ctx, userId)
+		if err == nil {
+			result.running = &running
+		} else if err != sql.ErrNoRows {
+			return result, err
+		}
+		loc, err := userLocation(tx, ctx, userId)
+		if err != nil {
+			return result, err
+		}
+		result.pomodorosToday, err = queries.CountFinishedPomodoros(tx, ctx, userId, toServerTime(timetracking.DayStart(now, loc)))
+		return result, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to get timer")
+			log.Printf("Unable to get timer : %s", err)
+		}
+		return
+	}
+
+	state, ok := data.(timerState)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get timer")
+		log.Printf("Unable to get timer : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertTimer(state, now))
+}
+
+// starts the timer of the caller, it is not allowed to run several timers at once
+func StartTimer(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	var dto TimerStartDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		validation.ProcessAndSendValidationErrorMessage(c, err)
+		return
+	}
+
+	if dto.Kind == "" {
+		dto.Kind = entities.TIME_ENTRY_KIND_TIMER
+	}
+	possibleTimerKinds := entities.GetPossibleTimerKinds()
+	if !utils.Contains(possibleTimerKinds, dto.Kind) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to start timer. Wrong 'Kind' value. Possible values: %v", possibleTimerKinds))
+		return
+	}
+	if isWrongReference(dto.NoteId, dto.TaskId, dto.CourseId) {
+		c.JSON(http.StatusBadRequest, api.ERROR_TIME_ENTRY_WRONG_REFERENCE)
+		return
+	}
+
+	if dto.Minutes == nil && dto.Kind == entities.TIME_ENTRY_KIND_POMODORO {
+		minutes := timetracking.POMODORO_MINUTES
+		dto.Minutes = &minutes
+	}
+	var plannedDuration sql.NullInt32
+	if dto.Minutes != nil {
+		plannedDuration = sql.NullInt32{Int32: int32(*dto.Minutes * 60), Valid: true}
+	}
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		now := time.Now()
+		err := queries.FinishExpiredTimers(tx, ctx, userId, now)
+		if err != nil {
+			return -1, err
+		}
+		_, err = queries.GetRunningTimeEntry(tx, ctx, userId)
+		if err == nil {
+			return -1, errorTimerIsRunning
+		}
+		if err != sql.ErrNoRows {
+			return -1, err
+		}
+		err = checkReference(tx, ctx, userId, dto.NoteId, dto.TaskId, dto.CourseId)
+		if err != nil {
+			return -1, err
+		}
+		result, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{
+			UserId:          userId,
+			NoteId:          toNullInt(dto.NoteId),
+			TaskId:          toNullInt(dto.TaskId),
+			CourseId:        toNullInt(dto.CourseId),
+			Description:     dto.Description,
+			Kind:            dto.Kind,
+			StartDate:       now,
+			PlannedDuration: plannedDuration,
+		})
+		return result, err
+	})()
+
+	if err != nil || data == -1 {
+		switch err {
+		case errorTimerIsRunning:
+			c.JSON(http.StatusConflict, api.ERROR_TIMER_IS_RUNNING)
+		case errorTimeEntryWrongReference:
+			c.JSON(http.StatusBadRequest, api.ERROR_TIME_ENTRY_WRONG_REFERENCE)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to start timer")
+			log.Printf("Unable to start timer : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// stops the running timer of the caller and returns the finished entry
+func StopTimer(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		err := queries.FinishExpiredTimers(tx, ctx, userId, now)
+		if err != nil {
+			return nil, err
+		}
+		running, err := queries.GetRunningTimeEntry(tx, ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		err = queries.FinishTimeEntry(tx, ctx, userId, running.Id, now)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := queries.GetTimeEntry(tx, ctx, userId, running.Id)
+		return entry, err
+	})()
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, api.ERROR_TIMER_IS_NOT_RUNNING)
+		} else {
+			c.JSON(http.StatusInternalServerError, "Unable to stop timer")
+			log.Printf("Unable to stop timer : %s", err)
+		}
+		return
+	}
+
+	entry, ok := data.(entities.TimeEntry)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to stop timer")
+		log.Printf("Unable to stop timer : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertTimeEntry(entry, now))
+}
@@ -0,0 +1,99 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/attachments"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+)
+
+const DEFAULT_RETENTION_IN_DAYS string = "30"
+const DEFAULT_PURGE_INTERVAL_IN_MINUTES string = "60"
+
+var retention time.Duration
+var purgeInterval time.Duration
+
+var once sync.Once
+var purgingOnce sync.Once
+
+func Setup() {
+	once.Do(func() {
+		days, err := strconv.Atoi(utils.EnvVarDefault("TRASH_RETENTION_IN_DAYS", DEFAULT_RETENTION_IN_DAYS))
+		if err != nil || days <= 0 {
+			log.Fatalf("Wrong value of environment variable: TRASH_RETENTION_IN_DAYS. It should be positive integer number")
+		}
+		minutes, err := strconv.Atoi(utils.EnvVarDefault("TRASH_PURGE_INTERVAL_IN_MINUTES", DEFAULT_PURGE_INTERVAL_IN_MINUTES))
+		if err != nil || minutes <= 0 {
+			log.Fatalf("Wrong value of environment variable: TRASH_PURGE_INTERVAL_IN_MINUTES. It should be positive integer number")
+		}
+		retention = time.Duration(days) * 24 * time.Hour
+		purgeInterval = time.Duration(minutes) * time.Minute
+	})
+}
+
+// starts purging of the items deleted longer than the retention period ago in background.
+// Every instance of the app could run it, the items are purged by a single transaction
+func StartPurging() {
+	purgingOnce.Do(func() {
+		Setup()
+		go func() {
+			ticker := time.NewTicker(purgeInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				purge()
+			}
+		}()
+	})
+}
+
+type purgeResult struct {
+	notes     int
+	tasks     int
+	tags      int
+	checksums []string
+}
+
+func purge() {
+	before := time.Now().Add(-retention)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		var result purgeResult
+		var err error
+		result.notes, result.checksums, err = queries.PurgeNotes(tx, ctx, before)
+		if err != nil {
+			return result, err
+		}
+		result.tasks, err = queries.PurgeTasks(tx, ctx, before)
+		if err != nil {
+			return result, err
+		}
+		result.tags, err = queries.PurgeTags(tx, ctx, before)
+		return result, err
+	})()
+
+	if err != nil {
+		log.Printf("Unable to purge trash : %s", err)
+		return
+	}
+
+	result, ok := data.(purgeResult)
+	if !ok {
+		log.Printf("Unable to purge trash : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	// the blobs are removed after the commit, so they are not lost if the purging is rolled back
+	attachments.DeleteOrphanBlobs(result.checksums)
+
+	if result.notes+result.tasks+result.tags > 0 {
+		log.Printf("Trash is purged, notes: %d, tasks: %d, tags: %d", result.notes, result.tasks, result.tags)
+	}
+}
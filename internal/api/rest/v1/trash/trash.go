@@ -0,0 +1,156 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/access"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/gin-gonic/gin"
+)
+
+// the item is purged at PurgeAt or a bit later, depending on the purging interval
+type TrashItemDTO struct {
+	Type      string
+	Id        int
+	Name      string
+	DeletedAt time.Time
+	PurgeAt   time.Time
+}
+
+type TrashDTO struct {
+	Count  int
+	Offset int
+	Limit  int
+	Data   []TrashItemDTO
+}
+
+func convertTrashItems(items []entities.TrashItem) []TrashItemDTO {
+	if items == nil {
+		return make([]TrashItemDTO, 0)
+	}
+	var result []TrashItemDTO
+	for _, item := range items {
+		result = append(result, TrashItemDTO{
+			Type:      item.Type,
+			Id:        item.Id,
+			Name:      item.Name,
+			DeletedAt: item.DeletedAt,
+			PurgeAt:   item.DeletedAt.Add(retention),
+		})
+	}
+	return result
+}
+
+// returns the deleted notes of the caller and the tasks and tags deleted by the caller, optionally filtered by 'type'
+func GetTrash(c *gin.Context) {
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	itemType := c.Query("type")
+	if itemType != "" && !utils.Contains(entities.GetPossibleTrashItemTypes(), itemType) {
+		c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to get trash. Wrong 'type' value. Possible values: %v", entities.GetPossibleTrashItemTypes()))
+		return
+	}
+	limit, offset := api.ParseLimitAndOffset(c)
+
+	data, err := db.Tx(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) (any, error) {
+		items, err := queries.GetTrashItems(tx, ctx, userId, itemType, limit, offset)
+		return items, err
+	})()
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "Unable to get trash")
+		log.Printf("Unable to get trash : %s", err)
+		return
+	}
+
+	items, ok := data.([]entities.TrashItem)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, "Unable to get trash")
+		log.Printf("Unable to get trash : %s", api.ERROR_ASSERT_RESULT_TYPE)
+		return
+	}
+
+	result := &TrashDTO{Data: convertTrashItems(items), Count: len(items), Offset: offset, Limit: limit}
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the item type by the name of the resource in the path, e.g. 'notes'
+func parseItemType(c *gin.Context) (string, bool) {
+	switch c.Param("type") {
+	case "notes":
+		return entities.TRASH_ITEM_TYPE_NOTE, true
+	case "tasks":
+		return entities.TRASH_ITEM_TYPE_TASK, true
+	case "tags":
+		return entities.TRASH_ITEM_TYPE_TAG, true
+	}
+	c.JSON(http.StatusBadRequest, fmt.Sprintf("Unable to restore item. Wrong 'type' value. Possible values: %v", []string{"notes", "tasks", "tags"}))
+	return "", false
+}
+
+// brings the item back from the trash of the caller, the tasks and the tags get their original names back
+func RestoreItem(c *gin.Context) {
+	itemType, ok := parseItemType(c)
+	if !ok {
+		return
+	}
+	id, ok := api.ParseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	userId, ok := access.Caller(c)
+	if !ok {
+		return
+	}
+
+	err := db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+		switch itemType {
+		case entities.TRASH_ITEM_TYPE_NOTE:
+			err := queries.RestoreNote(tx, ctx, userId, id)
+			if err != nil {
+				return err
+			}
+			return events.PublishNoteEvent(tx, ctx, id, entities.EVENT_ACTION_CREATED)
+		case entities.TRASH_ITEM_TYPE_TASK:
+			err := queries.RestoreTask(tx, ctx, userId, id)
+			if err != nil {
+				return err
+			}
+			return events.PublishTaskEvent(tx, ctx, id, entities.EVENT_ACTION_CREATED)
+		default:
+			err := queries.RestoreTag(tx, ctx, userId, id)
+			if err != nil {
+				return err
+			}
+			return events.PublishTagEvent(tx, ctx, id, entities.EVENT_ACTION_CREATED)
+		}
+	})()
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, api.PAGE_NOT_FOUND)
+		case db.ErrorTaskDuplicateKey, db.ErrorTagDuplicateKey:
+			c.JSON(http.StatusBadRequest, api.DUPLICATE_FOUND)
+		default:
+			c.JSON(http.StatusInternalServerError, "Unable to restore item")
+			log.Printf("Unable to restore item : %s", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DONE)
+}
@@ -28,6 +28,12 @@ func GetValidationMessageForTag(tag string) string {
 		return "This field should contain a basic numeric value only"
 	case "alphanum":
 		return "This field should contain ASCII alphanumeric characters only"
+	case "min":
+		return "This field value is less than allowed"
+	case "max":
+		return "This field value is greater than allowed"
+	case "url":
+		return "Wrong URL format"
 	}
 	return ""
 }
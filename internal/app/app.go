@@ -53,6 +53,14 @@ func AuthReqired() gin.HandlerFunc {
 			return
 		}
 
+		userId, ok := validationResult.GetUserId()
+		if !ok {
+			c.JSON(http.StatusUnauthorized, "Unauthorized")
+			c.Abort()
+			return
+		}
+		c.Set(auth.USER_ID_CONTEXT_KEY, userId)
+
 		c.Next()
 	}
 }
@@ -69,11 +77,15 @@ func GetHost() string {
 	return host
 }
 
-func StartServer(host string, router *gin.Engine) {
+// the shutdown hooks are called on the graceful shutdown, e.g. for closing the long-lived connections
+func StartServer(host string, router *gin.Engine, onShutdown ...func()) {
 	srv := &http.Server{
 		Addr:    host,
 		Handler: router,
 	}
+	for _, f := range onShutdown {
+		srv.RegisterOnShutdown(f)
+	}
 
 	// Initializing the server in a goroutine so that it won't block the graceful shutdown handling below
 	go func() {
@@ -83,7 +95,7 @@ func StartServer(host string, router *gin.Engine) {
 	}()
 
 	// Wait for interrupt signal to gracefully shutdown the server with a timeout of 5 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be caught, so don't need to add it
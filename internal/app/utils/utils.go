@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
 	"log"
 	"os"
@@ -22,6 +24,16 @@ func CreateSHA256HashHexEncoded(str string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// generates url-safe random token from the given count of random bytes
+func GenerateRandomToken(bytesCount int) (string, error) {
+	buf := make([]byte, bytesCount)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
 func Contains(array []string, elem string) bool {
 	for _, n := range array {
 		if elem == n {
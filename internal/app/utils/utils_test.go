@@ -31,3 +31,14 @@ func TestSha256Hash(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestGenerateRandomToken(t *testing.T) {
+	token1, err := utils.GenerateRandomToken(32)
+	assert.Nil(t, err)
+	token2, err := utils.GenerateRandomToken(32)
+	assert.Nil(t, err)
+
+	// 32 bytes in base64 without padding
+	assert.Equal(t, 43, len(token1))
+	assert.NotEqual(t, token1, token2)
+}
@@ -0,0 +1,390 @@
+package bibtex
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+)
+
+const YEAR_IS_UNKNOWN string = "n.d."
+
+var keyRegexp = regexp.MustCompile(`^` + markdown.CITATION_KEY_PATTERN + `$`)
+var nameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// the standard month macros
+var months = map[string]string{
+	"jan": "January", "feb": "February", "mar": "March", "apr": "April", "may": "May", "jun": "June",
+	"jul": "July", "aug": "August", "sep": "September", "oct": "October", "nov": "November", "dec": "December",
+}
+
+// the entry of bibliography, type and field names are in lower case, values are without the outer braces or quotes
+type Entry struct {
+	Type   string
+	Key    string
+	Fields map[string]string
+}
+
+func IsValidKey(key string) bool {
+	return keyRegexp.MatchString(key)
+}
+
+// checks the name of the entry type or the field, e.g. "book" or "booktitle"
+func IsValidName(name string) bool {
+	return nameRegexp.MatchString(name)
+}
+
+// checks that the braces of the field value are balanced, otherwise the formatted value could not be parsed back
+func IsBalanced(value string) bool {
+	depth := 0
+	for _, r := range value {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// parses BibTeX text, the broken entries are skipped and reported as errors with the line number.
+// @string macros are expanded, @comment and @preamble are ignored
+func Parse(text string) ([]Entry, []error) {
+	p := &parser{text: []rune(text), macros: make(map[string]string)}
+	for name, value := range months {
+		p.macros[name] = value
+	}
+
+	var entries []Entry
+	var errs []error
+	for p.skipTo('@') {
+		start := p.pos
+		entry, ok, err := p.parseEntry()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %v: %s", p.lineOf(start), err))
+			p.pos = start + 1
+			continue
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, errs
+}
+
+// formats the entries as BibTeX text, author and title go first, the other fields in alphabetical order
+func Format(entries []Entry) string {
+	var builder strings.Builder
+	for i, entry := range entries {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("@%s{%s", entry.Type, entry.Key))
+		for _, name := range fieldNames(entry.Fields) {
+			builder.WriteString(fmt.Sprintf(",\n  %s = {%s}", name, entry.Fields[name]))
+		}
+		builder.WriteString("\n}\n")
+	}
+	return builder.String()
+}
+
+// returns the short label of the entry for the author-date citations, e.g. "Knuth, 1984", "Aho and Ullman, 1977" or "Cormen et al., 2009"
+func Label(entry Entry) string {
+	names := entry.Fields["author"]
+	if names == "" {
+		names = entry.Fields["editor"]
+	}
+	year := entry.Fields["year"]
+	if year == "" {
+		year = YEAR_IS_UNKNOWN
+	}
+	if names == "" {
+		return fmt.Sprintf("%s, %s", entry.Key, year)
+	}
+
+	persons := splitNames(names)
+	switch len(persons) {
+	case 1:
+		return fmt.Sprintf("%s, %s", lastName(persons[0]), year)
+	case 2:
+		return fmt.Sprintf("%s and %s, %s", lastName(persons[0]), lastName(persons[1]), year)
+	default:
+		return fmt.Sprintf("%s et al., %s", lastName(persons[0]), year)
+	}
+}
+
+func fieldNames(fields map[string]string) []string {
+	var result []string
+	for _, name := range []string{"author", "title"} {
+		if _, ok := fields[name]; ok {
+			result = append(result, name)
+		}
+	}
+	var others []string
+	for name := range fields {
+		if name != "author" && name != "title" {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(result, others...)
+}
+
+// splits the names by the " and " separator which is not inside the braces
+func splitNames(names string) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(names); i++ {
+		switch names[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(names[i:], " and ") {
+				result = append(result, strings.TrimSpace(names[start:i]))
+				start = i + len(" and ")
+			}
+		}
+	}
+	return append(result, strings.TrimSpace(names[start:]))
+}
+
+// returns the last name of "Last, First" or "First Last", the braced name like "{World Health Organization}" is used as is
+func lastName(name string) string {
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		return name[1 : len(name)-1]
+	}
+	if index := strings.Index(name, ","); index >= 0 {
+		name = name[:index]
+	} else if words := strings.Fields(name); len(words) > 0 {
+		name = words[len(words)-1]
+	}
+	return strings.NewReplacer("{", "", "}", "").Replace(strings.TrimSpace(name))
+}
+
+type parser struct {
+	text   []rune
+	pos    int
+	macros map[string]string
+}
+
+func (p *parser) skipTo(r rune) bool {
+	for p.pos < len(p.text) {
+		if p.text[p.pos] == r {
+			return true
+		}
+		p.pos++
+	}
+	return false
+}
+
+func (p *parser) lineOf(pos int) int {
+	line := 1
+	for i := 0; i < pos && i < len(p.text); i++ {
+		if p.text[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.text) && unicode.IsSpace(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) identifier() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.text) && !unicode.IsSpace(p.text[p.pos]) && !strings.ContainsRune("{}()=,#\"@", p.text[p.pos]) {
+		p.pos++
+	}
+	return string(p.text[start:p.pos])
+}
+
+func (p *parser) expect(r rune) error {
+	p.skipSpaces()
+	if p.pos >= len(p.text) {
+		return fmt.Errorf("expected '%c' but the text is over", r)
+	}
+	if p.text[p.pos] != r {
+		return fmt.Errorf("expected '%c' but found '%c'", r, p.text[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// parses the entry which starts at '@', ok is false for the @string, @comment and @preamble
+func (p *parser) parseEntry() (Entry, bool, error) {
+	p.pos++
+	entryType := strings.ToLower(p.identifier())
+	if entryType == "" {
+		return Entry{}, false, fmt.Errorf("missed entry type")
+	}
+	p.skipSpaces()
+	if p.pos >= len(p.text) || (p.text[p.pos] != '{' && p.text[p.pos] != '(') {
+		return Entry{}, false, fmt.Errorf("expected '{' after @%s", entryType)
+	}
+	closing := '}'
+	if p.text[p.pos] == '(' {
+		closing = ')'
+	}
+
+	switch entryType {
+	case "comment", "preamble":
+		_, err := p.braced(p.text[p.pos], closing)
+		return Entry{}, false, err
+	case "string":
+		p.pos++
+		fields, err := p.fields(closing)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		for name, value := range fields {
+			p.macros[name] = value
+		}
+		return Entry{}, false, nil
+	}
+
+	p.pos++
+	key := p.identifier()
+	if !IsValidKey(key) {
+		return Entry{}, false, fmt.Errorf("wrong citation key '%s'", key)
+	}
+	p.skipSpaces()
+	if p.pos < len(p.text) && p.text[p.pos] == closing {
+		p.pos++
+		return Entry{Type: entryType, Key: key, Fields: make(map[string]string)}, true, nil
+	}
+	if err := p.expect(','); err != nil {
+		return Entry{}, false, err
+	}
+	fields, err := p.fields(closing)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	return Entry{Type: entryType, Key: key, Fields: fields}, true, nil
+}
+
+// parses "name = value, ..." until the closing rune, the trailing comma is allowed
+func (p *parser) fields(closing rune) (map[string]string, error) {
+	fields := make(map[string]string)
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.text) && p.text[p.pos] == closing {
+			p.pos++
+			return fields, nil
+		}
+		name := strings.ToLower(p.identifier())
+		if name == "" {
+			return nil, fmt.Errorf("missed field name")
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+
+		p.skipSpaces()
+		if p.pos < len(p.text) && p.text[p.pos] == ',' {
+			p.pos++
+		} else if err := p.expect(closing); err != nil {
+			return nil, err
+		} else {
+			return fields, nil
+		}
+	}
+}
+
+// parses the value parts joined by '#', the whitespaces are collapsed
+func (p *parser) value() (string, error) {
+	var builder strings.Builder
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.text) {
+			return "", fmt.Errorf("missed field value")
+		}
+		switch p.text[p.pos] {
+		case '{':
+			part, err := p.braced('{', '}')
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(part)
+		case '"':
+			part, err := p.quoted()
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(part)
+		default:
+			name := p.identifier()
+			if name == "" {
+				return "", fmt.Errorf("missed field value")
+			}
+			if macro, ok := p.macros[strings.ToLower(name)]; ok {
+				builder.WriteString(macro)
+			} else {
+				builder.WriteString(name)
+			}
+		}
+
+		p.skipSpaces()
+		if p.pos >= len(p.text) || p.text[p.pos] != '#' {
+			return strings.Join(strings.Fields(builder.String()), " "), nil
+		}
+		p.pos++
+	}
+}
+
+// returns the content between the balanced opening and closing runes
+func (p *parser) braced(opening rune, closing rune) (string, error) {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.text); p.pos++ {
+		switch p.text[p.pos] {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return string(p.text[start+1 : p.pos-1]), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("missed '%c'", closing)
+}
+
+// returns the content between the quotes, the quotes inside the braces are the part of the content
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	depth := 0
+	for p.pos++; p.pos < len(p.text); p.pos++ {
+		switch p.text[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '"':
+			if depth == 0 {
+				p.pos++
+				return string(p.text[start+1 : p.pos-1]), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("missed '\"'")
+}
@@ -0,0 +1,104 @@
+//go:build unit
+// +build unit
+
+package bibtex_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/bibtex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	text := `@comment{exported from somewhere}
+@string{aw = "Addison-Wesley"}
+@Book{knuth84,
+  Author    = {Donald E. Knuth},
+  title     = {The {\TeX}book},
+  publisher = aw # { Professional},
+  month     = jun,
+  year      = 1984,
+}
+@article(lamport94,
+  author = "Leslie Lamport",
+  title = "The Temporal Logic of
+           Actions"
+)`
+
+	entries, errs := bibtex.Parse(text)
+
+	assert.Empty(t, errs)
+	assert.Equal(t, []bibtex.Entry{
+		{Type: "book", Key: "knuth84", Fields: map[string]string{
+			"author":    "Donald E. Knuth",
+			"title":     "The {\\TeX}book",
+			"publisher": "Addison-Wesley Professional",
+			"month":     "June",
+			"year":      "1984",
+		}},
+		{Type: "article", Key: "lamport94", Fields: map[string]string{
+			"author": "Leslie Lamport",
+			"title":  "The Temporal Logic of Actions",
+		}},
+	}, entries)
+}
+
+func TestParseBrokenEntries(t *testing.T) {
+	text := "@book{wrong key, title = {A}}\n" +
+		"@book{unclosed, title = {B}\n" +
+		"@misc{valid, title = {C}}\n"
+
+	entries, errs := bibtex.Parse(text)
+
+	assert.Equal(t, 2, len(errs))
+	assert.Contains(t, errs[0].Error(), "line 1")
+	assert.Contains(t, errs[1].Error(), "line 2")
+	assert.Equal(t, []bibtex.Entry{{Type: "misc", Key: "valid", Fields: map[string]string{"title": "C"}}}, entries)
+}
+
+func TestFormatAndParse(t *testing.T) {
+	entries := []bibtex.Entry{
+		{Type: "book", Key: "knuth84", Fields: map[string]string{"year": "1984", "title": "The {\\TeX}book", "author": "Donald E. Knuth", "publisher": "Addison-Wesley"}},
+		{Type: "misc", Key: "empty", Fields: map[string]string{}},
+	}
+
+	text := bibtex.Format(entries)
+
+	assert.Equal(t, "@book{knuth84,\n"+
+		"  author = {Donald E. Knuth},\n"+
+		"  title = {The {\\TeX}book},\n"+
+		"  publisher = {Addison-Wesley},\n"+
+		"  year = {1984}\n"+
+		"}\n"+
+		"\n"+
+		"@misc{empty\n"+
+		"}\n", text)
+	parsed, errs := bibtex.Parse(text)
+	assert.Empty(t, errs)
+	assert.Equal(t, entries, parsed)
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, "Knuth, 1984", bibtex.Label(bibtex.Entry{Key: "a", Fields: map[string]string{"author": "Donald E. Knuth", "year": "1984"}}))
+	assert.Equal(t, "Aho and Ullman, 1977", bibtex.Label(bibtex.Entry{Key: "b", Fields: map[string]string{"author": "Aho, Alfred V. and Ullman, Jeffrey D.", "year": "1977"}}))
+	assert.Equal(t, "Cormen et al., 2009", bibtex.Label(bibtex.Entry{Key: "c", Fields: map[string]string{"author": "Thomas H. Cormen and Charles E. Leiserson and Ronald L. Rivest", "year": "2009"}}))
+	assert.Equal(t, "World Health Organization, n.d.", bibtex.Label(bibtex.Entry{Key: "d", Fields: map[string]string{"editor": "{World Health Organization}"}}))
+	assert.Equal(t, "e, 2020", bibtex.Label(bibtex.Entry{Key: "e", Fields: map[string]string{"year": "2020"}}))
+}
+
+func TestIsValidKey(t *testing.T) {
+	assert.True(t, bibtex.IsValidKey("knuth84"))
+	assert.True(t, bibtex.IsValidKey("doi:10.1000/182"))
+	assert.False(t, bibtex.IsValidKey(""))
+	assert.False(t, bibtex.IsValidKey("two words"))
+}
+
+func TestIsValidNameAndIsBalanced(t *testing.T) {
+	assert.True(t, bibtex.IsValidName("booktitle"))
+	assert.False(t, bibtex.IsValidName("Book"))
+	assert.False(t, bibtex.IsValidName("1st"))
+	assert.True(t, bibtex.IsBalanced("The {\\TeX}book"))
+	assert.False(t, bibtex.IsBalanced("}{"))
+	assert.False(t, bibtex.IsBalanced("{"))
+}
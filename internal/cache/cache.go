@@ -12,6 +12,7 @@ const (
 type Cache interface {
 	Get(k string) (string, bool)
 	Set(k string, v string)
+	Delete(k string)
 }
 
 type SimpleCache struct {
@@ -27,13 +28,21 @@ func (p *SimpleCache) Set(k string, v string) {
 	p.store[k] = v
 }
 
+func (p *SimpleCache) Delete(k string) {
+	delete(p.store, k)
+}
+
 type ConcurrentCache struct {
 	stores [BUCKET_NUMBER]map[string]string
 	rws    [BUCKET_NUMBER]sync.RWMutex
 }
 
+// the same seed is required for getting the same bucket of the key every time
+var seed = maphash.MakeSeed()
+
 func getBucketNumber(k string) int {
 	var h maphash.Hash
+	h.SetSeed(seed)
 	h.WriteString(k)
 	result := h.Sum64() % BUCKET_NUMBER
 	return int(result)
@@ -54,6 +63,13 @@ func (p *ConcurrentCache) Set(k string, v string) {
 	p.rws[bucketNumber].Unlock()
 }
 
+func (p *ConcurrentCache) Delete(k string) {
+	bucketNumber := getBucketNumber(k)
+	p.rws[bucketNumber].Lock()
+	delete(p.stores[bucketNumber], k)
+	p.rws[bucketNumber].Unlock()
+}
+
 func CreateSimpleCache() *SimpleCache {
 	return &SimpleCache{store: make(map[string]string)}
 }
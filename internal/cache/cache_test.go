@@ -0,0 +1,43 @@
+//go:build unit
+// +build unit
+
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCache(t *testing.T, c cache.Cache) {
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		value, ok := c.Get(fmt.Sprintf("key%d", i))
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("value%d", i), value)
+	}
+
+	c.Delete("key1")
+	_, ok := c.Get("key1")
+	assert.False(t, ok)
+	value, ok := c.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "value2", value)
+
+	// deleting of the missed key does nothing
+	c.Delete("missed")
+	_, ok = c.Get("missed")
+	assert.False(t, ok)
+}
+
+func TestSimpleCache(t *testing.T) {
+	testCache(t, cache.CreateSimpleCache())
+}
+
+func TestConcurrentCache(t *testing.T) {
+	testCache(t, cache.CreateConcurrentCache())
+}
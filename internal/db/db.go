@@ -68,8 +68,13 @@ func (s *singleton) GetTimeout() time.Duration {
 var ErrorTaskDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"tasks_name_state_unique\"")
 var ErrorTagDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"tags_name_state_unique\"")
 var ErrorUserDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"users_email_state_unique\"")
+var ErrorBibEntryDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"bib_entries_user_id_citation_key_unique\"")
+var ErrorContentReportDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"content_reports_reporter_open_unique\"")
+var ErrorBookmarkFolderDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"bookmark_folders_user_id_name_unique\"")
+var ErrorTagAliasDuplicateKey = errors.New("pq: duplicate key value violates unique constraint \"tag_aliases_name_unique\"")
 
-func createDatabase() *sql.DB {
+// returns the connection string of the database, e.g. for the listeners of the notifications which need own connection
+func ConnectionString() string {
 	dbEnvVars := [6]string{"DATABASE_HOST", "DATABASE_PORT", "DATABASE_USER", "DATABASE_PASSWORD", "DATABASE_NAME", "DATABASE_SSL_MODE"}
 	var variables []string
 	for _, element := range dbEnvVars {
@@ -77,8 +82,11 @@ func createDatabase() *sql.DB {
 		variables = append(variables, value)
 	}
 
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", variables[0], variables[1], variables[2], variables[3], variables[4], variables[5])
-	result, err := sql.Open("postgres", psqlInfo)
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", variables[0], variables[1], variables[2], variables[3], variables[4], variables[5])
+}
+
+func createDatabase() *sql.DB {
+	result, err := sql.Open("postgres", ConnectionString())
 	if err != nil {
 		log.Fatalf("Unable to connect to database : %s", err)
 	}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type Attachment struct {
+	Id         int
+	NoteId     int
+	UserId     int
+	FileName   string
+	MimeType   string
+	Size       int64
+	Checksum   string
+	CreateDate time.Time
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// the entry of the bibliography of the user, the notes cite it by the key, e.g. [@knuth84].
+// Fields are BibTeX fields (author, title, year, etc.) with lower case names
+type BibEntry struct {
+	Id             int
+	UserId         int
+	CitationKey    string
+	EntryType      string
+	Fields         map[string]string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the personal folder of the bookmarks, the name is unique for the user regardless of the case
+type BookmarkFolder struct {
+	Id         int
+	UserId     int
+	Name       string
+	CreateDate time.Time
+}
+
+// the note saved by the user for later, the folder is optional
+type Bookmark struct {
+	UserId     int
+	NoteId     int
+	FolderId   sql.NullInt32
+	NoteTopic  string
+	CreateDate time.Time
+}
@@ -0,0 +1,61 @@
+package entities
+
+import "time"
+
+type Card struct {
+	Id             int
+	NoteId         int
+	UserId         int
+	Front          string
+	Back           string
+	Source         string
+	State          string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+const (
+	CARD_STATE_NEW     string = "NEW"
+	CARD_STATE_DELETED string = "DELETED"
+)
+
+const (
+	// the card is created by the user
+	CARD_SOURCE_MANUAL string = "MANUAL"
+	// the card is extracted from "Q:/A:" block of the note text
+	CARD_SOURCE_NOTE string = "NOTE"
+)
+
+// the review schedule of the card for the user
+type CardReview struct {
+	CardId         int
+	UserId         int
+	Repetitions    int
+	IntervalDays   int
+	EasinessFactor float64
+	DueDate        time.Time
+	LastReviewDate time.Time
+}
+
+// the card with the review schedule of the user, the schedule is nil if the user has never reviewed the card
+type DueCard struct {
+	Card
+	Review *CardReview
+}
+
+type CardReviewStats struct {
+	Cards         int
+	DueCards      int
+	NewCards      int
+	MatureCards   int
+	ReviewsTotal  int
+	ReviewsToday  int
+	AverageGrade  float64
+	RetentionRate float64
+	ReviewsByDay  []CardReviewsByDay
+}
+
+type CardReviewsByDay struct {
+	Date  time.Time
+	Count int
+}
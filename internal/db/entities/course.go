@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Course struct {
+	Id             int
+	UserId         int
+	Title          string
+	Description    string
+	State          string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+const (
+	COURSE_STATE_NEW     string = "NEW"
+	COURSE_STATE_DELETED string = "DELETED"
+)
+
+type CourseModule struct {
+	Id       int
+	CourseId int
+	Title    string
+	Position int
+}
+
+// the item of the module references either the note or the task
+type CourseItem struct {
+	Id       int
+	ModuleId int
+	NoteId   sql.NullInt32
+	TaskId   sql.NullInt32
+	Position int
+}
+
+type CourseEnrollment struct {
+	CourseId       int
+	UserId         int
+	LastItemId     sql.NullInt32
+	EnrollDate     time.Time
+	LastUpdateDate time.Time
+}
+
+// the progress of the enrolled user, only the items which are still in the course are taken into account
+type CourseProgress struct {
+	Enrollment       CourseEnrollment
+	ItemsTotal       int
+	CompletedItemIds []int
+}
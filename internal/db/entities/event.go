@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+// the change of the resource streamed to the users who are able to see it. The audience is fixed when the event is published:
+// either everybody for the public resource or the listed users
+type Event struct {
+	Id           int
+	ResourceType string
+	ResourceId   int
+	Action       string
+	Public       bool
+	UserIds      []int
+	CreateDate   time.Time
+}
+
+const (
+	EVENT_RESOURCE_TYPE_NOTE    string = "NOTE"
+	EVENT_RESOURCE_TYPE_COMMENT string = "COMMENT"
+	EVENT_RESOURCE_TYPE_TASK    string = "TASK"
+	EVENT_RESOURCE_TYPE_TAG     string = "TAG"
+)
+
+const (
+	EVENT_ACTION_CREATED string = "CREATED"
+	EVENT_ACTION_UPDATED string = "UPDATED"
+	EVENT_ACTION_DELETED string = "DELETED"
+)
+
+func (e Event) IsVisibleTo(userId int) bool {
+	if e.Public {
+		return true
+	}
+	for _, id := range e.UserIds {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
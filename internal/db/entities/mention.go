@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the mention of the user by the actor in the note or, if the comment is set, in the comment of the note
+type Mention struct {
+	Id         int
+	UserId     int
+	ActorId    int
+	NoteId     int
+	CommentId  sql.NullInt32
+	NoteTopic  string
+	CreateDate time.Time
+}
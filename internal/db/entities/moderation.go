@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the complaint about the note or the comment, the report without reporter is made by the word filter
+type ContentReport struct {
+	Id             int
+	ContentType    string
+	ContentId      int
+	ReporterId     sql.NullInt32
+	Reason         string
+	Details        string
+	Status         string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+// the open reports of the same content grouped for the review, the author, the state and the text are of the content itself
+type ModerationQueueItem struct {
+	ContentType     string
+	ContentId       int
+	AuthorId        int
+	State           string
+	Text            string
+	ReportsCount    int
+	Reasons         []string
+	Flagged         bool
+	FirstReportDate time.Time
+	LastReportDate  time.Time
+}
+
+// the decision of the moderator, the previous state allows to restore the blocked content
+type ModerationLogEntry struct {
+	Id            int
+	ModeratorId   int
+	ContentType   string
+	ContentId     int
+	Action        string
+	Reason        string
+	PreviousState string
+	ReportsCount  int
+	CreateDate    time.Time
+}
+
+const (
+	CONTENT_TYPE_NOTE    string = "NOTE"
+	CONTENT_TYPE_COMMENT string = "COMMENT"
+)
+
+func GetPossibleContentTypes() []string {
+	return []string{CONTENT_TYPE_NOTE, CONTENT_TYPE_COMMENT}
+}
+
+const (
+	REPORT_REASON_SPAM          string = "SPAM"
+	REPORT_REASON_ABUSE         string = "ABUSE"
+	REPORT_REASON_INAPPROPRIATE string = "INAPPROPRIATE"
+	REPORT_REASON_COPYRIGHT     string = "COPYRIGHT"
+	REPORT_REASON_OTHER         string = "OTHER"
+	REPORT_REASON_WORD_FILTER   string = "WORD_FILTER"
+)
+
+// the reasons which users are able to choose, the word filter reason is set automatically
+func GetPossibleReportReasons() []string {
+	return []string{REPORT_REASON_SPAM, REPORT_REASON_ABUSE, REPORT_REASON_INAPPROPRIATE, REPORT_REASON_COPYRIGHT, REPORT_REASON_OTHER}
+}
+
+// the open report waits for the review, the others are closed by the corresponding moderation action
+const (
+	REPORT_STATUS_OPEN      string = "OPEN"
+	REPORT_STATUS_APPROVED  string = "APPROVED"
+	REPORT_STATUS_BLOCKED   string = "BLOCKED"
+	REPORT_STATUS_DISMISSED string = "DISMISSED"
+)
+
+const (
+	MODERATION_ACTION_APPROVE string = "APPROVE"
+	MODERATION_ACTION_BLOCK   string = "BLOCK"
+	MODERATION_ACTION_DISMISS string = "DISMISS"
+)
+
+// returns the status of the reports closed by the action
+func GetReportStatusByModerationAction(action string) string {
+	switch action {
+	case MODERATION_ACTION_APPROVE:
+		return REPORT_STATUS_APPROVED
+	case MODERATION_ACTION_BLOCK:
+		return REPORT_STATUS_BLOCKED
+	default:
+		return REPORT_STATUS_DISMISSED
+	}
+}
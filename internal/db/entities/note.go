@@ -1,6 +1,9 @@
 package entities
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type Note struct {
 	Id             int
@@ -9,16 +12,65 @@ type Note struct {
 	TagId          int
 	UserId         int
 	State          string
+	Visibility     string
 	CreateDate     time.Time
 	LastUpdateDate time.Time
+	PublishedAt    sql.NullTime // the date of the first publication
+	PublishAt      sql.NullTime // the date of the scheduled publication of the draft
 }
 
 const (
-	NOTE_STATE_NEW     string = "NEW"
-	NOTE_STATE_BLOCKED string = "BLOCKED"
-	NOTE_STATE_DELETED string = "DELETED"
+	NOTE_STATE_DRAFT     string = "DRAFT"
+	NOTE_STATE_PUBLISHED string = "PUBLISHED"
+	NOTE_STATE_ARCHIVED  string = "ARCHIVED"
+	NOTE_STATE_BLOCKED   string = "BLOCKED"
+	NOTE_STATE_DELETED   string = "DELETED"
 )
 
 func GetPossibleNoteStates() []string {
-	return []string{NOTE_STATE_NEW, NOTE_STATE_BLOCKED, NOTE_STATE_DELETED}
+	return []string{NOTE_STATE_DRAFT, NOTE_STATE_PUBLISHED, NOTE_STATE_ARCHIVED, NOTE_STATE_BLOCKED, NOTE_STATE_DELETED}
+}
+
+// the states of the author workflow
+func GetPossibleNoteWorkflowStates() []string {
+	return []string{NOTE_STATE_DRAFT, NOTE_STATE_PUBLISHED, NOTE_STATE_ARCHIVED}
+}
+
+// the states of the new notes
+func GetPossibleNoteInitialStates() []string {
+	return []string{NOTE_STATE_DRAFT, NOTE_STATE_PUBLISHED}
+}
+
+// the workflow of the note author, the author keeps the state while editing the note. The blocked notes are changed by the moderation
+// only, so their text is not rewritten without its review, and the deleted ones are changed by the deletion and the restoring only
+var noteStateTransitions = map[string][]string{
+	NOTE_STATE_DRAFT:     {NOTE_STATE_DRAFT, NOTE_STATE_PUBLISHED, NOTE_STATE_ARCHIVED},
+	NOTE_STATE_PUBLISHED: {NOTE_STATE_PUBLISHED, NOTE_STATE_DRAFT, NOTE_STATE_ARCHIVED},
+	NOTE_STATE_ARCHIVED:  {NOTE_STATE_ARCHIVED, NOTE_STATE_DRAFT, NOTE_STATE_PUBLISHED},
+	NOTE_STATE_BLOCKED:   {},
+	NOTE_STATE_DELETED:   {},
+}
+
+// returns the states the note could be moved to from the given one by its author, the note is not editable if there are none
+func GetPossibleNoteStateTransitions(from string) []string {
+	return append([]string{}, noteStateTransitions[from]...)
+}
+
+func IsNoteStateTransitionAllowed(from string, to string) bool {
+	for _, state := range GetPossibleNoteStateTransitions(from) {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	NOTE_VISIBILITY_PRIVATE string = "PRIVATE"
+	NOTE_VISIBILITY_SHARED  string = "SHARED"
+	NOTE_VISIBILITY_PUBLIC  string = "PUBLIC"
+)
+
+func GetPossibleNoteVisibilities() []string {
+	return []string{NOTE_VISIBILITY_PRIVATE, NOTE_VISIBILITY_SHARED, NOTE_VISIBILITY_PUBLIC}
 }
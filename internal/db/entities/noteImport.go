@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// the link between the imported file and the note created from it, allows to import the same files again without duplicates
+type NoteImport struct {
+	UserId         int
+	SourcePath     string
+	ContentHash    string
+	NoteId         int
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
@@ -0,0 +1,27 @@
+package entities
+
+import "database/sql"
+
+// the wiki link [[Target]] from the text of the note. The target is a topic or an id of the linked note,
+// TargetNoteId is not valid when the linked note does not exist (dangling link)
+type NoteLink struct {
+	SourceNoteId int
+	Target       string
+	TargetNoteId sql.NullInt32
+}
+
+// the node of the notes graph
+type NoteGraphNode struct {
+	Id    int
+	Topic string
+	TagId int
+}
+
+// the note with its tags and the ids of the notes it links to, it is used for finding the similar notes
+type NoteDocument struct {
+	Id        int
+	Topic     string
+	Text      string
+	TagIds    []int
+	LinkedIds []int
+}
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+type NoteShare struct {
+	NoteId     int
+	UserId     int
+	Permission string
+	CreateDate time.Time
+}
+
+// permissions are ordered by strength, each next one includes all previous
+const (
+	NOTE_PERMISSION_NONE  string = "NONE"
+	NOTE_PERMISSION_READ  string = "READ"
+	NOTE_PERMISSION_EDIT  string = "EDIT"
+	NOTE_PERMISSION_OWNER string = "OWNER"
+)
+
+func GetPossibleNoteSharePermissions() []string {
+	return []string{NOTE_PERMISSION_READ, NOTE_PERMISSION_EDIT}
+}
+
+func IsNotePermissionSufficient(actual string, required string) bool {
+	order := []string{NOTE_PERMISSION_NONE, NOTE_PERMISSION_READ, NOTE_PERMISSION_EDIT, NOTE_PERMISSION_OWNER}
+	actualIndex, requiredIndex := -1, len(order)
+	for i, permission := range order {
+		if permission == actual {
+			actualIndex = i
+		}
+		if permission == required {
+			requiredIndex = i
+		}
+	}
+	return actualIndex >= requiredIndex
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type NoteShareLink struct {
+	Id           int
+	NoteId       int
+	UserId       int
+	TokenHash    string
+	PasswordHash sql.NullString
+	ExpireAt     sql.NullTime
+	MaxViews     sql.NullInt32
+	Views        int
+	State        string
+	CreateDate   time.Time
+}
+
+const (
+	NOTE_SHARE_LINK_STATE_ACTIVE  string = "ACTIVE"
+	NOTE_SHARE_LINK_STATE_REVOKED string = "REVOKED"
+)
@@ -0,0 +1,20 @@
+//go:build unit
+// +build unit
+
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotePermissionSufficient(t *testing.T) {
+	assert.True(t, entities.IsNotePermissionSufficient(entities.NOTE_PERMISSION_OWNER, entities.NOTE_PERMISSION_EDIT))
+	assert.True(t, entities.IsNotePermissionSufficient(entities.NOTE_PERMISSION_EDIT, entities.NOTE_PERMISSION_EDIT))
+	assert.True(t, entities.IsNotePermissionSufficient(entities.NOTE_PERMISSION_EDIT, entities.NOTE_PERMISSION_READ))
+	assert.False(t, entities.IsNotePermissionSufficient(entities.NOTE_PERMISSION_READ, entities.NOTE_PERMISSION_EDIT))
+	assert.False(t, entities.IsNotePermissionSufficient(entities.NOTE_PERMISSION_NONE, entities.NOTE_PERMISSION_READ))
+	assert.False(t, entities.IsNotePermissionSufficient("UNKNOWN", entities.NOTE_PERMISSION_READ))
+}
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// the template of the note, the topic and the text could contain placeholders like '{{date}}', '{{topic}}' or custom ones.
+// The shared template is available to all users, but only the author could edit it
+type NoteTemplate struct {
+	Id             int
+	UserId         int
+	Name           string
+	Topic          string
+	Text           string
+	Shared         bool
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
@@ -0,0 +1,28 @@
+//go:build unit
+// +build unit
+
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNoteStateTransitionAllowed(t *testing.T) {
+	assert.True(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_DRAFT, entities.NOTE_STATE_PUBLISHED))
+	assert.True(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_PUBLISHED, entities.NOTE_STATE_ARCHIVED))
+	assert.True(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_ARCHIVED, entities.NOTE_STATE_DRAFT))
+	assert.True(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_DRAFT, entities.NOTE_STATE_DRAFT))
+	assert.False(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_BLOCKED, entities.NOTE_STATE_BLOCKED))
+	assert.False(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_BLOCKED, entities.NOTE_STATE_PUBLISHED))
+	assert.False(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_PUBLISHED, entities.NOTE_STATE_BLOCKED))
+	assert.False(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_DRAFT, entities.NOTE_STATE_DELETED))
+	assert.False(t, entities.IsNoteStateTransitionAllowed(entities.NOTE_STATE_DELETED, entities.NOTE_STATE_DRAFT))
+}
+
+func TestGetPossibleNoteStateTransitions(t *testing.T) {
+	assert.Equal(t, []string{entities.NOTE_STATE_DRAFT, entities.NOTE_STATE_PUBLISHED, entities.NOTE_STATE_ARCHIVED}, entities.GetPossibleNoteStateTransitions(entities.NOTE_STATE_DRAFT))
+	assert.Equal(t, []string{}, entities.GetPossibleNoteStateTransitions(entities.NOTE_STATE_BLOCKED))
+}
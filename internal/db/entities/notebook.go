@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the folder of the notes of the user, the notebooks without parent are the roots. The depth is set for the notebooks of the subtree only
+type Notebook struct {
+	Id             int
+	UserId         int
+	ParentId       sql.NullInt32
+	Name           string
+	Position       int
+	Depth          int
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+}
+
+// the note of the notebook, the note belongs to one notebook at most
+type NotebookNote struct {
+	NoteId     int
+	NotebookId int
+	Position   int
+	NoteTopic  string
+}
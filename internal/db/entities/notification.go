@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the notification of the user about the action of another user, the actor, the note and the comment are set if they are relevant for the type
+type Notification struct {
+	Id         int
+	UserId     int
+	Type       string
+	ActorId    sql.NullInt32
+	NoteId     sql.NullInt32
+	CommentId  sql.NullInt32
+	Text       string
+	ReadAt     sql.NullTime
+	CreateDate time.Time
+}
+
+// the user preference is enabled if it is not set explicitly
+type NotificationPreference struct {
+	UserId  int
+	Type    string
+	Enabled bool
+}
+
+const (
+	NOTIFICATION_TYPE_NOTE_COMMENTED    string = "NOTE_COMMENTED"
+	NOTIFICATION_TYPE_COMMENT_REPLIED   string = "COMMENT_REPLIED"
+	NOTIFICATION_TYPE_NOTE_SHARED       string = "NOTE_SHARED"
+	NOTIFICATION_TYPE_CONTENT_BLOCKED   string = "CONTENT_BLOCKED"
+	NOTIFICATION_TYPE_CONTENT_UNBLOCKED string = "CONTENT_UNBLOCKED"
+	NOTIFICATION_TYPE_MENTIONED         string = "MENTIONED"
+)
+
+func GetPossibleNotificationTypes() []string {
+	return []string{NOTIFICATION_TYPE_NOTE_COMMENTED, NOTIFICATION_TYPE_COMMENT_REPLIED, NOTIFICATION_TYPE_NOTE_SHARED,
+		NOTIFICATION_TYPE_CONTENT_BLOCKED, NOTIFICATION_TYPE_CONTENT_UNBLOCKED, NOTIFICATION_TYPE_MENTIONED}
+}
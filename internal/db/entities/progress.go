@@ -0,0 +1,42 @@
+package entities
+
+import "time"
+
+const (
+	ACTIVITY_TYPE_NOTE_EDIT   string = "NOTE_EDIT"
+	ACTIVITY_TYPE_CARD_REVIEW string = "CARD_REVIEW"
+	ACTIVITY_TYPE_TASK_DONE   string = "TASK_DONE"
+)
+
+// the activities of the same type within the minute, the minute is given in the server time
+type ActivityBucket struct {
+	Type   string
+	Minute time.Time
+	Count  int
+}
+
+const (
+	// the goals on the count of activities of the type
+	GOAL_TYPE_NOTE_EDITS   string = ACTIVITY_TYPE_NOTE_EDIT
+	GOAL_TYPE_CARD_REVIEWS string = ACTIVITY_TYPE_CARD_REVIEW
+	GOAL_TYPE_TASKS_DONE   string = ACTIVITY_TYPE_TASK_DONE
+	// the goal on the count of days with any activity
+	GOAL_TYPE_ACTIVE_DAYS string = "ACTIVE_DAYS"
+)
+
+func GetPossibleGoalTypes() []string {
+	return []string{GOAL_TYPE_NOTE_EDITS, GOAL_TYPE_CARD_REVIEWS, GOAL_TYPE_TASKS_DONE, GOAL_TYPE_ACTIVE_DAYS}
+}
+
+// the weekly goal of the user
+type UserGoal struct {
+	UserId int
+	Type   string
+	Target int
+}
+
+type UserAchievement struct {
+	UserId    int
+	Code      string
+	AwardDate time.Time
+}
@@ -0,0 +1,101 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the quiz belongs either to the note or to the course
+type Quiz struct {
+	Id               int
+	UserId           int
+	Title            string
+	NoteId           sql.NullInt32
+	CourseId         sql.NullInt32
+	TimeLimitSeconds sql.NullInt32
+	State            string
+	CreateDate       time.Time
+	LastUpdateDate   time.Time
+}
+
+const (
+	QUIZ_STATE_NEW     string = "NEW"
+	QUIZ_STATE_DELETED string = "DELETED"
+)
+
+type QuizQuestion struct {
+	Id        int
+	QuizId    int
+	Type      string
+	Text      string
+	Answer    sql.NullString // the expected answer of the free text question
+	MatchType sql.NullString
+	Points    int
+	Position  int
+	Options   []QuizQuestionOption
+}
+
+const (
+	QUIZ_QUESTION_TYPE_SINGLE_CHOICE   string = "SINGLE_CHOICE"
+	QUIZ_QUESTION_TYPE_MULTIPLE_CHOICE string = "MULTIPLE_CHOICE"
+	QUIZ_QUESTION_TYPE_FREE_TEXT       string = "FREE_TEXT"
+)
+
+func GetPossibleQuizQuestionTypes() []string {
+	return []string{QUIZ_QUESTION_TYPE_SINGLE_CHOICE, QUIZ_QUESTION_TYPE_MULTIPLE_CHOICE, QUIZ_QUESTION_TYPE_FREE_TEXT}
+}
+
+const (
+	// the answer equals to the expected one ignoring case and surrounding spaces
+	QUIZ_MATCH_TYPE_EXACT string = "EXACT"
+	// the whole answer matches the regular expression
+	QUIZ_MATCH_TYPE_REGEX string = "REGEX"
+)
+
+func GetPossibleQuizMatchTypes() []string {
+	return []string{QUIZ_MATCH_TYPE_EXACT, QUIZ_MATCH_TYPE_REGEX}
+}
+
+type QuizQuestionOption struct {
+	Id         int
+	QuestionId int
+	Text       string
+	Correct    bool
+	Position   int
+}
+
+type QuizAttempt struct {
+	Id         int
+	QuizId     int
+	UserId     int
+	State      string
+	Score      int
+	MaxScore   int
+	StartDate  time.Time
+	Deadline   sql.NullTime
+	FinishDate sql.NullTime
+}
+
+const (
+	QUIZ_ATTEMPT_STATE_IN_PROGRESS string = "IN_PROGRESS"
+	QUIZ_ATTEMPT_STATE_FINISHED    string = "FINISHED"
+	// the answers are submitted after the deadline, so they are not scored
+	QUIZ_ATTEMPT_STATE_EXPIRED string = "EXPIRED"
+)
+
+type QuizAnswer struct {
+	AttemptId  int
+	QuestionId int
+	OptionIds  []int
+	Text       string
+	Correct    bool
+	Points     int
+}
+
+// aggregated answers of the finished attempts for the question
+type QuizQuestionStats struct {
+	QuestionId    int
+	AnswersCount  int
+	CorrectCount  int
+	AveragePoints float64
+}
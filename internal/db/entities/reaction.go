@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// the reaction of the user to the note, the user could add several reactions of different types
+type NoteReaction struct {
+	NoteId     int
+	UserId     int
+	Type       string
+	CreateDate time.Time
+}
+
+const (
+	REACTION_TYPE_LIKE       string = "LIKE"
+	REACTION_TYPE_LOVE       string = "LOVE"
+	REACTION_TYPE_INSIGHTFUL string = "INSIGHTFUL"
+	REACTION_TYPE_CELEBRATE  string = "CELEBRATE"
+	REACTION_TYPE_CONFUSED   string = "CONFUSED"
+)
+
+func GetPossibleReactionTypes() []string {
+	return []string{REACTION_TYPE_LIKE, REACTION_TYPE_LOVE, REACTION_TYPE_INSIGHTFUL, REACTION_TYPE_CELEBRATE, REACTION_TYPE_CONFUSED}
+}
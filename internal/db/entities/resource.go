@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the external resource of the reading list of the user, e.g. the book or the video
+type Resource struct {
+	Id             int
+	UserId         int
+	Title          string
+	Url            string
+	Type           string
+	Author         string
+	Status         string
+	Rating         sql.NullInt32
+	Progress       int
+	ProgressTotal  sql.NullInt32 // the pages of the book or the minutes of the video
+	State          string
+	CreateDate     time.Time
+	LastUpdateDate time.Time
+	TagIds         []int
+	NoteIds        []int
+}
+
+const (
+	RESOURCE_STATE_NEW     string = "NEW"
+	RESOURCE_STATE_DELETED string = "DELETED"
+)
+
+const (
+	RESOURCE_TYPE_BOOK    string = "BOOK"
+	RESOURCE_TYPE_PAPER   string = "PAPER"
+	RESOURCE_TYPE_ARTICLE string = "ARTICLE"
+	RESOURCE_TYPE_VIDEO   string = "VIDEO"
+	RESOURCE_TYPE_OTHER   string = "OTHER"
+)
+
+func GetPossibleResourceTypes() []string {
+	return []string{RESOURCE_TYPE_BOOK, RESOURCE_TYPE_PAPER, RESOURCE_TYPE_ARTICLE, RESOURCE_TYPE_VIDEO, RESOURCE_TYPE_OTHER}
+}
+
+const (
+	RESOURCE_STATUS_TO_READ string = "TO_READ"
+	RESOURCE_STATUS_READING string = "READING"
+	RESOURCE_STATUS_DONE    string = "DONE"
+)
+
+func GetPossibleResourceStatuses() []string {
+	return []string{RESOURCE_STATUS_TO_READ, RESOURCE_STATUS_READING, RESOURCE_STATUS_DONE}
+}
+
+const (
+	RESOURCE_PROGRESS_UNIT_PAGES   string = "PAGES"
+	RESOURCE_PROGRESS_UNIT_MINUTES string = "MINUTES"
+)
+
+// returns the unit of the progress of the resource type, the empty one if the progress is not tracked for the type
+func GetResourceProgressUnit(resourceType string) string {
+	switch resourceType {
+	case RESOURCE_TYPE_BOOK, RESOURCE_TYPE_PAPER:
+		return RESOURCE_PROGRESS_UNIT_PAGES
+	case RESOURCE_TYPE_VIDEO:
+		return RESOURCE_PROGRESS_UNIT_MINUTES
+	default:
+		return ""
+	}
+}
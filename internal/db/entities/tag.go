@@ -1,9 +1,24 @@
 package entities
 
+import (
+	"database/sql"
+	"time"
+)
+
+// the tags are global, the tag without parent is the root of the hierarchy
 type Tag struct {
-	Id    int
-	Name  string
-	State string
+	Id       int
+	Name     string
+	State    string
+	ParentId sql.NullInt32
+}
+
+// the alternative name of the tag, e.g. "golang" of "Go". The tag is found by the names of its aliases regardless of the case
+type TagAlias struct {
+	Id         int
+	TagId      int
+	Name       string
+	CreateDate time.Time
 }
 
 const (
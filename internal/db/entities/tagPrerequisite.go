@@ -0,0 +1,14 @@
+package entities
+
+// the topic of the tag requires studying the topic of the prerequisite first
+type TagPrerequisite struct {
+	TagId          int
+	PrerequisiteId int
+}
+
+// the note of the topic visible to the user, it is completed if the user has completed it as an item of any course
+type TagNote struct {
+	TagId     int
+	NoteId    int
+	Completed bool
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// the studying time of the user, it is linked to at most one of the note, the task or the course.
+// The entry is running while the end date is missed, the user has at most one running entry
+type TimeEntry struct {
+	Id              int
+	UserId          int
+	NoteId          sql.NullInt32
+	TaskId          sql.NullInt32
+	CourseId        sql.NullInt32
+	Description     string
+	Kind            string
+	StartDate       time.Time
+	EndDate         sql.NullTime
+	PlannedDuration sql.NullInt32 // in seconds, the timer is stopped automatically when it is passed
+}
+
+const (
+	TIME_ENTRY_KIND_TIMER    string = "TIMER"
+	TIME_ENTRY_KIND_POMODORO string = "POMODORO"
+	TIME_ENTRY_KIND_MANUAL   string = "MANUAL"
+)
+
+// the kinds of the started timers, the manual entries are created with the end date at once
+func GetPossibleTimerKinds() []string {
+	return []string{TIME_ENTRY_KIND_TIMER, TIME_ENTRY_KIND_POMODORO}
+}
+
+// the tag of the note which the entry is linked to
+type TimeEntryTag struct {
+	TimeEntryId int
+	Name        string
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// the deleted note, task or tag which could be restored until it is purged. The name is the topic of the note or
+// the original name of the task or the tag (without the suffix added on deletion)
+type TrashItem struct {
+	Type      string
+	Id        int
+	Name      string
+	DeletedAt time.Time
+}
+
+const (
+	TRASH_ITEM_TYPE_NOTE string = "NOTE"
+	TRASH_ITEM_TYPE_TASK string = "TASK"
+	TRASH_ITEM_TYPE_TAG  string = "TAG"
+)
+
+func GetPossibleTrashItemTypes() []string {
+	return []string{TRASH_ITEM_TYPE_NOTE, TRASH_ITEM_TYPE_TASK, TRASH_ITEM_TYPE_TAG}
+}
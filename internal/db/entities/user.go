@@ -33,3 +33,8 @@ func GetPossibleUserStates() []string {
 func GetPossibleUserRoles() []string {
 	return []string{USER_ROLE_OWNER, USER_ROLE_RESIDENT, USER_ROLE_GI}
 }
+
+// the roles allowed to review the reported content
+func GetPossibleModeratorRoles() []string {
+	return []string{USER_ROLE_OWNER, USER_ROLE_GI}
+}
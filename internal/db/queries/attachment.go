@@ -0,0 +1,130 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+func GetAttachments(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	var attachment entities.Attachment
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, note_id, user_id, file_name, mime_type, size, checksum, create_date FROM attachments WHERE note_id = $1 ORDER BY id", noteId)
+	if err != nil {
+		return attachments, fmt.Errorf("error at loading attachments from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&attachment.Id, &attachment.NoteId, &attachment.UserId, &attachment.FileName, &attachment.MimeType, &attachment.Size, &attachment.Checksum, &attachment.CreateDate)
+		if err != nil {
+			return attachments, fmt.Errorf("error at loading attachments from db, case iterating and using rows.Scan: %s", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	err = rows.Err()
+	if err != nil {
+		return attachments, fmt.Errorf("error at loading attachments from db, case after iterating: %s", err)
+	}
+
+	return attachments, nil
+}
+
+func GetAttachment(tx *sql.Tx, ctx context.Context, noteId int, id int) (entities.Attachment, error) {
+	var attachment entities.Attachment
+
+	err := tx.QueryRowContext(ctx, "SELECT id, note_id, user_id, file_name, mime_type, size, checksum, create_date FROM attachments WHERE id = $1 and note_id = $2", id, noteId).
+		Scan(&attachment.Id, &attachment.NoteId, &attachment.UserId, &attachment.FileName, &attachment.MimeType, &attachment.Size, &attachment.Checksum, &attachment.CreateDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return attachment, err
+		}
+		return attachment, fmt.Errorf("error at loading attachment by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return attachment, nil
+}
+
+func CreateAttachment(tx *sql.Tx, ctx context.Context, noteId int, userId int, fileName string, mimeType string, size int64, checksum string) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO attachments(note_id, user_id, file_name, mime_type, size, checksum, create_date) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		noteId, userId, fileName, mimeType, size, checksum, createDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting attachment (NoteId: '%d', FileName: '%s') into db, case after QueryRow.Scan: %s", noteId, fileName, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// returns checksum of the deleted attachment, so the caller could check whether the blob is still in use
+func DeleteAttachment(tx *sql.Tx, ctx context.Context, noteId int, id int) (string, error) {
+	var checksum string
+
+	err := tx.QueryRowContext(ctx, "DELETE FROM attachments WHERE id = $1 and note_id = $2 RETURNING checksum", id, noteId).Scan(&checksum)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return checksum, err
+		}
+		return checksum, fmt.Errorf("error at deleting attachment by id '%d', case after QueryRow.Scan: %s", id, err)
+	}
+
+	return checksum, nil
+}
+
+// returns distinct checksums of the deleted attachments, so the caller could check whether the blobs are still in use
+func DeleteAttachmentsByNote(tx *sql.Tx, ctx context.Context, noteId int) ([]string, error) {
+	var checksums []string
+	var checksum string
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM attachments WHERE note_id = $1 RETURNING checksum", noteId)
+	if err != nil {
+		return checksums, fmt.Errorf("error at deleting attachments of note '%d', case after Query: %s", noteId, err)
+	}
+	defer rows.Close()
+
+	unique := make(map[string]bool)
+	for rows.Next() {
+		err := rows.Scan(&checksum)
+		if err != nil {
+			return checksums, fmt.Errorf("error at deleting attachments of note '%d', case iterating and using rows.Scan: %s", noteId, err)
+		}
+		if !unique[checksum] {
+			unique[checksum] = true
+			checksums = append(checksums, checksum)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return checksums, fmt.Errorf("error at deleting attachments of note '%d', case after iterating: %s", noteId, err)
+	}
+
+	return checksums, nil
+}
+
+// serializes the uploads and the orphan sweep of the same blob until the end of the transaction
+func LockAttachmentChecksum(tx *sql.Tx, ctx context.Context, checksum string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", checksum)
+	if err != nil {
+		return fmt.Errorf("error at locking attachment checksum '%s', case after executing statement: %s", checksum, err)
+	}
+	return nil
+}
+
+func IsAttachmentChecksumInUse(tx *sql.Tx, ctx context.Context, checksum string) (bool, error) {
+	var inUse bool
+
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM attachments WHERE checksum = $1)", checksum).Scan(&inUse)
+	if err != nil {
+		return inUse, fmt.Errorf("error at checking usage of attachment checksum '%s', case after QueryRow.Scan: %s", checksum, err)
+	}
+
+	return inUse, nil
+}
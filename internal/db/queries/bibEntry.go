@@ -0,0 +1,205 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanBibEntry()
+const BIB_ENTRY_COLUMNS string = "bib_entries.id, bib_entries.user_id, bib_entries.citation_key, bib_entries.entry_type, bib_entries.create_date, bib_entries.last_update_date"
+
+func scanBibEntry(row rowScanner) (entities.BibEntry, error) {
+	var entry entities.BibEntry
+	err := row.Scan(&entry.Id, &entry.UserId, &entry.CitationKey, &entry.EntryType, &entry.CreateDate, &entry.LastUpdateDate)
+	return entry, err
+}
+
+// returns the entries of the bibliography of the user in order of their keys, the negative limit means all entries
+func GetUserBibEntries(tx *sql.Tx, ctx context.Context, userId int, limit int, offset int) ([]entities.BibEntry, error) {
+	var limitParam sql.NullInt32
+	if limit >= 0 {
+		limitParam = sql.NullInt32{Int32: int32(limit), Valid: true}
+	}
+	return queryBibEntries(tx, ctx, fmt.Sprintf("user '%d'", userId),
+		"SELECT "+BIB_ENTRY_COLUMNS+" FROM bib_entries WHERE user_id = $1 ORDER BY citation_key, id LIMIT $2 OFFSET $3", userId, limitParam, offset)
+}
+
+// returns the entries of the bibliography of the user with the given keys, the unknown keys are skipped
+func GetBibEntriesByKeys(tx *sql.Tx, ctx context.Context, userId int, keys []string) ([]entities.BibEntry, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return queryBibEntries(tx, ctx, fmt.Sprintf("user '%d'", userId),
+		"SELECT "+BIB_ENTRY_COLUMNS+" FROM bib_entries WHERE user_id = $1 and citation_key = ANY($2) ORDER BY citation_key, id", userId, pq.Array(keys))
+}
+
+func GetBibEntry(tx *sql.Tx, ctx context.Context, id int) (entities.BibEntry, error) {
+	entry, err := scanBibEntry(tx.QueryRowContext(ctx, "SELECT "+BIB_ENTRY_COLUMNS+" FROM bib_entries WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entry, err
+		}
+		return entry, fmt.Errorf("error at loading bibliography entry by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	entries := []entities.BibEntry{entry}
+	err = loadBibEntryFields(tx, ctx, entries)
+	return entries[0], err
+}
+
+func CreateBibEntry(tx *sql.Tx, ctx context.Context, entry entities.BibEntry) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO bib_entries(user_id, citation_key, entry_type, create_date, last_update_date) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		entry.UserId, entry.CitationKey, entry.EntryType, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		if err.Error() == db.ErrorBibEntryDuplicateKey.Error() {
+			return -1, db.ErrorBibEntryDuplicateKey
+		}
+		return -1, fmt.Errorf("error at inserting bibliography entry (CitationKey: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", entry.CitationKey, entry.UserId, err)
+	}
+
+	err = setBibEntryFields(tx, ctx, lastInsertId, entry.Fields)
+	if err != nil {
+		return -1, err
+	}
+
+	return lastInsertId, nil
+}
+
+// updates the entry and replaces its fields
+func UpdateBibEntry(tx *sql.Tx, ctx context.Context, entry entities.BibEntry) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE bib_entries SET citation_key = $2, entry_type = $3, last_update_date = $4 WHERE id = $1")
+	if err != nil {
+		return fmt.Errorf("error at updating bibliography entry, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, entry.Id, entry.CitationKey, entry.EntryType, lastUpdateDate)
+	if err != nil {
+		if err.Error() == db.ErrorBibEntryDuplicateKey.Error() {
+			return db.ErrorBibEntryDuplicateKey
+		}
+		return fmt.Errorf("error at updating bibliography entry (Id: %d), case after executing statement: %s", entry.Id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating bibliography entry (Id: %d), case after counting affected rows: %s", entry.Id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	return setBibEntryFields(tx, ctx, entry.Id, entry.Fields)
+}
+
+func DeleteBibEntry(tx *sql.Tx, ctx context.Context, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM bib_entries WHERE id = $1")
+	if err != nil {
+		return fmt.Errorf("error at deleting bibliography entry, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error at deleting bibliography entry by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting bibliography entry by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM bib_entry_fields WHERE entry_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting fields of bibliography entry '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+func queryBibEntries(tx *sql.Tx, ctx context.Context, owner string, query string, args ...any) ([]entities.BibEntry, error) {
+	var entries []entities.BibEntry
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entries, fmt.Errorf("error at loading bibliography entries of %s from db, case after Query: %s", owner, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanBibEntry(rows)
+		if err != nil {
+			return entries, fmt.Errorf("error at loading bibliography entries of %s from db, case iterating and using rows.Scan: %s", owner, err)
+		}
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return entries, fmt.Errorf("error at loading bibliography entries of %s from db, case after iterating: %s", owner, err)
+	}
+
+	err = loadBibEntryFields(tx, ctx, entries)
+	return entries, err
+}
+
+func setBibEntryFields(tx *sql.Tx, ctx context.Context, id int, fields map[string]string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM bib_entry_fields WHERE entry_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting fields of bibliography entry '%d', case after executing statement: %s", id, err)
+	}
+	var names, values []string
+	for name, value := range fields {
+		names = append(names, name)
+		values = append(values, value)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO bib_entry_fields(entry_id, name, value) SELECT $1, UNNEST($2::varchar[]), UNNEST($3::text[])", id, pq.Array(names), pq.Array(values))
+	if err != nil {
+		return fmt.Errorf("error at adding fields of bibliography entry '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+// fills the fields of the entries
+func loadBibEntryFields(tx *sql.Tx, ctx context.Context, entries []entities.BibEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	index := make(map[int]int)
+	var ids []int
+	for i := range entries {
+		entries[i].Fields = make(map[string]string)
+		index[entries[i].Id] = i
+		ids = append(ids, entries[i].Id)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT entry_id, name, value FROM bib_entry_fields WHERE entry_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error at loading fields of bibliography entries from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryId int
+		var name, value string
+		err := rows.Scan(&entryId, &name, &value)
+		if err != nil {
+			return fmt.Errorf("error at loading fields of bibliography entries from db, case iterating and using rows.Scan: %s", err)
+		}
+		entries[index[entryId]].Fields[name] = value
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("error at loading fields of bibliography entries from db, case after iterating: %s", err)
+	}
+
+	return nil
+}
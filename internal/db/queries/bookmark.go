@@ -0,0 +1,200 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanBookmarkFolder()
+const BOOKMARK_FOLDER_COLUMNS string = "id, user_id, name, create_date"
+
+// the columns order matches scanBookmark()
+const BOOKMARK_COLUMNS string = "bookmarks.user_id, bookmarks.note_id, bookmarks.folder_id, notes.topic, bookmarks.create_date"
+
+func scanBookmarkFolder(row rowScanner) (entities.BookmarkFolder, error) {
+	var folder entities.BookmarkFolder
+	err := row.Scan(&folder.Id, &folder.UserId, &folder.Name, &folder.CreateDate)
+	return folder, err
+}
+
+func scanBookmark(row rowScanner) (entities.Bookmark, error) {
+	var bookmark entities.Bookmark
+	err := row.Scan(&bookmark.UserId, &bookmark.NoteId, &bookmark.FolderId, &bookmark.NoteTopic, &bookmark.CreateDate)
+	return bookmark, err
+}
+
+func GetBookmarkFolders(tx *sql.Tx, ctx context.Context, userId int) ([]entities.BookmarkFolder, error) {
+	var folders []entities.BookmarkFolder
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+BOOKMARK_FOLDER_COLUMNS+" FROM bookmark_folders WHERE user_id = $1 ORDER BY lower(name), id", userId)
+	if err != nil {
+		return folders, fmt.Errorf("error at loading bookmark folders of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		folder, err := scanBookmarkFolder(rows)
+		if err != nil {
+			return folders, fmt.Errorf("error at loading bookmark folders of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		folders = append(folders, folder)
+	}
+	err = rows.Err()
+	if err != nil {
+		return folders, fmt.Errorf("error at loading bookmark folders of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return folders, nil
+}
+
+// returns the folder of the user, sql.ErrNoRows if the folder belongs to another user
+func GetBookmarkFolder(tx *sql.Tx, ctx context.Context, userId int, id int) (entities.BookmarkFolder, error) {
+	folder, err := scanBookmarkFolder(tx.QueryRowContext(ctx, "SELECT "+BOOKMARK_FOLDER_COLUMNS+" FROM bookmark_folders WHERE id = $1 and user_id = $2", id, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return folder, err
+		}
+		return folder, fmt.Errorf("error at loading bookmark folder by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return folder, nil
+}
+
+func CreateBookmarkFolder(tx *sql.Tx, ctx context.Context, userId int, name string) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO bookmark_folders(user_id, name, create_date) VALUES($1, $2, $3) RETURNING id", userId, name, time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		if err.Error() == db.ErrorBookmarkFolderDuplicateKey.Error() {
+			return -1, db.ErrorBookmarkFolderDuplicateKey
+		}
+		return -1, fmt.Errorf("error at inserting bookmark folder (Name: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", name, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateBookmarkFolder(tx *sql.Tx, ctx context.Context, userId int, id int, name string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE bookmark_folders SET name = $3 WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at updating bookmark folder, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, name)
+	if err != nil {
+		if err.Error() == db.ErrorBookmarkFolderDuplicateKey.Error() {
+			return db.ErrorBookmarkFolderDuplicateKey
+		}
+		return fmt.Errorf("error at updating bookmark folder (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating bookmark folder (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// deletes the folder of the user, its bookmarks are kept without folder
+func DeleteBookmarkFolder(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM bookmark_folders WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark folder, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark folder (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark folder (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	stmt, err = tx.PrepareContext(ctx, "UPDATE bookmarks SET folder_id = NULL WHERE user_id = $1 and folder_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark folder, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, userId, id)
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark folder (Id: %d), case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+// the filter of the bookmarks, the folder is ignored if it is not set and the unfiled ones are the bookmarks without folder
+type BookmarkFilter struct {
+	FolderId sql.NullInt32
+	Unfiled  bool
+}
+
+// returns the bookmarks of the user matching the filter, the notes which are not visible to the user anymore are skipped. The recent ones go first
+func GetUserBookmarks(tx *sql.Tx, ctx context.Context, userId int, filter BookmarkFilter, limit int, offset int) ([]entities.Bookmark, error) {
+	var bookmarks []entities.Bookmark
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+BOOKMARK_COLUMNS+" FROM bookmarks JOIN notes ON notes.id = bookmarks.note_id "+
+		"WHERE bookmarks.user_id = $1 and notes.state != $2 and "+noteVisibleToUserCondition("$1")+" and "+
+		"($3::int IS NULL OR bookmarks.folder_id = $3) and (NOT $4 OR bookmarks.folder_id IS NULL) "+
+		"ORDER BY bookmarks.create_date DESC, bookmarks.note_id DESC LIMIT $5 OFFSET $6",
+		userId, entities.NOTE_STATE_DELETED, filter.FolderId, filter.Unfiled, limit, offset)
+	if err != nil {
+		return bookmarks, fmt.Errorf("error at loading bookmarks of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bookmark, err := scanBookmark(rows)
+		if err != nil {
+			return bookmarks, fmt.Errorf("error at loading bookmarks of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	err = rows.Err()
+	if err != nil {
+		return bookmarks, fmt.Errorf("error at loading bookmarks of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return bookmarks, nil
+}
+
+// bookmarks the note or moves the existing bookmark to the folder, the note is bookmarked once by the user
+func SetBookmark(tx *sql.Tx, ctx context.Context, userId int, noteId int, folderId sql.NullInt32) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO bookmarks(user_id, note_id, folder_id, create_date) VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (user_id, note_id) DO UPDATE SET folder_id = EXCLUDED.folder_id")
+	if err != nil {
+		return fmt.Errorf("error at setting bookmark, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, userId, noteId, folderId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at setting bookmark (UserId: %d, NoteId: %d), case after executing statement: %s", userId, noteId, err)
+	}
+	return nil
+}
+
+func DeleteBookmark(tx *sql.Tx, ctx context.Context, userId int, noteId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM bookmarks WHERE user_id = $1 and note_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, userId, noteId)
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark (UserId: %d, NoteId: %d), case after executing statement: %s", userId, noteId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting bookmark (UserId: %d, NoteId: %d), case after counting affected rows: %s", userId, noteId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
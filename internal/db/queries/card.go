@@ -0,0 +1,112 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanCard()
+const CARD_COLUMNS string = "cards.id, cards.note_id, cards.user_id, cards.front, cards.back, cards.source, cards.state, cards.create_date, cards.last_update_date"
+
+func scanCard(row rowScanner) (entities.Card, error) {
+	var card entities.Card
+	err := row.Scan(&card.Id, &card.NoteId, &card.UserId, &card.Front, &card.Back, &card.Source, &card.State, &card.CreateDate, &card.LastUpdateDate)
+	return card, err
+}
+
+func GetNoteCards(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.Card, error) {
+	var cards []entities.Card
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+CARD_COLUMNS+" FROM cards WHERE note_id = $1 and state != $2 ORDER BY id", noteId, entities.CARD_STATE_DELETED)
+	if err != nil {
+		return cards, fmt.Errorf("error at loading cards from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return cards, fmt.Errorf("error at loading cards from db, case iterating and using rows.Scan: %s", err)
+		}
+		cards = append(cards, card)
+	}
+	err = rows.Err()
+	if err != nil {
+		return cards, fmt.Errorf("error at loading cards from db, case after iterating: %s", err)
+	}
+
+	return cards, nil
+}
+
+// returns the card if it is not deleted and its note is not deleted too
+func GetCard(tx *sql.Tx, ctx context.Context, id int) (entities.Card, error) {
+	card, err := scanCard(tx.QueryRowContext(ctx, "SELECT "+CARD_COLUMNS+" FROM cards JOIN notes ON notes.id = cards.note_id "+
+		"WHERE cards.id = $1 and cards.state != $2 and notes.state != $3", id, entities.CARD_STATE_DELETED, entities.NOTE_STATE_DELETED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return card, err
+		}
+		return card, fmt.Errorf("error at loading card by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return card, nil
+}
+
+func CreateCard(tx *sql.Tx, ctx context.Context, noteId int, userId int, front string, back string, source string) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO cards(note_id, user_id, front, back, source, state, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		noteId, userId, front, back, source, entities.CARD_STATE_NEW, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting card (NoteId: '%d', UserId: '%d') into db, case after QueryRow.Scan: %s", noteId, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateCard(tx *sql.Tx, ctx context.Context, noteId int, id int, front string, back string) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE cards SET front = $3, back = $4, last_update_date = $5 WHERE id = $1 and note_id = $2 and state != $6")
+	if err != nil {
+		return fmt.Errorf("error at updating card, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, noteId, front, back, lastUpdateDate, entities.CARD_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating card (Id: %d, NoteId: %d), case after executing statement: %s", id, noteId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating card (Id: %d, NoteId: %d), case after counting affected rows: %s", id, noteId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func DeleteCard(tx *sql.Tx, ctx context.Context, noteId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE cards SET state = $3 WHERE id = $1 and note_id = $2 and state != $3")
+	if err != nil {
+		return fmt.Errorf("error at deleting card, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, noteId, entities.CARD_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at deleting card by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting card by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
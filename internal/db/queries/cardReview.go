@@ -0,0 +1,144 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/srs"
+)
+
+const CARD_MATURE_INTERVAL_DAYS int = 21
+
+// the condition of the cards which the user studies: the cards are reviewed by the user before or belong to the notes of the user or shared with the user
+func cardInStudySetCondition(userIdParam string) string {
+	return "cards.state != '" + entities.CARD_STATE_DELETED + "' AND notes.state != '" + entities.NOTE_STATE_DELETED + "' AND " + noteVisibleToUserCondition(userIdParam) +
+		" AND (card_reviews.card_id IS NOT NULL OR " + noteOwnedOrSharedCondition(userIdParam) + ")"
+}
+
+func GetCardReview(tx *sql.Tx, ctx context.Context, cardId int, userId int) (entities.CardReview, error) {
+	var review entities.CardReview
+
+	err := tx.QueryRowContext(ctx, "SELECT card_id, user_id, repetitions, interval_days, easiness_factor, due_date, last_review_date FROM card_reviews WHERE card_id = $1 and user_id = $2", cardId, userId).
+		Scan(&review.CardId, &review.UserId, &review.Repetitions, &review.IntervalDays, &review.EasinessFactor, &review.DueDate, &review.LastReviewDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return review, err
+		}
+		return review, fmt.Errorf("error at loading review of card '%d' by user '%d' from db, case after QueryRow.Scan: %s", cardId, userId, err)
+	}
+
+	return review, nil
+}
+
+// saves the schedule of the card for the user and logs the review with the given grade
+func SaveCardReview(tx *sql.Tx, ctx context.Context, review entities.CardReview, grade int) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO card_reviews(card_id, user_id, repetitions, interval_days, easiness_factor, due_date, last_review_date) VALUES($1, $2, $3, $4, $5, $6, $7) "+
+		"ON CONFLICT (card_id, user_id) DO UPDATE SET repetitions = EXCLUDED.repetitions, interval_days = EXCLUDED.interval_days, easiness_factor = EXCLUDED.easiness_factor, "+
+		"due_date = EXCLUDED.due_date, last_review_date = EXCLUDED.last_review_date")
+	if err != nil {
+		return fmt.Errorf("error at saving card review, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, review.CardId, review.UserId, review.Repetitions, review.IntervalDays, review.EasinessFactor, review.DueDate, review.LastReviewDate)
+	if err != nil {
+		return fmt.Errorf("error at saving card review (CardId: %d, UserId: %d), case after executing statement: %s", review.CardId, review.UserId, err)
+	}
+
+	stmt, err = tx.PrepareContext(ctx, "INSERT INTO card_review_log(card_id, user_id, grade, interval_days, review_date) VALUES($1, $2, $3, $4, $5)")
+	if err != nil {
+		return fmt.Errorf("error at logging card review, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, review.CardId, review.UserId, grade, review.IntervalDays, review.LastReviewDate)
+	if err != nil {
+		return fmt.Errorf("error at logging card review (CardId: %d, UserId: %d), case after executing statement: %s", review.CardId, review.UserId, err)
+	}
+
+	return nil
+}
+
+// returns the cards to review: the overdue ones go first, then the cards which have never been reviewed
+func GetDueCards(tx *sql.Tx, ctx context.Context, userId int, now time.Time, limit int) ([]entities.DueCard, error) {
+	var cards []entities.DueCard
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+CARD_COLUMNS+", card_reviews.repetitions, card_reviews.interval_days, card_reviews.easiness_factor, card_reviews.due_date, card_reviews.last_review_date "+
+		"FROM cards JOIN notes ON notes.id = cards.note_id LEFT JOIN card_reviews ON card_reviews.card_id = cards.id AND card_reviews.user_id = $1 "+
+		"WHERE "+cardInStudySetCondition("$1")+" AND (card_reviews.card_id IS NULL OR card_reviews.due_date <= $2) "+
+		"ORDER BY card_reviews.due_date NULLS LAST, cards.id LIMIT $3", userId, now, limit)
+	if err != nil {
+		return cards, fmt.Errorf("error at loading due cards from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var card entities.DueCard
+		var repetitions, intervalDays sql.NullInt32
+		var easinessFactor sql.NullFloat64
+		var dueDate, lastReviewDate sql.NullTime
+		err := rows.Scan(&card.Id, &card.NoteId, &card.UserId, &card.Front, &card.Back, &card.Source, &card.State, &card.CreateDate, &card.LastUpdateDate,
+			&repetitions, &intervalDays, &easinessFactor, &dueDate, &lastReviewDate)
+		if err != nil {
+			return cards, fmt.Errorf("error at loading due cards from db, case iterating and using rows.Scan: %s", err)
+		}
+		if dueDate.Valid {
+			card.Review = &entities.CardReview{CardId: card.Id, UserId: userId, Repetitions: int(repetitions.Int32), IntervalDays: int(intervalDays.Int32),
+				EasinessFactor: easinessFactor.Float64, DueDate: dueDate.Time, LastReviewDate: lastReviewDate.Time}
+		}
+		cards = append(cards, card)
+	}
+	err = rows.Err()
+	if err != nil {
+		return cards, fmt.Errorf("error at loading due cards from db, case after iterating: %s", err)
+	}
+
+	return cards, nil
+}
+
+// returns review statistics of the user, the reviews are counted by days since the given date
+func GetCardReviewStats(tx *sql.Tx, ctx context.Context, userId int, now time.Time, todayStart time.Time, since time.Time) (entities.CardReviewStats, error) {
+	var stats entities.CardReviewStats
+
+	err := tx.QueryRowContext(ctx, "SELECT "+
+		"COUNT(*), "+
+		"COUNT(*) FILTER (WHERE card_reviews.due_date <= $2), "+
+		"COUNT(*) FILTER (WHERE card_reviews.card_id IS NULL), "+
+		"COUNT(*) FILTER (WHERE card_reviews.interval_days >= $3) "+
+		"FROM cards JOIN notes ON notes.id = cards.note_id LEFT JOIN card_reviews ON card_reviews.card_id = cards.id AND card_reviews.user_id = $1 "+
+		"WHERE "+cardInStudySetCondition("$1"), userId, now, CARD_MATURE_INTERVAL_DAYS).
+		Scan(&stats.Cards, &stats.DueCards, &stats.NewCards, &stats.MatureCards)
+	if err != nil {
+		return stats, fmt.Errorf("error at loading card stats of user '%d' from db, case after QueryRow.Scan: %s", userId, err)
+	}
+
+	var averageGrade, retentionRate sql.NullFloat64
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE review_date >= $2), AVG(grade), AVG(CASE WHEN grade >= $3 THEN 1.0 ELSE 0.0 END) FROM card_review_log WHERE user_id = $1",
+		userId, todayStart, srs.GRADE_PASSING).
+		Scan(&stats.ReviewsTotal, &stats.ReviewsToday, &averageGrade, &retentionRate)
+	if err != nil {
+		return stats, fmt.Errorf("error at loading review stats of user '%d' from db, case after QueryRow.Scan: %s", userId, err)
+	}
+	stats.AverageGrade = averageGrade.Float64
+	stats.RetentionRate = retentionRate.Float64
+
+	rows, err := tx.QueryContext(ctx, "SELECT date_trunc('day', review_date) AS day, COUNT(*) FROM card_review_log WHERE user_id = $1 and review_date >= $2 GROUP BY day ORDER BY day", userId, since)
+	if err != nil {
+		return stats, fmt.Errorf("error at loading reviews by day of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day entities.CardReviewsByDay
+		err := rows.Scan(&day.Date, &day.Count)
+		if err != nil {
+			return stats, fmt.Errorf("error at loading reviews by day of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		stats.ReviewsByDay = append(stats.ReviewsByDay, day)
+	}
+	err = rows.Err()
+	if err != nil {
+		return stats, fmt.Errorf("error at loading reviews by day of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,124 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanComment()
+const COMMENT_COLUMNS string = "comments.id, comments.text, comments.user_id, comments.note_id, comments.linked_comment_id, comments.state, comments.create_date, comments.last_update_date"
+
+func scanComment(row rowScanner) (entities.Comment, error) {
+	var comment entities.Comment
+	var linkedCommentId sql.NullInt32
+	err := row.Scan(&comment.Id, &comment.Text, &comment.UserId, &comment.NoteId, &linkedCommentId, &comment.State, &comment.CreateDate, &comment.LastUpdateDate)
+	comment.LinkdedCommentId = int(linkedCommentId.Int32)
+	return comment, err
+}
+
+func GetNoteComments(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.Comment, error) {
+	var comments []entities.Comment
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+COMMENT_COLUMNS+" FROM comments WHERE note_id = $1 and state != $2 ORDER BY id",
+		noteId, entities.COMMENT_STATE_DELETED)
+	if err != nil {
+		return comments, fmt.Errorf("error at loading comments from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return comments, fmt.Errorf("error at loading comments from db, case iterating and using rows.Scan: %s", err)
+		}
+		comments = append(comments, comment)
+	}
+	err = rows.Err()
+	if err != nil {
+		return comments, fmt.Errorf("error at loading comments from db, case after iterating: %s", err)
+	}
+
+	return comments, nil
+}
+
+func GetComment(tx *sql.Tx, ctx context.Context, id int) (entities.Comment, error) {
+	comment, err := scanComment(tx.QueryRowContext(ctx, "SELECT "+COMMENT_COLUMNS+" FROM comments WHERE id = $1 and state != $2", id, entities.COMMENT_STATE_DELETED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return comment, err
+		}
+		return comment, fmt.Errorf("error at loading comment by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return comment, nil
+}
+
+// linkedCommentId equals 0 means the comment is not a reply
+func CreateComment(tx *sql.Tx, ctx context.Context, noteId int, userId int, text string, linkedCommentId int, state string) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	var linkedComment sql.NullInt32
+	if linkedCommentId != 0 {
+		linkedComment = sql.NullInt32{Int32: int32(linkedCommentId), Valid: true}
+	}
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO comments(text, user_id, note_id, linked_comment_id, state, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		text, userId, noteId, linkedComment, state, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting comment (NoteId: '%d', UserId: '%d') into db, case after QueryRow.Scan: %s", noteId, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// the blocked comment is not editable by its author
+func UpdateCommentText(tx *sql.Tx, ctx context.Context, id int, text string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE comments SET text = $2, last_update_date = $3 WHERE id = $1 and state = $4")
+	if err != nil {
+		return fmt.Errorf("error at updating comment, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, text, time.Now(), entities.COMMENT_STATE_NEW)
+	if err != nil {
+		return fmt.Errorf("error at updating comment (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating comment (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// sets the state of the comment regardless of the current one, e.g. for blocking it by the moderator
+func UpdateCommentState(tx *sql.Tx, ctx context.Context, id int, state string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE comments SET state = $2, last_update_date = $3 WHERE id = $1 and state != $4")
+	if err != nil {
+		return fmt.Errorf("error at updating comment state, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, state, time.Now(), entities.COMMENT_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating comment state (Id: %d, State: '%s'), case after executing statement: %s", id, state, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating comment state (Id: %d, State: '%s'), case after counting affected rows: %s", id, state, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func DeleteComment(tx *sql.Tx, ctx context.Context, id int) error {
+	return UpdateCommentState(tx, ctx, id, entities.COMMENT_STATE_DELETED)
+}
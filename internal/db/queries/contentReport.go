@@ -0,0 +1,113 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// a user is able to have one open report per content only, db.ErrorContentReportDuplicateKey is returned for the duplicate
+func CreateContentReport(tx *sql.Tx, ctx context.Context, report entities.ContentReport) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO content_reports(content_type, content_id, reporter_id, reason, details, status, create_date, last_update_date) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		report.ContentType, report.ContentId, report.ReporterId, report.Reason, report.Details, entities.REPORT_STATUS_OPEN, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		if err.Error() == db.ErrorContentReportDuplicateKey.Error() {
+			return -1, db.ErrorContentReportDuplicateKey
+		}
+		return -1, fmt.Errorf("error at inserting content report (ContentType: '%s', ContentId: '%d') into db, case after QueryRow.Scan: %s", report.ContentType, report.ContentId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// creates the open report of the word filter or updates its details if the content is flagged already, so the edited content is not flagged twice
+func FlagContent(tx *sql.Tx, ctx context.Context, contentType string, contentId int, details string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE content_reports SET details = $3, last_update_date = $4 "+
+		"WHERE content_type = $1 and content_id = $2 and reporter_id IS NULL and status = $5")
+	if err != nil {
+		return fmt.Errorf("error at flagging content, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, contentType, contentId, details, time.Now(), entities.REPORT_STATUS_OPEN)
+	if err != nil {
+		return fmt.Errorf("error at flagging content (ContentType: '%s', ContentId: '%d'), case after executing statement: %s", contentType, contentId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at flagging content (ContentType: '%s', ContentId: '%d'), case after counting affected rows: %s", contentType, contentId, err)
+	}
+	if affectedRowsCount > 0 {
+		return nil
+	}
+
+	_, err = CreateContentReport(tx, ctx, entities.ContentReport{
+		ContentType: contentType,
+		ContentId:   contentId,
+		Reason:      entities.REPORT_REASON_WORD_FILTER,
+		Details:     details,
+	})
+	return err
+}
+
+// returns the open reports grouped by content, the most reported content goes first and the oldest reports are reviewed first among the equally reported ones
+func GetModerationQueue(tx *sql.Tx, ctx context.Context, contentType string, limit int, offset int) ([]entities.ModerationQueueItem, error) {
+	var items []entities.ModerationQueueItem
+
+	rows, err := tx.QueryContext(ctx, "SELECT r.content_type, r.content_id, COALESCE(notes.user_id, comments.user_id, 0), COALESCE(notes.state, comments.state, ''), "+
+		"COALESCE(notes.text, comments.text, ''), r.reports_count, r.reasons, r.flagged, r.first_report_date, r.last_report_date "+
+		"FROM (SELECT content_type, content_id, count(*) AS reports_count, array_agg(DISTINCT reason) AS reasons, bool_or(reporter_id IS NULL) AS flagged, "+
+		"min(create_date) AS first_report_date, max(last_update_date) AS last_report_date "+
+		"FROM content_reports WHERE status = $1 and ($2 = '' OR content_type = $2) GROUP BY content_type, content_id) r "+
+		"LEFT JOIN notes ON r.content_type = $3 and notes.id = r.content_id "+
+		"LEFT JOIN comments ON r.content_type = $4 and comments.id = r.content_id "+
+		"ORDER BY r.reports_count DESC, r.first_report_date, r.content_type, r.content_id LIMIT $5 OFFSET $6",
+		entities.REPORT_STATUS_OPEN, contentType, entities.CONTENT_TYPE_NOTE, entities.CONTENT_TYPE_COMMENT, limit, offset)
+	if err != nil {
+		return items, fmt.Errorf("error at loading moderation queue from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entities.ModerationQueueItem
+		err := rows.Scan(&item.ContentType, &item.ContentId, &item.AuthorId, &item.State, &item.Text, &item.ReportsCount, pq.Array(&item.Reasons), &item.Flagged,
+			&item.FirstReportDate, &item.LastReportDate)
+		if err != nil {
+			return items, fmt.Errorf("error at loading moderation queue from db, case iterating and using rows.Scan: %s", err)
+		}
+		items = append(items, item)
+	}
+	err = rows.Err()
+	if err != nil {
+		return items, fmt.Errorf("error at loading moderation queue from db, case after iterating: %s", err)
+	}
+
+	return items, nil
+}
+
+// closes the open reports of the content with the given status and returns the count of the closed ones
+func CloseContentReports(tx *sql.Tx, ctx context.Context, contentType string, contentId int, status string) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE content_reports SET status = $3, last_update_date = $4 WHERE content_type = $1 and content_id = $2 and status = $5")
+	if err != nil {
+		return 0, fmt.Errorf("error at closing content reports, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, contentType, contentId, status, time.Now(), entities.REPORT_STATUS_OPEN)
+	if err != nil {
+		return 0, fmt.Errorf("error at closing content reports (ContentType: '%s', ContentId: '%d'), case after executing statement: %s", contentType, contentId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error at closing content reports (ContentType: '%s', ContentId: '%d'), case after counting affected rows: %s", contentType, contentId, err)
+	}
+	return int(affectedRowsCount), nil
+}
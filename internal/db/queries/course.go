@@ -0,0 +1,327 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanCourse()
+const COURSE_COLUMNS string = "courses.id, courses.user_id, courses.title, courses.description, courses.state, courses.create_date, courses.last_update_date"
+
+func scanCourse(row rowScanner) (entities.Course, error) {
+	var course entities.Course
+	err := row.Scan(&course.Id, &course.UserId, &course.Title, &course.Description, &course.State, &course.CreateDate, &course.LastUpdateDate)
+	return course, err
+}
+
+func scanCourses(rows *sql.Rows) ([]entities.Course, error) {
+	var courses []entities.Course
+	defer rows.Close()
+
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return courses, fmt.Errorf("error at loading courses from db, case iterating and using rows.Scan: %s", err)
+		}
+		courses = append(courses, course)
+	}
+	err := rows.Err()
+	if err != nil {
+		return courses, fmt.Errorf("error at loading courses from db, case after iterating: %s", err)
+	}
+
+	return courses, nil
+}
+
+func GetCourses(tx *sql.Tx, ctx context.Context, limit int, offset int) ([]entities.Course, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+COURSE_COLUMNS+" FROM courses WHERE state != $3 ORDER BY id LIMIT $1 OFFSET $2", limit, offset, entities.COURSE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading courses from db, case after Query: %s", err)
+	}
+	return scanCourses(rows)
+}
+
+// returns the courses which the user is enrolled in, the recently studied ones go first
+func GetUserCourses(tx *sql.Tx, ctx context.Context, userId int) ([]entities.Course, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+COURSE_COLUMNS+" FROM courses JOIN course_enrollments ON course_enrollments.course_id = courses.id "+
+		"WHERE course_enrollments.user_id = $1 and courses.state != $2 ORDER BY course_enrollments.last_update_date DESC, courses.id", userId, entities.COURSE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading courses of user '%d' from db, case after Query: %s", userId, err)
+	}
+	return scanCourses(rows)
+}
+
+func GetCourse(tx *sql.Tx, ctx context.Context, id int) (entities.Course, error) {
+	course, err := scanCourse(tx.QueryRowContext(ctx, "SELECT "+COURSE_COLUMNS+" FROM courses WHERE id = $1 and state != $2", id, entities.COURSE_STATE_DELETED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return course, err
+		}
+		return course, fmt.Errorf("error at loading course by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return course, nil
+}
+
+func CreateCourse(tx *sql.Tx, ctx context.Context, userId int, title string, description string) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO courses(user_id, title, description, state, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+		userId, title, description, entities.COURSE_STATE_NEW, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting course (Title: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", title, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateCourse(tx *sql.Tx, ctx context.Context, id int, title string, description string) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE courses SET title = $2, description = $3, last_update_date = $4 WHERE id = $1 and state != $5")
+	if err != nil {
+		return fmt.Errorf("error at updating course, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, title, description, lastUpdateDate, entities.COURSE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating course (Id: %d, Title: '%s'), case after executing statement: %s", id, title, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating course (Id: %d, Title: '%s'), case after counting affected rows: %s", id, title, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func DeleteCourse(tx *sql.Tx, ctx context.Context, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE courses SET state = $2 WHERE id = $1 and state != $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting course, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, entities.COURSE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at deleting course by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting course by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetCourseModules(tx *sql.Tx, ctx context.Context, courseId int) ([]entities.CourseModule, error) {
+	var modules []entities.CourseModule
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, course_id, title, position FROM course_modules WHERE course_id = $1 ORDER BY position, id", courseId)
+	if err != nil {
+		return modules, fmt.Errorf("error at loading modules of course '%d' from db, case after Query: %s", courseId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var module entities.CourseModule
+		err := rows.Scan(&module.Id, &module.CourseId, &module.Title, &module.Position)
+		if err != nil {
+			return modules, fmt.Errorf("error at loading modules of course '%d' from db, case iterating and using rows.Scan: %s", courseId, err)
+		}
+		modules = append(modules, module)
+	}
+	err = rows.Err()
+	if err != nil {
+		return modules, fmt.Errorf("error at loading modules of course '%d' from db, case after iterating: %s", courseId, err)
+	}
+
+	return modules, nil
+}
+
+// adds the module to the end of the course
+func CreateCourseModule(tx *sql.Tx, ctx context.Context, courseId int, title string) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO course_modules(course_id, title, position) "+
+		"SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM course_modules WHERE course_id = $1 RETURNING id", courseId, title).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting course module (CourseId: '%d', Title: '%s') into db, case after QueryRow.Scan: %s", courseId, title, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateCourseModule(tx *sql.Tx, ctx context.Context, courseId int, id int, title string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE course_modules SET title = $3 WHERE id = $1 and course_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at updating course module, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, courseId, title)
+	if err != nil {
+		return fmt.Errorf("error at updating course module (Id: %d, CourseId: %d), case after executing statement: %s", id, courseId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating course module (Id: %d, CourseId: %d), case after counting affected rows: %s", id, courseId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// deletes the module with its items and the progress of the users on these items
+func DeleteCourseModule(tx *sql.Tx, ctx context.Context, courseId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM course_modules WHERE id = $1 and course_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting course module, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, courseId)
+	if err != nil {
+		return fmt.Errorf("error at deleting course module by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting course module by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM course_item_completions USING course_items WHERE course_items.id = course_item_completions.item_id and course_items.module_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting completions of course module '%d', case after executing statement: %s", id, err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM course_items WHERE module_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting items of course module '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+// sets positions of the modules of the course by the order of ids, the ids should be the complete list of the course modules
+func SetCourseModulePositions(tx *sql.Tx, ctx context.Context, courseId int, ids []int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE course_modules SET position = $3 WHERE id = $1 and course_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at ordering course modules, case after preparing statement: %s", err)
+	}
+	for position, id := range ids {
+		_, err = stmt.ExecContext(ctx, id, courseId, position)
+		if err != nil {
+			return fmt.Errorf("error at ordering course modules (Id: %d, CourseId: %d), case after executing statement: %s", id, courseId, err)
+		}
+	}
+	return nil
+}
+
+// the columns order matches scanCourseItem()
+const COURSE_ITEM_COLUMNS string = "course_items.id, course_items.module_id, course_items.note_id, course_items.task_id, course_items.position"
+
+func scanCourseItem(row rowScanner) (entities.CourseItem, error) {
+	var item entities.CourseItem
+	err := row.Scan(&item.Id, &item.ModuleId, &item.NoteId, &item.TaskId, &item.Position)
+	return item, err
+}
+
+// returns items of all modules of the course in order of studying
+func GetCourseItems(tx *sql.Tx, ctx context.Context, courseId int) ([]entities.CourseItem, error) {
+	var items []entities.CourseItem
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+COURSE_ITEM_COLUMNS+" FROM course_items JOIN course_modules ON course_modules.id = course_items.module_id "+
+		"WHERE course_modules.course_id = $1 ORDER BY course_modules.position, course_modules.id, course_items.position, course_items.id", courseId)
+	if err != nil {
+		return items, fmt.Errorf("error at loading items of course '%d' from db, case after Query: %s", courseId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanCourseItem(rows)
+		if err != nil {
+			return items, fmt.Errorf("error at loading items of course '%d' from db, case iterating and using rows.Scan: %s", courseId, err)
+		}
+		items = append(items, item)
+	}
+	err = rows.Err()
+	if err != nil {
+		return items, fmt.Errorf("error at loading items of course '%d' from db, case after iterating: %s", courseId, err)
+	}
+
+	return items, nil
+}
+
+// returns the item if it belongs to the course
+func GetCourseItem(tx *sql.Tx, ctx context.Context, courseId int, id int) (entities.CourseItem, error) {
+	item, err := scanCourseItem(tx.QueryRowContext(ctx, "SELECT "+COURSE_ITEM_COLUMNS+" FROM course_items JOIN course_modules ON course_modules.id = course_items.module_id "+
+		"WHERE course_items.id = $1 and course_modules.course_id = $2", id, courseId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return item, err
+		}
+		return item, fmt.Errorf("error at loading course item by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return item, nil
+}
+
+// adds the item to the end of the module, either the note or the task should be set
+func CreateCourseItem(tx *sql.Tx, ctx context.Context, moduleId int, noteId sql.NullInt32, taskId sql.NullInt32) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO course_items(module_id, note_id, task_id, position) "+
+		"SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0) FROM course_items WHERE module_id = $1 RETURNING id", moduleId, noteId, taskId).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting course item (ModuleId: '%d') into db, case after QueryRow.Scan: %s", moduleId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func DeleteCourseItem(tx *sql.Tx, ctx context.Context, moduleId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM course_items WHERE id = $1 and module_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting course item, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, moduleId)
+	if err != nil {
+		return fmt.Errorf("error at deleting course item by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting course item by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM course_item_completions WHERE item_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting completions of course item '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+// sets positions of the items of the module by the order of ids, the ids should be the complete list of the module items
+func SetCourseItemPositions(tx *sql.Tx, ctx context.Context, moduleId int, ids []int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE course_items SET position = $3 WHERE id = $1 and module_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at ordering course items, case after preparing statement: %s", err)
+	}
+	for position, id := range ids {
+		_, err = stmt.ExecContext(ctx, id, moduleId, position)
+		if err != nil {
+			return fmt.Errorf("error at ordering course items (Id: %d, ModuleId: %d), case after executing statement: %s", id, moduleId, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+func GetCourseEnrollment(tx *sql.Tx, ctx context.Context, courseId int, userId int) (entities.CourseEnrollment, error) {
+	var enrollment entities.CourseEnrollment
+
+	err := tx.QueryRowContext(ctx, "SELECT course_id, user_id, last_item_id, enroll_date, last_update_date FROM course_enrollments WHERE course_id = $1 and user_id = $2", courseId, userId).
+		Scan(&enrollment.CourseId, &enrollment.UserId, &enrollment.LastItemId, &enrollment.EnrollDate, &enrollment.LastUpdateDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return enrollment, err
+		}
+		return enrollment, fmt.Errorf("error at loading enrollment of user '%d' into course '%d' from db, case after QueryRow.Scan: %s", userId, courseId, err)
+	}
+
+	return enrollment, nil
+}
+
+// enrolls the user into the course, the repeated enrollment keeps the progress
+func EnrollCourse(tx *sql.Tx, ctx context.Context, courseId int, userId int) error {
+	enrollDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO course_enrollments(course_id, user_id, enroll_date, last_update_date) VALUES($1, $2, $3, $4) ON CONFLICT (course_id, user_id) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at enrolling course, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, courseId, userId, enrollDate, lastUpdateDate)
+	if err != nil {
+		return fmt.Errorf("error at enrolling course (CourseId: %d, UserId: %d), case after executing statement: %s", courseId, userId, err)
+	}
+	return nil
+}
+
+// removes the enrollment and the progress of the user in the course
+func LeaveCourse(tx *sql.Tx, ctx context.Context, courseId int, userId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM course_enrollments WHERE course_id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at leaving course, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, courseId, userId)
+	if err != nil {
+		return fmt.Errorf("error at leaving course (CourseId: %d, UserId: %d), case after executing statement: %s", courseId, userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at leaving course (CourseId: %d, UserId: %d), case after counting affected rows: %s", courseId, userId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM course_item_completions USING course_items, course_modules "+
+		"WHERE course_items.id = course_item_completions.item_id and course_modules.id = course_items.module_id and course_modules.course_id = $1 and course_item_completions.user_id = $2",
+		courseId, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting completions of course '%d' by user '%d', case after executing statement: %s", courseId, userId, err)
+	}
+	return nil
+}
+
+// sets the item which the user studies now, returns sql.ErrNoRows if the user is not enrolled into the course
+func SetCourseLastItem(tx *sql.Tx, ctx context.Context, courseId int, userId int, itemId int) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE course_enrollments SET last_item_id = $3, last_update_date = $4 WHERE course_id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at setting course position, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, courseId, userId, itemId, lastUpdateDate)
+	if err != nil {
+		return fmt.Errorf("error at setting course position (CourseId: %d, UserId: %d), case after executing statement: %s", courseId, userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at setting course position (CourseId: %d, UserId: %d), case after counting affected rows: %s", courseId, userId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func CompleteCourseItem(tx *sql.Tx, ctx context.Context, itemId int, userId int) error {
+	completeDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO course_item_completions(item_id, user_id, complete_date) VALUES($1, $2, $3) ON CONFLICT (item_id, user_id) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at completing course item, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, itemId, userId, completeDate)
+	if err != nil {
+		return fmt.Errorf("error at completing course item (ItemId: %d, UserId: %d), case after executing statement: %s", itemId, userId, err)
+	}
+	return nil
+}
+
+func UncompleteCourseItem(tx *sql.Tx, ctx context.Context, itemId int, userId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM course_item_completions WHERE item_id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at uncompleting course item, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, itemId, userId)
+	if err != nil {
+		return fmt.Errorf("error at uncompleting course item (ItemId: %d, UserId: %d), case after executing statement: %s", itemId, userId, err)
+	}
+	return nil
+}
+
+// returns sql.ErrNoRows if the user is not enrolled into the course
+func GetCourseProgress(tx *sql.Tx, ctx context.Context, courseId int, userId int) (entities.CourseProgress, error) {
+	var progress entities.CourseProgress
+
+	enrollment, err := GetCourseEnrollment(tx, ctx, courseId, userId)
+	if err != nil {
+		return progress, err
+	}
+	progress.Enrollment = enrollment
+
+	rows, err := tx.QueryContext(ctx, "SELECT course_items.id, course_item_completions.item_id IS NOT NULL FROM course_items "+
+		"JOIN course_modules ON course_modules.id = course_items.module_id "+
+		"LEFT JOIN course_item_completions ON course_item_completions.item_id = course_items.id and course_item_completions.user_id = $2 "+
+		"WHERE course_modules.course_id = $1 ORDER BY course_modules.position, course_modules.id, course_items.position, course_items.id", courseId, userId)
+	if err != nil {
+		return progress, fmt.Errorf("error at loading progress of user '%d' in course '%d' from db, case after Query: %s", userId, courseId, err)
+	}
+	defer rows.Close()
+
+	progress.CompletedItemIds = []int{}
+	for rows.Next() {
+		var itemId int
+		var completed bool
+		err := rows.Scan(&itemId, &completed)
+		if err != nil {
+			return progress, fmt.Errorf("error at loading progress of user '%d' in course '%d' from db, case iterating and using rows.Scan: %s", userId, courseId, err)
+		}
+		progress.ItemsTotal++
+		if completed {
+			progress.CompletedItemIds = append(progress.CompletedItemIds, itemId)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return progress, fmt.Errorf("error at loading progress of user '%d' in course '%d' from db, case after iterating: %s", userId, courseId, err)
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,145 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanEvent()
+const EVENT_COLUMNS string = "id, resource_type, resource_id, action, public, user_ids, create_date"
+
+func scanEvent(row rowScanner) (entities.Event, error) {
+	var event entities.Event
+	var userIds pq.Int64Array
+	err := row.Scan(&event.Id, &event.ResourceType, &event.ResourceId, &event.Action, &event.Public, &userIds, &event.CreateDate)
+	event.UserIds = make([]int, 0, len(userIds))
+	for _, id := range userIds {
+		event.UserIds = append(event.UserIds, int(id))
+	}
+	return event, err
+}
+
+func GetEvent(tx *sql.Tx, ctx context.Context, id int) (entities.Event, error) {
+	event, err := scanEvent(tx.QueryRowContext(ctx, "SELECT "+EVENT_COLUMNS+" FROM events WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return event, err
+		}
+		return event, fmt.Errorf("error at loading event by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return event, nil
+}
+
+// returns the date of the given event or of the closest previous one if it is purged, the zero date is returned if there are no such events
+func GetEventDate(tx *sql.Tx, ctx context.Context, id int) (time.Time, error) {
+	var date time.Time
+
+	err := tx.QueryRowContext(ctx, "SELECT create_date FROM events WHERE id <= $1 ORDER BY id DESC LIMIT 1", id).Scan(&date)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return date, fmt.Errorf("error at loading date of event '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return date, nil
+}
+
+// returns the events published after the given position in the order of (create_date, id), it allows to resume the stream page by page.
+// The ids are not ordered by commits, so the position should be taken before the last received event with a safety window
+func GetEventsAfter(tx *sql.Tx, ctx context.Context, afterDate time.Time, afterId int, limit int) ([]entities.Event, error) {
+	var events []entities.Event
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+EVENT_COLUMNS+" FROM events WHERE (create_date, id) > ($1, $2) ORDER BY create_date, id LIMIT $3", afterDate, afterId, limit)
+	if err != nil {
+		return events, fmt.Errorf("error at loading events after '%v' and '%d' from db, case after Query: %s", afterDate, afterId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return events, fmt.Errorf("error at loading events after '%v' and '%d' from db, case iterating and using rows.Scan: %s", afterDate, afterId, err)
+		}
+		events = append(events, event)
+	}
+	err = rows.Err()
+	if err != nil {
+		return events, fmt.Errorf("error at loading events after '%v' and '%d' from db, case after iterating: %s", afterDate, afterId, err)
+	}
+
+	return events, nil
+}
+
+func CreateEvent(tx *sql.Tx, ctx context.Context, event entities.Event) (int, error) {
+	lastInsertId := -1
+
+	userIds := make([]int64, 0, len(event.UserIds))
+	for _, id := range event.UserIds {
+		userIds = append(userIds, int64(id))
+	}
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO events(resource_type, resource_id, action, public, user_ids, create_date) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+		event.ResourceType, event.ResourceId, event.Action, event.Public, pq.Int64Array(userIds), time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting event (ResourceType: '%s', ResourceId: '%d', Action: '%s') into db, case after QueryRow.Scan: %s",
+			event.ResourceType, event.ResourceId, event.Action, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// the notification is delivered to the listeners of the channel when the transaction is committed, it is dropped if the transaction is rolled back
+func NotifyChannel(tx *sql.Tx, ctx context.Context, channel string, payload string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	if err != nil {
+		return fmt.Errorf("error at notifying channel '%s', case after executing statement: %s", channel, err)
+	}
+	return nil
+}
+
+// deletes the events published before the date and returns their count
+func DeleteEventsBefore(tx *sql.Tx, ctx context.Context, date time.Time) (int, error) {
+	res, err := tx.ExecContext(ctx, "DELETE FROM events WHERE create_date < $1", date)
+	if err != nil {
+		return 0, fmt.Errorf("error at deleting events before '%v', case after executing statement: %s", date, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error at deleting events before '%v', case after counting affected rows: %s", date, err)
+	}
+	return int(affectedRowsCount), nil
+}
+
+// returns whether the note is public and the users who are able to see it otherwise: the owner and the users it is shared with.
+// The deleted note is not public, so its deletion should be published before deleting it
+func GetNoteAudience(tx *sql.Tx, ctx context.Context, noteId int) (bool, []int, error) {
+	var ownerId int
+	var public bool
+	var shareUserIds pq.Int64Array
+
+	err := tx.QueryRowContext(ctx, "SELECT notes.user_id, notes.state != $2 AND "+notePublicCondition()+", "+
+		"COALESCE(array_agg(note_shares.user_id) FILTER (WHERE note_shares.user_id IS NOT NULL AND notes.visibility != $3), '{}') "+
+		"FROM notes LEFT JOIN note_shares ON note_shares.note_id = notes.id WHERE notes.id = $1 GROUP BY notes.id",
+		noteId, entities.NOTE_STATE_DELETED, entities.NOTE_VISIBILITY_PRIVATE).
+		Scan(&ownerId, &public, &shareUserIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil, err
+		}
+		return false, nil, fmt.Errorf("error at loading audience of note '%d' from db, case after QueryRow.Scan: %s", noteId, err)
+	}
+
+	userIds := []int{ownerId}
+	for _, id := range shareUserIds {
+		userIds = append(userIds, int(id))
+	}
+	return public, userIds, nil
+}
@@ -0,0 +1,150 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanMention()
+const MENTION_COLUMNS string = "mentions.id, mentions.user_id, mentions.actor_id, mentions.note_id, mentions.comment_id, notes.topic, mentions.create_date"
+
+func scanMention(row rowScanner) (entities.Mention, error) {
+	var mention entities.Mention
+	err := row.Scan(&mention.Id, &mention.UserId, &mention.ActorId, &mention.NoteId, &mention.CommentId, &mention.NoteTopic, &mention.CreateDate)
+	return mention, err
+}
+
+// returns the ids of the active users with the given logins, the case is ignored
+func GetUserIdsByLogins(tx *sql.Tx, ctx context.Context, logins []string) ([]int, error) {
+	var ids []int
+
+	keys := make([]string, 0, len(logins))
+	for _, login := range logins {
+		keys = append(keys, strings.ToLower(login))
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE lower(login) = ANY($1) and state NOT IN ($2, $3) ORDER BY id",
+		pq.Array(keys), entities.USER_STATE_BLOCKED, entities.USER_STATE_DELETED)
+	if err != nil {
+		return ids, fmt.Errorf("error at loading users by logins from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, fmt.Errorf("error at loading users by logins from db, case iterating and using rows.Scan: %s", err)
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return ids, fmt.Errorf("error at loading users by logins from db, case after iterating: %s", err)
+	}
+
+	return ids, nil
+}
+
+// returns the mentions of the user in the notes and the comments which are still visible to the user, the recent ones go first
+func GetUserMentions(tx *sql.Tx, ctx context.Context, userId int, limit int, offset int) ([]entities.Mention, error) {
+	var mentions []entities.Mention
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+MENTION_COLUMNS+" FROM mentions JOIN notes ON notes.id = mentions.note_id "+
+		"LEFT JOIN comments ON comments.id = mentions.comment_id "+
+		"WHERE mentions.user_id = $1 and notes.state != $2 and "+noteVisibleToUserCondition("$1")+" and "+
+		"(mentions.comment_id IS NULL OR comments.state NOT IN ($3, $4)) "+
+		"ORDER BY mentions.create_date DESC, mentions.id DESC LIMIT $5 OFFSET $6",
+		userId, entities.NOTE_STATE_DELETED, entities.COMMENT_STATE_BLOCKED, entities.COMMENT_STATE_DELETED, limit, offset)
+	if err != nil {
+		return mentions, fmt.Errorf("error at loading mentions of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mention, err := scanMention(rows)
+		if err != nil {
+			return mentions, fmt.Errorf("error at loading mentions of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		mentions = append(mentions, mention)
+	}
+	err = rows.Err()
+	if err != nil {
+		return mentions, fmt.Errorf("error at loading mentions of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return mentions, nil
+}
+
+// returns the ids of the users mentioned in the note or, if the comment is set, in the comment
+func GetMentionedUserIds(tx *sql.Tx, ctx context.Context, noteId int, commentId sql.NullInt32) ([]int, error) {
+	var ids []int
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM mentions WHERE note_id = $1 and comment_id IS NOT DISTINCT FROM $2 ORDER BY user_id", noteId, commentId)
+	if err != nil {
+		return ids, fmt.Errorf("error at loading mentioned users of note '%d' from db, case after Query: %s", noteId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, fmt.Errorf("error at loading mentioned users of note '%d' from db, case iterating and using rows.Scan: %s", noteId, err)
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return ids, fmt.Errorf("error at loading mentioned users of note '%d' from db, case after iterating: %s", noteId, err)
+	}
+
+	return ids, nil
+}
+
+func CreateMention(tx *sql.Tx, ctx context.Context, mention entities.Mention) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO mentions(user_id, actor_id, note_id, comment_id, create_date) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		mention.UserId, mention.ActorId, mention.NoteId, mention.CommentId, time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting mention (UserId: '%d', NoteId: '%d') into db, case after QueryRow.Scan: %s", mention.UserId, mention.NoteId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// deletes the mentions of the note or, if the comment is set, of the comment except the mentions of the given users
+func DeleteMentionsExcept(tx *sql.Tx, ctx context.Context, noteId int, commentId sql.NullInt32, userIds []int) error {
+	keptIds := make([]int64, 0, len(userIds))
+	for _, id := range userIds {
+		keptIds = append(keptIds, int64(id))
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM mentions WHERE note_id = $1 and comment_id IS NOT DISTINCT FROM $2 and NOT (user_id = ANY($3))")
+	if err != nil {
+		return fmt.Errorf("error at deleting mentions, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId, commentId, pq.Int64Array(keptIds))
+	if err != nil {
+		return fmt.Errorf("error at deleting mentions of note '%d', case after executing statement: %s", noteId, err)
+	}
+	return nil
+}
+
+// returns the count of the mentions made by the actor since the date, it limits the mention spam
+func CountMentionsByActorSince(tx *sql.Tx, ctx context.Context, actorId int, since time.Time) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, "SELECT count(*) FROM mentions WHERE actor_id = $1 and create_date >= $2", actorId, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error at counting mentions of actor '%d', case after QueryRow.Scan: %s", actorId, err)
+	}
+	return count, nil
+}
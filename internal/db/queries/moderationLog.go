@@ -0,0 +1,77 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanModerationLogEntry()
+const MODERATION_LOG_COLUMNS string = "id, moderator_id, content_type, content_id, action, reason, previous_state, reports_count, create_date"
+
+func scanModerationLogEntry(row rowScanner) (entities.ModerationLogEntry, error) {
+	var entry entities.ModerationLogEntry
+	err := row.Scan(&entry.Id, &entry.ModeratorId, &entry.ContentType, &entry.ContentId, &entry.Action, &entry.Reason, &entry.PreviousState, &entry.ReportsCount, &entry.CreateDate)
+	return entry, err
+}
+
+// returns the decisions of the moderators, the recent ones go first. The empty content type and the zero content id are ignored
+func GetModerationLog(tx *sql.Tx, ctx context.Context, contentType string, contentId int, limit int, offset int) ([]entities.ModerationLogEntry, error) {
+	var entries []entities.ModerationLogEntry
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+MODERATION_LOG_COLUMNS+" FROM moderation_log WHERE ($1 = '' OR content_type = $1) and ($2 = 0 OR content_id = $2) "+
+		"ORDER BY create_date DESC, id DESC LIMIT $3 OFFSET $4", contentType, contentId, limit, offset)
+	if err != nil {
+		return entries, fmt.Errorf("error at loading moderation log from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanModerationLogEntry(rows)
+		if err != nil {
+			return entries, fmt.Errorf("error at loading moderation log from db, case iterating and using rows.Scan: %s", err)
+		}
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return entries, fmt.Errorf("error at loading moderation log from db, case after iterating: %s", err)
+	}
+
+	return entries, nil
+}
+
+// returns the state of the content before the last blocking, sql.ErrNoRows if the content was never blocked by the moderator
+func GetStateBeforeBlocking(tx *sql.Tx, ctx context.Context, contentType string, contentId int) (string, error) {
+	var state string
+
+	err := tx.QueryRowContext(ctx, "SELECT previous_state FROM moderation_log WHERE content_type = $1 and content_id = $2 and action = $3 ORDER BY create_date DESC, id DESC LIMIT 1",
+		contentType, contentId, entities.MODERATION_ACTION_BLOCK).
+		Scan(&state)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return state, err
+		}
+		return state, fmt.Errorf("error at loading state of content (ContentType: '%s', ContentId: '%d') before blocking from db, case after QueryRow.Scan: %s", contentType, contentId, err)
+	}
+
+	return state, nil
+}
+
+func CreateModerationLogEntry(tx *sql.Tx, ctx context.Context, entry entities.ModerationLogEntry) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO moderation_log(moderator_id, content_type, content_id, action, reason, previous_state, reports_count, create_date) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		entry.ModeratorId, entry.ContentType, entry.ContentId, entry.Action, entry.Reason, entry.PreviousState, entry.ReportsCount, time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting moderation log entry (ContentType: '%s', ContentId: '%d', Action: '%s') into db, case after QueryRow.Scan: %s",
+			entry.ContentType, entry.ContentId, entry.Action, err)
+	}
+
+	return lastInsertId, nil
+}
@@ -9,45 +9,91 @@ import (
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
 )
 
-func GetNotes(tx *sql.Tx, ctx context.Context, limit int, offset int) ([]entities.Note, error) {
-	var note []entities.Note
-	var (
-		id             int
-		text           string
-		topic          string
-		tagId          int
-		userId         int
-		state          string
-		createDate     time.Time
-		lastUpdateDate time.Time
-	)
-
-	rows, err := tx.QueryContext(ctx, "SELECT id, text, topic, tag_id, user_id, state, create_date, last_update_date FROM notes WHERE state != $3 LIMIT $1 OFFSET $2 ", limit, offset, entities.NOTE_STATE_DELETED)
-	if err != nil {
-		return note, fmt.Errorf("error at loading note from db, case after Query: %s", err)
-	}
+// the columns order matches scanNote()
+const NOTE_COLUMNS string = "notes.id, notes.text, notes.topic, notes.tag_id, notes.user_id, notes.state, notes.visibility, notes.create_date, notes.last_update_date, " +
+	"notes.published_at, notes.publish_at"
+
+// the condition that the note is readable by everyone, the drafts and the blocked notes are not public
+func notePublicCondition() string {
+	return "(notes.visibility = '" + entities.NOTE_VISIBILITY_PUBLIC + "' AND notes.state NOT IN ('" + entities.NOTE_STATE_DRAFT + "', '" + entities.NOTE_STATE_BLOCKED + "'))"
+}
+
+// the condition that the note is shared with the user explicitly, shares of private notes are ignored
+func noteSharedCondition(userIdParam string) string {
+	return "(notes.visibility != '" + entities.NOTE_VISIBILITY_PRIVATE + "' AND " +
+		"EXISTS (SELECT 1 FROM note_shares WHERE note_shares.note_id = notes.id AND note_shares.user_id = " + userIdParam + "))"
+}
+
+// the condition of note visibility for the user passed as parameter with the given number, it matches GetNotePermission()
+func noteVisibleToUserCondition(userIdParam string) string {
+	return "(" + notePublicCondition() + " OR " + noteOwnedOrSharedCondition(userIdParam) + ")"
+}
+
+// the condition that the note belongs to the user or is shared with the user explicitly
+func noteOwnedOrSharedCondition(userIdParam string) string {
+	return "(notes.user_id = " + userIdParam + " OR " + noteSharedCondition(userIdParam) + ")"
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNote(row rowScanner) (entities.Note, error) {
+	var note entities.Note
+	err := row.Scan(&note.Id, &note.Text, &note.Topic, &note.TagId, &note.UserId, &note.State, &note.Visibility, &note.CreateDate, &note.LastUpdateDate,
+		&note.PublishedAt, &note.PublishAt)
+	return note, err
+}
+
+func scanNotes(rows *sql.Rows) ([]entities.Note, error) {
+	var notes []entities.Note
 	defer rows.Close()
 
 	for rows.Next() {
-		err := rows.Scan(&id, &text, &topic, &tagId, &userId, &state, &createDate, &lastUpdateDate)
+		note, err := scanNote(rows)
 		if err != nil {
-			return note, fmt.Errorf("error at loading notes from db, case iterating and using rows.Scan: %s", err)
+			return notes, fmt.Errorf("error at loading notes from db, case iterating and using rows.Scan: %s", err)
 		}
-		note = append(note, entities.Note{Id: id, Text: text, Topic: topic, TagId: tagId, UserId: userId, State: state, CreateDate: createDate, LastUpdateDate: lastUpdateDate})
+		notes = append(notes, note)
 	}
-	err = rows.Err()
+	err := rows.Err()
 	if err != nil {
-		return note, fmt.Errorf("error at loading notes from db, case after iterating: %s", err)
+		return notes, fmt.Errorf("error at loading notes from db, case after iterating: %s", err)
 	}
 
-	return note, nil
+	return notes, nil
 }
 
-func GetNote(tx *sql.Tx, ctx context.Context, id int) (entities.Note, error) {
-	var note entities.Note
+func GetNotes(tx *sql.Tx, ctx context.Context, limit int, offset int) ([]entities.Note, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE state != $3 ORDER BY id LIMIT $1 OFFSET $2 ", limit, offset, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading note from db, case after Query: %s", err)
+	}
+	return scanNotes(rows)
+}
+
+// returns notes which the user is allowed to read: own, public and shared with the user
+func GetVisibleNotes(tx *sql.Tx, ctx context.Context, userId int, limit int, offset int) ([]entities.Note, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE state != $3 AND "+noteVisibleToUserCondition("$4")+" ORDER BY id LIMIT $1 OFFSET $2 ",
+		limit, offset, entities.NOTE_STATE_DELETED, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading notes visible to user '%d' from db, case after Query: %s", userId, err)
+	}
+	return scanNotes(rows)
+}
+
+// returns own notes of the user with id greater than afterId, it allows to iterate over all the notes page by page
+func GetUserNotes(tx *sql.Tx, ctx context.Context, userId int, afterId int, limit int) ([]entities.Note, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE user_id = $1 and id > $2 and state != $4 ORDER BY id LIMIT $3",
+		userId, afterId, limit, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading notes of user '%d' from db, case after Query: %s", userId, err)
+	}
+	return scanNotes(rows)
+}
 
-	err := tx.QueryRowContext(ctx, "SELECT id, text, topic, tag_id, user_id, state, create_date, last_update_date FROM notes WHERE id = $1 and state != $2 ", id, entities.NOTE_STATE_DELETED).
-		Scan(&note.Id, &note.Text, &note.Topic, &note.TagId, &note.UserId, &note.State, &note.CreateDate, &note.LastUpdateDate)
+func GetNote(tx *sql.Tx, ctx context.Context, id int) (entities.Note, error) {
+	note, err := scanNote(tx.QueryRowContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE id = $1 and state != $2 ", id, entities.NOTE_STATE_DELETED))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return note, err
@@ -59,29 +105,86 @@ func GetNote(tx *sql.Tx, ctx context.Context, id int) (entities.Note, error) {
 	return note, nil
 }
 
+// returns sql.ErrNoRows if the note does not exist or the user is not allowed to read it
+func GetVisibleNote(tx *sql.Tx, ctx context.Context, id int, userId int) (entities.Note, error) {
+	note, err := scanNote(tx.QueryRowContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE id = $1 and state != $2 AND "+noteVisibleToUserCondition("$3"), id, entities.NOTE_STATE_DELETED, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return note, err
+		}
+		return note, fmt.Errorf("error at loading note by id '%d' visible to user '%d' from db, case after QueryRow.Scan: %s", id, userId, err)
+	}
+
+	return note, nil
+}
+
+// returns one of entities.NOTE_PERMISSION_* values, the note is readable if it matches noteVisibleToUserCondition()
+func GetNotePermission(tx *sql.Tx, ctx context.Context, id int, userId int) (string, error) {
+	var permission string
+
+	err := tx.QueryRowContext(ctx, "SELECT CASE "+
+		"WHEN notes.user_id = $2 THEN $4 "+
+		"WHEN "+noteSharedCondition("$2")+" THEN (SELECT note_shares.permission FROM note_shares WHERE note_shares.note_id = notes.id AND note_shares.user_id = $2) "+
+		"WHEN "+notePublicCondition()+" THEN $5 "+
+		"ELSE $6 END "+
+		"FROM notes WHERE notes.id = $1 and notes.state != $3",
+		id, userId, entities.NOTE_STATE_DELETED, entities.NOTE_PERMISSION_OWNER, entities.NOTE_PERMISSION_READ, entities.NOTE_PERMISSION_NONE).
+		Scan(&permission)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.NOTE_PERMISSION_NONE, err
+		}
+		return entities.NOTE_PERMISSION_NONE, fmt.Errorf("error at loading permission of user '%d' for note '%d' from db, case after QueryRow.Scan: %s", userId, id, err)
+	}
+
+	return permission, nil
+}
+
+// the published note gets the publication date
 func CreateNote(tx *sql.Tx, ctx context.Context, text string, topic string, tagId int, userId int, state string) (int, error) {
 	lastInsertId := -1
 
 	createDate := time.Now()
 	lastUpdateDate := time.Now()
 
-	err := tx.QueryRowContext(ctx, "INSERT INTO notes(text, topic, tag_id, user_id, state, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id",
-		text, topic, tagId, userId, state, createDate, lastUpdateDate).
+	err := tx.QueryRowContext(ctx, "INSERT INTO notes(text, topic, tag_id, user_id, state, create_date, last_update_date, published_at) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, CASE WHEN $5 = $8 THEN $6::timestamp END) RETURNING id",
+		text, topic, tagId, userId, state, createDate, lastUpdateDate, entities.NOTE_STATE_PUBLISHED).
 		Scan(&lastInsertId) // scan will release the connection
 	if err != nil {
 		return -1, fmt.Errorf("error at inserting note (Topic: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", topic, userId, err)
 	}
 
+	err = AddNoteTags(tx, ctx, lastInsertId, []int{tagId})
+	if err != nil {
+		return -1, err
+	}
+
 	return lastInsertId, nil
 }
 
+// the note gets the publication date when it is published first time, the schedule of the publication is kept for the drafts only
 func UpdateNote(tx *sql.Tx, ctx context.Context, id int, text string, topic string, tagId int, userId int, state string) error {
 	lastUpdateDate := time.Now()
-	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET text = $2, topic = $3, tag_id = $4, user_id = $5, state = $6, last_update_date = $7 WHERE id = $1 and state != $8")
+
+	// the previous primary tag is replaced in the note tags by the new one
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_tags USING notes WHERE notes.id = $1 and note_tags.note_id = notes.id and note_tags.tag_id = notes.tag_id and notes.tag_id != $2")
+	if err != nil {
+		return fmt.Errorf("error at updating note tags, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, id, tagId)
+	if err != nil {
+		return fmt.Errorf("error at updating note tags (Id: %d, TagId: %d), case after executing statement: %s", id, tagId, err)
+	}
+
+	stmt, err = tx.PrepareContext(ctx, "UPDATE notes SET text = $2, topic = $3, tag_id = $4, user_id = $5, state = $6, last_update_date = $7, "+
+		"published_at = CASE WHEN published_at IS NULL AND $6 = $9 THEN $7::timestamp ELSE published_at END, "+
+		"publish_at = CASE WHEN $6 = $10 THEN publish_at END "+
+		"WHERE id = $1 and state != $8")
 	if err != nil {
 		return fmt.Errorf("error at updating note, case after preparing statement: %s", err)
 	}
-	res, err := stmt.ExecContext(ctx, id, text, topic, tagId, userId, state, lastUpdateDate, entities.NOTE_STATE_DELETED)
+	res, err := stmt.ExecContext(ctx, id, text, topic, tagId, userId, state, lastUpdateDate, entities.NOTE_STATE_DELETED, entities.NOTE_STATE_PUBLISHED, entities.NOTE_STATE_DRAFT)
 	if err != nil {
 		return fmt.Errorf("error at updating note (Id: %d, Topic: '%s', UserId: '%d', State: '%s'), case after executing statement: %s", id, topic, userId, state, err)
 	}
@@ -94,15 +197,16 @@ func UpdateNote(tx *sql.Tx, ctx context.Context, id int, text string, topic stri
 		return sql.ErrNoRows
 	}
 
-	return nil
+	return AddNoteTags(tx, ctx, id, []int{tagId})
 }
 
-func DeleteNote(tx *sql.Tx, ctx context.Context, id int) error {
-	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET state = $2 WHERE id = $1 and state != $2")
+// moves the note to the trash of its owner, the note is restorable until it is purged
+func DeleteNote(tx *sql.Tx, ctx context.Context, id int, userId int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET previous_state = state, state = $2, deleted_at = $3, deleted_by = $4 WHERE id = $1 and state != $2")
 	if err != nil {
 		return fmt.Errorf("error at deleting note, case after preparing statement: %s", err)
 	}
-	res, err := stmt.ExecContext(ctx, id, entities.NOTE_STATE_DELETED)
+	res, err := stmt.ExecContext(ctx, id, entities.NOTE_STATE_DELETED, time.Now(), userId)
 	if err != nil {
 		return fmt.Errorf("error at deleting note by id '%d', case after executing statement: %s", id, err)
 	}
@@ -115,3 +219,110 @@ func DeleteNote(tx *sql.Tx, ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+// overrides the dates of the note, e.g. for keeping the original dates of imported notes. The published note is considered published at its creation
+func UpdateNoteDates(tx *sql.Tx, ctx context.Context, id int, createDate time.Time, lastUpdateDate time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET create_date = $2, last_update_date = $3, published_at = CASE WHEN published_at IS NOT NULL THEN $2::timestamp END "+
+		"WHERE id = $1 and state != $4")
+	if err != nil {
+		return fmt.Errorf("error at updating note dates, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, createDate, lastUpdateDate, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating note dates (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating note dates (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// sets the state of the note regardless of the workflow, e.g. for blocking it by the moderator
+func UpdateNoteState(tx *sql.Tx, ctx context.Context, id int, state string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET state = $2, last_update_date = $3 WHERE id = $1 and state != $4")
+	if err != nil {
+		return fmt.Errorf("error at updating note state, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, state, time.Now(), entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating note state (Id: %d, State: '%s'), case after executing statement: %s", id, state, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating note state (Id: %d, State: '%s'), case after counting affected rows: %s", id, state, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func UpdateNoteVisibility(tx *sql.Tx, ctx context.Context, id int, visibility string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET visibility = $2 WHERE id = $1 and state != $3")
+	if err != nil {
+		return fmt.Errorf("error at updating note visibility, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, visibility, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating note visibility (Id: %d, Visibility: '%s'), case after executing statement: %s", id, visibility, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating note visibility (Id: %d, Visibility: '%s'), case after counting affected rows: %s", id, visibility, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// schedules the publication of the draft, the null date cancels the schedule
+func UpdateNotePublishAt(tx *sql.Tx, ctx context.Context, id int, publishAt sql.NullTime) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET publish_at = $2 WHERE id = $1 and state = $3")
+	if err != nil {
+		return fmt.Errorf("error at updating note publication schedule, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, publishAt, entities.NOTE_STATE_DRAFT)
+	if err != nil {
+		return fmt.Errorf("error at updating note publication schedule (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating note publication schedule (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// publishes the drafts scheduled before the given date, the publication date is the scheduled one. Returns the ids of the published notes
+func PublishScheduledNotes(tx *sql.Tx, ctx context.Context, now time.Time) ([]int, error) {
+	var ids []int
+
+	rows, err := tx.QueryContext(ctx, "UPDATE notes SET state = $1, published_at = COALESCE(published_at, publish_at), publish_at = NULL "+
+		"WHERE state = $2 and publish_at <= $3 RETURNING id", entities.NOTE_STATE_PUBLISHED, entities.NOTE_STATE_DRAFT, now)
+	if err != nil {
+		return ids, fmt.Errorf("error at publishing scheduled notes, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, fmt.Errorf("error at publishing scheduled notes, case iterating and using rows.Scan: %s", err)
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return ids, fmt.Errorf("error at publishing scheduled notes, case after iterating: %s", err)
+	}
+
+	return ids, nil
+}
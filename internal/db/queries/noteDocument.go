@@ -0,0 +1,92 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+const NOTE_DOCUMENT_COLUMNS string = "notes.id, notes.topic, notes.text, " +
+	"ARRAY(SELECT note_tags.tag_id FROM note_tags WHERE note_tags.note_id = notes.id ORDER BY note_tags.tag_id), " +
+	"ARRAY(SELECT DISTINCT note_links.target_note_id FROM note_links WHERE note_links.source_note_id = notes.id and note_links.target_note_id IS NOT NULL ORDER BY note_links.target_note_id)"
+
+func scanNoteDocuments(rows *sql.Rows) ([]entities.NoteDocument, error) {
+	var documents []entities.NoteDocument
+	defer rows.Close()
+
+	for rows.Next() {
+		var document entities.NoteDocument
+		var tagIds, linkedIds pq.Int64Array
+		err := rows.Scan(&document.Id, &document.Topic, &document.Text, &tagIds, &linkedIds)
+		if err != nil {
+			return documents, fmt.Errorf("error at loading note documents from db, case iterating and using rows.Scan: %s", err)
+		}
+		document.TagIds = toInts(tagIds)
+		document.LinkedIds = toInts(linkedIds)
+		documents = append(documents, document)
+	}
+	err := rows.Err()
+	if err != nil {
+		return documents, fmt.Errorf("error at loading note documents from db, case after iterating: %s", err)
+	}
+
+	return documents, nil
+}
+
+// returns all not deleted notes with their tags and the resolved links
+func GetAllNoteDocuments(tx *sql.Tx, ctx context.Context) ([]entities.NoteDocument, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_DOCUMENT_COLUMNS+" FROM notes WHERE notes.state != $1 ORDER BY notes.id", entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading note documents from db, case after Query: %s", err)
+	}
+	return scanNoteDocuments(rows)
+}
+
+// returns the given notes with their tags and the resolved links, the deleted notes are missed
+func GetNoteDocumentsByIds(tx *sql.Tx, ctx context.Context, ids []int) ([]entities.NoteDocument, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_DOCUMENT_COLUMNS+" FROM notes WHERE notes.id = ANY($1) and notes.state != $2 ORDER BY notes.id",
+		pq.Array(ids), entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading note documents by ids from db, case after Query: %s", err)
+	}
+	return scanNoteDocuments(rows)
+}
+
+// returns the ids of the notes linking to the given notes and of the notes with the given tags, their documents depend on them
+func GetLinkingOrTaggedNoteIds(tx *sql.Tx, ctx context.Context, noteIds []int, tagIds []int) ([]int, error) {
+	var ids pq.Int64Array
+
+	err := tx.QueryRowContext(ctx, "SELECT ARRAY(SELECT source_note_id FROM note_links WHERE target_note_id = ANY($1) "+
+		"UNION SELECT note_id FROM note_tags WHERE tag_id = ANY($2) ORDER BY 1)", pq.Array(noteIds), pq.Array(tagIds)).
+		Scan(&ids)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading linking or tagged note ids from db, case after QueryRow.Scan: %s", err)
+	}
+
+	return toInts(ids), nil
+}
+
+// returns the given notes which the user is allowed to read
+func GetVisibleNoteIds(tx *sql.Tx, ctx context.Context, userId int, ids []int) ([]int, error) {
+	var visibleIds pq.Int64Array
+
+	err := tx.QueryRowContext(ctx, "SELECT ARRAY(SELECT notes.id FROM notes WHERE notes.id = ANY($1) and notes.state != $2 AND "+
+		noteVisibleToUserCondition("$3")+" ORDER BY notes.id)", pq.Array(ids), entities.NOTE_STATE_DELETED, userId).
+		Scan(&visibleIds)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading note ids visible to user '%d' from db, case after QueryRow.Scan: %s", userId, err)
+	}
+
+	return toInts(visibleIds), nil
+}
+
+func toInts(values pq.Int64Array) []int {
+	result := make([]int, 0, len(values))
+	for _, value := range values {
+		result = append(result, int(value))
+	}
+	return result
+}
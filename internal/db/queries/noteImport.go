@@ -0,0 +1,42 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+func GetNoteImport(tx *sql.Tx, ctx context.Context, userId int, sourcePath string) (entities.NoteImport, error) {
+	var noteImport entities.NoteImport
+
+	err := tx.QueryRowContext(ctx, "SELECT user_id, source_path, content_hash, note_id, create_date, last_update_date FROM note_imports WHERE user_id = $1 and source_path = $2", userId, sourcePath).
+		Scan(&noteImport.UserId, &noteImport.SourcePath, &noteImport.ContentHash, &noteImport.NoteId, &noteImport.CreateDate, &noteImport.LastUpdateDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return noteImport, err
+		}
+		return noteImport, fmt.Errorf("error at loading note import (UserId: %d, SourcePath: '%s') from db, case after QueryRow.Scan: %s", userId, sourcePath, err)
+	}
+
+	return noteImport, nil
+}
+
+// creates the note import or updates the existing one
+func SaveNoteImport(tx *sql.Tx, ctx context.Context, userId int, sourcePath string, contentHash string, noteId int) error {
+	now := time.Now()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO note_imports(user_id, source_path, content_hash, note_id, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $5) "+
+		"ON CONFLICT (user_id, source_path) DO UPDATE SET content_hash = EXCLUDED.content_hash, note_id = EXCLUDED.note_id, last_update_date = EXCLUDED.last_update_date")
+	if err != nil {
+		return fmt.Errorf("error at saving note import, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, userId, sourcePath, contentHash, noteId, now)
+	if err != nil {
+		return fmt.Errorf("error at saving note import (UserId: %d, SourcePath: '%s'), case after executing statement: %s", userId, sourcePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// replaces the links of the note. The target which is a number links to the note with such id,
+// the other ones link to the note of the same user with such topic (case insensitive)
+func ReplaceNoteLinks(tx *sql.Tx, ctx context.Context, sourceNoteId int, userId int, targets []string) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_links WHERE source_note_id = $1")
+	if err != nil {
+		return fmt.Errorf("error at replacing note links, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, sourceNoteId)
+	if err != nil {
+		return fmt.Errorf("error at replacing note links (SourceNoteId: %d), case after executing statement: %s", sourceNoteId, err)
+	}
+
+	stmt, err = tx.PrepareContext(ctx, "INSERT INTO note_links(source_note_id, target, target_note_id) VALUES($1, $2::varchar, "+
+		"(SELECT id FROM notes WHERE state != $5 AND (id = $3 OR ($3 IS NULL AND user_id = $4 AND lower(topic) = lower($2::varchar))) ORDER BY id LIMIT 1)) "+
+		"ON CONFLICT (source_note_id, target) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at replacing note links, case after preparing statement: %s", err)
+	}
+	for _, target := range targets {
+		var targetId sql.NullInt32
+		if id, err := strconv.ParseInt(target, 10, 32); err == nil {
+			targetId = sql.NullInt32{Int32: int32(id), Valid: true}
+		}
+		_, err = stmt.ExecContext(ctx, sourceNoteId, target, targetId, userId, entities.NOTE_STATE_DELETED)
+		if err != nil {
+			return fmt.Errorf("error at replacing note links (SourceNoteId: %d, Target: '%s'), case after executing statement: %s", sourceNoteId, target, err)
+		}
+	}
+
+	return nil
+}
+
+// links the dangling links of the user's notes to the note with the matching topic
+func ResolveNoteLinks(tx *sql.Tx, ctx context.Context, noteId int, userId int, topic string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE note_links SET target_note_id = $1 FROM notes "+
+		"WHERE notes.id = note_links.source_note_id and notes.user_id = $2 and lower(note_links.target) = lower($3) and "+
+		"(note_links.target_note_id IS NULL OR NOT EXISTS (SELECT 1 FROM notes target WHERE target.id = note_links.target_note_id and target.state != $4))")
+	if err != nil {
+		return fmt.Errorf("error at resolving note links, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId, userId, topic, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at resolving note links (NoteId: %d), case after executing statement: %s", noteId, err)
+	}
+	return nil
+}
+
+// returns the links of the note, the links to deleted notes and to notes which are not visible to the user are returned as dangling
+func GetNoteLinks(tx *sql.Tx, ctx context.Context, noteId int, userId int) ([]entities.NoteLink, error) {
+	var links []entities.NoteLink
+	var link entities.NoteLink
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_links.source_note_id, note_links.target, notes.id FROM note_links "+
+		"LEFT JOIN notes ON notes.id = note_links.target_note_id and notes.state != $2 and "+noteVisibleToUserCondition("$3")+" "+
+		"WHERE note_links.source_note_id = $1 ORDER BY note_links.target", noteId, entities.NOTE_STATE_DELETED, userId)
+	if err != nil {
+		return links, fmt.Errorf("error at loading note links from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&link.SourceNoteId, &link.Target, &link.TargetNoteId)
+		if err != nil {
+			return links, fmt.Errorf("error at loading note links from db, case iterating and using rows.Scan: %s", err)
+		}
+		links = append(links, link)
+	}
+	err = rows.Err()
+	if err != nil {
+		return links, fmt.Errorf("error at loading note links from db, case after iterating: %s", err)
+	}
+
+	return links, nil
+}
+
+// returns notes visible to the user which link to the note
+func GetNoteBacklinks(tx *sql.Tx, ctx context.Context, noteId int, userId int) ([]entities.Note, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_COLUMNS+" FROM notes WHERE state != $2 AND "+noteVisibleToUserCondition("$3")+
+		" AND EXISTS (SELECT 1 FROM note_links WHERE note_links.source_note_id = notes.id and note_links.target_note_id = $1) ORDER BY id",
+		noteId, entities.NOTE_STATE_DELETED, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading backlinks of note '%d' from db, case after Query: %s", noteId, err)
+	}
+	return scanNotes(rows)
+}
+
+// returns the graph of notes visible to the user and links between them, tagId equals 0 means all tags
+func GetNotesGraph(tx *sql.Tx, ctx context.Context, userId int, tagId int) ([]entities.NoteGraphNode, []entities.NoteLink, error) {
+	var nodes []entities.NoteGraphNode
+	var edges []entities.NoteLink
+
+	nodesQuery := "SELECT notes.id, notes.topic, notes.tag_id FROM notes WHERE notes.state != $1 AND " + noteVisibleToUserCondition("$2") +
+		" AND ($3 = 0 OR EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id and note_tags.tag_id = $3))"
+
+	rows, err := tx.QueryContext(ctx, nodesQuery+" ORDER BY notes.id", entities.NOTE_STATE_DELETED, userId, tagId)
+	if err != nil {
+		return nodes, edges, fmt.Errorf("error at loading notes graph from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node entities.NoteGraphNode
+		err := rows.Scan(&node.Id, &node.Topic, &node.TagId)
+		if err != nil {
+			return nodes, edges, fmt.Errorf("error at loading notes graph from db, case iterating and using rows.Scan: %s", err)
+		}
+		nodes = append(nodes, node)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nodes, edges, fmt.Errorf("error at loading notes graph from db, case after iterating: %s", err)
+	}
+
+	rows, err = tx.QueryContext(ctx, "WITH nodes AS ("+nodesQuery+") "+
+		"SELECT note_links.source_note_id, note_links.target, note_links.target_note_id FROM note_links "+
+		"JOIN nodes source ON source.id = note_links.source_note_id JOIN nodes target ON target.id = note_links.target_note_id "+
+		"ORDER BY note_links.source_note_id, note_links.target", entities.NOTE_STATE_DELETED, userId, tagId)
+	if err != nil {
+		return nodes, edges, fmt.Errorf("error at loading notes graph from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge entities.NoteLink
+		err := rows.Scan(&edge.SourceNoteId, &edge.Target, &edge.TargetNoteId)
+		if err != nil {
+			return nodes, edges, fmt.Errorf("error at loading notes graph from db, case iterating and using rows.Scan: %s", err)
+		}
+		edges = append(edges, edge)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nodes, edges, fmt.Errorf("error at loading notes graph from db, case after iterating: %s", err)
+	}
+
+	return nodes, edges, nil
+}
@@ -0,0 +1,100 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// adds the reaction of the user to the note, the existing one is kept as is
+func AddNoteReaction(tx *sql.Tx, ctx context.Context, noteId int, userId int, reactionType string) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO note_reactions(note_id, user_id, type, create_date) VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (note_id, user_id, type) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at adding note reaction, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId, userId, reactionType, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at adding note reaction (NoteId: %d, UserId: %d, Type: '%s'), case after executing statement: %s", noteId, userId, reactionType, err)
+	}
+	return nil
+}
+
+func DeleteNoteReaction(tx *sql.Tx, ctx context.Context, noteId int, userId int, reactionType string) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_reactions WHERE note_id = $1 and user_id = $2 and type = $3")
+	if err != nil {
+		return fmt.Errorf("error at deleting note reaction, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, noteId, userId, reactionType)
+	if err != nil {
+		return fmt.Errorf("error at deleting note reaction (NoteId: %d, UserId: %d, Type: '%s'), case after executing statement: %s", noteId, userId, reactionType, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting note reaction (NoteId: %d, UserId: %d, Type: '%s'), case after counting affected rows: %s", noteId, userId, reactionType, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// returns the counts of the reactions of the notes by type, the notes without reactions are missed
+func GetNotesReactionCounts(tx *sql.Tx, ctx context.Context, noteIds []int) (map[int]map[string]int, error) {
+	counts := make(map[int]map[string]int)
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_id, type, count(*) FROM note_reactions WHERE note_id = ANY($1) GROUP BY note_id, type", pq.Array(noteIds))
+	if err != nil {
+		return counts, fmt.Errorf("error at loading note reaction counts from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteId, count int
+		var reactionType string
+		err := rows.Scan(&noteId, &reactionType, &count)
+		if err != nil {
+			return counts, fmt.Errorf("error at loading note reaction counts from db, case iterating and using rows.Scan: %s", err)
+		}
+		if counts[noteId] == nil {
+			counts[noteId] = make(map[string]int)
+		}
+		counts[noteId][reactionType] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return counts, fmt.Errorf("error at loading note reaction counts from db, case after iterating: %s", err)
+	}
+
+	return counts, nil
+}
+
+// returns the types of the reactions of the user to the notes, the notes without reactions of the user are missed
+func GetUserNotesReactions(tx *sql.Tx, ctx context.Context, userId int, noteIds []int) (map[int][]string, error) {
+	reactions := make(map[int][]string)
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_id, type FROM note_reactions WHERE user_id = $1 and note_id = ANY($2) ORDER BY note_id, type", userId, pq.Array(noteIds))
+	if err != nil {
+		return reactions, fmt.Errorf("error at loading note reactions of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteId int
+		var reactionType string
+		err := rows.Scan(&noteId, &reactionType)
+		if err != nil {
+			return reactions, fmt.Errorf("error at loading note reactions of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		reactions[noteId] = append(reactions[noteId], reactionType)
+	}
+	err = rows.Err()
+	if err != nil {
+		return reactions, fmt.Errorf("error at loading note reactions of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return reactions, nil
+}
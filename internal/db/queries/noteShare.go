@@ -0,0 +1,71 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+func GetNoteShares(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.NoteShare, error) {
+	var shares []entities.NoteShare
+	var share entities.NoteShare
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_id, user_id, permission, create_date FROM note_shares WHERE note_id = $1 ORDER BY user_id", noteId)
+	if err != nil {
+		return shares, fmt.Errorf("error at loading note shares from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&share.NoteId, &share.UserId, &share.Permission, &share.CreateDate)
+		if err != nil {
+			return shares, fmt.Errorf("error at loading note shares from db, case iterating and using rows.Scan: %s", err)
+		}
+		shares = append(shares, share)
+	}
+	err = rows.Err()
+	if err != nil {
+		return shares, fmt.Errorf("error at loading note shares from db, case after iterating: %s", err)
+	}
+
+	return shares, nil
+}
+
+// creates the share or updates permission of the existing one
+func CreateNoteShare(tx *sql.Tx, ctx context.Context, noteId int, userId int, permission string) error {
+	createDate := time.Now()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO note_shares(note_id, user_id, permission, create_date) VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (note_id, user_id) DO UPDATE SET permission = EXCLUDED.permission")
+	if err != nil {
+		return fmt.Errorf("error at creating note share, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId, userId, permission, createDate)
+	if err != nil {
+		return fmt.Errorf("error at creating note share (NoteId: %d, UserId: %d, Permission: '%s'), case after executing statement: %s", noteId, userId, permission, err)
+	}
+
+	return nil
+}
+
+func DeleteNoteShare(tx *sql.Tx, ctx context.Context, noteId int, userId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_shares WHERE note_id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting note share, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, noteId, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting note share (NoteId: %d, UserId: %d), case after executing statement: %s", noteId, userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting note share (NoteId: %d, UserId: %d), case after counting affected rows: %s", noteId, userId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
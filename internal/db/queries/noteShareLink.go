@@ -0,0 +1,108 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+const NOTE_SHARE_LINK_COLUMNS string = "id, note_id, user_id, token_hash, password_hash, expire_at, max_views, views, state, create_date"
+
+func scanNoteShareLink(row rowScanner) (entities.NoteShareLink, error) {
+	var link entities.NoteShareLink
+	err := row.Scan(&link.Id, &link.NoteId, &link.UserId, &link.TokenHash, &link.PasswordHash, &link.ExpireAt, &link.MaxViews, &link.Views, &link.State, &link.CreateDate)
+	return link, err
+}
+
+func GetNoteShareLinks(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.NoteShareLink, error) {
+	var links []entities.NoteShareLink
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_SHARE_LINK_COLUMNS+" FROM note_share_links WHERE note_id = $1 and state != $2 ORDER BY id", noteId, entities.NOTE_SHARE_LINK_STATE_REVOKED)
+	if err != nil {
+		return links, fmt.Errorf("error at loading note share links from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link, err := scanNoteShareLink(rows)
+		if err != nil {
+			return links, fmt.Errorf("error at loading note share links from db, case iterating and using rows.Scan: %s", err)
+		}
+		links = append(links, link)
+	}
+	err = rows.Err()
+	if err != nil {
+		return links, fmt.Errorf("error at loading note share links from db, case after iterating: %s", err)
+	}
+
+	return links, nil
+}
+
+// returns the active link by hash of its token, the note of the link should not be deleted or blocked
+func GetNoteShareLinkByTokenHash(tx *sql.Tx, ctx context.Context, tokenHash string) (entities.NoteShareLink, error) {
+	link, err := scanNoteShareLink(tx.QueryRowContext(ctx, "SELECT "+NOTE_SHARE_LINK_COLUMNS+" FROM note_share_links "+
+		"WHERE token_hash = $1 and state = $2 and EXISTS (SELECT 1 FROM notes WHERE notes.id = note_share_links.note_id and notes.state NOT IN ($3, $4))",
+		tokenHash, entities.NOTE_SHARE_LINK_STATE_ACTIVE, entities.NOTE_STATE_DELETED, entities.NOTE_STATE_BLOCKED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return link, err
+		}
+		return link, fmt.Errorf("error at loading note share link by token from db, case after QueryRow.Scan: %s", err)
+	}
+
+	return link, nil
+}
+
+func CreateNoteShareLink(tx *sql.Tx, ctx context.Context, noteId int, userId int, tokenHash string, passwordHash sql.NullString, expireAt sql.NullTime, maxViews sql.NullInt32) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO note_share_links(note_id, user_id, token_hash, password_hash, expire_at, max_views, views, state, create_date) VALUES($1, $2, $3, $4, $5, $6, 0, $7, $8) RETURNING id",
+		noteId, userId, tokenHash, passwordHash, expireAt, maxViews, entities.NOTE_SHARE_LINK_STATE_ACTIVE, createDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting note share link (NoteId: '%d', UserId: '%d') into db, case after QueryRow.Scan: %s", noteId, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// counts one more view of the link, returns false if the limit of views is already reached
+func IncreaseNoteShareLinkViews(tx *sql.Tx, ctx context.Context, id int) (bool, error) {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE note_share_links SET views = views + 1 WHERE id = $1 and (max_views IS NULL OR views < max_views)")
+	if err != nil {
+		return false, fmt.Errorf("error at increasing note share link views, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("error at increasing note share link views by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error at increasing note share link views by id '%d', case after counting affected rows: %s", id, err)
+	}
+	return affectedRowsCount != 0, nil
+}
+
+func RevokeNoteShareLink(tx *sql.Tx, ctx context.Context, noteId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE note_share_links SET state = $3 WHERE id = $1 and note_id = $2 and state != $3")
+	if err != nil {
+		return fmt.Errorf("error at revoking note share link, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, noteId, entities.NOTE_SHARE_LINK_STATE_REVOKED)
+	if err != nil {
+		return fmt.Errorf("error at revoking note share link by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at revoking note share link by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// returns names of not deleted tags of the note, the primary tag (notes.tag_id) goes first
+func GetNoteTagNames(tx *sql.Tx, ctx context.Context, noteId int) ([]string, error) {
+	var names []string
+	var name string
+
+	rows, err := tx.QueryContext(ctx, "SELECT tags.name FROM note_tags "+
+		"JOIN tags ON tags.id = note_tags.tag_id JOIN notes ON notes.id = note_tags.note_id "+
+		"WHERE note_tags.note_id = $1 and tags.state != $2 ORDER BY tags.id != notes.tag_id, tags.name", noteId, entities.TAG_STATE_DELETED)
+	if err != nil {
+		return names, fmt.Errorf("error at loading tags of note '%d' from db, case after Query: %s", noteId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&name)
+		if err != nil {
+			return names, fmt.Errorf("error at loading tags of note '%d' from db, case iterating and using rows.Scan: %s", noteId, err)
+		}
+		names = append(names, name)
+	}
+	err = rows.Err()
+	if err != nil {
+		return names, fmt.Errorf("error at loading tags of note '%d' from db, case after iterating: %s", noteId, err)
+	}
+
+	return names, nil
+}
+
+// adds tags to the note, already added tags are ignored
+func AddNoteTags(tx *sql.Tx, ctx context.Context, noteId int, tagIds []int) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO note_tags(note_id, tag_id) VALUES($1, $2) ON CONFLICT (note_id, tag_id) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at adding note tags, case after preparing statement: %s", err)
+	}
+	for _, tagId := range tagIds {
+		_, err = stmt.ExecContext(ctx, noteId, tagId)
+		if err != nil {
+			return fmt.Errorf("error at adding note tag (NoteId: %d, TagId: %d), case after executing statement: %s", noteId, tagId, err)
+		}
+	}
+	return nil
+}
+
+// replaces all tags of the note except the primary one
+func SetNoteTags(tx *sql.Tx, ctx context.Context, noteId int, tagIds []int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_tags USING notes WHERE note_tags.note_id = $1 and notes.id = note_tags.note_id and note_tags.tag_id != notes.tag_id")
+	if err != nil {
+		return fmt.Errorf("error at setting note tags, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId)
+	if err != nil {
+		return fmt.Errorf("error at setting note tags (NoteId: %d), case after executing statement: %s", noteId, err)
+	}
+	return AddNoteTags(tx, ctx, noteId, tagIds)
+}
@@ -0,0 +1,111 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanNoteTemplate()
+const NOTE_TEMPLATE_COLUMNS string = "id, user_id, name, topic, text, shared, create_date, last_update_date"
+
+func scanNoteTemplate(row rowScanner) (entities.NoteTemplate, error) {
+	var template entities.NoteTemplate
+	err := row.Scan(&template.Id, &template.UserId, &template.Name, &template.Topic, &template.Text, &template.Shared, &template.CreateDate, &template.LastUpdateDate)
+	return template, err
+}
+
+// returns the templates of the user and the shared ones of the other users, the own ones go first
+func GetNoteTemplates(tx *sql.Tx, ctx context.Context, userId int, limit int, offset int) ([]entities.NoteTemplate, error) {
+	var templates []entities.NoteTemplate
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTE_TEMPLATE_COLUMNS+" FROM note_templates WHERE user_id = $1 or shared "+
+		"ORDER BY user_id != $1, lower(name), id LIMIT $2 OFFSET $3", userId, limit, offset)
+	if err != nil {
+		return templates, fmt.Errorf("error at loading note templates of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		template, err := scanNoteTemplate(rows)
+		if err != nil {
+			return templates, fmt.Errorf("error at loading note templates of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		templates = append(templates, template)
+	}
+	err = rows.Err()
+	if err != nil {
+		return templates, fmt.Errorf("error at loading note templates of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return templates, nil
+}
+
+// returns the template if it belongs to the user or it is shared, sql.ErrNoRows otherwise
+func GetNoteTemplate(tx *sql.Tx, ctx context.Context, userId int, id int) (entities.NoteTemplate, error) {
+	template, err := scanNoteTemplate(tx.QueryRowContext(ctx, "SELECT "+NOTE_TEMPLATE_COLUMNS+" FROM note_templates WHERE id = $1 and (user_id = $2 or shared)", id, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return template, err
+		}
+		return template, fmt.Errorf("error at loading note template by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return template, nil
+}
+
+func CreateNoteTemplate(tx *sql.Tx, ctx context.Context, userId int, name string, topic string, text string, shared bool) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	err := tx.QueryRowContext(ctx, "INSERT INTO note_templates(user_id, name, topic, text, shared, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6, $6) RETURNING id",
+		userId, name, topic, text, shared, createDate).Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting note template (Name: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", name, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// updates the template of the user, sql.ErrNoRows if the template belongs to another user
+func UpdateNoteTemplate(tx *sql.Tx, ctx context.Context, userId int, id int, name string, topic string, text string, shared bool) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE note_templates SET name = $3, topic = $4, text = $5, shared = $6, last_update_date = $7 WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at updating note template, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, name, topic, text, shared, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at updating note template (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating note template (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// deletes the template of the user, the notes created from it are kept as is
+func DeleteNoteTemplate(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM note_templates WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting note template, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting note template (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting note template (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanNotebook()
+const NOTEBOOK_COLUMNS string = "id, user_id, parent_id, name, position, create_date, last_update_date"
+
+// the columns order matches scanNotebookNote()
+const NOTEBOOK_NOTE_COLUMNS string = "notebook_notes.note_id, notebook_notes.notebook_id, notebook_notes.position, notes.topic"
+
+func scanNotebook(row rowScanner) (entities.Notebook, error) {
+	var notebook entities.Notebook
+	err := row.Scan(&notebook.Id, &notebook.UserId, &notebook.ParentId, &notebook.Name, &notebook.Position, &notebook.CreateDate, &notebook.LastUpdateDate)
+	return notebook, err
+}
+
+func scanNotebookNote(row rowScanner) (entities.NotebookNote, error) {
+	var note entities.NotebookNote
+	err := row.Scan(&note.NoteId, &note.NotebookId, &note.Position, &note.NoteTopic)
+	return note, err
+}
+
+// returns the children of the notebook of the user in their order, the root notebooks if the parent is not set
+func GetNotebooks(tx *sql.Tx, ctx context.Context, userId int, parentId sql.NullInt32) ([]entities.Notebook, error) {
+	var notebooks []entities.Notebook
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTEBOOK_COLUMNS+" FROM notebooks WHERE user_id = $1 and parent_id IS NOT DISTINCT FROM $2 ORDER BY position, id", userId, parentId)
+	if err != nil {
+		return notebooks, fmt.Errorf("error at loading notebooks of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		notebook, err := scanNotebook(rows)
+		if err != nil {
+			return notebooks, fmt.Errorf("error at loading notebooks of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		notebooks = append(notebooks, notebook)
+	}
+	err = rows.Err()
+	if err != nil {
+		return notebooks, fmt.Errorf("error at loading notebooks of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return notebooks, nil
+}
+
+// returns the notebook of the user, sql.ErrNoRows if the notebook belongs to another user
+func GetNotebook(tx *sql.Tx, ctx context.Context, userId int, id int) (entities.Notebook, error) {
+	notebook, err := scanNotebook(tx.QueryRowContext(ctx, "SELECT "+NOTEBOOK_COLUMNS+" FROM notebooks WHERE id = $1 and user_id = $2", id, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notebook, err
+		}
+		return notebook, fmt.Errorf("error at loading notebook by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return notebook, nil
+}
+
+// returns the notebook of the user and all its descendants with their depths in one query, the parents go before their children
+// and the children of the same parent are in their order. Empty result if the notebook belongs to another user
+func GetNotebookSubtree(tx *sql.Tx, ctx context.Context, userId int, id int) ([]entities.Notebook, error) {
+	var notebooks []entities.Notebook
+
+	rows, err := tx.QueryContext(ctx, "WITH RECURSIVE subtree AS ("+
+		"SELECT "+NOTEBOOK_COLUMNS+", 0 AS depth FROM notebooks WHERE id = $1 and user_id = $2 "+
+		"UNION ALL "+
+		"SELECT notebooks.id, notebooks.user_id, notebooks.parent_id, notebooks.name, notebooks.position, notebooks.create_date, notebooks.last_update_date, subtree.depth + 1 "+
+		"FROM notebooks JOIN subtree ON notebooks.parent_id = subtree.id"+
+		") SELECT "+NOTEBOOK_COLUMNS+", depth FROM subtree ORDER BY depth, parent_id, position, id", id, userId)
+	if err != nil {
+		return notebooks, fmt.Errorf("error at loading subtree of notebook '%d' from db, case after Query: %s", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notebook entities.Notebook
+		err := rows.Scan(&notebook.Id, &notebook.UserId, &notebook.ParentId, &notebook.Name, &notebook.Position, &notebook.CreateDate, &notebook.LastUpdateDate, &notebook.Depth)
+		if err != nil {
+			return notebooks, fmt.Errorf("error at loading subtree of notebook '%d' from db, case iterating and using rows.Scan: %s", id, err)
+		}
+		notebooks = append(notebooks, notebook)
+	}
+	err = rows.Err()
+	if err != nil {
+		return notebooks, fmt.Errorf("error at loading subtree of notebook '%d' from db, case after iterating: %s", id, err)
+	}
+
+	return notebooks, nil
+}
+
+// creates the notebook at the end of the children of the parent, the root notebook if the parent is not set
+func CreateNotebook(tx *sql.Tx, ctx context.Context, userId int, parentId sql.NullInt32, name string) (int, error) {
+	lastInsertId := -1
+	now := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO notebooks(user_id, parent_id, name, position, create_date, last_update_date) "+
+		"SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0), $4, $4 FROM notebooks WHERE user_id = $1 and parent_id IS NOT DISTINCT FROM $2 RETURNING id",
+		userId, parentId, name, now).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting notebook (Name: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", name, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateNotebookName(tx *sql.Tx, ctx context.Context, userId int, id int, name string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notebooks SET name = $3, last_update_date = $4 WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at updating notebook, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, name, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at updating notebook (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating notebook (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// moves the notebook with its subtree to the end of the children of the new parent, to the roots if the parent is not set.
+// The caller guarantees that the new parent is not inside the subtree
+func MoveNotebook(tx *sql.Tx, ctx context.Context, userId int, id int, parentId sql.NullInt32) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notebooks SET parent_id = $3, last_update_date = $4, "+
+		"position = (SELECT COALESCE(MAX(position) + 1, 0) FROM notebooks WHERE user_id = $2 and parent_id IS NOT DISTINCT FROM $3 and id != $1) "+
+		"WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at moving notebook, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, parentId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at moving notebook (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at moving notebook (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// deletes the notebook of the user with its subtree, the notes of the deleted notebooks are kept without notebook
+func DeleteNotebookSubtree(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "WITH RECURSIVE subtree AS ("+
+		"SELECT id FROM notebooks WHERE id = $1 and user_id = $2 "+
+		"UNION ALL "+
+		"SELECT notebooks.id FROM notebooks JOIN subtree ON notebooks.parent_id = subtree.id"+
+		"), detached AS (DELETE FROM notebook_notes WHERE notebook_id IN (SELECT id FROM subtree)) "+
+		"DELETE FROM notebooks WHERE id IN (SELECT id FROM subtree)")
+	if err != nil {
+		return fmt.Errorf("error at deleting notebook, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting notebook (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting notebook (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// sets the positions of the notebooks of the user by their order in the ids
+func SetNotebookPositions(tx *sql.Tx, ctx context.Context, userId int, ids []int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notebooks SET position = $3 WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at ordering notebooks, case after preparing statement: %s", err)
+	}
+	for position, id := range ids {
+		_, err = stmt.ExecContext(ctx, id, userId, position)
+		if err != nil {
+			return fmt.Errorf("error at ordering notebooks (Id: %d, UserId: %d), case after executing statement: %s", id, userId, err)
+		}
+	}
+	return nil
+}
+
+// returns the notes of the notebooks in their order, the deleted notes are skipped
+func GetNotebookNotes(tx *sql.Tx, ctx context.Context, notebookIds []int) ([]entities.NotebookNote, error) {
+	var notes []entities.NotebookNote
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTEBOOK_NOTE_COLUMNS+" FROM notebook_notes JOIN notes ON notes.id = notebook_notes.note_id "+
+		"WHERE notebook_notes.notebook_id = ANY($1) and notes.state != $2 ORDER BY notebook_notes.notebook_id, notebook_notes.position, notebook_notes.note_id",
+		pq.Array(notebookIds), entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return notes, fmt.Errorf("error at loading notes of notebooks from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNotebookNote(rows)
+		if err != nil {
+			return notes, fmt.Errorf("error at loading notes of notebooks from db, case iterating and using rows.Scan: %s", err)
+		}
+		notes = append(notes, note)
+	}
+	err = rows.Err()
+	if err != nil {
+		return notes, fmt.Errorf("error at loading notes of notebooks from db, case after iterating: %s", err)
+	}
+
+	return notes, nil
+}
+
+// puts the note to the end of the notebook, the note is moved from its previous notebook. The position is kept if the note is in the notebook already
+func SetNoteNotebook(tx *sql.Tx, ctx context.Context, noteId int, notebookId int) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO notebook_notes(note_id, notebook_id, position) "+
+		"SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM notebook_notes WHERE notebook_id = $2 "+
+		"ON CONFLICT (note_id) DO UPDATE SET notebook_id = EXCLUDED.notebook_id, position = EXCLUDED.position "+
+		"WHERE notebook_notes.notebook_id != EXCLUDED.notebook_id")
+	if err != nil {
+		return fmt.Errorf("error at setting notebook of note, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, noteId, notebookId)
+	if err != nil {
+		return fmt.Errorf("error at setting notebook of note (NoteId: %d, NotebookId: %d), case after executing statement: %s", noteId, notebookId, err)
+	}
+	return nil
+}
+
+// removes the note from its notebook, sql.ErrNoRows if the note is not in any notebook
+func DeleteNoteNotebook(tx *sql.Tx, ctx context.Context, noteId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM notebook_notes WHERE note_id = $1")
+	if err != nil {
+		return fmt.Errorf("error at removing note from notebook, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, noteId)
+	if err != nil {
+		return fmt.Errorf("error at removing note from notebook (NoteId: %d), case after executing statement: %s", noteId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at removing note from notebook (NoteId: %d), case after counting affected rows: %s", noteId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// sets the positions of the notes of the notebook by their order in the note ids
+func SetNotebookNotePositions(tx *sql.Tx, ctx context.Context, notebookId int, noteIds []int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notebook_notes SET position = $3 WHERE note_id = $1 and notebook_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at ordering notes of notebook, case after preparing statement: %s", err)
+	}
+	for position, noteId := range noteIds {
+		_, err = stmt.ExecContext(ctx, noteId, notebookId, position)
+		if err != nil {
+			return fmt.Errorf("error at ordering notes of notebook (NoteId: %d, NotebookId: %d), case after executing statement: %s", noteId, notebookId, err)
+		}
+	}
+	return nil
+}
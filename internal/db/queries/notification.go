@@ -0,0 +1,186 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanNotification()
+const NOTIFICATION_COLUMNS string = "id, user_id, type, actor_id, note_id, comment_id, text, read_at, create_date"
+
+func scanNotification(row rowScanner) (entities.Notification, error) {
+	var notification entities.Notification
+	err := row.Scan(&notification.Id, &notification.UserId, &notification.Type, &notification.ActorId, &notification.NoteId, &notification.CommentId,
+		&notification.Text, &notification.ReadAt, &notification.CreateDate)
+	return notification, err
+}
+
+// the filter of the notifications, the empty type is ignored
+type NotificationFilter struct {
+	Type       string
+	UnreadOnly bool
+}
+
+// returns the notifications of the user matching the filter, the recent ones go first
+func GetUserNotifications(tx *sql.Tx, ctx context.Context, userId int, filter NotificationFilter, limit int, offset int) ([]entities.Notification, error) {
+	var notifications []entities.Notification
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+NOTIFICATION_COLUMNS+" FROM notifications WHERE user_id = $1 and ($2 = '' OR type = $2) and (NOT $3 OR read_at IS NULL) "+
+		"ORDER BY create_date DESC, id DESC LIMIT $4 OFFSET $5", userId, filter.Type, filter.UnreadOnly, limit, offset)
+	if err != nil {
+		return notifications, fmt.Errorf("error at loading notifications of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return notifications, fmt.Errorf("error at loading notifications of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		notifications = append(notifications, notification)
+	}
+	err = rows.Err()
+	if err != nil {
+		return notifications, fmt.Errorf("error at loading notifications of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return notifications, nil
+}
+
+// returns the count of the unread notifications of the user by type, the types without unread notifications are missed
+func GetUnreadNotificationCounts(tx *sql.Tx, ctx context.Context, userId int) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	rows, err := tx.QueryContext(ctx, "SELECT type, count(*) FROM notifications WHERE user_id = $1 and read_at IS NULL GROUP BY type", userId)
+	if err != nil {
+		return counts, fmt.Errorf("error at loading unread notification counts of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType string
+		var count int
+		err := rows.Scan(&notificationType, &count)
+		if err != nil {
+			return counts, fmt.Errorf("error at loading unread notification counts of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		counts[notificationType] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return counts, fmt.Errorf("error at loading unread notification counts of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return counts, nil
+}
+
+func CreateNotification(tx *sql.Tx, ctx context.Context, notification entities.Notification) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO notifications(user_id, type, actor_id, note_id, comment_id, text, create_date) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		notification.UserId, notification.Type, notification.ActorId, notification.NoteId, notification.CommentId, notification.Text, time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting notification (UserId: '%d', Type: '%s') into db, case after QueryRow.Scan: %s", notification.UserId, notification.Type, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// marks the notification of the user as read, the read date of the notification read already is kept
+func MarkNotificationRead(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notifications SET read_at = COALESCE(read_at, $3) WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at marking notification as read, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at marking notification as read (Id: %d, UserId: %d), case after executing statement: %s", id, userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at marking notification as read (Id: %d, UserId: %d), case after counting affected rows: %s", id, userId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// marks the unread notifications of the user as read and returns their count, the empty type means all types
+func MarkAllNotificationsRead(tx *sql.Tx, ctx context.Context, userId int, notificationType string) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notifications SET read_at = $3 WHERE user_id = $1 and ($2 = '' OR type = $2) and read_at IS NULL")
+	if err != nil {
+		return 0, fmt.Errorf("error at marking notifications as read, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, userId, notificationType, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("error at marking notifications of user '%d' as read, case after executing statement: %s", userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error at marking notifications of user '%d' as read, case after counting affected rows: %s", userId, err)
+	}
+	return int(affectedRowsCount), nil
+}
+
+// returns the preferences set by the user explicitly
+func GetNotificationPreferences(tx *sql.Tx, ctx context.Context, userId int) ([]entities.NotificationPreference, error) {
+	var preferences []entities.NotificationPreference
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_id, type, enabled FROM notification_preferences WHERE user_id = $1 ORDER BY type", userId)
+	if err != nil {
+		return preferences, fmt.Errorf("error at loading notification preferences of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var preference entities.NotificationPreference
+		err := rows.Scan(&preference.UserId, &preference.Type, &preference.Enabled)
+		if err != nil {
+			return preferences, fmt.Errorf("error at loading notification preferences of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		preferences = append(preferences, preference)
+	}
+	err = rows.Err()
+	if err != nil {
+		return preferences, fmt.Errorf("error at loading notification preferences of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return preferences, nil
+}
+
+// the type is enabled unless the user turned it off
+func IsNotificationTypeEnabled(tx *sql.Tx, ctx context.Context, userId int, notificationType string) (bool, error) {
+	var enabled bool
+
+	err := tx.QueryRowContext(ctx, "SELECT enabled FROM notification_preferences WHERE user_id = $1 and type = $2", userId, notificationType).
+		Scan(&enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, fmt.Errorf("error at loading notification preference (UserId: '%d', Type: '%s') from db, case after QueryRow.Scan: %s", userId, notificationType, err)
+	}
+
+	return enabled, nil
+}
+
+func SetNotificationPreferences(tx *sql.Tx, ctx context.Context, userId int, preferences []entities.NotificationPreference) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO notification_preferences(user_id, type, enabled) VALUES($1, $2, $3) "+
+		"ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled")
+	if err != nil {
+		return fmt.Errorf("error at setting notification preferences, case after preparing statement: %s", err)
+	}
+	for _, preference := range preferences {
+		_, err = stmt.ExecContext(ctx, userId, preference.Type, preference.Enabled)
+		if err != nil {
+			return fmt.Errorf("error at setting notification preference (UserId: %d, Type: '%s'), case after executing statement: %s", userId, preference.Type, err)
+		}
+	}
+	return nil
+}
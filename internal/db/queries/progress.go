@@ -0,0 +1,169 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+func AddUserActivity(tx *sql.Tx, ctx context.Context, userId int, activityType string, entityId int) error {
+	activityDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO user_activities(user_id, type, entity_id, activity_date) VALUES($1, $2, $3, $4)")
+	if err != nil {
+		return fmt.Errorf("error at adding user activity, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, userId, activityType, entityId, activityDate)
+	if err != nil {
+		return fmt.Errorf("error at adding user activity (UserId: %d, Type: '%s'), case after executing statement: %s", userId, activityType, err)
+	}
+	return nil
+}
+
+// returns all activities of the user grouped by minutes, the card reviews are taken from the review log
+func GetUserActivityBuckets(tx *sql.Tx, ctx context.Context, userId int) ([]entities.ActivityBucket, error) {
+	var buckets []entities.ActivityBucket
+
+	rows, err := tx.QueryContext(ctx, "SELECT type, date_trunc('minute', activity_date), COUNT(*) FROM user_activities WHERE user_id = $1 GROUP BY 1, 2 "+
+		"UNION ALL SELECT $2::varchar, date_trunc('minute', review_date), COUNT(*) FROM card_review_log WHERE user_id = $1 GROUP BY 1, 2", userId, entities.ACTIVITY_TYPE_CARD_REVIEW)
+	if err != nil {
+		return buckets, fmt.Errorf("error at loading activities of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket entities.ActivityBucket
+		err := rows.Scan(&bucket.Type, &bucket.Minute, &bucket.Count)
+		if err != nil {
+			return buckets, fmt.Errorf("error at loading activities of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		buckets = append(buckets, bucket)
+	}
+	err = rows.Err()
+	if err != nil {
+		return buckets, fmt.Errorf("error at loading activities of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return buckets, nil
+}
+
+func GetUserTimezone(tx *sql.Tx, ctx context.Context, userId int) (string, error) {
+	var timezone string
+
+	err := tx.QueryRowContext(ctx, "SELECT timezone FROM users WHERE id = $1 and state != $2", userId, entities.USER_STATE_DELETED).Scan(&timezone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return timezone, err
+		}
+		return timezone, fmt.Errorf("error at loading timezone of user '%d' from db, case after QueryRow.Scan: %s", userId, err)
+	}
+
+	return timezone, nil
+}
+
+func UpdateUserTimezone(tx *sql.Tx, ctx context.Context, userId int, timezone string) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE users SET timezone = $2 WHERE id = $1 and state != $3")
+	if err != nil {
+		return fmt.Errorf("error at updating user timezone, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, userId, timezone, entities.USER_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating user timezone (UserId: %d, Timezone: '%s'), case after executing statement: %s", userId, timezone, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating user timezone (UserId: %d, Timezone: '%s'), case after counting affected rows: %s", userId, timezone, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetUserGoals(tx *sql.Tx, ctx context.Context, userId int) ([]entities.UserGoal, error) {
+	var goals []entities.UserGoal
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_id, type, target FROM user_goals WHERE user_id = $1 ORDER BY type", userId)
+	if err != nil {
+		return goals, fmt.Errorf("error at loading goals of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var goal entities.UserGoal
+		err := rows.Scan(&goal.UserId, &goal.Type, &goal.Target)
+		if err != nil {
+			return goals, fmt.Errorf("error at loading goals of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		goals = append(goals, goal)
+	}
+	err = rows.Err()
+	if err != nil {
+		return goals, fmt.Errorf("error at loading goals of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return goals, nil
+}
+
+// replaces all goals of the user
+func SetUserGoals(tx *sql.Tx, ctx context.Context, userId int, goals []entities.UserGoal) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM user_goals WHERE user_id = $1", userId)
+	if err != nil {
+		return fmt.Errorf("error at setting goals of user '%d', case after deleting: %s", userId, err)
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO user_goals(user_id, type, target) VALUES($1, $2, $3)")
+	if err != nil {
+		return fmt.Errorf("error at setting goals, case after preparing statement: %s", err)
+	}
+	for _, goal := range goals {
+		_, err = stmt.ExecContext(ctx, userId, goal.Type, goal.Target)
+		if err != nil {
+			return fmt.Errorf("error at setting goal (UserId: %d, Type: '%s'), case after executing statement: %s", userId, goal.Type, err)
+		}
+	}
+	return nil
+}
+
+func GetUserAchievements(tx *sql.Tx, ctx context.Context, userId int) ([]entities.UserAchievement, error) {
+	var achievements []entities.UserAchievement
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_id, code, award_date FROM user_achievements WHERE user_id = $1 ORDER BY award_date, code", userId)
+	if err != nil {
+		return achievements, fmt.Errorf("error at loading achievements of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var achievement entities.UserAchievement
+		err := rows.Scan(&achievement.UserId, &achievement.Code, &achievement.AwardDate)
+		if err != nil {
+			return achievements, fmt.Errorf("error at loading achievements of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		achievements = append(achievements, achievement)
+	}
+	err = rows.Err()
+	if err != nil {
+		return achievements, fmt.Errorf("error at loading achievements of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return achievements, nil
+}
+
+// awards the achievement to the user, returns false if it is awarded already
+func AwardUserAchievement(tx *sql.Tx, ctx context.Context, userId int, code string, awardDate time.Time) (bool, error) {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO user_achievements(user_id, code, award_date) VALUES($1, $2, $3) ON CONFLICT (user_id, code) DO NOTHING")
+	if err != nil {
+		return false, fmt.Errorf("error at awarding achievement, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, userId, code, awardDate)
+	if err != nil {
+		return false, fmt.Errorf("error at awarding achievement (UserId: %d, Code: '%s'), case after executing statement: %s", userId, code, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error at awarding achievement (UserId: %d, Code: '%s'), case after counting affected rows: %s", userId, code, err)
+	}
+	return affectedRowsCount > 0, nil
+}
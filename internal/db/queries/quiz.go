@@ -0,0 +1,256 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanQuiz()
+const QUIZ_COLUMNS string = "quizzes.id, quizzes.user_id, quizzes.title, quizzes.note_id, quizzes.course_id, quizzes.time_limit_seconds, quizzes.state, quizzes.create_date, quizzes.last_update_date"
+
+func scanQuiz(row rowScanner) (entities.Quiz, error) {
+	var quiz entities.Quiz
+	err := row.Scan(&quiz.Id, &quiz.UserId, &quiz.Title, &quiz.NoteId, &quiz.CourseId, &quiz.TimeLimitSeconds, &quiz.State, &quiz.CreateDate, &quiz.LastUpdateDate)
+	return quiz, err
+}
+
+func getQuizzes(tx *sql.Tx, ctx context.Context, column string, id int) ([]entities.Quiz, error) {
+	var quizzes []entities.Quiz
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+QUIZ_COLUMNS+" FROM quizzes WHERE "+column+" = $1 and state != $2 ORDER BY id", id, entities.QUIZ_STATE_DELETED)
+	if err != nil {
+		return quizzes, fmt.Errorf("error at loading quizzes from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		quiz, err := scanQuiz(rows)
+		if err != nil {
+			return quizzes, fmt.Errorf("error at loading quizzes from db, case iterating and using rows.Scan: %s", err)
+		}
+		quizzes = append(quizzes, quiz)
+	}
+	err = rows.Err()
+	if err != nil {
+		return quizzes, fmt.Errorf("error at loading quizzes from db, case after iterating: %s", err)
+	}
+
+	return quizzes, nil
+}
+
+func GetNoteQuizzes(tx *sql.Tx, ctx context.Context, noteId int) ([]entities.Quiz, error) {
+	return getQuizzes(tx, ctx, "note_id", noteId)
+}
+
+func GetCourseQuizzes(tx *sql.Tx, ctx context.Context, courseId int) ([]entities.Quiz, error) {
+	return getQuizzes(tx, ctx, "course_id", courseId)
+}
+
+func GetQuiz(tx *sql.Tx, ctx context.Context, id int) (entities.Quiz, error) {
+	quiz, err := scanQuiz(tx.QueryRowContext(ctx, "SELECT "+QUIZ_COLUMNS+" FROM quizzes WHERE id = $1 and state != $2", id, entities.QUIZ_STATE_DELETED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return quiz, err
+		}
+		return quiz, fmt.Errorf("error at loading quiz by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return quiz, nil
+}
+
+func CreateQuiz(tx *sql.Tx, ctx context.Context, userId int, title string, noteId sql.NullInt32, courseId sql.NullInt32, timeLimitSeconds sql.NullInt32) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO quizzes(user_id, title, note_id, course_id, time_limit_seconds, state, create_date, last_update_date) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		userId, title, noteId, courseId, timeLimitSeconds, entities.QUIZ_STATE_NEW, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting quiz (Title: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", title, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func UpdateQuiz(tx *sql.Tx, ctx context.Context, id int, title string, timeLimitSeconds sql.NullInt32) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE quizzes SET title = $2, time_limit_seconds = $3, last_update_date = $4 WHERE id = $1 and state != $5")
+	if err != nil {
+		return fmt.Errorf("error at updating quiz, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, title, timeLimitSeconds, lastUpdateDate, entities.QUIZ_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating quiz (Id: %d, Title: '%s'), case after executing statement: %s", id, title, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating quiz (Id: %d, Title: '%s'), case after counting affected rows: %s", id, title, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func DeleteQuiz(tx *sql.Tx, ctx context.Context, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE quizzes SET state = $2 WHERE id = $1 and state != $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, entities.QUIZ_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// returns the questions of the quiz in order with their options
+func GetQuizQuestions(tx *sql.Tx, ctx context.Context, quizId int) ([]entities.QuizQuestion, error) {
+	var questions []entities.QuizQuestion
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, quiz_id, type, text, answer, match_type, points, position FROM quiz_questions WHERE quiz_id = $1 ORDER BY position, id", quizId)
+	if err != nil {
+		return questions, fmt.Errorf("error at loading questions of quiz '%d' from db, case after Query: %s", quizId, err)
+	}
+	defer rows.Close()
+
+	indexes := make(map[int]int)
+	for rows.Next() {
+		var question entities.QuizQuestion
+		err := rows.Scan(&question.Id, &question.QuizId, &question.Type, &question.Text, &question.Answer, &question.MatchType, &question.Points, &question.Position)
+		if err != nil {
+			return questions, fmt.Errorf("error at loading questions of quiz '%d' from db, case iterating and using rows.Scan: %s", quizId, err)
+		}
+		indexes[question.Id] = len(questions)
+		questions = append(questions, question)
+	}
+	err = rows.Err()
+	if err != nil {
+		return questions, fmt.Errorf("error at loading questions of quiz '%d' from db, case after iterating: %s", quizId, err)
+	}
+
+	optionRows, err := tx.QueryContext(ctx, "SELECT quiz_question_options.id, quiz_question_options.question_id, quiz_question_options.text, quiz_question_options.correct, quiz_question_options.position "+
+		"FROM quiz_question_options JOIN quiz_questions ON quiz_questions.id = quiz_question_options.question_id "+
+		"WHERE quiz_questions.quiz_id = $1 ORDER BY quiz_question_options.position, quiz_question_options.id", quizId)
+	if err != nil {
+		return questions, fmt.Errorf("error at loading question options of quiz '%d' from db, case after Query: %s", quizId, err)
+	}
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var option entities.QuizQuestionOption
+		err := optionRows.Scan(&option.Id, &option.QuestionId, &option.Text, &option.Correct, &option.Position)
+		if err != nil {
+			return questions, fmt.Errorf("error at loading question options of quiz '%d' from db, case iterating and using rows.Scan: %s", quizId, err)
+		}
+		i := indexes[option.QuestionId]
+		questions[i].Options = append(questions[i].Options, option)
+	}
+	err = optionRows.Err()
+	if err != nil {
+		return questions, fmt.Errorf("error at loading question options of quiz '%d' from db, case after iterating: %s", quizId, err)
+	}
+
+	return questions, nil
+}
+
+// adds the question with its options to the end of the quiz
+func CreateQuizQuestion(tx *sql.Tx, ctx context.Context, question entities.QuizQuestion) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO quiz_questions(quiz_id, type, text, answer, match_type, points, position) "+
+		"SELECT $1, $2, $3, $4, $5, $6, COALESCE(MAX(position) + 1, 0) FROM quiz_questions WHERE quiz_id = $1 RETURNING id",
+		question.QuizId, question.Type, question.Text, question.Answer, question.MatchType, question.Points).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting quiz question (QuizId: '%d') into db, case after QueryRow.Scan: %s", question.QuizId, err)
+	}
+
+	err = createQuizQuestionOptions(tx, ctx, lastInsertId, question.Options)
+	if err != nil {
+		return -1, err
+	}
+
+	return lastInsertId, nil
+}
+
+// updates the question and replaces its options
+func UpdateQuizQuestion(tx *sql.Tx, ctx context.Context, question entities.QuizQuestion) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE quiz_questions SET type = $3, text = $4, answer = $5, match_type = $6, points = $7 WHERE id = $1 and quiz_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at updating quiz question, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, question.Id, question.QuizId, question.Type, question.Text, question.Answer, question.MatchType, question.Points)
+	if err != nil {
+		return fmt.Errorf("error at updating quiz question (Id: %d, QuizId: %d), case after executing statement: %s", question.Id, question.QuizId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating quiz question (Id: %d, QuizId: %d), case after counting affected rows: %s", question.Id, question.QuizId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM quiz_question_options WHERE question_id = $1", question.Id)
+	if err != nil {
+		return fmt.Errorf("error at deleting options of quiz question '%d', case after executing statement: %s", question.Id, err)
+	}
+	return createQuizQuestionOptions(tx, ctx, question.Id, question.Options)
+}
+
+// deletes the question with its options and answers
+func DeleteQuizQuestion(tx *sql.Tx, ctx context.Context, quizId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM quiz_questions WHERE id = $1 and quiz_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz question, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, quizId)
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz question by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting quiz question by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM quiz_question_options WHERE question_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting options of quiz question '%d', case after executing statement: %s", id, err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM quiz_answers WHERE question_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting answers of quiz question '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+func createQuizQuestionOptions(tx *sql.Tx, ctx context.Context, questionId int, options []entities.QuizQuestionOption) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO quiz_question_options(question_id, text, correct, position) VALUES($1, $2, $3, $4)")
+	if err != nil {
+		return fmt.Errorf("error at inserting quiz question options, case after preparing statement: %s", err)
+	}
+	for position, option := range options {
+		_, err = stmt.ExecContext(ctx, questionId, option.Text, option.Correct, position)
+		if err != nil {
+			return fmt.Errorf("error at inserting quiz question option (QuestionId: %d), case after executing statement: %s", questionId, err)
+		}
+	}
+	return nil
+}
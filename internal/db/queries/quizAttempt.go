@@ -0,0 +1,169 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanQuizAttempt()
+const QUIZ_ATTEMPT_COLUMNS string = "id, quiz_id, user_id, state, score, max_score, start_date, deadline, finish_date"
+
+func scanQuizAttempt(row rowScanner) (entities.QuizAttempt, error) {
+	var attempt entities.QuizAttempt
+	err := row.Scan(&attempt.Id, &attempt.QuizId, &attempt.UserId, &attempt.State, &attempt.Score, &attempt.MaxScore, &attempt.StartDate, &attempt.Deadline, &attempt.FinishDate)
+	return attempt, err
+}
+
+// returns attempts of the user in the quiz, the recent ones go first
+func GetUserQuizAttempts(tx *sql.Tx, ctx context.Context, quizId int, userId int) ([]entities.QuizAttempt, error) {
+	var attempts []entities.QuizAttempt
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+QUIZ_ATTEMPT_COLUMNS+" FROM quiz_attempts WHERE quiz_id = $1 and user_id = $2 ORDER BY start_date DESC, id DESC", quizId, userId)
+	if err != nil {
+		return attempts, fmt.Errorf("error at loading quiz attempts from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attempt, err := scanQuizAttempt(rows)
+		if err != nil {
+			return attempts, fmt.Errorf("error at loading quiz attempts from db, case iterating and using rows.Scan: %s", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	err = rows.Err()
+	if err != nil {
+		return attempts, fmt.Errorf("error at loading quiz attempts from db, case after iterating: %s", err)
+	}
+
+	return attempts, nil
+}
+
+func GetQuizAttempt(tx *sql.Tx, ctx context.Context, quizId int, id int) (entities.QuizAttempt, error) {
+	attempt, err := scanQuizAttempt(tx.QueryRowContext(ctx, "SELECT "+QUIZ_ATTEMPT_COLUMNS+" FROM quiz_attempts WHERE id = $1 and quiz_id = $2", id, quizId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return attempt, err
+		}
+		return attempt, fmt.Errorf("error at loading quiz attempt by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return attempt, nil
+}
+
+func CreateQuizAttempt(tx *sql.Tx, ctx context.Context, quizId int, userId int, maxScore int, startDate time.Time, deadline sql.NullTime) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO quiz_attempts(quiz_id, user_id, state, score, max_score, start_date, deadline) VALUES($1, $2, $3, 0, $4, $5, $6) RETURNING id",
+		quizId, userId, entities.QUIZ_ATTEMPT_STATE_IN_PROGRESS, maxScore, startDate, deadline).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting quiz attempt (QuizId: '%d', UserId: '%d') into db, case after QueryRow.Scan: %s", quizId, userId, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// finishes the attempt which is in progress, returns sql.ErrNoRows if it is finished already
+func FinishQuizAttempt(tx *sql.Tx, ctx context.Context, id int, state string, score int, finishDate time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE quiz_attempts SET state = $2, score = $3, finish_date = $4 WHERE id = $1 and state = $5")
+	if err != nil {
+		return fmt.Errorf("error at finishing quiz attempt, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, state, score, finishDate, entities.QUIZ_ATTEMPT_STATE_IN_PROGRESS)
+	if err != nil {
+		return fmt.Errorf("error at finishing quiz attempt (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at finishing quiz attempt (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func SaveQuizAnswers(tx *sql.Tx, ctx context.Context, answers []entities.QuizAnswer) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO quiz_answers(attempt_id, question_id, option_ids, text, correct, points) VALUES($1, $2, $3, $4, $5, $6)")
+	if err != nil {
+		return fmt.Errorf("error at saving quiz answers, case after preparing statement: %s", err)
+	}
+	for _, answer := range answers {
+		optionIds := answer.OptionIds
+		if optionIds == nil {
+			optionIds = []int{}
+		}
+		_, err = stmt.ExecContext(ctx, answer.AttemptId, answer.QuestionId, pq.Array(optionIds), answer.Text, answer.Correct, answer.Points)
+		if err != nil {
+			return fmt.Errorf("error at saving quiz answer (AttemptId: %d, QuestionId: %d), case after executing statement: %s", answer.AttemptId, answer.QuestionId, err)
+		}
+	}
+	return nil
+}
+
+func GetQuizAnswers(tx *sql.Tx, ctx context.Context, attemptId int) ([]entities.QuizAnswer, error) {
+	var answers []entities.QuizAnswer
+
+	rows, err := tx.QueryContext(ctx, "SELECT attempt_id, question_id, option_ids, text, correct, points FROM quiz_answers WHERE attempt_id = $1 ORDER BY question_id", attemptId)
+	if err != nil {
+		return answers, fmt.Errorf("error at loading answers of quiz attempt '%d' from db, case after Query: %s", attemptId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var answer entities.QuizAnswer
+		var optionIds pq.Int64Array
+		err := rows.Scan(&answer.AttemptId, &answer.QuestionId, &optionIds, &answer.Text, &answer.Correct, &answer.Points)
+		if err != nil {
+			return answers, fmt.Errorf("error at loading answers of quiz attempt '%d' from db, case iterating and using rows.Scan: %s", attemptId, err)
+		}
+		answer.OptionIds = make([]int, 0, len(optionIds))
+		for _, id := range optionIds {
+			answer.OptionIds = append(answer.OptionIds, int(id))
+		}
+		answers = append(answers, answer)
+	}
+	err = rows.Err()
+	if err != nil {
+		return answers, fmt.Errorf("error at loading answers of quiz attempt '%d' from db, case after iterating: %s", attemptId, err)
+	}
+
+	return answers, nil
+}
+
+// aggregates the answers of the finished attempts by questions of the quiz, the questions without answers have zero stats
+func GetQuizQuestionStats(tx *sql.Tx, ctx context.Context, quizId int) ([]entities.QuizQuestionStats, error) {
+	var stats []entities.QuizQuestionStats
+
+	rows, err := tx.QueryContext(ctx, "SELECT quiz_questions.id, COUNT(quiz_attempts.id), COUNT(quiz_attempts.id) FILTER (WHERE quiz_answers.correct), "+
+		"COALESCE(AVG(quiz_answers.points) FILTER (WHERE quiz_attempts.id IS NOT NULL), 0) "+
+		"FROM quiz_questions LEFT JOIN quiz_answers ON quiz_answers.question_id = quiz_questions.id "+
+		"LEFT JOIN quiz_attempts ON quiz_attempts.id = quiz_answers.attempt_id and quiz_attempts.state = $2 "+
+		"WHERE quiz_questions.quiz_id = $1 GROUP BY quiz_questions.id, quiz_questions.position ORDER BY quiz_questions.position, quiz_questions.id",
+		quizId, entities.QUIZ_ATTEMPT_STATE_FINISHED)
+	if err != nil {
+		return stats, fmt.Errorf("error at loading stats of quiz '%d' from db, case after Query: %s", quizId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionStats entities.QuizQuestionStats
+		err := rows.Scan(&questionStats.QuestionId, &questionStats.AnswersCount, &questionStats.CorrectCount, &questionStats.AveragePoints)
+		if err != nil {
+			return stats, fmt.Errorf("error at loading stats of quiz '%d' from db, case iterating and using rows.Scan: %s", quizId, err)
+		}
+		stats = append(stats, questionStats)
+	}
+	err = rows.Err()
+	if err != nil {
+		return stats, fmt.Errorf("error at loading stats of quiz '%d' from db, case after iterating: %s", quizId, err)
+	}
+
+	return stats, nil
+}
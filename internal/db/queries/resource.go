@@ -0,0 +1,251 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanResource()
+const RESOURCE_COLUMNS string = "resources.id, resources.user_id, resources.title, resources.url, resources.type, resources.author, resources.status, resources.rating, " +
+	"resources.progress, resources.progress_total, resources.state, resources.create_date, resources.last_update_date"
+
+func scanResource(row rowScanner) (entities.Resource, error) {
+	var resource entities.Resource
+	err := row.Scan(&resource.Id, &resource.UserId, &resource.Title, &resource.Url, &resource.Type, &resource.Author, &resource.Status, &resource.Rating,
+		&resource.Progress, &resource.ProgressTotal, &resource.State, &resource.CreateDate, &resource.LastUpdateDate)
+	return resource, err
+}
+
+// the filter of the resources, the empty values are ignored
+type ResourceFilter struct {
+	Status string
+	Type   string
+	TagId  int
+}
+
+// returns the resources of the user matching the filter, the recently updated ones go first
+func GetUserResources(tx *sql.Tx, ctx context.Context, userId int, filter ResourceFilter, limit int, offset int) ([]entities.Resource, error) {
+	var resources []entities.Resource
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+RESOURCE_COLUMNS+" FROM resources WHERE user_id = $1 and state != $2 "+
+		"and ($3 = '' OR status = $3) and ($4 = '' OR type = $4) "+
+		"and ($5 = 0 OR EXISTS (SELECT 1 FROM resource_tags WHERE resource_tags.resource_id = resources.id and resource_tags.tag_id = $5)) "+
+		"ORDER BY last_update_date DESC, id DESC LIMIT $6 OFFSET $7",
+		userId, entities.RESOURCE_STATE_DELETED, filter.Status, filter.Type, filter.TagId, limit, offset)
+	if err != nil {
+		return resources, fmt.Errorf("error at loading resources of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			return resources, fmt.Errorf("error at loading resources of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		resources = append(resources, resource)
+	}
+	err = rows.Err()
+	if err != nil {
+		return resources, fmt.Errorf("error at loading resources of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	err = loadResourceLinks(tx, ctx, resources)
+	return resources, err
+}
+
+// returns the resources of the user linked to the note
+func GetNoteResources(tx *sql.Tx, ctx context.Context, noteId int, userId int) ([]entities.Resource, error) {
+	var resources []entities.Resource
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+RESOURCE_COLUMNS+" FROM resources JOIN resource_notes ON resource_notes.resource_id = resources.id "+
+		"WHERE resource_notes.note_id = $1 and resources.user_id = $2 and resources.state != $3 ORDER BY resources.title, resources.id",
+		noteId, userId, entities.RESOURCE_STATE_DELETED)
+	if err != nil {
+		return resources, fmt.Errorf("error at loading resources of note '%d' from db, case after Query: %s", noteId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		resource, err := scanResource(rows)
+		if err != nil {
+			return resources, fmt.Errorf("error at loading resources of note '%d' from db, case iterating and using rows.Scan: %s", noteId, err)
+		}
+		resources = append(resources, resource)
+	}
+	err = rows.Err()
+	if err != nil {
+		return resources, fmt.Errorf("error at loading resources of note '%d' from db, case after iterating: %s", noteId, err)
+	}
+
+	err = loadResourceLinks(tx, ctx, resources)
+	return resources, err
+}
+
+func GetResource(tx *sql.Tx, ctx context.Context, id int) (entities.Resource, error) {
+	resource, err := scanResource(tx.QueryRowContext(ctx, "SELECT "+RESOURCE_COLUMNS+" FROM resources WHERE id = $1 and state != $2", id, entities.RESOURCE_STATE_DELETED))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return resource, err
+		}
+		return resource, fmt.Errorf("error at loading resource by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	resources := []entities.Resource{resource}
+	err = loadResourceLinks(tx, ctx, resources)
+	return resources[0], err
+}
+
+func CreateResource(tx *sql.Tx, ctx context.Context, resource entities.Resource) (int, error) {
+	lastInsertId := -1
+
+	createDate := time.Now()
+	lastUpdateDate := time.Now()
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO resources(user_id, title, url, type, author, status, rating, progress, progress_total, state, create_date, last_update_date) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id",
+		resource.UserId, resource.Title, resource.Url, resource.Type, resource.Author, resource.Status, resource.Rating, resource.Progress, resource.ProgressTotal,
+		entities.RESOURCE_STATE_NEW, createDate, lastUpdateDate).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting resource (Title: '%s', UserId: '%d') into db, case after QueryRow.Scan: %s", resource.Title, resource.UserId, err)
+	}
+
+	err = setResourceLinks(tx, ctx, lastInsertId, resource.TagIds, resource.NoteIds)
+	if err != nil {
+		return -1, err
+	}
+
+	return lastInsertId, nil
+}
+
+// updates the resource and replaces its tags and notes
+func UpdateResource(tx *sql.Tx, ctx context.Context, resource entities.Resource) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE resources SET title = $2, url = $3, type = $4, author = $5, status = $6, rating = $7, progress = $8, progress_total = $9, "+
+		"last_update_date = $10 WHERE id = $1 and state != $11")
+	if err != nil {
+		return fmt.Errorf("error at updating resource, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, resource.Id, resource.Title, resource.Url, resource.Type, resource.Author, resource.Status, resource.Rating, resource.Progress,
+		resource.ProgressTotal, lastUpdateDate, entities.RESOURCE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating resource (Id: %d), case after executing statement: %s", resource.Id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating resource (Id: %d), case after counting affected rows: %s", resource.Id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+
+	return setResourceLinks(tx, ctx, resource.Id, resource.TagIds, resource.NoteIds)
+}
+
+func UpdateResourceProgress(tx *sql.Tx, ctx context.Context, id int, status string, progress int, progressTotal sql.NullInt32) error {
+	lastUpdateDate := time.Now()
+	stmt, err := tx.PrepareContext(ctx, "UPDATE resources SET status = $2, progress = $3, progress_total = $4, last_update_date = $5 WHERE id = $1 and state != $6")
+	if err != nil {
+		return fmt.Errorf("error at updating resource progress, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, status, progress, progressTotal, lastUpdateDate, entities.RESOURCE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at updating resource progress (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating resource progress (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func DeleteResource(tx *sql.Tx, ctx context.Context, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE resources SET state = $2 WHERE id = $1 and state != $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting resource, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, entities.RESOURCE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at deleting resource by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting resource by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func setResourceLinks(tx *sql.Tx, ctx context.Context, id int, tagIds []int, noteIds []int) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM resource_tags WHERE resource_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting tags of resource '%d', case after executing statement: %s", id, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO resource_tags(resource_id, tag_id) SELECT $1, UNNEST($2::int[]) ON CONFLICT DO NOTHING", id, pq.Array(tagIds))
+	if err != nil {
+		return fmt.Errorf("error at adding tags of resource '%d', case after executing statement: %s", id, err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM resource_notes WHERE resource_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting notes of resource '%d', case after executing statement: %s", id, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO resource_notes(resource_id, note_id) SELECT $1, UNNEST($2::int[]) ON CONFLICT DO NOTHING", id, pq.Array(noteIds))
+	if err != nil {
+		return fmt.Errorf("error at adding notes of resource '%d', case after executing statement: %s", id, err)
+	}
+	return nil
+}
+
+// fills the ids of the tags and the notes of the resources, the deleted tags and notes are skipped
+func loadResourceLinks(tx *sql.Tx, ctx context.Context, resources []entities.Resource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	index := make(map[int]int)
+	var ids []int
+	for i, resource := range resources {
+		index[resource.Id] = i
+		ids = append(ids, resource.Id)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT resource_tags.resource_id, 'TAG', resource_tags.tag_id FROM resource_tags JOIN tags ON tags.id = resource_tags.tag_id "+
+		"WHERE resource_tags.resource_id = ANY($1) and tags.state != $2 "+
+		"UNION ALL SELECT resource_notes.resource_id, 'NOTE', resource_notes.note_id FROM resource_notes JOIN notes ON notes.id = resource_notes.note_id "+
+		"WHERE resource_notes.resource_id = ANY($1) and notes.state != $3 ORDER BY 1, 2, 3",
+		pq.Array(ids), entities.TAG_STATE_DELETED, entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at loading links of resources from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resourceId, linkId int
+		var linkType string
+		err := rows.Scan(&resourceId, &linkType, &linkId)
+		if err != nil {
+			return fmt.Errorf("error at loading links of resources from db, case iterating and using rows.Scan: %s", err)
+		}
+		resource := &resources[index[resourceId]]
+		if linkType == "TAG" {
+			resource.TagIds = append(resource.TagIds, linkId)
+		} else {
+			resource.NoteIds = append(resource.NoteIds, linkId)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("error at loading links of resources from db, case after iterating: %s", err)
+	}
+
+	return nil
+}
@@ -4,31 +4,37 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
 )
 
+// the columns order matches scanTag()
+const TAG_COLUMNS string = "tags.id, tags.name, tags.state, tags.parent_id"
+
+func scanTag(row rowScanner) (entities.Tag, error) {
+	var tag entities.Tag
+	err := row.Scan(&tag.Id, &tag.Name, &tag.State, &tag.ParentId)
+	return tag, err
+}
+
 func GetTags(tx *sql.Tx, ctx context.Context, limit int, offset int) ([]entities.Tag, error) {
 	var tags []entities.Tag
-	var (
-		id    int
-		name  string
-		state string
-	)
 
-	rows, err := tx.QueryContext(ctx, "SELECT id, name, state FROM tags WHERE state != $3 LIMIT $1 OFFSET $2 ", limit, offset, entities.TAG_STATE_DELETED)
+	rows, err := tx.QueryContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tags WHERE state != $3 LIMIT $1 OFFSET $2 ", limit, offset, entities.TAG_STATE_DELETED)
 	if err != nil {
 		return tags, fmt.Errorf("error at loading tags from db, case after Query: %s", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		err := rows.Scan(&id, &name, &state)
+		tag, err := scanTag(rows)
 		if err != nil {
 			return tags, fmt.Errorf("error at loading tags from db, case iterating and using rows.Scan: %s", err)
 		}
-		tags = append(tags, entities.Tag{Id: id, Name: name, State: state})
+		tags = append(tags, tag)
 	}
 	err = rows.Err()
 	if err != nil {
@@ -39,9 +45,7 @@ func GetTags(tx *sql.Tx, ctx context.Context, limit int, offset int) ([]entities
 }
 
 func GetTag(tx *sql.Tx, ctx context.Context, id int) (entities.Tag, error) {
-	var tag entities.Tag
-
-	err := tx.QueryRowContext(ctx, "SELECT id, name, state FROM tags WHERE id = $1 and state != $2 ", id, entities.TAG_STATE_DELETED).Scan(&tag.Id, &tag.Name, &tag.State)
+	tag, err := scanTag(tx.QueryRowContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tags WHERE id = $1 and state != $2 ", id, entities.TAG_STATE_DELETED))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return tag, err
@@ -52,6 +56,24 @@ func GetTag(tx *sql.Tx, ctx context.Context, id int) (entities.Tag, error) {
 	return tag, nil
 }
 
+// returns not deleted tag with the given name, the tag in state NEW is preferred over the blocked one.
+// The canonical tag of the alias is returned if there is no tag with the name
+func GetTagByName(tx *sql.Tx, ctx context.Context, name string) (entities.Tag, error) {
+	tag, err := scanTag(tx.QueryRowContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tags WHERE name = $1 and state != $2 ORDER BY state != $3 LIMIT 1", name, entities.TAG_STATE_DELETED, entities.TAG_STATE_NEW))
+	if err == sql.ErrNoRows {
+		tag, err = scanTag(tx.QueryRowContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id "+
+			"WHERE lower(tag_aliases.name) = lower($1) and tags.state != $2", name, entities.TAG_STATE_DELETED))
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return tag, err
+		}
+		return tag, fmt.Errorf("error at loading tag by name '%s' from db, case after QueryRow.Scan: %s", name, err)
+	}
+
+	return tag, nil
+}
+
 func CreateTag(tx *sql.Tx, ctx context.Context, name string, state string) (int, error) {
 	lastInsertId := -1
 
@@ -92,14 +114,16 @@ func UpdateTag(tx *sql.Tx, ctx context.Context, id int, name string, state strin
 	return nil
 }
 
-func DeleteTag(tx *sql.Tx, ctx context.Context, id int) error {
+// deletes the tag with its aliases, the children of the tag are moved to its parent
+// moves the tag to the trash of the user who deleted it, the tag is restorable until it is purged
+func DeleteTag(tx *sql.Tx, ctx context.Context, id int, userId int) error {
 	// just for keeping the history we will add suffix to name and change state to 'DELETED', because of key constraint (name, state)
-	stmt, err := tx.PrepareContext(ctx, "UPDATE tags SET name = name||'_deleted_'||$1, state = $2 WHERE id = $1 and state != $2")
+	stmt, err := tx.PrepareContext(ctx, "UPDATE tags SET name = name||'_deleted_'||$1, previous_state = state, state = $2, deleted_at = $3, deleted_by = $4 WHERE id = $1 and state != $2")
 	if err != nil {
 		return fmt.Errorf("error at deleting tag, case after preparing statement: %s", err)
 	}
 
-	res, err := stmt.ExecContext(ctx, id, entities.TAG_STATE_DELETED)
+	res, err := stmt.ExecContext(ctx, id, entities.TAG_STATE_DELETED, time.Now(), userId)
 	if err != nil {
 		return fmt.Errorf("error at deleting tag by id '%d', case after executing statement: %s", id, err)
 	}
@@ -113,5 +137,153 @@ func DeleteTag(tx *sql.Tx, ctx context.Context, id int) error {
 		return sql.ErrNoRows
 	}
 
+	_, err = tx.ExecContext(ctx, "UPDATE tags SET parent_id = (SELECT parent_id FROM tags WHERE id = $1) WHERE parent_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting tag by id '%d', case after moving children: %s", id, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tag_aliases WHERE tag_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error at deleting tag by id '%d', case after deleting aliases: %s", id, err)
+	}
+
+	return nil
+}
+
+// returns the direct children of the tag
+func GetTagChildren(tx *sql.Tx, ctx context.Context, id int) ([]entities.Tag, error) {
+	var tags []entities.Tag
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tags WHERE parent_id = $1 and state != $2 ORDER BY name, id", id, entities.TAG_STATE_DELETED)
+	if err != nil {
+		return tags, fmt.Errorf("error at loading children of tag '%d' from db, case after Query: %s", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return tags, fmt.Errorf("error at loading children of tag '%d' from db, case iterating and using rows.Scan: %s", id, err)
+		}
+		tags = append(tags, tag)
+	}
+	err = rows.Err()
+	if err != nil {
+		return tags, fmt.Errorf("error at loading children of tag '%d' from db, case after iterating: %s", id, err)
+	}
+
+	return tags, nil
+}
+
+// returns the tag and all its ancestors up to the root
+func GetTagAncestorIds(tx *sql.Tx, ctx context.Context, id int) ([]int, error) {
+	var ids []int
+
+	// the union skips the visited tags, so the broken hierarchy could not loop forever
+	rows, err := tx.QueryContext(ctx, "WITH RECURSIVE ancestors AS ("+
+		"SELECT id, parent_id FROM tags WHERE id = $1 "+
+		"UNION "+
+		"SELECT tags.id, tags.parent_id FROM tags JOIN ancestors ON tags.id = ancestors.parent_id"+
+		") SELECT id FROM ancestors", id)
+	if err != nil {
+		return ids, fmt.Errorf("error at loading ancestors of tag '%d' from db, case after Query: %s", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ancestorId int
+		err := rows.Scan(&ancestorId)
+		if err != nil {
+			return ids, fmt.Errorf("error at loading ancestors of tag '%d' from db, case iterating and using rows.Scan: %s", id, err)
+		}
+		ids = append(ids, ancestorId)
+	}
+	err = rows.Err()
+	if err != nil {
+		return ids, fmt.Errorf("error at loading ancestors of tag '%d' from db, case after iterating: %s", id, err)
+	}
+
+	return ids, nil
+}
+
+// sets the parent of the tag, the tag becomes the root if the parent is not set. The caller guarantees that the parent is not a descendant of the tag
+func SetTagParent(tx *sql.Tx, ctx context.Context, id int, parentId sql.NullInt32) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE tags SET parent_id = $2 WHERE id = $1 and state != $3")
+	if err != nil {
+		return fmt.Errorf("error at setting parent of tag, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, parentId, entities.TAG_STATE_DELETED)
+	if err != nil {
+		return fmt.Errorf("error at setting parent of tag (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at setting parent of tag (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// returns the counts of the not deleted notes of the tags, the tags without notes are missed
+func GetTagsUsageCounts(tx *sql.Tx, ctx context.Context, tagIds []int) (map[int]int, error) {
+	counts := make(map[int]int)
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_tags.tag_id, count(*) FROM note_tags JOIN notes ON notes.id = note_tags.note_id "+
+		"WHERE note_tags.tag_id = ANY($1) and notes.state != $2 GROUP BY note_tags.tag_id", pq.Array(tagIds), entities.NOTE_STATE_DELETED)
+	if err != nil {
+		return counts, fmt.Errorf("error at loading tag usage counts from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tagId, count int
+		err := rows.Scan(&tagId, &count)
+		if err != nil {
+			return counts, fmt.Errorf("error at loading tag usage counts from db, case iterating and using rows.Scan: %s", err)
+		}
+		counts[tagId] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return counts, fmt.Errorf("error at loading tag usage counts from db, case after iterating: %s", err)
+	}
+
+	return counts, nil
+}
+
+// re-points the notes, the resources, the prerequisites, the aliases and the children of the source tag to the target one and
+// keeps the name of the source as the alias of the target. The prerequisites between the source and the target are dropped.
+// The target is moved to the parent of the source if it is its descendant. The source itself is left to the caller for deleting
+func MergeTag(tx *sql.Tx, ctx context.Context, sourceId int, targetId int) error {
+	statements := []string{
+		"UPDATE notes SET tag_id = $2 WHERE tag_id = $1",
+		"INSERT INTO note_tags(note_id, tag_id) SELECT note_id, $2 FROM note_tags WHERE tag_id = $1 ON CONFLICT (note_id, tag_id) DO NOTHING",
+		"DELETE FROM note_tags WHERE tag_id = $1",
+		"INSERT INTO resource_tags(resource_id, tag_id) SELECT resource_id, $2 FROM resource_tags WHERE tag_id = $1 ON CONFLICT DO NOTHING",
+		"DELETE FROM resource_tags WHERE tag_id = $1",
+		"INSERT INTO tag_prerequisites(tag_id, prerequisite_id) SELECT $2, prerequisite_id FROM tag_prerequisites WHERE tag_id = $1 and prerequisite_id != $2 " +
+			"ON CONFLICT (tag_id, prerequisite_id) DO NOTHING",
+		"INSERT INTO tag_prerequisites(tag_id, prerequisite_id) SELECT tag_id, $2 FROM tag_prerequisites WHERE prerequisite_id = $1 and tag_id != $2 " +
+			"ON CONFLICT (tag_id, prerequisite_id) DO NOTHING",
+		"DELETE FROM tag_prerequisites WHERE tag_id = $1 or prerequisite_id = $1",
+		"UPDATE tag_aliases SET tag_id = $2 WHERE tag_id = $1",
+		"WITH RECURSIVE ancestors AS (SELECT id, parent_id FROM tags WHERE id = $2 UNION SELECT tags.id, tags.parent_id FROM tags JOIN ancestors ON tags.id = ancestors.parent_id) " +
+			"UPDATE tags SET parent_id = (SELECT parent_id FROM tags WHERE id = $1) WHERE id = $2 and $1 IN (SELECT parent_id FROM ancestors)",
+		"UPDATE tags SET parent_id = $2 WHERE parent_id = $1 and id != $2",
+	}
+	for _, statement := range statements {
+		_, err := tx.ExecContext(ctx, statement, sourceId, targetId)
+		if err != nil {
+			return fmt.Errorf("error at merging tag (SourceId: %d, TargetId: %d), case after executing statement: %s", sourceId, targetId, err)
+		}
+	}
+
+	// the name could be taken by the alias already
+	_, err := tx.ExecContext(ctx, "INSERT INTO tag_aliases(tag_id, name, create_date) SELECT $2, name, $3 FROM tags WHERE id = $1 ON CONFLICT DO NOTHING", sourceId, targetId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error at merging tag (SourceId: %d, TargetId: %d), case after adding alias: %s", sourceId, targetId, err)
+	}
 	return nil
 }
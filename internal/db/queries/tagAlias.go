@@ -0,0 +1,93 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the columns order matches scanTagAlias()
+const TAG_ALIAS_COLUMNS string = "id, tag_id, name, create_date"
+
+func scanTagAlias(row rowScanner) (entities.TagAlias, error) {
+	var alias entities.TagAlias
+	err := row.Scan(&alias.Id, &alias.TagId, &alias.Name, &alias.CreateDate)
+	return alias, err
+}
+
+// returns the aliases of the tags ordered by name, the tags without aliases are missed
+func GetTagsAliases(tx *sql.Tx, ctx context.Context, tagIds []int) (map[int][]entities.TagAlias, error) {
+	aliases := make(map[int][]entities.TagAlias)
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+TAG_ALIAS_COLUMNS+" FROM tag_aliases WHERE tag_id = ANY($1) ORDER BY tag_id, lower(name), id", pq.Array(tagIds))
+	if err != nil {
+		return aliases, fmt.Errorf("error at loading tag aliases from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		alias, err := scanTagAlias(rows)
+		if err != nil {
+			return aliases, fmt.Errorf("error at loading tag aliases from db, case iterating and using rows.Scan: %s", err)
+		}
+		aliases[alias.TagId] = append(aliases[alias.TagId], alias)
+	}
+	err = rows.Err()
+	if err != nil {
+		return aliases, fmt.Errorf("error at loading tag aliases from db, case after iterating: %s", err)
+	}
+
+	return aliases, nil
+}
+
+// returns the alias with the name regardless of the case
+func GetTagAliasByName(tx *sql.Tx, ctx context.Context, name string) (entities.TagAlias, error) {
+	alias, err := scanTagAlias(tx.QueryRowContext(ctx, "SELECT "+TAG_ALIAS_COLUMNS+" FROM tag_aliases WHERE lower(name) = lower($1)", name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return alias, err
+		}
+		return alias, fmt.Errorf("error at loading tag alias by name '%s' from db, case after QueryRow.Scan: %s", name, err)
+	}
+
+	return alias, nil
+}
+
+func CreateTagAlias(tx *sql.Tx, ctx context.Context, tagId int, name string) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO tag_aliases(tag_id, name, create_date) VALUES($1, $2, $3) RETURNING id", tagId, name, time.Now()).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		if err.Error() == db.ErrorTagAliasDuplicateKey.Error() {
+			return -1, db.ErrorTagAliasDuplicateKey
+		}
+		return -1, fmt.Errorf("error at inserting tag alias (TagId: '%d', Name: '%s') into db, case after QueryRow.Scan: %s", tagId, name, err)
+	}
+
+	return lastInsertId, nil
+}
+
+func DeleteTagAlias(tx *sql.Tx, ctx context.Context, tagId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM tag_aliases WHERE id = $1 and tag_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting tag alias, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, tagId)
+	if err != nil {
+		return fmt.Errorf("error at deleting tag alias (Id: %d), case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting tag alias (Id: %d), case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
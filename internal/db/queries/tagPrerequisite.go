@@ -0,0 +1,161 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// returns the direct prerequisites of the tag
+func GetTagPrerequisites(tx *sql.Tx, ctx context.Context, tagId int) ([]entities.Tag, error) {
+	var tags []entities.Tag
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tag_prerequisites JOIN tags ON tags.id = tag_prerequisites.prerequisite_id "+
+		"WHERE tag_prerequisites.tag_id = $1 and tags.state != $2 ORDER BY tags.name, tags.id", tagId, entities.TAG_STATE_DELETED)
+	if err != nil {
+		return tags, fmt.Errorf("error at loading prerequisites of tag '%d' from db, case after Query: %s", tagId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return tags, fmt.Errorf("error at loading prerequisites of tag '%d' from db, case iterating and using rows.Scan: %s", tagId, err)
+		}
+		tags = append(tags, tag)
+	}
+	err = rows.Err()
+	if err != nil {
+		return tags, fmt.Errorf("error at loading prerequisites of tag '%d' from db, case after iterating: %s", tagId, err)
+	}
+
+	return tags, nil
+}
+
+// returns all prerequisite edges between the tags which are not deleted
+func GetTagPrerequisiteEdges(tx *sql.Tx, ctx context.Context) ([]entities.TagPrerequisite, error) {
+	var edges []entities.TagPrerequisite
+
+	rows, err := tx.QueryContext(ctx, "SELECT tag_prerequisites.tag_id, tag_prerequisites.prerequisite_id FROM tag_prerequisites "+
+		"JOIN tags ON tags.id = tag_prerequisites.tag_id JOIN tags prerequisites ON prerequisites.id = tag_prerequisites.prerequisite_id "+
+		"WHERE tags.state != $1 and prerequisites.state != $1", entities.TAG_STATE_DELETED)
+	if err != nil {
+		return edges, fmt.Errorf("error at loading tag prerequisites from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge entities.TagPrerequisite
+		err := rows.Scan(&edge.TagId, &edge.PrerequisiteId)
+		if err != nil {
+			return edges, fmt.Errorf("error at loading tag prerequisites from db, case iterating and using rows.Scan: %s", err)
+		}
+		edges = append(edges, edge)
+	}
+	err = rows.Err()
+	if err != nil {
+		return edges, fmt.Errorf("error at loading tag prerequisites from db, case after iterating: %s", err)
+	}
+
+	return edges, nil
+}
+
+// prevents the concurrent changes of the prerequisites until the end of the transaction, so the checked graph stays acyclic
+func LockTagPrerequisites(tx *sql.Tx, ctx context.Context) error {
+	_, err := tx.ExecContext(ctx, "LOCK TABLE tag_prerequisites IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return fmt.Errorf("error at locking tag prerequisites, case after executing statement: %s", err)
+	}
+	return nil
+}
+
+// adds the prerequisite to the tag, the existing one is kept as is
+func AddTagPrerequisite(tx *sql.Tx, ctx context.Context, tagId int, prerequisiteId int) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO tag_prerequisites(tag_id, prerequisite_id) VALUES($1, $2) ON CONFLICT (tag_id, prerequisite_id) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("error at adding tag prerequisite, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, tagId, prerequisiteId)
+	if err != nil {
+		return fmt.Errorf("error at adding tag prerequisite (TagId: %d, PrerequisiteId: %d), case after executing statement: %s", tagId, prerequisiteId, err)
+	}
+	return nil
+}
+
+func DeleteTagPrerequisite(tx *sql.Tx, ctx context.Context, tagId int, prerequisiteId int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM tag_prerequisites WHERE tag_id = $1 and prerequisite_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting tag prerequisite, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, tagId, prerequisiteId)
+	if err != nil {
+		return fmt.Errorf("error at deleting tag prerequisite (TagId: %d, PrerequisiteId: %d), case after executing statement: %s", tagId, prerequisiteId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting tag prerequisite (TagId: %d, PrerequisiteId: %d), case after counting affected rows: %s", tagId, prerequisiteId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetTagsByIds(tx *sql.Tx, ctx context.Context, ids []int) ([]entities.Tag, error) {
+	var tags []entities.Tag
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+TAG_COLUMNS+" FROM tags WHERE id = ANY($1) and state != $2 ORDER BY id", pq.Array(ids), entities.TAG_STATE_DELETED)
+	if err != nil {
+		return tags, fmt.Errorf("error at loading tags by ids from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return tags, fmt.Errorf("error at loading tags by ids from db, case iterating and using rows.Scan: %s", err)
+		}
+		tags = append(tags, tag)
+	}
+	err = rows.Err()
+	if err != nil {
+		return tags, fmt.Errorf("error at loading tags by ids from db, case after iterating: %s", err)
+	}
+
+	return tags, nil
+}
+
+// returns the notes of the tags visible to the user, the note is completed if it is completed by the user as an item of any course
+func GetTagNotes(tx *sql.Tx, ctx context.Context, userId int, tagIds []int) ([]entities.TagNote, error) {
+	var notes []entities.TagNote
+
+	rows, err := tx.QueryContext(ctx, "SELECT note_tags.tag_id, notes.id, EXISTS (SELECT 1 FROM course_item_completions "+
+		"JOIN course_items ON course_items.id = course_item_completions.item_id "+
+		"JOIN course_modules ON course_modules.id = course_items.module_id JOIN courses ON courses.id = course_modules.course_id "+
+		"WHERE course_items.note_id = notes.id and course_item_completions.user_id = $1 and courses.state != $4) "+
+		"FROM note_tags JOIN notes ON notes.id = note_tags.note_id "+
+		"WHERE note_tags.tag_id = ANY($2) and notes.state != $3 and "+noteVisibleToUserCondition("$1")+" ORDER BY note_tags.tag_id, notes.id",
+		userId, pq.Array(tagIds), entities.NOTE_STATE_DELETED, entities.COURSE_STATE_DELETED)
+	if err != nil {
+		return notes, fmt.Errorf("error at loading notes of tags from db, case after Query: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var note entities.TagNote
+		err := rows.Scan(&note.TagId, &note.NoteId, &note.Completed)
+		if err != nil {
+			return notes, fmt.Errorf("error at loading notes of tags from db, case iterating and using rows.Scan: %s", err)
+		}
+		notes = append(notes, note)
+	}
+	err = rows.Err()
+	if err != nil {
+		return notes, fmt.Errorf("error at loading notes of tags from db, case after iterating: %s", err)
+	}
+
+	return notes, nil
+}
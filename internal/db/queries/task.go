@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
@@ -92,14 +93,15 @@ func UpdateTask(tx *sql.Tx, ctx context.Context, id int, name string, state stri
 	return nil
 }
 
-func DeleteTask(tx *sql.Tx, ctx context.Context, id int) error {
+// moves the task to the trash of the user who deleted it, the task is restorable until it is purged
+func DeleteTask(tx *sql.Tx, ctx context.Context, id int, userId int) error {
 	// just for keeping the history we will add suffix to name and change state to 'DELETED', because of key constraint (name, state)
-	stmt, err := tx.PrepareContext(ctx, "UPDATE tasks SET name = name||'_deleted_'||$1, state = $2 WHERE id = $1 and state != $2")
+	stmt, err := tx.PrepareContext(ctx, "UPDATE tasks SET name = name||'_deleted_'||$1, previous_state = state, state = $2, deleted_at = $3, deleted_by = $4 WHERE id = $1 and state != $2")
 	if err != nil {
 		return fmt.Errorf("error at deleting task, case after preparing statement: %s", err)
 	}
 
-	res, err := stmt.ExecContext(ctx, id, entities.TASK_STATE_DELETED)
+	res, err := stmt.ExecContext(ctx, id, entities.TASK_STATE_DELETED, time.Now(), userId)
 	if err != nil {
 		return fmt.Errorf("error at deleting task by id '%d', case after executing statement: %s", id, err)
 	}
@@ -0,0 +1,215 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the columns order matches scanTimeEntry()
+const TIME_ENTRY_COLUMNS string = "time_entries.id, time_entries.user_id, time_entries.note_id, time_entries.task_id, time_entries.course_id, time_entries.description, " +
+	"time_entries.kind, time_entries.start_date, time_entries.end_date, time_entries.planned_duration"
+
+func scanTimeEntry(row rowScanner) (entities.TimeEntry, error) {
+	var entry entities.TimeEntry
+	err := row.Scan(&entry.Id, &entry.UserId, &entry.NoteId, &entry.TaskId, &entry.CourseId, &entry.Description,
+		&entry.Kind, &entry.StartDate, &entry.EndDate, &entry.PlannedDuration)
+	return entry, err
+}
+
+func scanTimeEntries(rows *sql.Rows) ([]entities.TimeEntry, error) {
+	var entries []entities.TimeEntry
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return entries, fmt.Errorf("error at loading time entries from db, case iterating and using rows.Scan: %s", err)
+		}
+		entries = append(entries, entry)
+	}
+	err := rows.Err()
+	if err != nil {
+		return entries, fmt.Errorf("error at loading time entries from db, case after iterating: %s", err)
+	}
+
+	return entries, nil
+}
+
+// returns the entries of the user, the recent ones go first
+func GetTimeEntries(tx *sql.Tx, ctx context.Context, userId int, limit int, offset int) ([]entities.TimeEntry, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+TIME_ENTRY_COLUMNS+" FROM time_entries WHERE user_id = $1 ORDER BY start_date DESC, id DESC LIMIT $2 OFFSET $3", userId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading time entries of user '%d' from db, case after Query: %s", userId, err)
+	}
+	return scanTimeEntries(rows)
+}
+
+// returns the entries of the user which overlap the interval, the running entry is considered to last until now
+func GetTimeEntriesInRange(tx *sql.Tx, ctx context.Context, userId int, from time.Time, to time.Time) ([]entities.TimeEntry, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+TIME_ENTRY_COLUMNS+" FROM time_entries WHERE user_id = $1 and start_date < $3 and (end_date IS NULL or end_date > $2) "+
+		"ORDER BY start_date, id", userId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error at loading time entries of user '%d' from db, case after Query: %s", userId, err)
+	}
+	return scanTimeEntries(rows)
+}
+
+// returns the tags of the notes which the entries overlapping the interval are linked to
+func GetTimeEntryTagsInRange(tx *sql.Tx, ctx context.Context, userId int, from time.Time, to time.Time) ([]entities.TimeEntryTag, error) {
+	var tags []entities.TimeEntryTag
+
+	rows, err := tx.QueryContext(ctx, "SELECT time_entries.id, tags.name FROM time_entries "+
+		"JOIN note_tags ON note_tags.note_id = time_entries.note_id JOIN tags ON tags.id = note_tags.tag_id "+
+		"WHERE time_entries.user_id = $1 and time_entries.start_date < $3 and (time_entries.end_date IS NULL or time_entries.end_date > $2) and tags.state != $4 "+
+		"ORDER BY time_entries.id, tags.name", userId, from, to, entities.TAG_STATE_DELETED)
+	if err != nil {
+		return tags, fmt.Errorf("error at loading tags of time entries of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag entities.TimeEntryTag
+		err := rows.Scan(&tag.TimeEntryId, &tag.Name)
+		if err != nil {
+			return tags, fmt.Errorf("error at loading tags of time entries of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		tags = append(tags, tag)
+	}
+	err = rows.Err()
+	if err != nil {
+		return tags, fmt.Errorf("error at loading tags of time entries of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return tags, nil
+}
+
+func GetTimeEntry(tx *sql.Tx, ctx context.Context, userId int, id int) (entities.TimeEntry, error) {
+	entry, err := scanTimeEntry(tx.QueryRowContext(ctx, "SELECT "+TIME_ENTRY_COLUMNS+" FROM time_entries WHERE id = $1 and user_id = $2", id, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entry, err
+		}
+		return entry, fmt.Errorf("error at loading time entry by id '%d' from db, case after QueryRow.Scan: %s", id, err)
+	}
+
+	return entry, nil
+}
+
+// returns sql.ErrNoRows if the user has no running timer
+func GetRunningTimeEntry(tx *sql.Tx, ctx context.Context, userId int) (entities.TimeEntry, error) {
+	entry, err := scanTimeEntry(tx.QueryRowContext(ctx, "SELECT "+TIME_ENTRY_COLUMNS+" FROM time_entries WHERE user_id = $1 and end_date IS NULL", userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entry, err
+		}
+		return entry, fmt.Errorf("error at loading running time entry of user '%d' from db, case after QueryRow.Scan: %s", userId, err)
+	}
+
+	return entry, nil
+}
+
+// counts the pomodoros of the user which are started since the date and lasted the whole planned duration
+func CountFinishedPomodoros(tx *sql.Tx, ctx context.Context, userId int, since time.Time) (int, error) {
+	var count int
+
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM time_entries WHERE user_id = $1 and kind = $2 and start_date >= $3 "+
+		"and end_date >= start_date + planned_duration * interval '1 second'", userId, entities.TIME_ENTRY_KIND_POMODORO, since).Scan(&count)
+	if err != nil {
+		return count, fmt.Errorf("error at counting pomodoros of user '%d', case after QueryRow.Scan: %s", userId, err)
+	}
+
+	return count, nil
+}
+
+// the entry is running if the end date is missed, the user is not allowed to have several running entries
+func CreateTimeEntry(tx *sql.Tx, ctx context.Context, entry entities.TimeEntry) (int, error) {
+	lastInsertId := -1
+
+	err := tx.QueryRowContext(ctx, "INSERT INTO time_entries(user_id, note_id, task_id, course_id, description, kind, start_date, end_date, planned_duration) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		entry.UserId, entry.NoteId, entry.TaskId, entry.CourseId, entry.Description, entry.Kind, entry.StartDate, entry.EndDate, entry.PlannedDuration).
+		Scan(&lastInsertId) // scan will release the connection
+	if err != nil {
+		return -1, fmt.Errorf("error at inserting time entry (UserId: '%d', Kind: '%s') into db, case after QueryRow.Scan: %s", entry.UserId, entry.Kind, err)
+	}
+
+	return lastInsertId, nil
+}
+
+// updates the links, the description and the dates of the finished entry
+func UpdateTimeEntry(tx *sql.Tx, ctx context.Context, entry entities.TimeEntry) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE time_entries SET note_id = $3, task_id = $4, course_id = $5, description = $6, start_date = $7, end_date = $8 "+
+		"WHERE id = $1 and user_id = $2 and end_date IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("error at updating time entry, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, entry.Id, entry.UserId, entry.NoteId, entry.TaskId, entry.CourseId, entry.Description, entry.StartDate, entry.EndDate)
+	if err != nil {
+		return fmt.Errorf("error at updating time entry (Id: %d, UserId: %d), case after executing statement: %s", entry.Id, entry.UserId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at updating time entry (Id: %d, UserId: %d), case after counting affected rows: %s", entry.Id, entry.UserId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// stops the running entry of the user
+func FinishTimeEntry(tx *sql.Tx, ctx context.Context, userId int, id int, endDate time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE time_entries SET end_date = GREATEST($3, start_date) WHERE id = $1 and user_id = $2 and end_date IS NULL")
+	if err != nil {
+		return fmt.Errorf("error at finishing time entry, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, endDate)
+	if err != nil {
+		return fmt.Errorf("error at finishing time entry (Id: %d, UserId: %d), case after executing statement: %s", id, userId, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at finishing time entry (Id: %d, UserId: %d), case after counting affected rows: %s", id, userId, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// stops the running timer of the user at the end of the planned duration if it is passed, e.g. the pomodoro
+func FinishExpiredTimers(tx *sql.Tx, ctx context.Context, userId int, now time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE time_entries SET end_date = start_date + planned_duration * interval '1 second' "+
+		"WHERE user_id = $1 and end_date IS NULL and planned_duration IS NOT NULL and start_date + planned_duration * interval '1 second' <= $2")
+	if err != nil {
+		return fmt.Errorf("error at finishing expired timers, case after preparing statement: %s", err)
+	}
+	_, err = stmt.ExecContext(ctx, userId, now)
+	if err != nil {
+		return fmt.Errorf("error at finishing expired timers of user '%d', case after executing statement: %s", userId, err)
+	}
+	return nil
+}
+
+func DeleteTimeEntry(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM time_entries WHERE id = $1 and user_id = $2")
+	if err != nil {
+		return fmt.Errorf("error at deleting time entry, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId)
+	if err != nil {
+		return fmt.Errorf("error at deleting time entry by id '%d', case after executing statement: %s", id, err)
+	}
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at deleting time entry by id '%d', case after counting affected rows: %s", id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
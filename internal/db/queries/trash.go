@@ -0,0 +1,256 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/lib/pq"
+)
+
+// the deleted tasks and tags keep the suffix '_deleted_<id>' in the name, because of key constraint (name, state)
+const TRASH_ORIGINAL_NAME string = "left(name, -length('_deleted_'||id))"
+
+// returns the deleted notes of the user and the tasks and tags deleted by the user, the most recently deleted first.
+// All the types are returned if the type is empty
+func GetTrashItems(tx *sql.Tx, ctx context.Context, userId int, itemType string, limit int, offset int) ([]entities.TrashItem, error) {
+	var items []entities.TrashItem
+
+	rows, err := tx.QueryContext(ctx, "SELECT type, id, name, deleted_at FROM ("+
+		"SELECT $2::varchar AS type, id, topic AS name, deleted_at FROM notes WHERE state = $3 and user_id = $1 and deleted_at IS NOT NULL "+
+		"UNION ALL SELECT $4::varchar, id, "+TRASH_ORIGINAL_NAME+", deleted_at FROM tasks WHERE state = $5 and deleted_by = $1 "+
+		"UNION ALL SELECT $6::varchar, id, "+TRASH_ORIGINAL_NAME+", deleted_at FROM tags WHERE state = $7 and deleted_by = $1"+
+		") AS trash WHERE $8::varchar = '' or type = $8 ORDER BY deleted_at DESC, type, id LIMIT $9 OFFSET $10",
+		userId, entities.TRASH_ITEM_TYPE_NOTE, entities.NOTE_STATE_DELETED, entities.TRASH_ITEM_TYPE_TASK, entities.TASK_STATE_DELETED,
+		entities.TRASH_ITEM_TYPE_TAG, entities.TAG_STATE_DELETED, itemType, limit, offset)
+	if err != nil {
+		return items, fmt.Errorf("error at loading trash of user '%d' from db, case after Query: %s", userId, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entities.TrashItem
+		err := rows.Scan(&item.Type, &item.Id, &item.Name, &item.DeletedAt)
+		if err != nil {
+			return items, fmt.Errorf("error at loading trash of user '%d' from db, case iterating and using rows.Scan: %s", userId, err)
+		}
+		items = append(items, item)
+	}
+	err = rows.Err()
+	if err != nil {
+		return items, fmt.Errorf("error at loading trash of user '%d' from db, case after iterating: %s", userId, err)
+	}
+
+	return items, nil
+}
+
+// brings the deleted note of the user back to the state it had before the deletion
+func RestoreNote(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE notes SET state = COALESCE(previous_state, $4), previous_state = NULL, deleted_at = NULL, deleted_by = NULL "+
+		"WHERE id = $1 and user_id = $2 and state = $3")
+	if err != nil {
+		return fmt.Errorf("error at restoring note, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, entities.NOTE_STATE_DELETED, entities.NOTE_STATE_DRAFT)
+	if err != nil {
+		return fmt.Errorf("error at restoring note by id '%d', case after executing statement: %s", id, err)
+	}
+	return checkRestored(res, "note", id)
+}
+
+// brings the task deleted by the user back with its original name, db.ErrorTaskDuplicateKey if the name is taken already
+func RestoreTask(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	stmt, err := tx.PrepareContext(ctx, "UPDATE tasks SET name = "+TRASH_ORIGINAL_NAME+", state = COALESCE(previous_state, $4), previous_state = NULL, deleted_at = NULL, deleted_by = NULL "+
+		"WHERE id = $1 and deleted_by = $2 and state = $3")
+	if err != nil {
+		return fmt.Errorf("error at restoring task, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, entities.TASK_STATE_DELETED, entities.TASK_STATE_NEW)
+	if err != nil {
+		if err.Error() == db.ErrorTaskDuplicateKey.Error() {
+			return db.ErrorTaskDuplicateKey
+		}
+		return fmt.Errorf("error at restoring task by id '%d', case after executing statement: %s", id, err)
+	}
+	return checkRestored(res, "task", id)
+}
+
+// brings the tag deleted by the user back with its original name, db.ErrorTagDuplicateKey if the name is taken already.
+// The tag becomes the root if its parent is deleted too, the children and the aliases of the tag are not restored.
+// The name could be taken by the alias, e.g. when the tag is merged into another one, it is reported as the duplicate too
+func RestoreTag(tx *sql.Tx, ctx context.Context, userId int, id int) error {
+	var aliased bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tag_aliases WHERE lower(name) = "+
+		"lower((SELECT "+TRASH_ORIGINAL_NAME+" FROM tags WHERE id = $1 and deleted_by = $2 and state = $3)))", id, userId, entities.TAG_STATE_DELETED).Scan(&aliased)
+	if err != nil {
+		return fmt.Errorf("error at restoring tag by id '%d', case after checking aliases: %s", id, err)
+	}
+	if aliased {
+		return db.ErrorTagDuplicateKey
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE tags SET name = "+TRASH_ORIGINAL_NAME+", state = COALESCE(previous_state, $4), previous_state = NULL, deleted_at = NULL, deleted_by = NULL, "+
+		"parent_id = CASE WHEN EXISTS (SELECT 1 FROM tags parents WHERE parents.id = tags.parent_id and parents.state != $3) THEN parent_id END "+
+		"WHERE id = $1 and deleted_by = $2 and state = $3")
+	if err != nil {
+		return fmt.Errorf("error at restoring tag, case after preparing statement: %s", err)
+	}
+	res, err := stmt.ExecContext(ctx, id, userId, entities.TAG_STATE_DELETED, entities.TAG_STATE_NEW)
+	if err != nil {
+		if err.Error() == db.ErrorTagDuplicateKey.Error() {
+			return db.ErrorTagDuplicateKey
+		}
+		return fmt.Errorf("error at restoring tag by id '%d', case after executing statement: %s", id, err)
+	}
+	return checkRestored(res, "tag", id)
+}
+
+func checkRestored(res sql.Result, itemType string, id int) error {
+	affectedRowsCount, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error at restoring %s by id '%d', case after counting affected rows: %s", itemType, id, err)
+	}
+	if affectedRowsCount == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// hard-deletes the notes deleted before the date with everything which makes no sense without them and returns
+// the count of the purged notes and the checksums of their attachments for cleaning the blobs up.
+// The notes referred by the courses or the quizzes are kept in the trash until they are removed from them, the time entries of the notes are kept for the history
+func PurgeNotes(tx *sql.Tx, ctx context.Context, before time.Time) (int, []string, error) {
+	var checksums []string
+
+	ids, err := purgeItems(tx, ctx, "DELETE FROM notes WHERE state = $1 and deleted_at < $2 "+
+		"and NOT EXISTS (SELECT 1 FROM course_items WHERE course_items.note_id = notes.id) "+
+		"and NOT EXISTS (SELECT 1 FROM quizzes WHERE quizzes.note_id = notes.id) RETURNING id", entities.NOTE_STATE_DELETED, before)
+	if err != nil {
+		return 0, checksums, fmt.Errorf("error at purging notes, case after deleting notes: %s", err)
+	}
+	if len(ids) == 0 {
+		return 0, checksums, nil
+	}
+
+	statements := []string{
+		"DELETE FROM note_tags WHERE note_id = ANY($1)",
+		"DELETE FROM note_shares WHERE note_id = ANY($1)",
+		"DELETE FROM note_share_links WHERE note_id = ANY($1)",
+		"DELETE FROM note_links WHERE source_note_id = ANY($1) or target_note_id = ANY($1)",
+		"DELETE FROM note_reactions WHERE note_id = ANY($1)",
+		"DELETE FROM note_imports WHERE note_id = ANY($1)",
+		"DELETE FROM notebook_notes WHERE note_id = ANY($1)",
+		"DELETE FROM bookmarks WHERE note_id = ANY($1)",
+		"DELETE FROM resource_notes WHERE note_id = ANY($1)",
+		"DELETE FROM mentions WHERE note_id = ANY($1)",
+		"DELETE FROM notifications WHERE note_id = ANY($1)",
+		"DELETE FROM content_reports WHERE content_type = '" + entities.CONTENT_TYPE_COMMENT + "' and content_id IN (SELECT id FROM comments WHERE note_id = ANY($1))",
+		"DELETE FROM content_reports WHERE content_type = '" + entities.CONTENT_TYPE_NOTE + "' and content_id = ANY($1)",
+		"DELETE FROM comments WHERE note_id = ANY($1)",
+		"DELETE FROM card_review_log WHERE card_id IN (SELECT id FROM cards WHERE note_id = ANY($1))",
+		"DELETE FROM card_reviews WHERE card_id IN (SELECT id FROM cards WHERE note_id = ANY($1))",
+		"DELETE FROM cards WHERE note_id = ANY($1)",
+		"UPDATE time_entries SET note_id = NULL WHERE note_id = ANY($1)",
+	}
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement, pq.Array(ids))
+		if err != nil {
+			return 0, checksums, fmt.Errorf("error at purging notes, case after executing '%s': %s", statement, err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM attachments WHERE note_id = ANY($1) RETURNING checksum", pq.Array(ids))
+	if err != nil {
+		return 0, checksums, fmt.Errorf("error at purging notes, case after deleting attachments: %s", err)
+	}
+	defer rows.Close()
+
+	unique := make(map[string]bool)
+	for rows.Next() {
+		var checksum string
+		err := rows.Scan(&checksum)
+		if err != nil {
+			return 0, checksums, fmt.Errorf("error at purging notes, case iterating and using rows.Scan: %s", err)
+		}
+		if !unique[checksum] {
+			unique[checksum] = true
+			checksums = append(checksums, checksum)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return 0, checksums, fmt.Errorf("error at purging notes, case after iterating: %s", err)
+	}
+
+	return len(ids), checksums, nil
+}
+
+// hard-deletes the tasks deleted before the date and returns their count, the time entries of the tasks are kept for the history.
+// The tasks referred by the courses are kept in the trash until they are removed from the courses
+func PurgeTasks(tx *sql.Tx, ctx context.Context, before time.Time) (int, error) {
+	ids, err := purgeItems(tx, ctx, "DELETE FROM tasks WHERE state = $1 and deleted_at < $2 "+
+		"and NOT EXISTS (SELECT 1 FROM course_items WHERE course_items.task_id = tasks.id) RETURNING id", entities.TASK_STATE_DELETED, before)
+	if err != nil {
+		return 0, fmt.Errorf("error at purging tasks, case after deleting tasks: %s", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE time_entries SET task_id = NULL WHERE task_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("error at purging tasks, case after unlinking time entries: %s", err)
+	}
+	return len(ids), nil
+}
+
+// hard-deletes the tags deleted before the date with their relations and returns their count.
+// The tags which are still the primary tags of notes are kept in the trash
+func PurgeTags(tx *sql.Tx, ctx context.Context, before time.Time) (int, error) {
+	ids, err := purgeItems(tx, ctx, "DELETE FROM tags WHERE state = $1 and deleted_at < $2 "+
+		"and NOT EXISTS (SELECT 1 FROM notes WHERE notes.tag_id = tags.id) RETURNING id", entities.TAG_STATE_DELETED, before)
+	if err != nil {
+		return 0, fmt.Errorf("error at purging tags, case after deleting tags: %s", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	statements := []string{
+		"DELETE FROM note_tags WHERE tag_id = ANY($1)",
+		"DELETE FROM resource_tags WHERE tag_id = ANY($1)",
+		"DELETE FROM tag_prerequisites WHERE tag_id = ANY($1) or prerequisite_id = ANY($1)",
+		"DELETE FROM tag_aliases WHERE tag_id = ANY($1)",
+	}
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement, pq.Array(ids))
+		if err != nil {
+			return 0, fmt.Errorf("error at purging tags, case after executing '%s': %s", statement, err)
+		}
+	}
+
+	return len(ids), nil
+}
+
+func purgeItems(tx *sql.Tx, ctx context.Context, query string, state string, before time.Time) ([]int, error) {
+	var ids []int
+
+	rows, err := tx.QueryContext(ctx, query, state, before)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
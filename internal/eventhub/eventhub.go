@@ -0,0 +1,108 @@
+package eventhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the event as it is sent to the clients, the audience is not disclosed
+type Message struct {
+	Id           int
+	ResourceType string
+	ResourceId   int
+	Action       string
+}
+
+// the stream of the events visible to the user, the channel is closed if the subscriber falls behind or the subscription is closed
+type Subscription struct {
+	UserId int
+	C      chan entities.Event
+	hub    *Hub
+}
+
+// delivers the events to the subscribers of the instance, the instances get the events from the database, so every one of them has own hub
+type Hub struct {
+	mutex       sync.Mutex
+	bufferSize  int
+	subscribers map[*Subscription]bool
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{bufferSize: bufferSize, subscribers: make(map[*Subscription]bool)}
+}
+
+func (h *Hub) Subscribe(userId int) *Subscription {
+	subscription := &Subscription{UserId: userId, C: make(chan entities.Event, h.bufferSize), hub: h}
+	h.mutex.Lock()
+	h.subscribers[subscription] = true
+	h.mutex.Unlock()
+	return subscription
+}
+
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.subscribers[subscription] {
+		delete(h.subscribers, subscription)
+		close(subscription.C)
+	}
+}
+
+// closes all subscriptions, e.g. on shutdown or when the events could be missed, so the clients reconnect and resume the streams
+func (h *Hub) CloseAll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for subscription := range h.subscribers {
+		delete(h.subscribers, subscription)
+		close(subscription.C)
+	}
+}
+
+func (h *Hub) Count() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.subscribers)
+}
+
+// sends the event to the subscribers who are able to see it without waiting for them. The subscriber with the full buffer is dropped,
+// so the client reconnects and resumes the stream from the last received event
+func (h *Hub) Broadcast(event entities.Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for subscription := range h.subscribers {
+		if !event.IsVisibleTo(subscription.UserId) {
+			continue
+		}
+		select {
+		case subscription.C <- event:
+		default:
+			delete(h.subscribers, subscription)
+			close(subscription.C)
+		}
+	}
+}
+
+func ToMessage(event entities.Event) Message {
+	return Message{Id: event.Id, ResourceType: event.ResourceType, ResourceId: event.ResourceId, Action: event.Action}
+}
+
+// returns the name of the event, e.g. 'NOTE_UPDATED'
+func Name(event entities.Event) string {
+	return event.ResourceType + "_" + event.Action
+}
+
+// formats the event according to the Server-Sent Events specification
+func FormatSSE(event entities.Event) (string, error) {
+	data, err := json.Marshal(ToMessage(event))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.Id, Name(event), data), nil
+}
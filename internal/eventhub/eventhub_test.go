@@ -0,0 +1,76 @@
+//go:build unit
+// +build unit
+
+package eventhub_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/eventhub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcast(t *testing.T) {
+	hub := eventhub.NewHub(2)
+	owner := hub.Subscribe(1)
+	reader := hub.Subscribe(2)
+	stranger := hub.Subscribe(3)
+
+	private := entities.Event{Id: 1, ResourceType: entities.EVENT_RESOURCE_TYPE_NOTE, ResourceId: 10, Action: entities.EVENT_ACTION_UPDATED, UserIds: []int{1, 2}}
+	public := entities.Event{Id: 2, ResourceType: entities.EVENT_RESOURCE_TYPE_TASK, ResourceId: 20, Action: entities.EVENT_ACTION_CREATED, Public: true}
+	hub.Broadcast(private)
+	hub.Broadcast(public)
+
+	assert.Equal(t, private, <-owner.C)
+	assert.Equal(t, public, <-owner.C)
+	assert.Equal(t, private, <-reader.C)
+	assert.Equal(t, public, <-reader.C)
+	assert.Equal(t, public, <-stranger.C)
+	assert.Equal(t, 0, len(stranger.C))
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	hub := eventhub.NewHub(1)
+	slow := hub.Subscribe(1)
+	fast := hub.Subscribe(2)
+
+	event := entities.Event{Id: 1, Public: true}
+	hub.Broadcast(event)
+	<-fast.C
+	hub.Broadcast(event)
+
+	<-slow.C
+	_, ok := <-slow.C
+	assert.False(t, ok)
+	assert.Equal(t, 1, hub.Count())
+
+	// closing of the dropped subscription is safe
+	slow.Close()
+	fast.Close()
+	assert.Equal(t, 0, hub.Count())
+}
+
+func TestFormatSSE(t *testing.T) {
+	event := entities.Event{Id: 7, ResourceType: entities.EVENT_RESOURCE_TYPE_NOTE, ResourceId: 3, Action: entities.EVENT_ACTION_DELETED, UserIds: []int{1}}
+
+	actual, err := eventhub.FormatSSE(event)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "id: 7\nevent: NOTE_DELETED\ndata: {\"Id\":7,\"ResourceType\":\"NOTE\",\"ResourceId\":3,\"Action\":\"DELETED\"}\n\n", actual)
+}
+
+func TestCloseAll(t *testing.T) {
+	hub := eventhub.NewHub(1)
+	first := hub.Subscribe(1)
+	second := hub.Subscribe(1)
+
+	hub.CloseAll()
+
+	_, ok := <-first.C
+	assert.False(t, ok)
+	_, ok = <-second.C
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Count())
+	hub.Broadcast(entities.Event{Id: 1, Public: true})
+}
@@ -0,0 +1,161 @@
+package learningpath
+
+import (
+	"sort"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// the direct prerequisites of the tags in ascending order of ids
+type Graph map[int][]int
+
+// the topic of the learning path, it is done when all its notes are completed, so the topic without notes has nothing to study.
+// The topic is available when it is not done and all its prerequisites are done
+type Step struct {
+	TagId            int
+	Prerequisites    []int
+	NotesTotal       int
+	NotesCompleted   int
+	RemainingNoteIds []int
+	Done             bool
+	Available        bool
+}
+
+func NewGraph(edges []entities.TagPrerequisite) Graph {
+	graph := make(Graph)
+	for _, edge := range edges {
+		graph[edge.TagId] = append(graph[edge.TagId], edge.PrerequisiteId)
+	}
+	for _, prerequisites := range graph {
+		sort.Ints(prerequisites)
+	}
+	return graph
+}
+
+// returns the shortest chain of prerequisites from one tag to another including both of them, nil if there is no chain
+func (g Graph) Path(from int, to int) []int {
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []int{to}
+			for current != from {
+				current = previous[current]
+				path = append([]int{current}, path...)
+			}
+			return path
+		}
+		for _, next := range g[current] {
+			if _, visited := previous[next]; !visited {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// returns the cycle which would appear if the prerequisite is added to the tag, e.g. [A, B, C, A]
+// for A requiring B while C requires A and B requires C. Returns nil if the graph stays acyclic
+func (g Graph) CycleWith(tagId int, prerequisiteId int) []int {
+	path := g.Path(prerequisiteId, tagId)
+	if path == nil {
+		return nil
+	}
+	return append([]int{tagId}, path...)
+}
+
+// returns the cycle which would appear if the source tag is merged into the target one, e.g. [B, C, B] for A requiring C
+// while C requires B and A is merged into B. The direct prerequisites between the source and the target are ignored,
+// because they are dropped by the merge. Returns nil if the graph stays acyclic
+func (g Graph) CycleWithMerge(sourceId int, targetId int) []int {
+	merged := make(Graph)
+	for tagId, prerequisites := range g {
+		for _, prerequisite := range prerequisites {
+			if (tagId == sourceId && prerequisite == targetId) || (tagId == targetId && prerequisite == sourceId) {
+				continue
+			}
+			merged[tagId] = append(merged[tagId], prerequisite)
+		}
+	}
+	path := merged.Path(sourceId, targetId)
+	if path == nil {
+		path = merged.Path(targetId, sourceId)
+	}
+	if path == nil {
+		return nil
+	}
+	for i, tagId := range path {
+		if tagId == sourceId {
+			path[i] = targetId
+		}
+	}
+	return path
+}
+
+// returns the target with all its transitive prerequisites, every tag goes after its prerequisites
+func (g Graph) Order(target int) []int {
+	var result []int
+	visited := make(map[int]bool)
+	var visit func(tagId int)
+	visit = func(tagId int) {
+		// the graph is kept acyclic, but the visited tag is skipped anyway, so the broken graph could not loop forever
+		if visited[tagId] {
+			return
+		}
+		visited[tagId] = true
+		for _, prerequisite := range g[tagId] {
+			visit(prerequisite)
+		}
+		result = append(result, tagId)
+	}
+	visit(target)
+	return result
+}
+
+// returns the steps to study the target topic in the topological order, the notes are the ones of the topics visible to the user
+func Plan(g Graph, target int, notes []entities.TagNote) []Step {
+	order := g.Order(target)
+	steps := make(map[int]*Step, len(order))
+	for _, tagId := range order {
+		steps[tagId] = &Step{TagId: tagId, Prerequisites: g[tagId]}
+	}
+	for _, note := range notes {
+		step, ok := steps[note.TagId]
+		if !ok {
+			continue
+		}
+		step.NotesTotal++
+		if note.Completed {
+			step.NotesCompleted++
+		} else {
+			step.RemainingNoteIds = append(step.RemainingNoteIds, note.NoteId)
+		}
+	}
+
+	result := make([]Step, 0, len(order))
+	for _, tagId := range order {
+		step := steps[tagId]
+		step.Done = step.NotesCompleted == step.NotesTotal
+		step.Available = !step.Done
+		for _, prerequisite := range step.Prerequisites {
+			if !steps[prerequisite].Done {
+				step.Available = false
+			}
+		}
+		result = append(result, *step)
+	}
+	return result
+}
+
+// returns the first available topic of the steps, false if the target is done or blocked
+func Next(steps []Step) (int, bool) {
+	for _, step := range steps {
+		if step.Available {
+			return step.TagId, true
+		}
+	}
+	return -1, false
+}
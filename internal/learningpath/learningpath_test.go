@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package learningpath_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/learningpath"
+	"github.com/stretchr/testify/assert"
+)
+
+// 4 requires 2 and 3, both of them require 1
+func diamond() learningpath.Graph {
+	return learningpath.NewGraph([]entities.TagPrerequisite{
+		{TagId: 4, PrerequisiteId: 3},
+		{TagId: 4, PrerequisiteId: 2},
+		{TagId: 2, PrerequisiteId: 1},
+		{TagId: 3, PrerequisiteId: 1},
+	})
+}
+
+func TestCycleWith(t *testing.T) {
+	graph := diamond()
+
+	assert.Nil(t, graph.CycleWith(4, 1))
+	assert.Nil(t, graph.CycleWith(5, 4))
+	assert.Equal(t, []int{1, 4, 2, 1}, graph.CycleWith(1, 4))
+	assert.Equal(t, []int{2, 2}, graph.CycleWith(2, 2))
+}
+
+func TestCycleWithMerge(t *testing.T) {
+	graph := diamond()
+
+	// the direct prerequisite between the merged tags is dropped
+	assert.Nil(t, graph.CycleWithMerge(2, 1))
+	assert.Nil(t, graph.CycleWithMerge(2, 3))
+	assert.Equal(t, []int{1, 2, 1}, graph.CycleWithMerge(4, 1))
+	assert.Equal(t, []int{4, 2, 4}, graph.CycleWithMerge(1, 4))
+}
+
+func TestOrder(t *testing.T) {
+	graph := diamond()
+
+	assert.Equal(t, []int{1, 2, 3, 4}, graph.Order(4))
+	assert.Equal(t, []int{1, 3}, graph.Order(3))
+	assert.Equal(t, []int{5}, graph.Order(5))
+}
+
+func TestPlan(t *testing.T) {
+	graph := diamond()
+	notes := []entities.TagNote{
+		{TagId: 1, NoteId: 10, Completed: true},
+		{TagId: 2, NoteId: 20, Completed: true},
+		{TagId: 3, NoteId: 30, Completed: false},
+		{TagId: 3, NoteId: 31, Completed: true},
+		{TagId: 4, NoteId: 40, Completed: false},
+		// the note of the tag out of the path
+		{TagId: 5, NoteId: 50, Completed: false},
+	}
+
+	steps := learningpath.Plan(graph, 4, notes)
+	assert.Equal(t, 4, len(steps))
+
+	assert.True(t, steps[0].Done)
+	assert.True(t, steps[1].Done)
+	assert.Equal(t, 3, steps[2].TagId)
+	assert.False(t, steps[2].Done)
+	assert.True(t, steps[2].Available)
+	assert.Equal(t, 2, steps[2].NotesTotal)
+	assert.Equal(t, []int{30}, steps[2].RemainingNoteIds)
+	// the prerequisite 3 is not done yet
+	assert.False(t, steps[3].Available)
+	assert.Equal(t, []int{2, 3}, steps[3].Prerequisites)
+
+	next, ok := learningpath.Next(steps)
+	assert.True(t, ok)
+	assert.Equal(t, 3, next)
+}
+
+func TestPlanOfDoneTarget(t *testing.T) {
+	steps := learningpath.Plan(diamond(), 2, []entities.TagNote{{TagId: 1, NoteId: 10, Completed: true}})
+	assert.True(t, steps[0].Done)
+	// the topic without notes has nothing to study
+	assert.True(t, steps[1].Done)
+
+	_, ok := learningpath.Next(steps)
+	assert.False(t, ok)
+}
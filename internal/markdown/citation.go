@@ -0,0 +1,89 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// the key of the citation as it is allowed in BibTeX entries
+const CITATION_KEY_PATTERN string = `[\p{L}\p{N}_:.+/-]+`
+
+var citationRegexp = regexp.MustCompile(`\[(@[^\[\]\n]+)\]`)
+var citationItemRegexp = regexp.MustCompile(`^@(` + CITATION_KEY_PATTERN + `)\s*(?:,\s*(.*))?$`)
+
+// the cited key with the optional locator, e.g. "p. 33" of [@knuth84, p. 33]
+type Citation struct {
+	Key     string
+	Locator string
+}
+
+// returns distinct keys of the [@key] and [@key1; @key2] citations in order of their appearance, code blocks and inline code are skipped
+func ExtractCitationKeys(text string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, line := range textLines(text) {
+		for _, match := range citationRegexp.FindAllStringSubmatch(line, -1) {
+			citations, ok := parseCitations(match[1])
+			if !ok {
+				continue
+			}
+			for _, citation := range citations {
+				if !seen[citation.Key] {
+					seen[citation.Key] = true
+					result = append(result, citation.Key)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// replaces the citations of the text by the result of the render function, code blocks and inline code are kept as is
+func RenderCitations(text string, render func(citations []Citation) string) string {
+	lines := strings.Split(text, "\n")
+	inCodeBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		var builder strings.Builder
+		start := 0
+		for _, code := range inlineCodeRegexp.FindAllStringIndex(line, -1) {
+			builder.WriteString(renderCitationsOfLine(line[start:code[0]], render))
+			builder.WriteString(line[code[0]:code[1]])
+			start = code[1]
+		}
+		builder.WriteString(renderCitationsOfLine(line[start:], render))
+		lines[i] = builder.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderCitationsOfLine(line string, render func(citations []Citation) string) string {
+	return citationRegexp.ReplaceAllStringFunc(line, func(match string) string {
+		citations, ok := parseCitations(match[1 : len(match)-1])
+		if !ok {
+			return match
+		}
+		return render(citations)
+	})
+}
+
+// parses "@key1, locator; @key2", the group is not a citation if any of its items is not
+func parseCitations(group string) ([]Citation, bool) {
+	var result []Citation
+	for _, item := range strings.Split(group, ";") {
+		match := citationItemRegexp.FindStringSubmatch(strings.TrimSpace(item))
+		if match == nil {
+			return nil, false
+		}
+		result = append(result, Citation{Key: match[1], Locator: strings.TrimSpace(match[2])})
+	}
+	return result, true
+}
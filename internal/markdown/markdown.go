@@ -0,0 +1,107 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	FRONT_MATTER_DELIMITER string = "---"
+	SLUG_MAX_LENGTH        int    = 64
+	SLUG_DEFAULT           string = "note"
+)
+
+// the field of YAML front matter, supported values: string, int, bool, time.Time, []string
+type Field struct {
+	Key   string
+	Value any
+}
+
+// writes the markdown document with YAML front matter, the fields are written in the given order
+func WriteDocument(w io.Writer, frontMatter []Field, body string) error {
+	var buf bytes.Buffer
+	buf.WriteString(FRONT_MATTER_DELIMITER + "\n")
+	for _, field := range frontMatter {
+		value, err := formatValue(field.Value)
+		if err != nil {
+			return fmt.Errorf("unable to write front matter field '%s': %s", field.Key, err)
+		}
+		buf.WriteString(field.Key + ": " + value + "\n")
+	}
+	buf.WriteString(FRONT_MATTER_DELIMITER + "\n")
+	if body != "" {
+		buf.WriteString("\n" + body)
+		if !strings.HasSuffix(body, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func formatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case int:
+		return fmt.Sprintf("%d", v), nil
+	case bool:
+		return fmt.Sprintf("%t", v), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case []string:
+		var items []string
+		for _, item := range v {
+			quoted, err := quote(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, quoted)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
+
+// JSON string is valid double-quoted YAML scalar
+func quote(str string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(str)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// converts the text to the string which is safe to use as a file name
+func Slugify(str string) string {
+	var builder strings.Builder
+	length := 0
+	dash := false
+	for _, r := range strings.ToLower(str) {
+		if length >= SLUG_MAX_LENGTH {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			length++
+			dash = false
+		} else if !dash && length > 0 {
+			builder.WriteRune('-')
+			length++
+			dash = true
+		}
+	}
+	result := strings.TrimSuffix(builder.String(), "-")
+	if result == "" {
+		return SLUG_DEFAULT
+	}
+	return result
+}
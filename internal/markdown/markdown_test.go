@@ -0,0 +1,198 @@
+//go:build unit
+// +build unit
+
+package markdown_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/markdown"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDocument(t *testing.T) {
+	var buf bytes.Buffer
+	date := time.Date(2022, 7, 1, 10, 30, 0, 0, time.UTC)
+
+	err := markdown.WriteDocument(&buf, []markdown.Field{
+		{Key: "id", Value: 1},
+		{Key: "topic", Value: "Graphs: \"BFS\" & <DFS>"},
+		{Key: "tags", Value: []string{"algorithms", "graphs"}},
+		{Key: "created", Value: date},
+	}, "# Graphs\nSome text")
+
+	assert.Nil(t, err)
+	expected := "---\n" +
+		"id: 1\n" +
+		"topic: \"Graphs: \\\"BFS\\\" & <DFS>\"\n" +
+		"tags: [\"algorithms\", \"graphs\"]\n" +
+		"created: 2022-07-01T10:30:00Z\n" +
+		"---\n" +
+		"\n# Graphs\nSome text\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteDocumentUnsupportedValue(t *testing.T) {
+	var buf bytes.Buffer
+	err := markdown.WriteDocument(&buf, []markdown.Field{{Key: "size", Value: 1.5}}, "")
+	assert.NotNil(t, err)
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "graphs-bfs-dfs", markdown.Slugify("Graphs: BFS & DFS!"))
+	assert.Equal(t, "линейная-алгебра", markdown.Slugify("Линейная алгебра"))
+	assert.Equal(t, markdown.SLUG_DEFAULT, markdown.Slugify("???"))
+	assert.Equal(t, markdown.SLUG_MAX_LENGTH, len([]rune(markdown.Slugify(string(bytes.Repeat([]byte("a"), 100))))))
+}
+
+func TestParseDocument(t *testing.T) {
+	content := "---\r\n" +
+		"topic: \"Graphs: \\\"BFS\\\"\"\r\n" +
+		"title: 'It''s simple' # comment\r\n" +
+		"tags: [algorithms, \"graphs, trees\"]\r\n" +
+		"aliases:\r\n" +
+		"  - bfs\r\n" +
+		"- dfs\r\n" +
+		"created: 2022-07-01 10:30\r\n" +
+		"nested:\r\n" +
+		"  key: value\r\n" +
+		"---\r\n" +
+		"\r\n" +
+		"# Graphs\r\n"
+
+	frontMatter, body, err := markdown.ParseDocument(content)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "# Graphs\n", body)
+	topic, ok := frontMatter.String("topic")
+	assert.True(t, ok)
+	assert.Equal(t, "Graphs: \"BFS\"", topic)
+	title, _ := frontMatter.String("title")
+	assert.Equal(t, "It's simple", title)
+	assert.Equal(t, []string{"algorithms", "graphs, trees"}, frontMatter.List("tags"))
+	assert.Equal(t, []string{"bfs", "dfs"}, frontMatter.List("aliases"))
+	created, _ := frontMatter.String("created")
+	assert.Equal(t, "2022-07-01 10:30", created)
+	assert.Nil(t, frontMatter.List("nested"))
+}
+
+func TestParseDocumentWithoutFrontMatter(t *testing.T) {
+	frontMatter, body, err := markdown.ParseDocument("# Graphs\n---\n")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(frontMatter))
+	assert.Equal(t, "# Graphs\n---\n", body)
+}
+
+func TestParseDocumentWrongFrontMatter(t *testing.T) {
+	_, _, err := markdown.ParseDocument("---\ntopic: Graphs\n")
+	assert.NotNil(t, err)
+
+	_, _, err = markdown.ParseDocument("---\njust text\n---\n")
+	assert.NotNil(t, err)
+
+	_, _, err = markdown.ParseDocument("---\ntopic: \"Graphs\n---\n")
+	assert.NotNil(t, err)
+}
+
+func TestWriteAndParseDocument(t *testing.T) {
+	var buf bytes.Buffer
+	markdown.WriteDocument(&buf, []markdown.Field{
+		{Key: "topic", Value: "Graphs: \"BFS\", 'DFS' & #tags"},
+		{Key: "tags", Value: []string{"a, b", "c\"d"}},
+	}, "text")
+
+	frontMatter, body, err := markdown.ParseDocument(buf.String())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "text\n", body)
+	topic, _ := frontMatter.String("topic")
+	assert.Equal(t, "Graphs: \"BFS\", 'DFS' & #tags", topic)
+	assert.Equal(t, []string{"a, b", "c\"d"}, frontMatter.List("tags"))
+}
+
+func TestExtractHashtags(t *testing.T) {
+	text := "# Heading\n" +
+		"Study #algorithms and #graphs/trees, see #algorithms again.\n" +
+		"Issue #42 and http://example.com/#anchor are not tags, `#code` too\n" +
+		"```\n#notatag\n```\n" +
+		"(#линал)"
+
+	assert.Equal(t, []string{"algorithms", "graphs/trees", "линал"}, markdown.ExtractHashtags(text))
+}
+
+func TestExtractWikiLinks(t *testing.T) {
+	text := "See [[Graphs]], [[graphs|the graphs]] and [[Trees#Balanced]].\n" +
+		"Embedded ![[42]] and `[[code]]`\n" +
+		"```\n[[skipped]]\n```\n" +
+		"Empty [[ ]] link"
+
+	assert.Equal(t, []string{"Graphs", "Trees", "42"}, markdown.ExtractWikiLinks(text))
+}
+
+func TestExtractMentions(t *testing.T) {
+	text := "Thanks @alice and @Bob_2, cc (@carol).\n" +
+		"Mail alice@example.com, cite [@knuth84; @lamport94], ask @ALICE again, `@code` too\n" +
+		"```\n@skipped\n```\n" +
+		"Alone @ sign and @dave."
+
+	assert.Equal(t, []string{"alice", "Bob_2", "carol", "dave"}, markdown.ExtractMentions(text))
+}
+
+func TestExtractQuestionAnswers(t *testing.T) {
+	text := "# Graphs\n" +
+		"Q: What is BFS?\n" +
+		"A: Breadth-first search.\n" +
+		"It uses a queue.\n" +
+		"q: Complexity of BFS\n" +
+		"on adjacency list?\n" +
+		"a: O(V + E)\n" +
+		"\n" +
+		"Q: Question without answer\n" +
+		"\n" +
+		"```\nQ: skipped\nA: skipped\n```\n"
+
+	expected := []markdown.QuestionAnswer{
+		{Question: "What is BFS?", Answer: "Breadth-first search.\nIt uses a queue."},
+		{Question: "Complexity of BFS\non adjacency list?", Answer: "O(V + E)"},
+	}
+	assert.Equal(t, expected, markdown.ExtractQuestionAnswers(text))
+}
+
+func TestExtractCitationKeys(t *testing.T) {
+	text := "As shown in [@knuth84], see also [@lamport94; @knuth84, p. 33].\n" +
+		"Not citations: [see @knuth84], [@], `[@code]` and user@example.com\n" +
+		"```\n[@skipped]\n```\n" +
+		"[@doi:10.1000/182]"
+
+	assert.Equal(t, []string{"knuth84", "lamport94", "doi:10.1000/182"}, markdown.ExtractCitationKeys(text))
+}
+
+func TestRenderCitations(t *testing.T) {
+	text := "As shown in [@knuth84, p. 33] and [@unknown; @knuth84].\n" +
+		"Code `[@knuth84]` is kept\n" +
+		"```\n[@knuth84]\n```"
+
+	result := markdown.RenderCitations(text, func(citations []markdown.Citation) string {
+		var parts []string
+		for _, citation := range citations {
+			label := "@" + citation.Key
+			if citation.Key == "knuth84" {
+				label = "Knuth, 1984"
+			}
+			if citation.Locator != "" {
+				label += ", " + citation.Locator
+			}
+			parts = append(parts, label)
+		}
+		return "(" + strings.Join(parts, "; ") + ")"
+	})
+
+	expected := "As shown in (Knuth, 1984, p. 33) and (@unknown; Knuth, 1984).\n" +
+		"Code `[@knuth84]` is kept\n" +
+		"```\n[@knuth84]\n```"
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,345 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// the values of parsed front matter are strings or lists of strings.
+// Only flat mappings are supported, nested mappings are skipped
+type FrontMatter map[string]any
+
+var hashtagRegexp = regexp.MustCompile(`(?:^|[\s(\[,;])#([\p{L}\p{N}_/-]+)`)
+var wikiLinkRegexp = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+var inlineCodeRegexp = regexp.MustCompile("`[^`\n]*`")
+var mentionRegexp = regexp.MustCompile(`(?:^|[\s(,;])@([\p{L}\p{N}_.-]+)`)
+
+// splits the markdown document into YAML front matter and the body, the document without front matter has empty one
+func ParseDocument(content string) (FrontMatter, string, error) {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	frontMatter := make(FrontMatter)
+	if !strings.HasPrefix(content, FRONT_MATTER_DELIMITER+"\n") {
+		return frontMatter, content, nil
+	}
+
+	lines := strings.Split(content, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == FRONT_MATTER_DELIMITER || lines[i] == "..." {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return frontMatter, content, fmt.Errorf("front matter is not closed")
+	}
+
+	var currentKey string
+	for i := 1; i < end; i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			// items of the block list, the other nested values are skipped
+			if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+				if list, ok := frontMatter[currentKey].([]string); ok && currentKey != "" {
+					item, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+					if err != nil {
+						return frontMatter, content, fmt.Errorf("line %d: %s", i+1, err)
+					}
+					frontMatter[currentKey] = append(list, item)
+				}
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) == "" {
+			return frontMatter, content, fmt.Errorf("line %d: expected 'key: value'", i+1)
+		}
+		currentKey = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case value == "":
+			// the value could be defined by the block list on the next lines
+			frontMatter[currentKey] = []string{}
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			list, err := parseFlowList(value[1 : len(value)-1])
+			if err != nil {
+				return frontMatter, content, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			frontMatter[currentKey] = list
+		default:
+			scalar, err := parseScalar(value)
+			if err != nil {
+				return frontMatter, content, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			frontMatter[currentKey] = scalar
+		}
+	}
+
+	body := strings.Join(lines[end+1:], "\n")
+	return frontMatter, strings.TrimLeft(body, "\n"), nil
+}
+
+// returns the scalar value by the key, the list with single item is considered as a scalar too
+func (f FrontMatter) String(key string) (string, bool) {
+	switch v := f[key].(type) {
+	case string:
+		return v, true
+	case []string:
+		if len(v) == 1 {
+			return v[0], true
+		}
+	}
+	return "", false
+}
+
+// returns the list value by the key, the scalar is considered as a list with single item
+func (f FrontMatter) List(key string) []string {
+	switch v := f[key].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+		return v
+	}
+	return nil
+}
+
+// returns distinct #hashtags of the markdown text in order of their appearance, code blocks and inline code are skipped
+func ExtractHashtags(text string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, line := range textLines(text) {
+		for _, match := range hashtagRegexp.FindAllStringSubmatch(line, -1) {
+			tag := strings.TrimRight(match[1], "/-")
+			if !hasLetter(tag) || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// returns distinct targets of [[wiki links]] in order of their appearance. The alias ([[target|alias]]) and
+// the heading or block reference ([[target#heading]]) are cut off, code blocks and inline code are skipped
+func ExtractWikiLinks(text string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, line := range textLines(text) {
+		for _, match := range wikiLinkRegexp.FindAllStringSubmatch(line, -1) {
+			target := match[1]
+			if index := strings.IndexAny(target, "|#^"); index != -1 {
+				target = target[:index]
+			}
+			target = strings.TrimSpace(target)
+			key := strings.ToLower(target)
+			if target == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, target)
+		}
+	}
+	return result
+}
+
+// returns distinct @logins of the markdown text in order of their appearance, the case is ignored. The e-mails, the citations ([@key])
+// and the trailing punctuation are not the part of the mentions, code blocks and inline code are skipped
+func ExtractMentions(text string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, line := range textLines(text) {
+		line = citationRegexp.ReplaceAllString(line, "")
+		for _, match := range mentionRegexp.FindAllStringSubmatch(line, -1) {
+			login := strings.TrimRight(match[1], ".-")
+			key := strings.ToLower(login)
+			if login == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, login)
+		}
+	}
+	return result
+}
+
+// returns lines of the text except code blocks, inline code is removed from the lines
+func textLines(text string) []string {
+	var result []string
+	inCodeBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		result = append(result, inlineCodeRegexp.ReplaceAllString(line, ""))
+	}
+	return result
+}
+
+func hasLetter(str string) bool {
+	for _, r := range str {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseScalar(value string) (string, error) {
+	if value == "" || value[0] == '#' {
+		return "", nil
+	}
+	if value[0] != '"' && value[0] != '\'' {
+		// plain value could be followed by the comment
+		if index := strings.Index(value, " #"); index != -1 {
+			value = value[:index]
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	quote := value[0]
+	end := -1
+	for i := 1; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			if quote == '\'' && i+1 < len(value) && value[i+1] == '\'' {
+				i++
+				continue
+			}
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return "", fmt.Errorf("quoted value is not closed")
+	}
+	rest := strings.TrimSpace(value[end+1:])
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected characters after quoted value")
+	}
+
+	if quote == '\'' {
+		return strings.ReplaceAll(value[1:end], "''", "'"), nil
+	}
+	var result string
+	err := json.Unmarshal([]byte(value[:end+1]), &result)
+	if err != nil {
+		return "", fmt.Errorf("wrong double-quoted value: %s", err)
+	}
+	return result, nil
+}
+
+func parseFlowList(value string) ([]string, error) {
+	result := []string{}
+	var item strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range value {
+		switch {
+		case quote != 0:
+			item.WriteRune(r)
+			if escaped {
+				escaped = false
+			} else if quote == '"' && r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			item.WriteRune(r)
+		case r == ',':
+			scalar, err := parseScalar(strings.TrimSpace(item.String()))
+			if err != nil {
+				return nil, err
+			}
+			if scalar != "" {
+				result = append(result, scalar)
+			}
+			item.Reset()
+		default:
+			item.WriteRune(r)
+		}
+	}
+	scalar, err := parseScalar(strings.TrimSpace(item.String()))
+	if err != nil {
+		return nil, err
+	}
+	if scalar != "" {
+		result = append(result, scalar)
+	}
+	return result, nil
+}
+
+type QuestionAnswer struct {
+	Question string
+	Answer   string
+}
+
+// returns "Q:/A:" blocks of the text. The question and the answer could take several lines,
+// the block ends with the blank line or the next question. Questions without answers are skipped
+func ExtractQuestionAnswers(text string) []QuestionAnswer {
+	var result []QuestionAnswer
+	var question, answer []string
+	inAnswer := false
+
+	flush := func() {
+		if len(question) > 0 && inAnswer {
+			qa := QuestionAnswer{Question: strings.TrimSpace(strings.Join(question, "\n")), Answer: strings.TrimSpace(strings.Join(answer, "\n"))}
+			if qa.Question != "" && qa.Answer != "" {
+				result = append(result, qa)
+			}
+		}
+		question, answer, inAnswer = nil, nil, false
+	}
+
+	for _, line := range textLines(text) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case hasPrefixFold(trimmed, "Q:"):
+			flush()
+			question = append(question, trimmed[2:])
+		case hasPrefixFold(trimmed, "A:") && len(question) > 0 && !inAnswer:
+			inAnswer = true
+			answer = append(answer, trimmed[2:])
+		case trimmed == "":
+			flush()
+		case inAnswer:
+			answer = append(answer, line)
+		case len(question) > 0:
+			question = append(question, line)
+		}
+	}
+	flush()
+
+	return result
+}
+
+func hasPrefixFold(str string, prefix string) bool {
+	return len(str) >= len(prefix) && strings.EqualFold(str[:len(prefix)], prefix)
+}
@@ -0,0 +1,52 @@
+package notetemplate
+
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	PLACEHOLDER_DATE  string = "date"
+	PLACEHOLDER_TOPIC string = "topic"
+)
+
+const DATE_FORMAT string = "2006-01-02"
+
+// the placeholders look like '{{name}}', the spaces around the name are allowed, e.g. '{{ speaker }}'
+var placeholder = regexp.MustCompile(`\{\{\s*([\p{L}\p{N}_]+)\s*\}\}`)
+
+func isBuiltIn(name string) bool {
+	return name == PLACEHOLDER_DATE || name == PLACEHOLDER_TOPIC
+}
+
+// returns the names of the custom placeholders of the texts in order of appearance without duplicates, the built-in ones are skipped
+func Fields(texts ...string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if isBuiltIn(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// replaces the placeholders with the values, '{{date}}' is the given date unless the value is provided.
+// The placeholders without values are kept as is, so they could be filled in later
+func Render(text string, values map[string]string, date time.Time) string {
+	return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		if name == PLACEHOLDER_DATE {
+			return date.Format(DATE_FORMAT)
+		}
+		return match
+	})
+}
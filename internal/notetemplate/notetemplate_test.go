@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package notetemplate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/notetemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	fields := notetemplate.Fields("Lecture {{ course }}: {{topic}}", "{{date}}\nSpeaker: {{speaker}}\n{{course}} {{ not a field }} {single}")
+
+	assert.Equal(t, []string{"course", "speaker"}, fields)
+	assert.Nil(t, notetemplate.Fields("plain text"))
+}
+
+func TestRender(t *testing.T) {
+	date := time.Date(2022, 9, 1, 10, 30, 0, 0, time.UTC)
+
+	text := notetemplate.Render("# {{topic}}\nDate: {{ date }}\nSpeaker: {{speaker}}\nRoom: {{room}}",
+		map[string]string{"topic": "Graphs", "speaker": "Dr. Smith"}, date)
+
+	assert.Equal(t, "# Graphs\nDate: 2022-09-01\nSpeaker: Dr. Smith\nRoom: {{room}}", text)
+}
+
+func TestRenderOverridesDate(t *testing.T) {
+	text := notetemplate.Render("{{date}}", map[string]string{"date": "yesterday"}, time.Now())
+
+	assert.Equal(t, "yesterday", text)
+}
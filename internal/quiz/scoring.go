@@ -0,0 +1,68 @@
+package quiz
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+)
+
+// compiles the expected answer of the free text question with REGEX match type, the whole answer should match it
+func CompileAnswerPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// checks the answer to the question, the correct answer gets all points of the question and the wrong one gets nothing.
+// The choice question is answered correctly if exactly the correct options are chosen
+func Score(question entities.QuizQuestion, answer entities.QuizAnswer) (bool, int) {
+	var correct bool
+	switch question.Type {
+	case entities.QUIZ_QUESTION_TYPE_SINGLE_CHOICE, entities.QUIZ_QUESTION_TYPE_MULTIPLE_CHOICE:
+		correct = isChoiceCorrect(question, answer.OptionIds)
+	case entities.QUIZ_QUESTION_TYPE_FREE_TEXT:
+		correct = isTextCorrect(question, answer.Text)
+	}
+	if !correct {
+		return false, 0
+	}
+	return true, question.Points
+}
+
+func isChoiceCorrect(question entities.QuizQuestion, optionIds []int) bool {
+	chosen := make(map[int]bool)
+	for _, id := range optionIds {
+		chosen[id] = true
+	}
+	if question.Type == entities.QUIZ_QUESTION_TYPE_SINGLE_CHOICE && len(chosen) != 1 {
+		return false
+	}
+
+	correctCount := 0
+	for _, option := range question.Options {
+		if option.Correct != chosen[option.Id] {
+			return false
+		}
+		if option.Correct {
+			correctCount++
+		}
+	}
+	// the unknown options are chosen
+	return correctCount == len(chosen)
+}
+
+func isTextCorrect(question entities.QuizQuestion, text string) bool {
+	if !question.Answer.Valid {
+		return false
+	}
+	text = strings.TrimSpace(text)
+	switch question.MatchType.String {
+	case entities.QUIZ_MATCH_TYPE_REGEX:
+		pattern, err := CompileAnswerPattern(question.Answer.String)
+		if err != nil {
+			return false
+		}
+		return pattern.MatchString(text)
+	default:
+		return strings.EqualFold(text, strings.TrimSpace(question.Answer.String))
+	}
+}
@@ -0,0 +1,77 @@
+//go:build unit
+// +build unit
+
+package quiz_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/quiz"
+	"github.com/stretchr/testify/assert"
+)
+
+func choiceQuestion(questionType string) entities.QuizQuestion {
+	return entities.QuizQuestion{Type: questionType, Points: 2, Options: []entities.QuizQuestionOption{
+		{Id: 1, Correct: true},
+		{Id: 2, Correct: false},
+		{Id: 3, Correct: questionType == entities.QUIZ_QUESTION_TYPE_MULTIPLE_CHOICE},
+	}}
+}
+
+func TestScoreSingleChoice(t *testing.T) {
+	question := choiceQuestion(entities.QUIZ_QUESTION_TYPE_SINGLE_CHOICE)
+
+	correct, points := quiz.Score(question, entities.QuizAnswer{OptionIds: []int{1}})
+	assert.True(t, correct)
+	assert.Equal(t, 2, points)
+
+	correct, points = quiz.Score(question, entities.QuizAnswer{OptionIds: []int{2}})
+	assert.False(t, correct)
+	assert.Equal(t, 0, points)
+
+	correct, _ = quiz.Score(question, entities.QuizAnswer{OptionIds: []int{1, 2}})
+	assert.False(t, correct)
+
+	correct, _ = quiz.Score(question, entities.QuizAnswer{})
+	assert.False(t, correct)
+}
+
+func TestScoreMultipleChoice(t *testing.T) {
+	question := choiceQuestion(entities.QUIZ_QUESTION_TYPE_MULTIPLE_CHOICE)
+
+	correct, points := quiz.Score(question, entities.QuizAnswer{OptionIds: []int{3, 1, 1}})
+	assert.True(t, correct)
+	assert.Equal(t, 2, points)
+
+	// no partial points
+	correct, _ = quiz.Score(question, entities.QuizAnswer{OptionIds: []int{1}})
+	assert.False(t, correct)
+
+	correct, _ = quiz.Score(question, entities.QuizAnswer{OptionIds: []int{1, 3, 4}})
+	assert.False(t, correct)
+}
+
+func TestScoreFreeText(t *testing.T) {
+	question := entities.QuizQuestion{Type: entities.QUIZ_QUESTION_TYPE_FREE_TEXT, Points: 1,
+		Answer: sql.NullString{String: "Dijkstra", Valid: true}, MatchType: sql.NullString{String: entities.QUIZ_MATCH_TYPE_EXACT, Valid: true}}
+
+	correct, points := quiz.Score(question, entities.QuizAnswer{Text: "  dijkstra "})
+	assert.True(t, correct)
+	assert.Equal(t, 1, points)
+
+	correct, _ = quiz.Score(question, entities.QuizAnswer{Text: "Dijkstra's"})
+	assert.False(t, correct)
+
+	question.Answer.String = "O\\(n ?log ?n\\)"
+	question.MatchType.String = entities.QUIZ_MATCH_TYPE_REGEX
+
+	correct, _ = quiz.Score(question, entities.QuizAnswer{Text: "O(n log n)"})
+	assert.True(t, correct)
+	correct, _ = quiz.Score(question, entities.QuizAnswer{Text: "O(nlogn)"})
+	assert.True(t, correct)
+	// the whole answer should match
+	correct, _ = quiz.Score(question, entities.QuizAnswer{Text: "O(n log n) or O(n)"})
+	assert.False(t, correct)
+}
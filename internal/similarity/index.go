@@ -0,0 +1,203 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+)
+
+// the documents with the frequencies of their terms, the document frequencies and the inverted indexes by the terms, the tags and the links.
+// The changed document is reindexed without the other ones. The index is not safe for concurrent use
+type Index struct {
+	documents   map[int]indexedDocument
+	frequencies map[string]int
+	termIds     map[string]map[int]bool
+	tagIds      map[int]map[int]bool
+	linkingIds  map[int]map[int]bool
+}
+
+// the text is kept as the counts of its terms only
+type indexedDocument struct {
+	document Document
+	terms    map[string]int
+	total    int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		documents:   make(map[int]indexedDocument),
+		frequencies: make(map[string]int),
+		termIds:     make(map[string]map[int]bool),
+		tagIds:      make(map[int]map[int]bool),
+		linkingIds:  make(map[int]map[int]bool),
+	}
+}
+
+// adds the document or replaces the one with the same id
+func (index *Index) Put(document Document) {
+	index.Remove(document.Id)
+
+	indexed := indexedDocument{document: Document{Id: document.Id, TagIds: document.TagIds, LinkedIds: document.LinkedIds}, terms: make(map[string]int)}
+	for _, term := range Tokenize(document.Text) {
+		indexed.terms[term]++
+		indexed.total++
+	}
+	index.documents[document.Id] = indexed
+
+	for term := range indexed.terms {
+		index.frequencies[term]++
+		index.termIds[term] = addId(index.termIds[term], document.Id)
+	}
+	for _, tagId := range document.TagIds {
+		index.tagIds[tagId] = addId(index.tagIds[tagId], document.Id)
+	}
+	for _, linkedId := range document.LinkedIds {
+		index.linkingIds[linkedId] = addId(index.linkingIds[linkedId], document.Id)
+	}
+}
+
+func (index *Index) Remove(id int) {
+	indexed, ok := index.documents[id]
+	if !ok {
+		return
+	}
+	delete(index.documents, id)
+
+	for term := range indexed.terms {
+		index.frequencies[term]--
+		if index.frequencies[term] == 0 {
+			delete(index.frequencies, term)
+		}
+		delete(index.termIds[term], id)
+		if len(index.termIds[term]) == 0 {
+			delete(index.termIds, term)
+		}
+	}
+	for _, tagId := range indexed.document.TagIds {
+		delete(index.tagIds[tagId], id)
+		if len(index.tagIds[tagId]) == 0 {
+			delete(index.tagIds, tagId)
+		}
+	}
+	for _, linkedId := range indexed.document.LinkedIds {
+		delete(index.linkingIds[linkedId], id)
+		if len(index.linkingIds[linkedId]) == 0 {
+			delete(index.linkingIds, linkedId)
+		}
+	}
+}
+
+func (index *Index) Contains(id int) bool {
+	_, ok := index.documents[id]
+	return ok
+}
+
+// returns the ids of the documents with the tag
+func (index *Index) TaggedIds(tagId int) []int {
+	return keys(index.tagIds[tagId])
+}
+
+// returns the ids of the documents linking to the given one
+func (index *Index) LinkingIds(id int) []int {
+	return keys(index.linkingIds[id])
+}
+
+// returns the ids of the documents which could be similar to the given one: the ones sharing a weighted term or a tag with it
+// and the ones linked with it. The document itself is skipped
+func (index *Index) Candidates(id int) []int {
+	indexed, ok := index.documents[id]
+	if !ok {
+		return []int{}
+	}
+
+	candidates := make(map[int]bool)
+	for term := range indexed.terms {
+		// the terms found in every document have no weight
+		if index.frequencies[term] == len(index.documents) {
+			continue
+		}
+		for candidateId := range index.termIds[term] {
+			candidates[candidateId] = true
+		}
+	}
+	for _, tagId := range indexed.document.TagIds {
+		for candidateId := range index.tagIds[tagId] {
+			candidates[candidateId] = true
+		}
+	}
+	for _, linkedId := range indexed.document.LinkedIds {
+		if index.Contains(linkedId) {
+			candidates[linkedId] = true
+		}
+	}
+	for linkingId := range index.linkingIds[id] {
+		candidates[linkingId] = true
+	}
+	delete(candidates, id)
+	return keys(candidates)
+}
+
+// returns the given documents similar to the source, the most similar first. The source itself, the documents which are not indexed
+// and the ones without anything in common are skipped. The inverse document frequencies are calculated over the whole index
+func (index *Index) Rank(sourceId int, ids []int, limit int) []Match {
+	source, ok := index.documents[sourceId]
+	if !ok {
+		return nil
+	}
+	sourceVector := index.vector(source)
+
+	var result []Match
+	for _, id := range ids {
+		document, ok := index.documents[id]
+		if !ok || id == sourceId {
+			continue
+		}
+		match := Match{
+			Id:        id,
+			TagScore:  jaccard(source.document.TagIds, document.document.TagIds),
+			LinkScore: linkScore(source.document, document.document),
+			TextScore: cosine(sourceVector, index.vector(document)),
+		}
+		match.Score = TAG_WEIGHT*match.TagScore + LINK_WEIGHT*match.LinkScore + TEXT_WEIGHT*match.TextScore
+		if match.Score > 0 {
+			result = append(result, match)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Id < result[j].Id
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// TF-IDF vector of the document
+func (index *Index) vector(indexed indexedDocument) map[string]float64 {
+	count := float64(len(index.documents))
+	vector := make(map[string]float64)
+	for term, n := range indexed.terms {
+		vector[term] = float64(n) / float64(indexed.total) * math.Log(count/float64(index.frequencies[term]))
+	}
+	return vector
+}
+
+func addId(set map[int]bool, id int) map[int]bool {
+	if set == nil {
+		set = make(map[int]bool)
+	}
+	set[id] = true
+	return set
+}
+
+func keys(set map[int]bool) []int {
+	result := make([]int, 0, len(set))
+	for id := range set {
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result
+}
@@ -0,0 +1,116 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// the weights of the shared tags, the links and the text in the score, the score is between 0 and 1
+const (
+	TAG_WEIGHT  float64 = 0.4
+	LINK_WEIGHT float64 = 0.3
+	TEXT_WEIGHT float64 = 0.3
+)
+
+// the shorter words are too common to make the texts similar
+const MIN_TERM_LENGTH int = 3
+
+// the note as it is compared with the other ones, the links are the ids of the notes linked by the note
+type Document struct {
+	Id        int
+	Text      string
+	TagIds    []int
+	LinkedIds []int
+}
+
+// the similarity of the document to the source one: the tags score is the share of the common tags (Jaccard index),
+// the link score is 1 if one of the notes links to another one and the text score is the cosine similarity of TF-IDF vectors
+type Match struct {
+	Id        int
+	Score     float64
+	TagScore  float64
+	LinkScore float64
+	TextScore float64
+}
+
+// splits the text into the lowercase words, the short ones are skipped
+func Tokenize(text string) []string {
+	var result []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) >= MIN_TERM_LENGTH {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+// returns the documents of the corpus similar to the source, the most similar first. The source itself and the documents
+// without anything in common are skipped. The inverse document frequencies are calculated over the whole corpus
+func Rank(source Document, corpus []Document, limit int) []Match {
+	index := NewIndex()
+	ids := make([]int, 0, len(corpus))
+	for _, document := range corpus {
+		index.Put(document)
+		ids = append(ids, document.Id)
+	}
+	// the source is counted once even if it is in the corpus
+	index.Put(source)
+	return index.Rank(source.Id, ids, limit)
+}
+
+func cosine(a map[string]float64, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+func jaccard(a []int, b []int) float64 {
+	set := make(map[int]bool)
+	for _, id := range a {
+		set[id] = true
+	}
+	union := len(set)
+	common := 0
+	seen := make(map[int]bool)
+	for _, id := range b {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if set[id] {
+			common++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+func linkScore(a Document, b Document) float64 {
+	for _, id := range a.LinkedIds {
+		if id == b.Id {
+			return 1
+		}
+	}
+	for _, id := range b.LinkedIds {
+		if id == a.Id {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+//go:build unit
+// +build unit
+
+package similarity_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/similarity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"dijkstra", "shortest", "paths", "графы"}, similarity.Tokenize("Dijkstra: shortest-paths in Графы, O(n)"))
+	assert.Nil(t, similarity.Tokenize("a b to"))
+}
+
+func TestRank(t *testing.T) {
+	source := similarity.Document{Id: 1, Text: "Dijkstra algorithm finds shortest paths in graphs", TagIds: []int{10, 11}, LinkedIds: []int{4}}
+	corpus := []similarity.Document{
+		source,
+		{Id: 2, Text: "Bellman-Ford algorithm finds shortest paths with negative weights", TagIds: []int{10, 11}},
+		{Id: 3, Text: "Sorting algorithm compares elements", TagIds: []int{12}},
+		{Id: 4, Text: "Introduction", TagIds: []int{13}},
+		{Id: 5, Text: "Cooking recipes", TagIds: []int{14}},
+	}
+
+	matches := similarity.Rank(source, corpus, 10)
+
+	assert.Equal(t, 3, len(matches))
+	assert.Equal(t, 2, matches[0].Id)
+	assert.Equal(t, 1.0, matches[0].TagScore)
+	assert.Greater(t, matches[0].TextScore, 0.0)
+	assert.Equal(t, 4, matches[1].Id)
+	assert.Equal(t, 1.0, matches[1].LinkScore)
+	assert.Equal(t, 0.0, matches[1].TextScore)
+	assert.Equal(t, 3, matches[2].Id)
+	assert.Equal(t, 0.0, matches[2].TagScore)
+	assert.Greater(t, matches[2].TextScore, 0.0)
+
+	assert.Equal(t, 1, len(similarity.Rank(source, corpus, 1)))
+}
+
+func TestRankByBacklink(t *testing.T) {
+	source := similarity.Document{Id: 1, Text: "Graphs"}
+	corpus := []similarity.Document{{Id: 2, Text: "Trees", LinkedIds: []int{1}}}
+
+	matches := similarity.Rank(source, corpus, 10)
+
+	assert.Equal(t, []similarity.Match{{Id: 2, Score: similarity.LINK_WEIGHT, LinkScore: 1}}, matches)
+}
+
+func TestIndex(t *testing.T) {
+	index := similarity.NewIndex()
+	index.Put(similarity.Document{Id: 1, Text: "Dijkstra algorithm finds shortest paths", TagIds: []int{10}})
+	index.Put(similarity.Document{Id: 2, Text: "Bellman-Ford algorithm finds shortest paths", LinkedIds: []int{3}})
+	index.Put(similarity.Document{Id: 3, Text: "Cooking recipes", TagIds: []int{10}})
+	index.Put(similarity.Document{Id: 4, Text: "Gardening"})
+
+	assert.Equal(t, []int{2, 3}, index.Candidates(1))
+	assert.Equal(t, []int{1, 3}, index.Candidates(2))
+	assert.Equal(t, []int{1, 2}, index.Candidates(3))
+	assert.Equal(t, []int{}, index.Candidates(4))
+	assert.Equal(t, []int{1, 3}, index.TaggedIds(10))
+	assert.Equal(t, []int{2}, index.LinkingIds(3))
+
+	// the replaced document is reindexed
+	index.Put(similarity.Document{Id: 2, Text: "Gardening tools"})
+	assert.Equal(t, []int{3}, index.Candidates(1))
+	assert.Equal(t, []int{4}, index.Candidates(2))
+	assert.Equal(t, []int{}, index.LinkingIds(3))
+
+	index.Remove(3)
+	assert.False(t, index.Contains(3))
+	assert.Equal(t, []int{}, index.Candidates(1))
+	assert.Equal(t, []int{1}, index.TaggedIds(10))
+	assert.Equal(t, []int{}, index.Candidates(3))
+}
+
+func TestIndexRank(t *testing.T) {
+	source := similarity.Document{Id: 1, Text: "Dijkstra algorithm finds shortest paths in graphs", TagIds: []int{10, 11}, LinkedIds: []int{4}}
+	corpus := []similarity.Document{
+		{Id: 2, Text: "Bellman-Ford algorithm finds shortest paths with negative weights", TagIds: []int{10, 11}},
+		{Id: 3, Text: "Sorting algorithm compares elements", TagIds: []int{12}},
+		{Id: 4, Text: "Introduction", TagIds: []int{13}},
+		{Id: 5, Text: "Cooking recipes", TagIds: []int{14}},
+	}
+	index := similarity.NewIndex()
+	index.Put(source)
+	for _, document := range corpus {
+		index.Put(document)
+	}
+
+	// the ranking by the index matches the ranking of the whole corpus
+	assert.Equal(t, similarity.Rank(source, corpus, 10), index.Rank(1, index.Candidates(1), 10))
+	assert.Equal(t, []int{2, 3, 4}, index.Candidates(1))
+	assert.Nil(t, index.Rank(6, []int{1, 2}, 10))
+}
@@ -0,0 +1,70 @@
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	GRADE_MIN int = 0
+	GRADE_MAX int = 5
+	// the answer with the grade less than this one is considered as forgotten
+	GRADE_PASSING int = 3
+
+	INITIAL_EASINESS_FACTOR float64 = 2.5
+	MIN_EASINESS_FACTOR     float64 = 1.3
+
+	FIRST_INTERVAL_DAYS  int = 1
+	SECOND_INTERVAL_DAYS int = 6
+)
+
+type Schedule struct {
+	Repetitions    int
+	IntervalDays   int
+	EasinessFactor float64
+}
+
+// the schedule of the card which has never been reviewed
+func NewSchedule() Schedule {
+	return Schedule{Repetitions: 0, IntervalDays: 0, EasinessFactor: INITIAL_EASINESS_FACTOR}
+}
+
+// returns the next schedule of the card after the answer with the given grade (0-5),
+// see SM-2 algorithm description: https://super-memory.com/english/ol/sm2.htm
+func Review(schedule Schedule, grade int) Schedule {
+	if grade < GRADE_MIN {
+		grade = GRADE_MIN
+	}
+	if grade > GRADE_MAX {
+		grade = GRADE_MAX
+	}
+
+	result := schedule
+	if grade >= GRADE_PASSING {
+		switch schedule.Repetitions {
+		case 0:
+			result.IntervalDays = FIRST_INTERVAL_DAYS
+		case 1:
+			result.IntervalDays = SECOND_INTERVAL_DAYS
+		default:
+			result.IntervalDays = int(math.Round(float64(schedule.IntervalDays) * schedule.EasinessFactor))
+		}
+		result.Repetitions = schedule.Repetitions + 1
+	} else {
+		result.Repetitions = 0
+		result.IntervalDays = FIRST_INTERVAL_DAYS
+	}
+
+	q := float64(GRADE_MAX - grade)
+	result.EasinessFactor = schedule.EasinessFactor + (0.1 - q*(0.08+q*0.02))
+	if result.EasinessFactor < MIN_EASINESS_FACTOR {
+		result.EasinessFactor = MIN_EASINESS_FACTOR
+	}
+
+	return result
+}
+
+// returns the date of the next review
+func (s Schedule) DueDate(reviewDate time.Time) time.Time {
+	return reviewDate.AddDate(0, 0, s.IntervalDays)
+}
@@ -0,0 +1,48 @@
+//go:build unit
+// +build unit
+
+package srs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/srs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewSuccessfulAnswers(t *testing.T) {
+	schedule := srs.NewSchedule()
+
+	schedule = srs.Review(schedule, 5)
+	assert.Equal(t, 1, schedule.Repetitions)
+	assert.Equal(t, 1, schedule.IntervalDays)
+	assert.InDelta(t, 2.6, schedule.EasinessFactor, 0.0001)
+
+	schedule = srs.Review(schedule, 4)
+	assert.Equal(t, 2, schedule.Repetitions)
+	assert.Equal(t, 6, schedule.IntervalDays)
+	assert.InDelta(t, 2.6, schedule.EasinessFactor, 0.0001)
+
+	schedule = srs.Review(schedule, 3)
+	assert.Equal(t, 3, schedule.Repetitions)
+	assert.Equal(t, 16, schedule.IntervalDays) // round(6 * 2.6)
+	assert.InDelta(t, 2.46, schedule.EasinessFactor, 0.0001)
+}
+
+func TestReviewForgottenAnswer(t *testing.T) {
+	schedule := srs.Schedule{Repetitions: 4, IntervalDays: 30, EasinessFactor: 1.4}
+
+	schedule = srs.Review(schedule, 1)
+
+	assert.Equal(t, 0, schedule.Repetitions)
+	assert.Equal(t, 1, schedule.IntervalDays)
+	assert.Equal(t, srs.MIN_EASINESS_FACTOR, schedule.EasinessFactor)
+}
+
+func TestDueDate(t *testing.T) {
+	reviewDate := time.Date(2022, 7, 30, 10, 0, 0, 0, time.UTC)
+	schedule := srs.Schedule{Repetitions: 2, IntervalDays: 6, EasinessFactor: 2.5}
+
+	assert.Equal(t, time.Date(2022, 8, 5, 10, 0, 0, 0, time.UTC), schedule.DueDate(reviewDate))
+}
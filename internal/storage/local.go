@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type LocalStorage struct {
+	basePath string
+}
+
+type localBlob struct {
+	*os.File
+	size int64
+}
+
+func (p *localBlob) Size() int64 {
+	return p.size
+}
+
+func CreateLocalStorage(basePath string) *LocalStorage {
+	return &LocalStorage{basePath: basePath}
+}
+
+// blobs are spread over subdirectories by the first two chars of the key to keep directories small
+func (p *LocalStorage) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(p.basePath, key)
+	}
+	return filepath.Join(p.basePath, key[:2], key)
+}
+
+func (p *LocalStorage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	target := p.path(key)
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error at putting blob '%s' into local storage, case after creating dir: %s", key, err)
+	}
+
+	// write into temporary file first, so readers never see a partially written blob
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error at putting blob '%s' into local storage, case after creating temp file: %s", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error at putting blob '%s' into local storage, case after writing: %s", key, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("error at putting blob '%s' into local storage, expected %d bytes, but written %d", key, size, written)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("error at putting blob '%s' into local storage, case after renaming temp file: %s", key, err)
+	}
+	return nil
+}
+
+func (p *LocalStorage) Open(ctx context.Context, key string) (Blob, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrorBlobNotFound
+		}
+		return nil, fmt.Errorf("error at opening blob '%s' from local storage: %s", key, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error at opening blob '%s' from local storage, case after stat: %s", key, err)
+	}
+	return &localBlob{File: file, size: info.Size()}, nil
+}
+
+func (p *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	_, err := os.Stat(p.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error at checking blob '%s' in local storage: %s", key, err)
+	}
+	return true, nil
+}
+
+func (p *LocalStorage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(p.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error at deleting blob '%s' from local storage: %s", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	local := storage.CreateLocalStorage(t.TempDir())
+	ctx := context.Background()
+	content := []byte("0123456789abcdef")
+
+	err := local.Put(ctx, "0a1b2c", bytes.NewReader(content), int64(len(content)), "text/plain")
+	assert.Nil(t, err)
+
+	exists, err := local.Exists(ctx, "0a1b2c")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	blob, err := local.Open(ctx, "0a1b2c")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), blob.Size())
+
+	_, err = blob.Seek(10, io.SeekStart)
+	assert.Nil(t, err)
+	tail, err := io.ReadAll(blob)
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef", string(tail))
+	assert.Nil(t, blob.Close())
+
+	assert.Nil(t, local.Delete(ctx, "0a1b2c"))
+	assert.Nil(t, local.Delete(ctx, "0a1b2c"))
+
+	_, err = local.Open(ctx, "0a1b2c")
+	assert.Equal(t, storage.ErrorBlobNotFound, err)
+}
+
+func TestLocalStorageSizeMismatch(t *testing.T) {
+	local := storage.CreateLocalStorage(t.TempDir())
+	ctx := context.Background()
+
+	err := local.Put(ctx, "0a1b2c", bytes.NewReader([]byte("abc")), 10, "text/plain")
+	assert.NotNil(t, err)
+
+	exists, err := local.Exists(ctx, "0a1b2c")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
+
+func TestLocalStorageWrongKey(t *testing.T) {
+	local := storage.CreateLocalStorage(t.TempDir())
+
+	_, err := local.Open(context.Background(), "../secret")
+
+	assert.Equal(t, storage.ErrorBlobKeyWrongFormat, err)
+}
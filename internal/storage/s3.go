@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	S3_UNSIGNED_PAYLOAD string = "UNSIGNED-PAYLOAD"
+	S3_EMPTY_PAYLOAD    string = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256 of empty string
+)
+
+// S3Storage works with any S3-compatible service (AWS S3, MinIO, etc.) via path-style URLs and Signature Version 4
+type S3Storage struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+type s3Blob struct {
+	storage *S3Storage
+	ctx     context.Context
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func CreateS3Storage(endpoint string, region string, bucket string, accessKey string, secretKey string) *S3Storage {
+	return &S3Storage{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    http.DefaultClient,
+		now:       time.Now,
+	}
+}
+
+func (p *S3Storage) objectURL(key string) string {
+	return p.endpoint + "/" + url.PathEscape(p.bucket) + "/" + url.PathEscape(key)
+}
+
+func (p *S3Storage) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, p.objectURL(key), body)
+}
+
+func (p *S3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	p.sign(req, payloadHash)
+	return p.client.Do(req)
+}
+
+func (p *S3Storage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := p.newRequest(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return fmt.Errorf("error at putting blob '%s' into s3 storage, case after creating request: %s", key, err)
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := p.do(req, S3_UNSIGNED_PAYLOAD)
+	if err != nil {
+		return fmt.Errorf("error at putting blob '%s' into s3 storage: %s", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error at putting blob '%s' into s3 storage, unexpected status: %s", key, readS3Error(resp))
+	}
+	return nil
+}
+
+func (p *S3Storage) head(ctx context.Context, key string) (int64, error) {
+	req, err := p.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return -1, err
+	}
+	resp, err := p.do(req, S3_EMPTY_PAYLOAD)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return -1, ErrorBlobNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return -1, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.ContentLength, nil
+}
+
+func (p *S3Storage) Open(ctx context.Context, key string) (Blob, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	size, err := p.head(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrorBlobNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error at opening blob '%s' from s3 storage: %s", key, err)
+	}
+	return &s3Blob{storage: p, ctx: ctx, key: key, size: size}, nil
+}
+
+func (p *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	_, err := p.head(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrorBlobNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error at checking blob '%s' in s3 storage: %s", key, err)
+	}
+	return true, nil
+}
+
+func (p *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := p.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("error at deleting blob '%s' from s3 storage, case after creating request: %s", key, err)
+	}
+	resp, err := p.do(req, S3_EMPTY_PAYLOAD)
+	if err != nil {
+		return fmt.Errorf("error at deleting blob '%s' from s3 storage: %s", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error at deleting blob '%s' from s3 storage, unexpected status: %s", key, readS3Error(resp))
+	}
+	return nil
+}
+
+func (p *s3Blob) Size() int64 {
+	return p.size
+}
+
+// the object body is requested lazily starting from the current offset, so seeking is free until the next read
+func (p *s3Blob) Read(b []byte) (int, error) {
+	if p.offset >= p.size {
+		return 0, io.EOF
+	}
+	if p.body == nil {
+		req, err := p.storage.newRequest(p.ctx, http.MethodGet, p.key, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(p.offset, 10)+"-")
+		resp, err := p.storage.do(req, S3_EMPTY_PAYLOAD)
+		if err != nil {
+			return 0, fmt.Errorf("error at reading blob '%s' from s3 storage: %s", p.key, err)
+		}
+		if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return 0, fmt.Errorf("error at reading blob '%s' from s3 storage, unexpected status: %s", p.key, readS3Error(resp))
+		}
+		p.body = resp.Body
+	}
+	n, err := p.body.Read(b)
+	p.offset += int64(n)
+	return n, err
+}
+
+func (p *s3Blob) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = p.offset + offset
+	case io.SeekEnd:
+		target = p.size + offset
+	default:
+		return p.offset, errors.New("s3 blob seek: invalid whence")
+	}
+	if target < 0 {
+		return p.offset, errors.New("s3 blob seek: negative position")
+	}
+	if target != p.offset {
+		p.closeBody()
+		p.offset = target
+	}
+	return target, nil
+}
+
+func (p *s3Blob) Close() error {
+	return p.closeBody()
+}
+
+func (p *s3Blob) closeBody() error {
+	if p.body == nil {
+		return nil
+	}
+	err := p.body.Close()
+	p.body = nil
+	return err
+}
+
+func readS3Error(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Sprintf("%s %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (p *S3Storage) sign(req *http.Request, payloadHash string) {
+	now := p.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "range" || lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + p.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	signingKey = hmacSHA256(signingKey, p.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+p.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
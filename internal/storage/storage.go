@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
+)
+
+const (
+	STORAGE_TYPE_LOCAL string = "LOCAL"
+	STORAGE_TYPE_S3    string = "S3"
+)
+
+var ErrorBlobNotFound = errors.New("blob not found")
+var ErrorBlobKeyWrongFormat = errors.New("blob key has wrong format")
+
+var keyRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Blob is an opened stored object, it supports seeking, so it could be served with HTTP Range requests
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (Blob, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var once sync.Once
+var instance Storage
+
+func GetInstance() Storage {
+	once.Do(func() {
+		if instance == nil {
+			instance = createStorage()
+		}
+	})
+	return instance
+}
+
+func createStorage() Storage {
+	storageType := utils.EnvVarDefault("STORAGE_TYPE", STORAGE_TYPE_LOCAL)
+	switch storageType {
+	case STORAGE_TYPE_LOCAL:
+		return CreateLocalStorage(utils.EnvVarDefault("STORAGE_LOCAL_PATH", "./data/blobs"))
+	case STORAGE_TYPE_S3:
+		return CreateS3Storage(
+			utils.EnvVar("STORAGE_S3_ENDPOINT"),
+			utils.EnvVarDefault("STORAGE_S3_REGION", "us-east-1"),
+			utils.EnvVar("STORAGE_S3_BUCKET"),
+			utils.EnvVar("STORAGE_S3_ACCESS_KEY"),
+			utils.EnvVar("STORAGE_S3_SECRET_KEY"),
+		)
+	}
+	log.Fatalf("Wrong value of environment variable: STORAGE_TYPE. Possible values: %v", []string{STORAGE_TYPE_LOCAL, STORAGE_TYPE_S3})
+	return nil
+}
+
+func validateKey(key string) error {
+	if !keyRegexp.MatchString(key) || key == "." || key == ".." {
+		return ErrorBlobKeyWrongFormat
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package timetracking
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/activity"
+)
+
+const (
+	POMODORO_MINUTES            int = 25
+	SHORT_BREAK_MINUTES         int = 5
+	LONG_BREAK_MINUTES          int = 15
+	POMODOROS_BEFORE_LONG_BREAK int = 4
+)
+
+const (
+	GROUP_BY_DAY  string = "day"
+	GROUP_BY_WEEK string = "week"
+	GROUP_BY_TAG  string = "tag"
+)
+
+func GetPossibleGroupings() []string {
+	return []string{GROUP_BY_DAY, GROUP_BY_WEEK, GROUP_BY_TAG}
+}
+
+// the studying interval with the tags of the linked note
+type Span struct {
+	Start time.Time
+	End   time.Time
+	Tags  []string
+}
+
+// the key is the date of the day, the date of the first day of the week or the tag name.
+// The time of the spans without tags is grouped under the empty key
+type Group struct {
+	Key     string
+	Seconds int64
+}
+
+type Report struct {
+	TotalSeconds int64
+	Groups       []Group
+}
+
+// returns the length of the break after the pomodoro, every fourth one is followed by the long break
+func NextBreakMinutes(pomodorosFinished int) int {
+	if pomodorosFinished > 0 && pomodorosFinished%POMODOROS_BEFORE_LONG_BREAK == 0 {
+		return LONG_BREAK_MINUTES
+	}
+	return SHORT_BREAK_MINUTES
+}
+
+// returns the beginning of the day in the location
+func DayStart(date time.Time, loc *time.Location) time.Time {
+	date = date.In(loc)
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// aggregates the time of the spans between the beginnings of the days 'from' and 'to' (inclusive) in the location.
+// The spans crossing the midnight are split between the days, every day and week of the range is given even if it is empty
+func Summarize(spans []Span, from time.Time, to time.Time, groupBy string, loc *time.Location) Report {
+	rangeStart := DayStart(from, loc)
+	rangeEnd := nextDay(DayStart(to, loc))
+
+	var report Report
+	for _, span := range spans {
+		report.TotalSeconds += overlap(span, rangeStart, rangeEnd)
+	}
+
+	switch groupBy {
+	case GROUP_BY_TAG:
+		report.Groups = groupByTag(spans, rangeStart, rangeEnd)
+	case GROUP_BY_WEEK:
+		for weekStart := activity.WeekStart(rangeStart); weekStart.Before(rangeEnd); weekStart = weekStart.AddDate(0, 0, 7) {
+			report.Groups = append(report.Groups, group(spans, weekStart.Format(activity.DAY_LAYOUT), later(weekStart, rangeStart), earlier(weekStart.AddDate(0, 0, 7), rangeEnd)))
+		}
+	default:
+		for day := rangeStart; day.Before(rangeEnd); day = nextDay(day) {
+			report.Groups = append(report.Groups, group(spans, day.Format(activity.DAY_LAYOUT), day, nextDay(day)))
+		}
+	}
+	return report
+}
+
+func groupByTag(spans []Span, start time.Time, end time.Time) []Group {
+	seconds := make(map[string]int64)
+	for _, span := range spans {
+		duration := overlap(span, start, end)
+		if duration == 0 {
+			continue
+		}
+		if len(span.Tags) == 0 {
+			seconds[""] += duration
+		}
+		for _, tag := range span.Tags {
+			seconds[tag] += duration
+		}
+	}
+
+	result := make([]Group, 0, len(seconds))
+	for key, value := range seconds {
+		result = append(result, Group{Key: key, Seconds: value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Seconds != result[j].Seconds {
+			return result[i].Seconds > result[j].Seconds
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func group(spans []Span, key string, start time.Time, end time.Time) Group {
+	result := Group{Key: key}
+	for _, span := range spans {
+		result.Seconds += overlap(span, start, end)
+	}
+	return result
+}
+
+// returns the seconds of the span within the interval
+func overlap(span Span, start time.Time, end time.Time) int64 {
+	from := later(span.Start, start)
+	to := earlier(span.End, end)
+	if !to.After(from) {
+		return 0
+	}
+	return int64(to.Sub(from) / time.Second)
+}
+
+// the days are added to the date in the location, so the days of daylight saving time changes are not 24 hours long
+func nextDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
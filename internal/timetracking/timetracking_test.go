@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package timetracking_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/timetracking"
+	"github.com/stretchr/testify/assert"
+)
+
+func span(start time.Time, minutes int, tags ...string) timetracking.Span {
+	return timetracking.Span{Start: start, End: start.Add(time.Duration(minutes) * time.Minute), Tags: tags}
+}
+
+func TestSummarizeByDay(t *testing.T) {
+	from := time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 8, 10, 0, 0, 0, 0, time.UTC)
+	spans := []timetracking.Span{
+		span(time.Date(2022, 8, 8, 10, 0, 0, 0, time.UTC), 30),
+		// crosses the midnight
+		span(time.Date(2022, 8, 9, 23, 30, 0, 0, time.UTC), 60),
+		// out of the range
+		span(time.Date(2022, 8, 11, 10, 0, 0, 0, time.UTC), 60),
+	}
+
+	report := timetracking.Summarize(spans, from, to, timetracking.GROUP_BY_DAY, time.UTC)
+	assert.Equal(t, int64(90*60), report.TotalSeconds)
+	assert.Equal(t, []timetracking.Group{
+		{Key: "2022-08-08", Seconds: 30 * 60},
+		{Key: "2022-08-09", Seconds: 30 * 60},
+		{Key: "2022-08-10", Seconds: 30 * 60},
+	}, report.Groups)
+}
+
+func TestSummarizeInTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+	day := time.Date(2022, 8, 9, 0, 0, 0, 0, loc)
+	// it is the 9th of August in Tokyo already
+	spans := []timetracking.Span{span(time.Date(2022, 8, 8, 20, 0, 0, 0, time.UTC), 45)}
+
+	report := timetracking.Summarize(spans, day, day, timetracking.GROUP_BY_DAY, loc)
+	assert.Equal(t, []timetracking.Group{{Key: "2022-08-09", Seconds: 45 * 60}}, report.Groups)
+}
+
+func TestSummarizeByWeek(t *testing.T) {
+	from := time.Date(2022, 8, 10, 0, 0, 0, 0, time.UTC) // Wednesday
+	to := time.Date(2022, 8, 16, 0, 0, 0, 0, time.UTC)
+	spans := []timetracking.Span{
+		// before the range, but in the same week
+		span(time.Date(2022, 8, 8, 10, 0, 0, 0, time.UTC), 60),
+		span(time.Date(2022, 8, 12, 10, 0, 0, 0, time.UTC), 20),
+		span(time.Date(2022, 8, 15, 10, 0, 0, 0, time.UTC), 40),
+	}
+
+	report := timetracking.Summarize(spans, from, to, timetracking.GROUP_BY_WEEK, time.UTC)
+	assert.Equal(t, int64(60*60), report.TotalSeconds)
+	assert.Equal(t, []timetracking.Group{
+		{Key: "2022-08-08", Seconds: 20 * 60},
+		{Key: "2022-08-15", Seconds: 40 * 60},
+	}, report.Groups)
+}
+
+func TestSummarizeByTag(t *testing.T) {
+	day := time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)
+	spans := []timetracking.Span{
+		span(day.Add(time.Hour), 30, "math", "graphs"),
+		span(day.Add(2*time.Hour), 20, "math"),
+		span(day.Add(3*time.Hour), 10),
+	}
+
+	report := timetracking.Summarize(spans, day, day, timetracking.GROUP_BY_TAG, time.UTC)
+	// the span with several tags is counted for each of them, but once in total
+	assert.Equal(t, int64(60*60), report.TotalSeconds)
+	assert.Equal(t, []timetracking.Group{
+		{Key: "math", Seconds: 50 * 60},
+		{Key: "graphs", Seconds: 30 * 60},
+		{Key: "", Seconds: 10 * 60},
+	}, report.Groups)
+}
+
+func TestNextBreakMinutes(t *testing.T) {
+	assert.Equal(t, timetracking.SHORT_BREAK_MINUTES, timetracking.NextBreakMinutes(1))
+	assert.Equal(t, timetracking.SHORT_BREAK_MINUTES, timetracking.NextBreakMinutes(3))
+	assert.Equal(t, timetracking.LONG_BREAK_MINUTES, timetracking.NextBreakMinutes(4))
+	assert.Equal(t, timetracking.SHORT_BREAK_MINUTES, timetracking.NextBreakMinutes(5))
+}
@@ -0,0 +1,57 @@
+package wordfilter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// matches the whole words and phrases of the list ignoring the case, e.g. 'spam' is found in 'Spam!' but not in 'spammer'
+type Filter struct {
+	words    []string
+	patterns []*regexp.Regexp
+}
+
+// the words are trimmed and the empty ones are skipped, the words of the phrase are matched with any whitespace between them
+func New(words []string) *Filter {
+	filter := &Filter{}
+	seen := make(map[string]bool)
+	for _, word := range words {
+		word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		var parts []string
+		for _, part := range strings.Fields(word) {
+			parts = append(parts, regexp.QuoteMeta(part))
+		}
+		pattern := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])` + strings.Join(parts, `\s+`) + `(?:$|[^\p{L}\p{N}_])`)
+		filter.words = append(filter.words, word)
+		filter.patterns = append(filter.patterns, pattern)
+	}
+	return filter
+}
+
+// parses the comma separated list of the words
+func ParseList(list string) []string {
+	return strings.Split(list, ",")
+}
+
+func (f *Filter) IsEmpty() bool {
+	return len(f.words) == 0
+}
+
+// returns the words of the list found in any of the texts in the order of the list, nil if the texts are clean
+func (f *Filter) Match(texts ...string) []string {
+	var result []string
+	for i, pattern := range f.patterns {
+		for _, text := range texts {
+			if pattern.MatchString(text) {
+				result = append(result, f.words[i])
+				break
+			}
+		}
+	}
+	return result
+}
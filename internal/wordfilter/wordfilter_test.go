@@ -0,0 +1,31 @@
+//go:build unit
+// +build unit
+
+package wordfilter_test
+
+import (
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/wordfilter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMatch(t *testing.T) {
+	filter := wordfilter.New(wordfilter.ParseList(" Spam, buy  now,,c++,spam,плохое"))
+
+	assert.False(t, filter.IsEmpty())
+	assert.Nil(t, filter.Match("clean text", "spammer and antispam"))
+	assert.Equal(t, []string{"spam"}, filter.Match("It is SPAM!"))
+	assert.Equal(t, []string{"spam", "buy now"}, filter.Match("Buy\n now", "spam"))
+	assert.Equal(t, []string{"c++"}, filter.Match("learn c++ today"))
+	assert.Nil(t, filter.Match("learn c+ today"))
+	assert.Equal(t, []string{"плохое"}, filter.Match("Это Плохое слово"))
+	assert.Nil(t, filter.Match("неплохое слово"))
+}
+
+func TestEmptyFilter(t *testing.T) {
+	filter := wordfilter.New(wordfilter.ParseList(""))
+
+	assert.True(t, filter.IsEmpty())
+	assert.Nil(t, filter.Match("any text"))
+}
@@ -6,11 +6,36 @@ import (
 
 	"github.com/gin-contrib/expvar"
 
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/attachments"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/auth"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/bibliography"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/bookmarks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/cards"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/comments"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/courses"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/export"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/imports"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/moderation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notebooks"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notes"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/ping"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/progress"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/quizzes"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/reactions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/related"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/resources"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/reviews"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/sharelinks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/shares"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/tags"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/tasks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/templates"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/timeentries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/trash"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/users"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app/utils"
@@ -22,6 +47,13 @@ func main() {
 
 	app.InitEnv()
 	auth.Setup()
+	attachments.Setup()
+	imports.Setup()
+	moderation.Setup()
+	events.Setup()
+	mentions.Setup()
+	trash.Setup()
+	related.Setup()
 	host := app.GetHost()
 
 	router := gin.Default()
@@ -42,6 +74,9 @@ func main() {
 	}))
 
 	db.GetInstance()
+	notes.StartScheduledPublishing()
+	events.StartListening()
+	trash.StartPurging()
 
 	// TODO: add permission controller by user role and user state
 	// v1 := router.Group("/api/v1", gin.BasicAuth(apiUsers)) // TODO: add auth via jwt, update model accordingly
@@ -50,6 +85,7 @@ func main() {
 	v1.GET("/ping", ping.Ping)
 	v1.POST("/auth/login", auth.Authenicate)
 	v1.POST("/auth/refresh-token", auth.RefreshToken)
+	v1.GET("/shared/:token", sharelinks.GetSharedNote)
 	authorized := router.Group("/api/v1")
 	authorized.Use(app.AuthReqired())
 	{
@@ -69,6 +105,15 @@ func main() {
 		authorized.POST("/tags", tags.CreateTag)
 		authorized.PUT("/tags/:id", tags.UpdateTag)
 		authorized.DELETE("/tags/:id", tags.DeleteTag)
+		authorized.GET("/tags/:id/prerequisites", tags.GetTagPrerequisites)
+		authorized.PUT("/tags/:id/prerequisites/:prerequisiteId", tags.AddTagPrerequisite)
+		authorized.DELETE("/tags/:id/prerequisites/:prerequisiteId", tags.DeleteTagPrerequisite)
+		authorized.GET("/tags/:id/learning-path", tags.GetLearningPath)
+		authorized.GET("/tags/:id/children", tags.GetTagChildren)
+		authorized.PUT("/tags/:id/parent", tags.SetTagParent)
+		authorized.POST("/tags/:id/aliases", tags.CreateTagAlias)
+		authorized.DELETE("/tags/:id/aliases/:aliasId", tags.DeleteTagAlias)
+		authorized.POST("/tags/:id/merge", tags.MergeTag)
 
 		authorized.GET("/users", users.GetUsers)
 		authorized.GET("/users/:id", users.GetUser)
@@ -81,7 +126,158 @@ func main() {
 		authorized.POST("/notes", notes.CreateNote)
 		authorized.PUT("/notes/:id", notes.UpdateNote)
 		authorized.DELETE("/notes/:id", notes.DeleteNote)
+		authorized.DELETE("/notes/:id/schedule", notes.CancelNotePublication)
+
+		authorized.GET("/notes/:id/attachments", attachments.GetAttachments)
+		authorized.GET("/notes/:id/attachments/:attachmentId", attachments.DownloadAttachment)
+		authorized.POST("/notes/:id/attachments", attachments.CreateAttachment)
+		authorized.DELETE("/notes/:id/attachments/:attachmentId", attachments.DeleteAttachment)
+
+		authorized.GET("/notes/:id/shares", shares.GetNoteShares)
+		authorized.POST("/notes/:id/shares", shares.CreateNoteShare)
+		authorized.DELETE("/notes/:id/shares/:userId", shares.DeleteNoteShare)
+
+		authorized.GET("/notes/:id/share-links", sharelinks.GetNoteShareLinks)
+		authorized.POST("/notes/:id/share-links", sharelinks.CreateNoteShareLink)
+		authorized.DELETE("/notes/:id/share-links/:linkId", sharelinks.RevokeNoteShareLink)
+
+		authorized.GET("/notes/:id/links", links.GetNoteLinks)
+		authorized.GET("/notes/:id/backlinks", links.GetNoteBacklinks)
+		authorized.GET("/graph", links.GetGraph)
+		authorized.GET("/notes/:id/related", related.GetRelatedNotes)
+
+		authorized.GET("/notes/:id/cards", cards.GetNoteCards)
+		authorized.POST("/notes/:id/cards", cards.CreateNoteCard)
+		authorized.POST("/notes/:id/cards/extract", cards.ExtractNoteCards)
+		authorized.PUT("/notes/:id/cards/:cardId", cards.UpdateNoteCard)
+		authorized.DELETE("/notes/:id/cards/:cardId", cards.DeleteNoteCard)
+		authorized.GET("/reviews/due", reviews.GetDueCards)
+		authorized.GET("/reviews/stats", reviews.GetReviewStats)
+		authorized.POST("/reviews/:cardId", reviews.ReviewCard)
+
+		authorized.GET("/courses", courses.GetCourses)
+		authorized.POST("/courses", courses.CreateCourse)
+		authorized.GET("/courses/:id", courses.GetCourse)
+		authorized.PUT("/courses/:id", courses.UpdateCourse)
+		authorized.DELETE("/courses/:id", courses.DeleteCourse)
+		authorized.POST("/courses/:id/modules", courses.CreateCourseModule)
+		authorized.PUT("/courses/:id/modules/order", courses.OrderCourseModules)
+		authorized.PUT("/courses/:id/modules/:moduleId", courses.UpdateCourseModule)
+		authorized.DELETE("/courses/:id/modules/:moduleId", courses.DeleteCourseModule)
+		authorized.POST("/courses/:id/modules/:moduleId/items", courses.CreateCourseItem)
+		authorized.PUT("/courses/:id/modules/:moduleId/items/order", courses.OrderCourseItems)
+		authorized.DELETE("/courses/:id/modules/:moduleId/items/:itemId", courses.DeleteCourseItem)
+		authorized.POST("/courses/:id/enrollment", courses.EnrollCourse)
+		authorized.DELETE("/courses/:id/enrollment", courses.LeaveCourse)
+		authorized.GET("/courses/:id/progress", courses.GetCourseProgress)
+		authorized.PUT("/courses/:id/progress/position", courses.SetCoursePosition)
+		authorized.POST("/courses/:id/items/:itemId/completion", courses.CompleteCourseItem)
+		authorized.DELETE("/courses/:id/items/:itemId/completion", courses.UncompleteCourseItem)
+		authorized.GET("/me/courses", courses.GetUserCourses)
+
+		authorized.GET("/notes/:id/quizzes", quizzes.GetNoteQuizzes)
+		authorized.GET("/courses/:id/quizzes", quizzes.GetCourseQuizzes)
+		authorized.POST("/quizzes", quizzes.CreateQuiz)
+		authorized.GET("/quizzes/:id", quizzes.GetQuiz)
+		authorized.PUT("/quizzes/:id", quizzes.UpdateQuiz)
+		authorized.DELETE("/quizzes/:id", quizzes.DeleteQuiz)
+		authorized.POST("/quizzes/:id/questions", quizzes.CreateQuizQuestion)
+		authorized.PUT("/quizzes/:id/questions/:questionId", quizzes.UpdateQuizQuestion)
+		authorized.DELETE("/quizzes/:id/questions/:questionId", quizzes.DeleteQuizQuestion)
+		authorized.GET("/quizzes/:id/stats", quizzes.GetQuizStats)
+		authorized.POST("/quizzes/:id/attempts", quizzes.StartQuizAttempt)
+		authorized.GET("/quizzes/:id/attempts", quizzes.GetQuizAttempts)
+		authorized.GET("/quizzes/:id/attempts/:attemptId", quizzes.GetQuizAttempt)
+		authorized.POST("/quizzes/:id/attempts/:attemptId/submit", quizzes.SubmitQuizAttempt)
+
+		authorized.GET("/me/progress", progress.GetProgress)
+		authorized.PUT("/me/goals", progress.SetGoals)
+		authorized.PUT("/me/timezone", progress.SetTimezone)
+
+		authorized.GET("/me/time-entries", timeentries.GetTimeEntries)
+		authorized.POST("/me/time-entries", timeentries.CreateTimeEntry)
+		authorized.PUT("/me/time-entries/:id", timeentries.UpdateTimeEntry)
+		authorized.DELETE("/me/time-entries/:id", timeentries.DeleteTimeEntry)
+		authorized.GET("/me/time-entries/timer", timeentries.GetTimer)
+		authorized.POST("/me/time-entries/timer/start", timeentries.StartTimer)
+		authorized.POST("/me/time-entries/timer/stop", timeentries.StopTimer)
+		authorized.GET("/me/reports/time", timeentries.GetTimeReport)
+
+		authorized.GET("/resources", resources.GetResources)
+		authorized.GET("/resources/:id", resources.GetResource)
+		authorized.POST("/resources", resources.CreateResource)
+		authorized.PUT("/resources/:id", resources.UpdateResource)
+		authorized.DELETE("/resources/:id", resources.DeleteResource)
+		authorized.PUT("/resources/:id/progress", resources.UpdateResourceProgress)
+		authorized.GET("/notes/:id/resources", resources.GetNoteResources)
+
+		authorized.GET("/bibliography", bibliography.GetBibEntries)
+		authorized.GET("/bibliography/:id", bibliography.GetBibEntry)
+		authorized.POST("/bibliography", bibliography.CreateBibEntry)
+		authorized.PUT("/bibliography/:id", bibliography.UpdateBibEntry)
+		authorized.DELETE("/bibliography/:id", bibliography.DeleteBibEntry)
+		authorized.POST("/bibliography/import", bibliography.ImportBibliography)
+		authorized.GET("/bibliography/export", bibliography.ExportBibliography)
+		authorized.GET("/notes/:id/references", bibliography.GetNoteReferences)
+		authorized.GET("/notes/:id/render", bibliography.RenderNote)
+
+		authorized.GET("/notes/:id/comments", comments.GetNoteComments)
+		authorized.POST("/notes/:id/comments", comments.CreateComment)
+		authorized.PUT("/comments/:id", comments.UpdateComment)
+		authorized.DELETE("/comments/:id", comments.DeleteComment)
+
+		authorized.POST("/notes/:id/reports", moderation.ReportNote)
+		authorized.POST("/comments/:id/reports", moderation.ReportComment)
+		authorized.GET("/moderation/queue", moderation.GetModerationQueue)
+		authorized.GET("/moderation/log", moderation.GetModerationLog)
+		authorized.POST("/moderation/:type/:id/approve", moderation.ApproveContent)
+		authorized.POST("/moderation/:type/:id/block", moderation.BlockContent)
+		authorized.POST("/moderation/:type/:id/dismiss", moderation.DismissContent)
+
+		authorized.GET("/me/notifications", notifications.GetNotifications)
+		authorized.POST("/me/notifications/read", notifications.MarkAllNotificationsRead)
+		authorized.POST("/me/notifications/:id/read", notifications.MarkNotificationRead)
+		authorized.GET("/me/notification-preferences", notifications.GetNotificationPreferences)
+		authorized.PUT("/me/notification-preferences", notifications.SetNotificationPreferences)
+		authorized.GET("/me/mentions", mentions.GetMentions)
+
+		authorized.PUT("/notes/:id/reactions/:type", reactions.AddNoteReaction)
+		authorized.DELETE("/notes/:id/reactions/:type", reactions.DeleteNoteReaction)
+		authorized.PUT("/notes/:id/bookmark", bookmarks.SetBookmark)
+		authorized.DELETE("/notes/:id/bookmark", bookmarks.DeleteBookmark)
+		authorized.GET("/me/bookmarks", bookmarks.GetBookmarks)
+		authorized.GET("/me/bookmark-folders", bookmarks.GetBookmarkFolders)
+		authorized.POST("/me/bookmark-folders", bookmarks.CreateBookmarkFolder)
+		authorized.PUT("/me/bookmark-folders/:id", bookmarks.UpdateBookmarkFolder)
+		authorized.DELETE("/me/bookmark-folders/:id", bookmarks.DeleteBookmarkFolder)
+
+		authorized.GET("/notebooks", notebooks.GetNotebooks)
+		authorized.POST("/notebooks", notebooks.CreateNotebook)
+		authorized.GET("/notebooks/:id", notebooks.GetNotebook)
+		authorized.PUT("/notebooks/:id", notebooks.UpdateNotebook)
+		authorized.DELETE("/notebooks/:id", notebooks.DeleteNotebook)
+		authorized.GET("/notebooks/:id/tree", notebooks.GetNotebookTree)
+		authorized.PUT("/notebooks/:id/parent", notebooks.MoveNotebook)
+		authorized.PUT("/notebooks/:id/children/order", notebooks.OrderNotebookChildren)
+		authorized.PUT("/notebooks/:id/notes/order", notebooks.OrderNotebookNotes)
+		authorized.PUT("/notes/:id/notebook", notebooks.SetNoteNotebook)
+		authorized.DELETE("/notes/:id/notebook", notebooks.DeleteNoteNotebook)
+
+		authorized.GET("/note-templates", templates.GetNoteTemplates)
+		authorized.POST("/note-templates", templates.CreateNoteTemplate)
+		authorized.GET("/note-templates/:id", templates.GetNoteTemplate)
+		authorized.PUT("/note-templates/:id", templates.UpdateNoteTemplate)
+		authorized.DELETE("/note-templates/:id", templates.DeleteNoteTemplate)
+
+		authorized.GET("/events", events.GetEvents)
+		authorized.GET("/events/ws", events.GetEventsWebSocket)
+
+		authorized.GET("/trash", trash.GetTrash)
+		authorized.POST("/trash/:type/:id/restore", trash.RestoreItem)
+
+		authorized.GET("/me/export", export.ExportNotes)
+		authorized.POST("/me/import", imports.ImportNotes)
 	}
 
-	app.StartServer(host, router)
+	app.StartServer(host, router, events.Shutdown)
 }
@@ -0,0 +1,75 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func readArchive(t *testing.T, content []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.Nil(t, err)
+
+	result := make(map[string]string)
+	for _, file := range reader.File {
+		r, err := file.Open()
+		assert.Nil(t, err)
+		data, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		r.Close()
+		result[file.Name] = string(data)
+	}
+	return result
+}
+
+func TestApiExportNotes(t *testing.T) {
+	t.Run("EmptyCase", RunWithRecreateDB((func(t *testing.T) {
+		httpStatusCode, body := testHttpClient.ExportNotes()
+
+		assert.Equal(t, http.StatusOK, httpStatusCode)
+		assert.Equal(t, 0, len(readArchive(t, body)))
+	})))
+	t.Run("BasicCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_CALLER_USER_ID, TEST_NOTE_STATE_1)
+			CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_CALLER_USER_ID+1, TEST_NOTE_STATE_1)
+			_, err := queries.CreateComment(tx, ctx, noteId, TEST_CALLER_USER_ID+1, "Nice note", 0, entities.COMMENT_STATE_NEW)
+			assert.Nil(t, err)
+			return err
+		})()
+
+		httpStatusCode, body := testHttpClient.ExportNotes()
+		assert.Equal(t, http.StatusOK, httpStatusCode)
+
+		files := readArchive(t, body)
+		assert.Equal(t, 2, len(files))
+
+		var noteFile, commentsFile string
+		for name, content := range files {
+			if strings.HasSuffix(name, ".comments.json") {
+				commentsFile = content
+			} else if strings.HasSuffix(name, ".md") {
+				noteFile = content
+			}
+		}
+		assert.True(t, strings.HasPrefix(noteFile, "---\nid: 1\n"))
+		assert.Contains(t, noteFile, "tags: [\""+TEST_TAG_NAME_1+"\"]\n")
+		assert.Contains(t, noteFile, "state: \""+TEST_NOTE_STATE_1+"\"\n")
+		assert.Contains(t, noteFile, TEST_NOTE_TEXT_1)
+		assert.Contains(t, commentsFile, "Nice note")
+	})))
+}
@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/imports"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func createArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		assert.Nil(t, err)
+		w.Write([]byte(content))
+	}
+	assert.Nil(t, writer.Close())
+	return buf.Bytes()
+}
+
+func importNotes(t *testing.T, archive []byte) imports.ImportResultDTO {
+	var result imports.ImportResultDTO
+	httpStatusCode, body, err := testHttpClient.ImportNotes(archive)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, httpStatusCode)
+	assert.Nil(t, json.Unmarshal([]byte(body), &result))
+	return result
+}
+
+func TestApiImportNotes(t *testing.T) {
+	t.Run("WrongArchiveCase", RunWithRecreateDB((func(t *testing.T) {
+		httpStatusCode, _, err := testHttpClient.ImportNotes([]byte("not a zip"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, httpStatusCode)
+	})))
+	t.Run("BasicCase", RunWithRecreateDB((func(t *testing.T) {
+		files := map[string]string{
+			"vault/Graphs.md":           "---\ntags: [algorithms]\ncreated: 2021-03-01\n---\nBFS and DFS #graphs",
+			"vault/Broken.md":           "---\ntitle: broken\n",
+			"vault/.obsidian/config.md": "skipped",
+			"vault/image.png":           "skipped",
+		}
+
+		result := importNotes(t, createArchive(t, files))
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, 2, len(result.Files))
+
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			note, err := queries.GetNote(tx, ctx, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, "Graphs", note.Topic)
+			assert.Equal(t, TEST_CALLER_USER_ID, note.UserId)
+			assert.Equal(t, entities.NOTE_VISIBILITY_PRIVATE, note.Visibility)
+			assert.Equal(t, 2021, note.CreateDate.Year())
+
+			tags, err := queries.GetNoteTagNames(tx, ctx, note.Id)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"algorithms", "graphs"}, tags)
+			return err
+		})()
+
+		// the same archive is imported without duplicates
+		result = importNotes(t, createArchive(t, files))
+		assert.Equal(t, 0, result.Created)
+		assert.Equal(t, 1, result.Unchanged)
+
+		files["vault/Graphs.md"] = "Only BFS"
+		result = importNotes(t, createArchive(t, files))
+		assert.Equal(t, 1, result.Updated)
+
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			note, err := queries.GetNote(tx, ctx, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, "Only BFS", note.Text)
+
+			tags, _ := queries.GetNoteTagNames(tx, ctx, note.Id)
+			assert.Equal(t, []string{imports.DEFAULT_TAG_NAME}, tags)
+			return err
+		})()
+	})))
+}
@@ -6,6 +6,7 @@ package integration
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"testing"
 
@@ -37,10 +38,17 @@ var (
 		"{\"Field\":\"UserId\",\"Msg\":\"This field is required\"}," +
 		"{\"Field\":\"State\",\"Msg\":\"This field is required\"}" +
 		"]}"
-	ERROR_NOTE_CREATE_STATE_WRONG_VALUE string = fmt.Sprintf("Unable to create note. Wrong 'State' value. Possible values: %v", entities.GetPossibleNoteStates())
+	ERROR_NOTE_CREATE_STATE_WRONG_VALUE string = fmt.Sprintf("Unable to create note. Wrong 'State' value. Possible values: %v", entities.GetPossibleNoteInitialStates())
 	ERROR_NOTE_UPDATE_STATE_WRONG_VALUE string = fmt.Sprintf("Unable to update note. Wrong 'State' value. Possible values: %v", entities.GetPossibleNoteStates())
 )
 
+var publicationDateRegexp = regexp.MustCompile(`,"PublishedAt":"[^"]*"`)
+
+// the publication date is the time of creating of the published note, so it is not compared
+func withoutPublicationDate(body string) string {
+	return publicationDateRegexp.ReplaceAllString(body, "")
+}
+
 func TestApiNoteGet(t *testing.T) {
 	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
 		httpStatusCode, body := testHttpClient.GetNote("1")
@@ -54,14 +62,15 @@ func TestApiNoteGet(t *testing.T) {
 		topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, 1)
 		tagId := 1
 		userId := 1
-		state := entities.NOTE_STATE_NEW
+		state := entities.NOTE_STATE_PUBLISHED
 		expectedBody := "{" +
 			"\"Id\":" + id + "," +
 			"\"Text\":\"" + text + "\"," +
 			"\"Topic\":\"" + topic + "\"," +
 			"\"TagId\":" + strconv.Itoa(tagId) + "," +
 			"\"UserId\":" + strconv.Itoa(userId) + "," +
-			"\"State\":\"" + state + "\"" +
+			"\"State\":\"" + state + "\"," +
+			"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 			"}"
 
 		httpStatusCode, body, _ := testHttpClient.CreateNote(text, topic, tagId, userId, state)
@@ -72,7 +81,7 @@ func TestApiNoteGet(t *testing.T) {
 		httpStatusCode, body = testHttpClient.GetNote(id)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 	})))
 	t.Run("WrongInput: 'Id' is a string", RunWithRecreateDB((func(t *testing.T) {
 		httpStatusCode, body := testHttpClient.GetNote("text")
@@ -106,8 +115,8 @@ func TestApiNoteGetAll(t *testing.T) {
 			text := utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, i)
 			topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, i)
 			tagId := i
-			userId := i
-			state := entities.NOTE_STATE_NEW
+			userId := TEST_CALLER_USER_ID
+			state := entities.NOTE_STATE_PUBLISHED
 
 			testHttpClient.CreateNote(text, topic, tagId, userId, state)
 			expectedBody += "{" +
@@ -116,7 +125,8 @@ func TestApiNoteGetAll(t *testing.T) {
 				"\"Topic\":\"" + topic + "\"," +
 				"\"TagId\":" + strconv.Itoa(tagId) + "," +
 				"\"UserId\":" + strconv.Itoa(userId) + "," +
-				"\"State\":\"" + state + "\"" +
+				"\"State\":\"" + state + "\"," +
+				"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 				"}"
 			if i != 10 {
 				expectedBody += ","
@@ -129,7 +139,7 @@ func TestApiNoteGetAll(t *testing.T) {
 		httpStatusCode, body, _ := testHttpClient.GetNotes(nil, nil)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 	})))
 	t.Run("EmptyResult", RunWithRecreateDB((func(t *testing.T) {
 		expectedBody := "{"
@@ -141,7 +151,7 @@ func TestApiNoteGetAll(t *testing.T) {
 		httpStatusCode, body, _ := testHttpClient.GetNotes(nil, nil)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 	})))
 	t.Run("LimitCase", RunWithRecreateDB((func(t *testing.T) {
 		expectedBody := "{"
@@ -154,15 +164,16 @@ func TestApiNoteGetAll(t *testing.T) {
 			text := utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, i)
 			topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, i)
 			tagId := i
-			userId := i
-			state := entities.NOTE_STATE_NEW
+			userId := TEST_CALLER_USER_ID
+			state := entities.NOTE_STATE_PUBLISHED
 			expectedBody += "{" +
 				"\"Id\":" + id + "," +
 				"\"Text\":\"" + text + "\"," +
 				"\"Topic\":\"" + topic + "\"," +
 				"\"TagId\":" + strconv.Itoa(tagId) + "," +
 				"\"UserId\":" + strconv.Itoa(userId) + "," +
-				"\"State\":\"" + state + "\"" +
+				"\"State\":\"" + state + "\"," +
+				"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 				"}"
 			if i != 5 {
 				expectedBody += ","
@@ -176,8 +187,8 @@ func TestApiNoteGetAll(t *testing.T) {
 			text := utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, i)
 			topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, i)
 			tagId := i
-			userId := i
-			state := entities.NOTE_STATE_NEW
+			userId := TEST_CALLER_USER_ID
+			state := entities.NOTE_STATE_PUBLISHED
 
 			testHttpClient.CreateNote(text, topic, tagId, userId, state)
 		}
@@ -185,7 +196,7 @@ func TestApiNoteGetAll(t *testing.T) {
 		httpStatusCode, body, _ := testHttpClient.GetNotes(5, 0)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 	})))
 	t.Run("OffsetCase", RunWithRecreateDB((func(t *testing.T) {
 		expectedBody := "{"
@@ -198,15 +209,16 @@ func TestApiNoteGetAll(t *testing.T) {
 			text := utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, i)
 			topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, i)
 			tagId := i
-			userId := i
-			state := entities.NOTE_STATE_NEW
+			userId := TEST_CALLER_USER_ID
+			state := entities.NOTE_STATE_PUBLISHED
 			expectedBody += "{" +
 				"\"Id\":" + id + "," +
 				"\"Text\":\"" + text + "\"," +
 				"\"Topic\":\"" + topic + "\"," +
 				"\"TagId\":" + strconv.Itoa(tagId) + "," +
 				"\"UserId\":" + strconv.Itoa(userId) + "," +
-				"\"State\":\"" + state + "\"" +
+				"\"State\":\"" + state + "\"," +
+				"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 				"}"
 			if i != 10 {
 				expectedBody += ","
@@ -220,8 +232,8 @@ func TestApiNoteGetAll(t *testing.T) {
 			text := utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, i)
 			topic := utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, i)
 			tagId := i
-			userId := i
-			state := entities.NOTE_STATE_NEW
+			userId := TEST_CALLER_USER_ID
+			state := entities.NOTE_STATE_PUBLISHED
 
 			testHttpClient.CreateNote(text, topic, tagId, userId, state)
 		}
@@ -229,7 +241,7 @@ func TestApiNoteGetAll(t *testing.T) {
 		httpStatusCode, body, _ := testHttpClient.GetNotes(50, 5)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 	})))
 }
 
@@ -240,6 +252,12 @@ func TestApiNoteCreate(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, httpStatusCode)
 		assert.Equal(t, "1", body)
 	})))
+	t.Run("ForbiddenCase: Note of another user", RunWithRecreateDB((func(t *testing.T) {
+		httpStatusCode, body, _ := testHttpClient.CreateNote(TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_2, TEST_NOTE_STATE_1)
+
+		assert.Equal(t, http.StatusForbidden, httpStatusCode)
+		assert.Equal(t, "\""+api.ACCESS_DENIED+"\"", body)
+	})))
 	t.Run("WrongInput: Missed 'Text'", RunWithRecreateDB((func(t *testing.T) {
 		httpStatusCode, body, _ := testHttpClient.CreateNote(nil, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_1)
 
@@ -348,7 +366,8 @@ func TestApiNoteUpdate(t *testing.T) {
 			"\"Topic\":\"" + topic + "\"," +
 			"\"TagId\":" + strconv.Itoa(tagId) + "," +
 			"\"UserId\":" + strconv.Itoa(userId) + "," +
-			"\"State\":\"" + state + "\"" +
+			"\"State\":\"" + state + "\"," +
+			"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 			"}"
 
 		httpStatusCode, body, _ := testHttpClient.CreateNote(TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_1)
@@ -363,7 +382,7 @@ func TestApiNoteUpdate(t *testing.T) {
 		httpStatusCode, body = testHttpClient.GetNote(id)
 
 		assert.Equal(t, http.StatusOK, httpStatusCode)
-		assert.Equal(t, expectedBody, body)
+		assert.Equal(t, expectedBody, withoutPublicationDate(body))
 
 	})))
 	t.Run("WrongInput: 'Id' is a empty string", RunWithRecreateDB((func(t *testing.T) {
@@ -504,8 +523,10 @@ func TestApiNoteUpdate(t *testing.T) {
 
 		httpStatusCode, body, _ = testHttpClient.UpdateNote(expectedId, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_USER_ID_2, entities.NOTE_STATE_DELETED)
 
-		assert.Equal(t, http.StatusBadRequest, httpStatusCode)
-		assert.Equal(t, "\""+api.DELETE_VIA_PUT_REQUEST_IS_FODBIDDEN+"\"", body)
+		// the note is deleted by DELETE request only, so the state is not reachable by the workflow
+		expectedBody := fmt.Sprintf(api.ERROR_NOTE_STATE_TRANSITION_IS_NOT_ALLOWED, TEST_NOTE_STATE_1, entities.NOTE_STATE_DELETED, entities.GetPossibleNoteStateTransitions(TEST_NOTE_STATE_1))
+		assert.Equal(t, http.StatusConflict, httpStatusCode)
+		assert.Equal(t, "\""+expectedBody+"\"", body)
 	})))
 	t.Run("MultipleUpdateCase", RunWithRecreateDB((func(t *testing.T) {
 		id := "1"
@@ -514,8 +535,9 @@ func TestApiNoteUpdate(t *testing.T) {
 			"\"Text\":\"" + TEST_NOTE_TEXT_2 + "\"," +
 			"\"Topic\":\"" + TEST_NOTE_TOPIC_2 + "\"," +
 			"\"TagId\":" + strconv.Itoa(TEST_NOTE_TAG_ID_2) + "," +
-			"\"UserId\":" + strconv.Itoa(TEST_NOTE_USER_ID_2) + "," +
-			"\"State\":\"" + TEST_NOTE_STATE_2 + "\"" +
+			"\"UserId\":" + strconv.Itoa(TEST_NOTE_USER_ID_1) + "," +
+			"\"State\":\"" + TEST_NOTE_STATE_2 + "\"," +
+			"\"Visibility\":\"" + entities.NOTE_VISIBILITY_PUBLIC + "\"" +
 			"}"
 
 		httpStatusCode, body, _ := testHttpClient.CreateNote(TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_1)
@@ -523,8 +545,9 @@ func TestApiNoteUpdate(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, httpStatusCode)
 		assert.Equal(t, id, body)
 
+		// the owner is kept, otherwise the caller would lose edit permission after the first update
 		for i := 1; i <= 3; i++ {
-			httpStatusCode, body, _ = testHttpClient.UpdateNote(id, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_USER_ID_2, TEST_NOTE_STATE_2)
+			httpStatusCode, body, _ = testHttpClient.UpdateNote(id, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_2)
 
 			assert.Equal(t, http.StatusOK, httpStatusCode)
 			assert.Equal(t, "\""+api.DONE+"\"", body)
@@ -532,7 +555,7 @@ func TestApiNoteUpdate(t *testing.T) {
 			httpStatusCode, body = testHttpClient.GetNote(id)
 
 			assert.Equal(t, http.StatusOK, httpStatusCode)
-			assert.Equal(t, expectedBody, body)
+			assert.Equal(t, expectedBody, withoutPublicationDate(body))
 		}
 	})))
 }
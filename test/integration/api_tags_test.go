@@ -43,7 +43,8 @@ func TestApiTagGet(t *testing.T) {
 		expectedBody := "{" +
 			"\"Id\":" + expectedId + "," +
 			"\"Name\":\"" + expectedName + "\"," +
-			"\"State\":\"" + expectedState + "\"" +
+			"\"State\":\"" + expectedState + "\"," +
+			"\"UsageCount\":0" +
 			"}"
 
 		httpStatusCode, body, _ := testHttpClient.CreateTag(expectedName, expectedState)
@@ -89,7 +90,7 @@ func TestApiTagGetAll(t *testing.T) {
 			state := entities.TAG_STATE_NEW
 
 			testHttpClient.CreateTag(name, state)
-			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"}"
+			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"," + "\"UsageCount\":0}"
 			if i != 10 {
 				expectedBody += ","
 			} else {
@@ -125,7 +126,7 @@ func TestApiTagGetAll(t *testing.T) {
 			id := strconv.Itoa(i)
 			name := "Test Tag " + id
 			state := entities.TAG_STATE_NEW
-			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"}"
+			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"," + "\"UsageCount\":0}"
 			if i != 5 {
 				expectedBody += ","
 			} else {
@@ -156,7 +157,7 @@ func TestApiTagGetAll(t *testing.T) {
 			id := strconv.Itoa(i)
 			name := "Test Tag " + id
 			state := entities.TAG_STATE_NEW
-			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"}"
+			expectedBody += "{\"Id\":" + id + "," + "\"Name\":\"" + name + "\"," + "\"State\":\"" + state + "\"," + "\"UsageCount\":0}"
 			if i != 10 {
 				expectedBody += ","
 			} else {
@@ -263,7 +264,8 @@ func TestApiTagUpdate(t *testing.T) {
 		expectedBody := "{" +
 			"\"Id\":" + expectedId + "," +
 			"\"Name\":\"" + expectedName + "\"," +
-			"\"State\":\"" + expectedState + "\"" +
+			"\"State\":\"" + expectedState + "\"," +
+			"\"UsageCount\":0" +
 			"}"
 		testHttpClient.CreateTag("Test Tag 1", entities.TAG_STATE_NEW)
 
@@ -388,7 +390,8 @@ func TestApiTagUpdate(t *testing.T) {
 		expectedBody := "{" +
 			"\"Id\":" + expectedId + "," +
 			"\"Name\":\"" + expectedName + "\"," +
-			"\"State\":\"" + expectedState + "\"" +
+			"\"State\":\"" + expectedState + "\"," +
+			"\"UsageCount\":0" +
 			"}"
 
 		testHttpClient.CreateTag("Test Tag 1", entities.TAG_STATE_NEW)
@@ -0,0 +1,98 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_ATTACHMENT_FILE_NAME_1 string = "lecture1.pdf"
+	TEST_ATTACHMENT_FILE_NAME_2 string = "lecture2.pdf"
+	TEST_ATTACHMENT_MIME_TYPE   string = "application/pdf"
+	TEST_ATTACHMENT_SIZE        int64  = 1024
+	TEST_ATTACHMENT_CHECKSUM_1  string = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	TEST_ATTACHMENT_CHECKSUM_2  string = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+)
+
+func TestDBAttachmentCreateAndGet(t *testing.T) {
+	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.GetAttachment(tx, ctx, 1, 1)
+
+			assert.Equal(t, sql.ErrNoRows, err)
+			return err
+		})()
+	})))
+	t.Run("BasicCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, err := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_1)
+			assert.Nil(t, err)
+
+			attachmentId, err := queries.CreateAttachment(tx, ctx, noteId, TEST_NOTE_USER_ID_1, TEST_ATTACHMENT_FILE_NAME_1, TEST_ATTACHMENT_MIME_TYPE, TEST_ATTACHMENT_SIZE, TEST_ATTACHMENT_CHECKSUM_1)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, attachmentId)
+
+			actual, err := queries.GetAttachment(tx, ctx, noteId, attachmentId)
+			assert.Nil(t, err)
+			assert.Equal(t, noteId, actual.NoteId)
+			assert.Equal(t, TEST_ATTACHMENT_FILE_NAME_1, actual.FileName)
+			assert.Equal(t, TEST_ATTACHMENT_MIME_TYPE, actual.MimeType)
+			assert.Equal(t, TEST_ATTACHMENT_SIZE, actual.Size)
+			assert.Equal(t, TEST_ATTACHMENT_CHECKSUM_1, actual.Checksum)
+
+			_, err = queries.GetAttachment(tx, ctx, noteId+1, attachmentId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			attachments, err := queries.GetAttachments(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(attachments))
+			return err
+		})()
+	})))
+}
+
+func TestDBAttachmentDelete(t *testing.T) {
+	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.DeleteAttachment(tx, ctx, 1, 1)
+
+			assert.Equal(t, sql.ErrNoRows, err)
+			return err
+		})()
+	})))
+	t.Run("SharedChecksumCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId1, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_USER_ID_1, TEST_NOTE_STATE_1)
+			noteId2, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_USER_ID_2, TEST_NOTE_STATE_1)
+
+			attachmentId, _ := queries.CreateAttachment(tx, ctx, noteId1, TEST_NOTE_USER_ID_1, TEST_ATTACHMENT_FILE_NAME_1, TEST_ATTACHMENT_MIME_TYPE, TEST_ATTACHMENT_SIZE, TEST_ATTACHMENT_CHECKSUM_1)
+			queries.CreateAttachment(tx, ctx, noteId1, TEST_NOTE_USER_ID_1, TEST_ATTACHMENT_FILE_NAME_2, TEST_ATTACHMENT_MIME_TYPE, TEST_ATTACHMENT_SIZE, TEST_ATTACHMENT_CHECKSUM_2)
+			queries.CreateAttachment(tx, ctx, noteId2, TEST_NOTE_USER_ID_2, TEST_ATTACHMENT_FILE_NAME_1, TEST_ATTACHMENT_MIME_TYPE, TEST_ATTACHMENT_SIZE, TEST_ATTACHMENT_CHECKSUM_1)
+
+			checksum, err := queries.DeleteAttachment(tx, ctx, noteId1, attachmentId)
+			assert.Nil(t, err)
+			assert.Equal(t, TEST_ATTACHMENT_CHECKSUM_1, checksum)
+
+			inUse, err := queries.IsAttachmentChecksumInUse(tx, ctx, TEST_ATTACHMENT_CHECKSUM_1)
+			assert.Nil(t, err)
+			assert.True(t, inUse)
+
+			checksums, err := queries.DeleteAttachmentsByNote(tx, ctx, noteId1)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{TEST_ATTACHMENT_CHECKSUM_2}, checksums)
+
+			inUse, err = queries.IsAttachmentChecksumInUse(tx, ctx, TEST_ATTACHMENT_CHECKSUM_2)
+			assert.Nil(t, err)
+			assert.False(t, inUse)
+			return err
+		})()
+	})))
+}
@@ -0,0 +1,82 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBBibEntry(t *testing.T) {
+	t.Run("FieldsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			entryId, err := queries.CreateBibEntry(tx, ctx, entities.BibEntry{
+				UserId:      TEST_NOTE_OWNER_ID,
+				CitationKey: "knuth84",
+				EntryType:   "book",
+				Fields:      map[string]string{"author": "Donald E. Knuth", "title": "The {\\TeX}book", "year": "1984"},
+			})
+			assert.Nil(t, err)
+
+			entry, err := queries.GetBibEntry(tx, ctx, entryId)
+			assert.Nil(t, err)
+			assert.Equal(t, "knuth84", entry.CitationKey)
+			assert.Equal(t, map[string]string{"author": "Donald E. Knuth", "title": "The {\\TeX}book", "year": "1984"}, entry.Fields)
+
+			// the fields are replaced
+			entry.Fields = map[string]string{"title": "The TeXbook"}
+			entry.EntryType = "manual"
+			err = queries.UpdateBibEntry(tx, ctx, entry)
+			assert.Nil(t, err)
+			entry, _ = queries.GetBibEntry(tx, ctx, entryId)
+			assert.Equal(t, "manual", entry.EntryType)
+			assert.Equal(t, map[string]string{"title": "The TeXbook"}, entry.Fields)
+
+			err = queries.DeleteBibEntry(tx, ctx, entryId)
+			assert.Nil(t, err)
+			_, err = queries.GetBibEntry(tx, ctx, entryId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.DeleteBibEntry(tx, ctx, entryId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("KeysCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.CreateBibEntry(tx, ctx, entities.BibEntry{UserId: TEST_NOTE_OWNER_ID, CitationKey: "lamport94", EntryType: "article"})
+			assert.Nil(t, err)
+			_, err = queries.CreateBibEntry(tx, ctx, entities.BibEntry{UserId: TEST_NOTE_OWNER_ID, CitationKey: "knuth84", EntryType: "book"})
+			assert.Nil(t, err)
+			// the keys are unique per user only
+			_, err = queries.CreateBibEntry(tx, ctx, entities.BibEntry{UserId: TEST_NOTE_READER_ID, CitationKey: "knuth84", EntryType: "book"})
+			assert.Nil(t, err)
+
+			entries, err := queries.GetUserBibEntries(tx, ctx, TEST_NOTE_OWNER_ID, -1, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(entries))
+			assert.Equal(t, "knuth84", entries[0].CitationKey)
+			assert.Equal(t, "lamport94", entries[1].CitationKey)
+			assert.NotNil(t, entries[0].Fields)
+
+			entries, _ = queries.GetUserBibEntries(tx, ctx, TEST_NOTE_OWNER_ID, 1, 1)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, "lamport94", entries[0].CitationKey)
+
+			entries, err = queries.GetBibEntriesByKeys(tx, ctx, TEST_NOTE_OWNER_ID, []string{"knuth84", "unknown"})
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, TEST_NOTE_OWNER_ID, entries[0].UserId)
+
+			_, err = queries.CreateBibEntry(tx, ctx, entities.BibEntry{UserId: TEST_NOTE_OWNER_ID, CitationKey: "knuth84", EntryType: "misc"})
+			assert.Equal(t, db.ErrorBibEntryDuplicateKey, err)
+			return nil
+		})()
+	})))
+}
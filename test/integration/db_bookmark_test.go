@@ -0,0 +1,137 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNoteReaction(t *testing.T) {
+	t.Run("CountsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			otherNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			err := queries.AddNoteReaction(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.REACTION_TYPE_LIKE)
+			assert.Nil(t, err)
+			// the repeated reaction is counted once
+			err = queries.AddNoteReaction(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.REACTION_TYPE_LIKE)
+			assert.Nil(t, err)
+			err = queries.AddNoteReaction(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.REACTION_TYPE_INSIGHTFUL)
+			assert.Nil(t, err)
+			err = queries.AddNoteReaction(tx, ctx, noteId, TEST_NOTE_STRANGER_ID, entities.REACTION_TYPE_LIKE)
+			assert.Nil(t, err)
+
+			counts, err := queries.GetNotesReactionCounts(tx, ctx, []int{noteId, otherNoteId})
+			assert.Nil(t, err)
+			assert.Equal(t, map[int]map[string]int{noteId: {entities.REACTION_TYPE_LIKE: 2, entities.REACTION_TYPE_INSIGHTFUL: 1}}, counts)
+
+			reactions, err := queries.GetUserNotesReactions(tx, ctx, TEST_NOTE_READER_ID, []int{noteId, otherNoteId})
+			assert.Nil(t, err)
+			assert.Equal(t, map[int][]string{noteId: {entities.REACTION_TYPE_INSIGHTFUL, entities.REACTION_TYPE_LIKE}}, reactions)
+
+			err = queries.DeleteNoteReaction(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.REACTION_TYPE_LIKE)
+			assert.Nil(t, err)
+			err = queries.DeleteNoteReaction(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.REACTION_TYPE_LIKE)
+			assert.Equal(t, sql.ErrNoRows, err)
+			counts, err = queries.GetNotesReactionCounts(tx, ctx, []int{noteId})
+			assert.Nil(t, err)
+			assert.Equal(t, 1, counts[noteId][entities.REACTION_TYPE_LIKE])
+			return nil
+		})()
+	})))
+}
+
+func TestDBBookmark(t *testing.T) {
+	t.Run("FoldersCase", RunWithRecreateDB((func(t *testing.T) {
+		var folderId int
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			var err error
+			folderId, err = queries.CreateBookmarkFolder(tx, ctx, TEST_NOTE_READER_ID, "Later")
+			assert.Nil(t, err)
+			_, err = queries.CreateBookmarkFolder(tx, ctx, TEST_NOTE_STRANGER_ID, "Later")
+			assert.Nil(t, err)
+			return err
+		})()
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			// the names are unique per user regardless of the case
+			_, err := queries.CreateBookmarkFolder(tx, ctx, TEST_NOTE_READER_ID, "later")
+			assert.Equal(t, db.ErrorBookmarkFolderDuplicateKey, err)
+			return err
+		})()
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.GetBookmarkFolder(tx, ctx, TEST_NOTE_STRANGER_ID, folderId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.UpdateBookmarkFolder(tx, ctx, TEST_NOTE_STRANGER_ID, folderId, "Mine")
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.UpdateBookmarkFolder(tx, ctx, TEST_NOTE_READER_ID, folderId, "Read later")
+			assert.Nil(t, err)
+			folders, err := queries.GetBookmarkFolders(tx, ctx, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(folders))
+			assert.Equal(t, "Read later", folders[0].Name)
+			return err
+		})()
+	})))
+	t.Run("BookmarksCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			otherNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			folderId, err := queries.CreateBookmarkFolder(tx, ctx, TEST_NOTE_READER_ID, "Later")
+			assert.Nil(t, err)
+			folder := sql.NullInt32{Int32: int32(folderId), Valid: true}
+
+			err = queries.SetBookmark(tx, ctx, TEST_NOTE_READER_ID, noteId, sql.NullInt32{})
+			assert.Nil(t, err)
+			// the bookmark is unique per user, so it is moved to the folder
+			err = queries.SetBookmark(tx, ctx, TEST_NOTE_READER_ID, noteId, folder)
+			assert.Nil(t, err)
+			err = queries.SetBookmark(tx, ctx, TEST_NOTE_READER_ID, otherNoteId, sql.NullInt32{})
+			assert.Nil(t, err)
+
+			all, err := queries.GetUserBookmarks(tx, ctx, TEST_NOTE_READER_ID, queries.BookmarkFilter{}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(all))
+			inFolder, err := queries.GetUserBookmarks(tx, ctx, TEST_NOTE_READER_ID, queries.BookmarkFilter{FolderId: folder}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(inFolder))
+			assert.Equal(t, noteId, inFolder[0].NoteId)
+			assert.Equal(t, TEST_NOTE_TOPIC_1, inFolder[0].NoteTopic)
+			unfiled, err := queries.GetUserBookmarks(tx, ctx, TEST_NOTE_READER_ID, queries.BookmarkFilter{Unfiled: true}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(unfiled))
+			assert.Equal(t, otherNoteId, unfiled[0].NoteId)
+
+			// the bookmarks of the deleted folder are kept without folder
+			err = queries.DeleteBookmarkFolder(tx, ctx, TEST_NOTE_READER_ID, folderId)
+			assert.Nil(t, err)
+			unfiled, err = queries.GetUserBookmarks(tx, ctx, TEST_NOTE_READER_ID, queries.BookmarkFilter{Unfiled: true}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(unfiled))
+
+			// the notes which are not visible anymore are skipped
+			err = queries.UpdateNoteVisibility(tx, ctx, otherNoteId, entities.NOTE_VISIBILITY_PRIVATE)
+			assert.Nil(t, err)
+			all, err = queries.GetUserBookmarks(tx, ctx, TEST_NOTE_READER_ID, queries.BookmarkFilter{}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(all))
+
+			err = queries.DeleteBookmark(tx, ctx, TEST_NOTE_READER_ID, noteId)
+			assert.Nil(t, err)
+			err = queries.DeleteBookmark(tx, ctx, TEST_NOTE_READER_ID, noteId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
@@ -0,0 +1,89 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/srs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBCard(t *testing.T) {
+	t.Run("CRUDCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			cardId, err := queries.CreateCard(tx, ctx, noteId, TEST_NOTE_OWNER_ID, "Question", "Answer", entities.CARD_SOURCE_MANUAL)
+			assert.Nil(t, err)
+
+			err = queries.UpdateCard(tx, ctx, noteId, cardId, "Question", "Another answer")
+			assert.Nil(t, err)
+
+			card, err := queries.GetCard(tx, ctx, cardId)
+			assert.Nil(t, err)
+			assert.Equal(t, "Another answer", card.Back)
+			assert.Equal(t, entities.CARD_SOURCE_MANUAL, card.Source)
+
+			err = queries.DeleteCard(tx, ctx, noteId, cardId)
+			assert.Nil(t, err)
+			err = queries.DeleteCard(tx, ctx, noteId, cardId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			cards, err := queries.GetNoteCards(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(cards))
+			return nil
+		})()
+	})))
+	t.Run("ReviewCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			cardId1, _ := queries.CreateCard(tx, ctx, noteId, TEST_NOTE_OWNER_ID, "Question 1", "Answer 1", entities.CARD_SOURCE_MANUAL)
+			cardId2, _ := queries.CreateCard(tx, ctx, noteId, TEST_NOTE_OWNER_ID, "Question 2", "Answer 2", entities.CARD_SOURCE_MANUAL)
+
+			now := time.Now()
+			dueCards, err := queries.GetDueCards(tx, ctx, TEST_NOTE_OWNER_ID, now, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(dueCards))
+			assert.Nil(t, dueCards[0].Review)
+
+			schedule := srs.Review(srs.NewSchedule(), srs.GRADE_MAX)
+			err = queries.SaveCardReview(tx, ctx, entities.CardReview{CardId: cardId1, UserId: TEST_NOTE_OWNER_ID, Repetitions: schedule.Repetitions,
+				IntervalDays: schedule.IntervalDays, EasinessFactor: schedule.EasinessFactor, DueDate: schedule.DueDate(now), LastReviewDate: now}, srs.GRADE_MAX)
+			assert.Nil(t, err)
+
+			review, err := queries.GetCardReview(tx, ctx, cardId1, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, review.Repetitions)
+
+			dueCards, err = queries.GetDueCards(tx, ctx, TEST_NOTE_OWNER_ID, now, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(dueCards))
+			assert.Equal(t, cardId2, dueCards[0].Id)
+
+			// the private note cards are not studied by strangers
+			queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_PRIVATE)
+			dueCards, err = queries.GetDueCards(tx, ctx, TEST_NOTE_STRANGER_ID, now, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(dueCards))
+
+			todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			stats, err := queries.GetCardReviewStats(tx, ctx, TEST_NOTE_OWNER_ID, now, todayStart, todayStart.AddDate(0, 0, -29))
+			assert.Nil(t, err)
+			assert.Equal(t, 2, stats.Cards)
+			assert.Equal(t, 1, stats.NewCards)
+			assert.Equal(t, 1, stats.ReviewsTotal)
+			assert.Equal(t, 1, stats.ReviewsToday)
+			assert.Equal(t, float64(srs.GRADE_MAX), stats.AverageGrade)
+			return nil
+		})()
+	})))
+}
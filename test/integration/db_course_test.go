@@ -0,0 +1,88 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBCourse(t *testing.T) {
+	t.Run("ModulesOrderCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			courseId, err := queries.CreateCourse(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", "")
+			assert.Nil(t, err)
+
+			moduleId1, _ := queries.CreateCourseModule(tx, ctx, courseId, "Basics")
+			moduleId2, _ := queries.CreateCourseModule(tx, ctx, courseId, "Algorithms")
+
+			modules, err := queries.GetCourseModules(tx, ctx, courseId)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(modules))
+			assert.Equal(t, moduleId1, modules[0].Id)
+
+			err = queries.SetCourseModulePositions(tx, ctx, courseId, []int{moduleId2, moduleId1})
+			assert.Nil(t, err)
+			modules, _ = queries.GetCourseModules(tx, ctx, courseId)
+			assert.Equal(t, moduleId2, modules[0].Id)
+			assert.Equal(t, moduleId1, modules[1].Id)
+
+			err = queries.DeleteCourseModule(tx, ctx, courseId, moduleId2)
+			assert.Nil(t, err)
+			err = queries.DeleteCourseModule(tx, ctx, courseId, moduleId2)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("ProgressCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			taskId, _ := CreateTaskInDB(t, tx, ctx, TEST_TASK_NAME_1, TEST_TASK_STATE_1)
+			courseId, _ := queries.CreateCourse(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", "")
+			moduleId, _ := queries.CreateCourseModule(tx, ctx, courseId, "Basics")
+			itemId1, err := queries.CreateCourseItem(tx, ctx, moduleId, sql.NullInt32{Int32: int32(noteId), Valid: true}, sql.NullInt32{})
+			assert.Nil(t, err)
+			itemId2, err := queries.CreateCourseItem(tx, ctx, moduleId, sql.NullInt32{}, sql.NullInt32{Int32: int32(taskId), Valid: true})
+			assert.Nil(t, err)
+
+			_, err = queries.GetCourseProgress(tx, ctx, courseId, TEST_NOTE_READER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.EnrollCourse(tx, ctx, courseId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			err = queries.SetCourseLastItem(tx, ctx, courseId, TEST_NOTE_READER_ID, itemId1)
+			assert.Nil(t, err)
+			err = queries.CompleteCourseItem(tx, ctx, itemId1, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+
+			progress, err := queries.GetCourseProgress(tx, ctx, courseId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, progress.ItemsTotal)
+			assert.Equal(t, []int{itemId1}, progress.CompletedItemIds)
+			assert.Equal(t, int32(itemId1), progress.Enrollment.LastItemId.Int32)
+
+			// the removed items are not taken into account
+			err = queries.DeleteCourseItem(tx, ctx, moduleId, itemId1)
+			assert.Nil(t, err)
+			progress, _ = queries.GetCourseProgress(tx, ctx, courseId, TEST_NOTE_READER_ID)
+			assert.Equal(t, 1, progress.ItemsTotal)
+			assert.Equal(t, 0, len(progress.CompletedItemIds))
+
+			courses, err := queries.GetUserCourses(tx, ctx, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(courses))
+
+			err = queries.LeaveCourse(tx, ctx, courseId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			_, err = queries.GetCourseItem(tx, ctx, courseId, itemId2)
+			assert.Nil(t, err)
+			return nil
+		})()
+	})))
+}
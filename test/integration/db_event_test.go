@@ -0,0 +1,133 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBEvent(t *testing.T) {
+	t.Run("ResumeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			firstId, err := queries.CreateEvent(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_TASK, ResourceId: 1, Action: entities.EVENT_ACTION_CREATED, Public: true})
+			assert.Nil(t, err)
+			secondId, err := queries.CreateEvent(tx, ctx, entities.Event{ResourceType: entities.EVENT_RESOURCE_TYPE_NOTE, ResourceId: 1, Action: entities.EVENT_ACTION_UPDATED, UserIds: []int{TEST_NOTE_OWNER_ID, TEST_NOTE_READER_ID}})
+			assert.Nil(t, err)
+
+			event, err := queries.GetEvent(tx, ctx, secondId)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.EVENT_RESOURCE_TYPE_NOTE, event.ResourceType)
+			assert.False(t, event.Public)
+			assert.Equal(t, []int{TEST_NOTE_OWNER_ID, TEST_NOTE_READER_ID}, event.UserIds)
+			assert.True(t, event.IsVisibleTo(TEST_NOTE_READER_ID))
+			assert.False(t, event.IsVisibleTo(TEST_NOTE_STRANGER_ID))
+
+			firstDate, err := queries.GetEventDate(tx, ctx, firstId)
+			assert.Nil(t, err)
+			missed, err := queries.GetEventsAfter(tx, ctx, firstDate, firstId, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(missed))
+			assert.Equal(t, secondId, missed[0].Id)
+
+			all, err := queries.GetEventsAfter(tx, ctx, time.Time{}, 0, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(all))
+			assert.Equal(t, firstId, all[0].Id)
+			page, err := queries.GetEventsAfter(tx, ctx, time.Time{}, 0, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(page))
+			page, err = queries.GetEventsAfter(tx, ctx, page[0].CreateDate, page[0].Id, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, secondId, page[0].Id)
+
+			// the date of the purged event is taken from the closest previous one
+			date, err := queries.GetEventDate(tx, ctx, secondId+1)
+			assert.Nil(t, err)
+			assert.True(t, date.After(firstDate) || date.Equal(firstDate))
+			date, err = queries.GetEventDate(tx, ctx, firstId-1)
+			assert.Nil(t, err)
+			assert.True(t, date.IsZero())
+
+			_, err = queries.GetEvent(tx, ctx, secondId+1)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("NoteAudienceCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			public, userIds, err := queries.GetNoteAudience(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.True(t, public)
+			assert.Equal(t, []int{TEST_NOTE_OWNER_ID}, userIds)
+
+			err = queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_SHARED)
+			assert.Nil(t, err)
+			err = queries.CreateNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_READ)
+			assert.Nil(t, err)
+			public, userIds, err = queries.GetNoteAudience(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.False(t, public)
+			assert.Equal(t, []int{TEST_NOTE_OWNER_ID, TEST_NOTE_READER_ID}, userIds)
+
+			// the shares are kept, but the private note is visible to the owner only
+			err = queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_PRIVATE)
+			assert.Nil(t, err)
+			_, userIds, err = queries.GetNoteAudience(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, []int{TEST_NOTE_OWNER_ID}, userIds)
+
+			err = queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_PUBLIC)
+			assert.Nil(t, err)
+			err = queries.UpdateNoteState(tx, ctx, noteId, entities.NOTE_STATE_BLOCKED)
+			assert.Nil(t, err)
+			public, _, err = queries.GetNoteAudience(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.False(t, public)
+
+			_, _, err = queries.GetNoteAudience(tx, ctx, noteId+1)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("PublishCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+
+			err := events.PublishNoteEvent(tx, ctx, noteId, entities.EVENT_ACTION_CREATED)
+			assert.Nil(t, err)
+			err = events.PublishTaskEvent(tx, ctx, 1, entities.EVENT_ACTION_DELETED)
+			assert.Nil(t, err)
+
+			published, err := queries.GetEventsAfter(tx, ctx, time.Time{}, 0, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(published))
+			// the draft is visible to its owner only
+			assert.False(t, published[0].Public)
+			assert.True(t, published[0].IsVisibleTo(TEST_NOTE_OWNER_ID))
+			assert.False(t, published[0].IsVisibleTo(TEST_NOTE_READER_ID))
+			assert.True(t, published[1].IsVisibleTo(TEST_NOTE_STRANGER_ID))
+
+			count, err := queries.DeleteEventsBefore(tx, ctx, time.Now().Add(-time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 0, count)
+			count, err = queries.DeleteEventsBefore(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 2, count)
+			return nil
+		})()
+	})))
+}
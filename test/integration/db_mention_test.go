@@ -0,0 +1,81 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBMention(t *testing.T) {
+	t.Run("NoteMentionsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			ownerId, _ := CreateUserInDB(t, tx, ctx, "owner", "owner@somewhere.com", TEST_USER_PASSWORD_1, entities.USER_ROLE_RESIDENT, entities.USER_STATE_CONFRIMED)
+			readerId, _ := CreateUserInDB(t, tx, ctx, "Reader", "reader@somewhere.com", TEST_USER_PASSWORD_1, entities.USER_ROLE_RESIDENT, entities.USER_STATE_CONFRIMED)
+			strangerId, _ := CreateUserInDB(t, tx, ctx, "stranger", "stranger@somewhere.com", TEST_USER_PASSWORD_1, entities.USER_ROLE_RESIDENT, entities.USER_STATE_CONFRIMED)
+
+			ids, err := queries.GetUserIdsByLogins(tx, ctx, []string{"READER", "nobody"})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{readerId}, ids)
+
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, ownerId, entities.NOTE_STATE_PUBLISHED)
+			err = queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_SHARED)
+			assert.Nil(t, err)
+			err = queries.CreateNoteShare(tx, ctx, noteId, readerId, entities.NOTE_PERMISSION_READ)
+			assert.Nil(t, err)
+
+			// the stranger is not able to see the note and the owner does not mention self
+			err = mentions.SaveNoteMentions(tx, ctx, noteId, ownerId, TEST_NOTE_TOPIC_1, "cc @reader @stranger @owner")
+			assert.Nil(t, err)
+			mentioned, err := queries.GetUserMentions(tx, ctx, readerId, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(mentioned))
+			assert.Equal(t, TEST_NOTE_TOPIC_1, mentioned[0].NoteTopic)
+			assert.False(t, mentioned[0].CommentId.Valid)
+			mentioned, err = queries.GetUserMentions(tx, ctx, strangerId, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(mentioned))
+
+			// the repeated save does not notify again
+			err = mentions.SaveNoteMentions(tx, ctx, noteId, ownerId, TEST_NOTE_TOPIC_1, "cc @Reader again")
+			assert.Nil(t, err)
+			notifications, err := queries.GetUserNotifications(tx, ctx, readerId, queries.NotificationFilter{Type: entities.NOTIFICATION_TYPE_MENTIONED}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(notifications))
+
+			// the comment mentions are kept apart from the note ones
+			err = mentions.SaveCommentMentions(tx, ctx, noteId, 1, ownerId, "@reader see this")
+			assert.Nil(t, err)
+			err = mentions.SaveNoteMentions(tx, ctx, noteId, ownerId, TEST_NOTE_TOPIC_1, "no mentions")
+			assert.Nil(t, err)
+			userIds, err := queries.GetMentionedUserIds(tx, ctx, noteId, sql.NullInt32{})
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(userIds))
+			userIds, err = queries.GetMentionedUserIds(tx, ctx, noteId, sql.NullInt32{Int32: 1, Valid: true})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{readerId}, userIds)
+
+			count, err := queries.CountMentionsByActorSince(tx, ctx, ownerId, time.Now().Add(-time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+
+			// the mentions of the deleted note are hidden
+			err = queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			mentioned, err = queries.GetUserMentions(tx, ctx, readerId, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(mentioned))
+			return nil
+		})()
+	})))
+}
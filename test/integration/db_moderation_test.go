@@ -0,0 +1,151 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func userReport(contentType string, contentId int, reporterId int, reason string) entities.ContentReport {
+	return entities.ContentReport{
+		ContentType: contentType,
+		ContentId:   contentId,
+		ReporterId:  sql.NullInt32{Int32: int32(reporterId), Valid: true},
+		Reason:      reason,
+	}
+}
+
+func TestDBModeration(t *testing.T) {
+	t.Run("QueueCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			commentId, err := queries.CreateComment(tx, ctx, noteId, TEST_NOTE_READER_ID, "comment", 0, entities.COMMENT_STATE_NEW)
+			assert.Nil(t, err)
+
+			_, err = queries.CreateContentReport(tx, ctx, userReport(entities.CONTENT_TYPE_NOTE, noteId, TEST_NOTE_READER_ID, entities.REPORT_REASON_SPAM))
+			assert.Nil(t, err)
+			_, err = queries.CreateContentReport(tx, ctx, userReport(entities.CONTENT_TYPE_NOTE, noteId, TEST_NOTE_STRANGER_ID, entities.REPORT_REASON_ABUSE))
+			assert.Nil(t, err)
+			_, err = queries.CreateContentReport(tx, ctx, userReport(entities.CONTENT_TYPE_COMMENT, commentId, TEST_NOTE_OWNER_ID, entities.REPORT_REASON_SPAM))
+			assert.Nil(t, err)
+
+			// the word filter flags the content once
+			err = queries.FlagContent(tx, ctx, entities.CONTENT_TYPE_COMMENT, commentId, "Matched words: spam")
+			assert.Nil(t, err)
+			err = queries.FlagContent(tx, ctx, entities.CONTENT_TYPE_COMMENT, commentId, "Matched words: casino")
+			assert.Nil(t, err)
+
+			items, err := queries.GetModerationQueue(tx, ctx, "", 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(items))
+			assert.Equal(t, entities.CONTENT_TYPE_NOTE, items[0].ContentType)
+			assert.Equal(t, noteId, items[0].ContentId)
+			assert.Equal(t, TEST_NOTE_OWNER_ID, items[0].AuthorId)
+			assert.Equal(t, TEST_NOTE_TEXT_1, items[0].Text)
+			assert.Equal(t, 2, items[0].ReportsCount)
+			assert.Equal(t, []string{entities.REPORT_REASON_ABUSE, entities.REPORT_REASON_SPAM}, items[0].Reasons)
+			assert.False(t, items[0].Flagged)
+			assert.Equal(t, entities.CONTENT_TYPE_COMMENT, items[1].ContentType)
+			assert.Equal(t, TEST_NOTE_READER_ID, items[1].AuthorId)
+			assert.Equal(t, 2, items[1].ReportsCount)
+			assert.True(t, items[1].Flagged)
+
+			items, err = queries.GetModerationQueue(tx, ctx, entities.CONTENT_TYPE_COMMENT, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(items))
+
+			closed, err := queries.CloseContentReports(tx, ctx, entities.CONTENT_TYPE_NOTE, noteId, entities.REPORT_STATUS_DISMISSED)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, closed)
+			items, err = queries.GetModerationQueue(tx, ctx, "", 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(items))
+			return nil
+		})()
+	})))
+	t.Run("DuplicateReportCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			report := userReport(entities.CONTENT_TYPE_NOTE, noteId, TEST_NOTE_READER_ID, entities.REPORT_REASON_SPAM)
+			_, err := queries.CreateContentReport(tx, ctx, report)
+			assert.Nil(t, err)
+			_, err = queries.CreateContentReport(tx, ctx, report)
+			assert.Equal(t, db.ErrorContentReportDuplicateKey, err)
+			return nil
+		})()
+	})))
+	t.Run("ReportAgainAfterClosingCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			report := userReport(entities.CONTENT_TYPE_NOTE, noteId, TEST_NOTE_READER_ID, entities.REPORT_REASON_SPAM)
+			_, err := queries.CreateContentReport(tx, ctx, report)
+			assert.Nil(t, err)
+			_, err = queries.CloseContentReports(tx, ctx, entities.CONTENT_TYPE_NOTE, noteId, entities.REPORT_STATUS_APPROVED)
+			assert.Nil(t, err)
+			_, err = queries.CreateContentReport(tx, ctx, report)
+			assert.Nil(t, err)
+			return nil
+		})()
+	})))
+	t.Run("BlockedNoteCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			err := queries.UpdateNoteState(tx, ctx, noteId, entities.NOTE_STATE_BLOCKED)
+			assert.Nil(t, err)
+
+			// the blocked note is visible to its author only
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_NONE, permission)
+			return nil
+		})()
+	})))
+	t.Run("LogCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.GetStateBeforeBlocking(tx, ctx, entities.CONTENT_TYPE_NOTE, 1)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			_, err = queries.CreateModerationLogEntry(tx, ctx, entities.ModerationLogEntry{ModeratorId: TEST_NOTE_OWNER_ID, ContentType: entities.CONTENT_TYPE_NOTE, ContentId: 1,
+				Action: entities.MODERATION_ACTION_BLOCK, Reason: "spam", PreviousState: entities.NOTE_STATE_DRAFT, ReportsCount: 2})
+			assert.Nil(t, err)
+			_, err = queries.CreateModerationLogEntry(tx, ctx, entities.ModerationLogEntry{ModeratorId: TEST_NOTE_OWNER_ID, ContentType: entities.CONTENT_TYPE_COMMENT, ContentId: 1,
+				Action: entities.MODERATION_ACTION_DISMISS, PreviousState: entities.COMMENT_STATE_NEW, ReportsCount: 1})
+			assert.Nil(t, err)
+
+			state, err := queries.GetStateBeforeBlocking(tx, ctx, entities.CONTENT_TYPE_NOTE, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_STATE_DRAFT, state)
+
+			entries, err := queries.GetModerationLog(tx, ctx, "", 0, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(entries))
+			assert.Equal(t, entities.MODERATION_ACTION_DISMISS, entries[0].Action)
+
+			entries, err = queries.GetModerationLog(tx, ctx, entities.CONTENT_TYPE_NOTE, 1, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, "spam", entries[0].Reason)
+			assert.Equal(t, 2, entries[0].ReportsCount)
+			return nil
+		})()
+	})))
+}
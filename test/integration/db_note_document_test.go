@@ -0,0 +1,65 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNoteDocuments(t *testing.T) {
+	t.Run("VisibilityCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			targetId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, "Graphs", TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			sourceId, _ := CreateNoteInDB(t, tx, ctx, "See [[Graphs]]", TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			err := links.SaveNoteLinks(tx, ctx, sourceId, TEST_NOTE_OWNER_ID, TEST_NOTE_TOPIC_2, "See [[Graphs]]")
+			assert.Nil(t, err)
+			err = queries.AddNoteTags(tx, ctx, sourceId, []int{TEST_NOTE_TAG_ID_1})
+			assert.Nil(t, err)
+			deletedId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			err = queries.DeleteNote(tx, ctx, deletedId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			documents, err := queries.GetAllNoteDocuments(tx, ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(documents))
+			assert.Equal(t, targetId, documents[0].Id)
+			assert.Equal(t, "Graphs", documents[0].Topic)
+			assert.Equal(t, 0, len(documents[0].LinkedIds))
+			assert.Equal(t, sourceId, documents[1].Id)
+			assert.Contains(t, documents[1].TagIds, TEST_NOTE_TAG_ID_1)
+			assert.Equal(t, []int{targetId}, documents[1].LinkedIds)
+
+			documents, err = queries.GetNoteDocumentsByIds(tx, ctx, []int{sourceId, deletedId})
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(documents))
+			assert.Equal(t, sourceId, documents[0].Id)
+
+			ids, err := queries.GetLinkingOrTaggedNoteIds(tx, ctx, []int{targetId}, []int{})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{sourceId}, ids)
+			ids, err = queries.GetLinkingOrTaggedNoteIds(tx, ctx, []int{}, []int{TEST_NOTE_TAG_ID_2})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{sourceId}, ids)
+
+			// the private notes of the other users are not ranked
+			err = queries.UpdateNoteVisibility(tx, ctx, targetId, entities.NOTE_VISIBILITY_PRIVATE)
+			assert.Nil(t, err)
+			ids, err = queries.GetVisibleNoteIds(tx, ctx, TEST_NOTE_STRANGER_ID, []int{targetId, sourceId, deletedId})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{sourceId}, ids)
+			ids, err = queries.GetVisibleNoteIds(tx, ctx, TEST_NOTE_OWNER_ID, []int{targetId, sourceId, deletedId})
+			assert.Nil(t, err)
+			assert.Equal(t, []int{targetId, sourceId}, ids)
+			return err
+		})()
+	})))
+}
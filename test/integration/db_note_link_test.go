@@ -0,0 +1,74 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNoteLinks(t *testing.T) {
+	t.Run("BacklinksCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			targetId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, "Graphs", TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			text := "See [[graphs]] and [[" + strconv.Itoa(targetId) + "]] and [[Trees]]"
+			sourceId, _ := CreateNoteInDB(t, tx, ctx, text, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			err := links.SaveNoteLinks(tx, ctx, sourceId, TEST_NOTE_OWNER_ID, TEST_NOTE_TOPIC_2, text)
+			assert.Nil(t, err)
+
+			noteLinks, err := queries.GetNoteLinks(tx, ctx, sourceId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 3, len(noteLinks))
+			assert.Equal(t, "Trees", noteLinks[2].Target)
+			assert.False(t, noteLinks[2].TargetNoteId.Valid)
+
+			backlinks, err := queries.GetNoteBacklinks(tx, ctx, targetId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(backlinks))
+			assert.Equal(t, sourceId, backlinks[0].Id)
+
+			// the dangling link is resolved when the note with such topic appears
+			treesId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, "trees", TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			err = links.SaveNoteLinks(tx, ctx, treesId, TEST_NOTE_OWNER_ID, "trees", TEST_NOTE_TEXT_1)
+			assert.Nil(t, err)
+			noteLinks, _ = queries.GetNoteLinks(tx, ctx, sourceId, TEST_NOTE_OWNER_ID)
+			assert.Equal(t, int32(treesId), noteLinks[2].TargetNoteId.Int32)
+
+			// links to notes which are not visible are dangling for the stranger
+			queries.UpdateNoteVisibility(tx, ctx, treesId, entities.NOTE_VISIBILITY_PRIVATE)
+			noteLinks, _ = queries.GetNoteLinks(tx, ctx, sourceId, TEST_NOTE_STRANGER_ID)
+			assert.False(t, noteLinks[2].TargetNoteId.Valid)
+			return err
+		})()
+	})))
+	t.Run("GraphCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			targetId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, "Graphs", TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			sourceId, _ := CreateNoteInDB(t, tx, ctx, "[[Graphs]]", TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			links.SaveNoteLinks(tx, ctx, sourceId, TEST_NOTE_OWNER_ID, TEST_NOTE_TOPIC_2, "[[Graphs]]")
+
+			nodes, edges, err := queries.GetNotesGraph(tx, ctx, TEST_NOTE_OWNER_ID, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(nodes))
+			assert.Equal(t, 1, len(edges))
+			assert.Equal(t, sourceId, edges[0].SourceNoteId)
+			assert.Equal(t, int32(targetId), edges[0].TargetNoteId.Int32)
+
+			nodes, edges, err = queries.GetNotesGraph(tx, ctx, TEST_NOTE_OWNER_ID, TEST_NOTE_TAG_ID_1)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(nodes))
+			assert.Equal(t, 0, len(edges))
+			return err
+		})()
+	})))
+}
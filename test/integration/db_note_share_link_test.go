@@ -0,0 +1,91 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_NOTE_SHARE_LINK_TOKEN_HASH_1 string = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	TEST_NOTE_SHARE_LINK_TOKEN_HASH_2 string = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+)
+
+func TestDBNoteShareLink(t *testing.T) {
+	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.GetNoteShareLinkByTokenHash(tx, ctx, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.RevokeNoteShareLink(tx, ctx, 1, 1)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return err
+		})()
+	})))
+	t.Run("ViewsLimitCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			linkId, err := queries.CreateNoteShareLink(tx, ctx, noteId, TEST_NOTE_OWNER_ID, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1, sql.NullString{}, sql.NullTime{}, sql.NullInt32{Int32: 2, Valid: true})
+			assert.Nil(t, err)
+
+			link, err := queries.GetNoteShareLinkByTokenHash(tx, ctx, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1)
+			assert.Nil(t, err)
+			assert.Equal(t, linkId, link.Id)
+			assert.Equal(t, noteId, link.NoteId)
+			assert.Equal(t, entities.NOTE_SHARE_LINK_STATE_ACTIVE, link.State)
+			assert.False(t, link.PasswordHash.Valid)
+			assert.False(t, link.ExpireAt.Valid)
+
+			counted, err := queries.IncreaseNoteShareLinkViews(tx, ctx, linkId)
+			assert.Nil(t, err)
+			assert.True(t, counted)
+			counted, _ = queries.IncreaseNoteShareLinkViews(tx, ctx, linkId)
+			assert.True(t, counted)
+			counted, _ = queries.IncreaseNoteShareLinkViews(tx, ctx, linkId)
+			assert.False(t, counted)
+
+			link, _ = queries.GetNoteShareLinkByTokenHash(tx, ctx, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1)
+			assert.Equal(t, 2, link.Views)
+			return err
+		})()
+	})))
+	t.Run("RevokeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			linkId, _ := queries.CreateNoteShareLink(tx, ctx, noteId, TEST_NOTE_OWNER_ID, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1, sql.NullString{}, sql.NullTime{}, sql.NullInt32{})
+			queries.CreateNoteShareLink(tx, ctx, noteId, TEST_NOTE_OWNER_ID, TEST_NOTE_SHARE_LINK_TOKEN_HASH_2, sql.NullString{}, sql.NullTime{}, sql.NullInt32{})
+
+			links, err := queries.GetNoteShareLinks(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(links))
+
+			err = queries.RevokeNoteShareLink(tx, ctx, noteId+1, linkId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.RevokeNoteShareLink(tx, ctx, noteId, linkId)
+			assert.Nil(t, err)
+
+			_, err = queries.GetNoteShareLinkByTokenHash(tx, ctx, TEST_NOTE_SHARE_LINK_TOKEN_HASH_1)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			links, _ = queries.GetNoteShareLinks(tx, ctx, noteId)
+			assert.Equal(t, 1, len(links))
+
+			// links of deleted notes are not valid anymore
+			err = queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			_, err = queries.GetNoteShareLinkByTokenHash(tx, ctx, TEST_NOTE_SHARE_LINK_TOKEN_HASH_2)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
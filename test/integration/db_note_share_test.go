@@ -0,0 +1,131 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_NOTE_OWNER_ID    int = 1
+	TEST_NOTE_READER_ID   int = 2
+	TEST_NOTE_STRANGER_ID int = 3
+)
+
+func TestDBNoteVisibility(t *testing.T) {
+	t.Run("PrivateCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			err := queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_PRIVATE)
+			assert.Nil(t, err)
+
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			// shares of private notes are ignored
+			err = queries.CreateNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_READ)
+			assert.Nil(t, err)
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_NONE, permission)
+			return err
+		})()
+	})))
+	t.Run("SharedCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_2, TEST_NOTE_STRANGER_ID, TEST_NOTE_STATE_1)
+			queries.UpdateNoteVisibility(tx, ctx, noteId, entities.NOTE_VISIBILITY_SHARED)
+			queries.CreateNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_READ)
+
+			notes, err := queries.GetVisibleNotes(tx, ctx, TEST_NOTE_READER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(notes))
+
+			notes, err = queries.GetVisibleNotes(tx, ctx, TEST_NOTE_STRANGER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(notes))
+
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_READ, permission)
+
+			// sharing again changes the permission
+			err = queries.CreateNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_EDIT)
+			assert.Nil(t, err)
+			permission, _ = queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Equal(t, entities.NOTE_PERMISSION_EDIT, permission)
+
+			permission, _ = queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Equal(t, entities.NOTE_PERMISSION_OWNER, permission)
+
+			shares, err := queries.GetNoteShares(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(shares))
+
+			err = queries.DeleteNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.DeleteNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("SharedPublicDraftCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+			err := queries.CreateNoteShare(tx, ctx, noteId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_EDIT)
+			assert.Nil(t, err)
+
+			// the permission check and the listing agree on the share of the public draft
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_EDIT, permission)
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			notes, err := queries.GetVisibleNotes(tx, ctx, TEST_NOTE_READER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(notes))
+
+			// the draft is not public
+			permission, err = queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_NONE, permission)
+			_, err = queries.GetVisibleNote(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			notes, err = queries.GetVisibleNotes(tx, ctx, TEST_NOTE_STRANGER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(notes))
+			return nil
+		})()
+	})))
+	t.Run("PublicCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			permission, err := queries.GetNotePermission(tx, ctx, noteId, TEST_NOTE_STRANGER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_READ, permission)
+
+			_, err = queries.GetNotePermission(tx, ctx, noteId+1, TEST_NOTE_STRANGER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
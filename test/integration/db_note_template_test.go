@@ -0,0 +1,76 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNoteTemplate(t *testing.T) {
+	t.Run("VisibilityCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			personalId, err := queries.CreateNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, "Lecture", "Lecture: {{course}}", "# {{topic}}\n{{date}}", false)
+			assert.Nil(t, err)
+			sharedId, err := queries.CreateNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, "Book summary", "", "Author: {{author}}", true)
+			assert.Nil(t, err)
+			otherId, err := queries.CreateNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, "Meeting", "", "Agenda", false)
+			assert.Nil(t, err)
+
+			templates, err := queries.GetNoteTemplates(tx, ctx, TEST_NOTE_OWNER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(templates))
+			assert.Equal(t, sharedId, templates[0].Id)
+			assert.Equal(t, personalId, templates[1].Id)
+
+			// the own templates go before the shared ones
+			templates, err = queries.GetNoteTemplates(tx, ctx, TEST_NOTE_READER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(templates))
+			assert.Equal(t, otherId, templates[0].Id)
+			assert.Equal(t, sharedId, templates[1].Id)
+
+			template, err := queries.GetNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, sharedId)
+			assert.Nil(t, err)
+			assert.Equal(t, "Author: {{author}}", template.Text)
+			assert.True(t, template.Shared)
+			_, err = queries.GetNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, personalId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("EditCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			templateId, err := queries.CreateNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, "Lecture", "", "# {{topic}}", true)
+			assert.Nil(t, err)
+
+			// the shared template is read-only for the other users
+			err = queries.UpdateNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, templateId, "Mine", "", "text", false)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.DeleteNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, templateId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.UpdateNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, templateId, "Lecture notes", "{{course}}", "# {{topic}}\nSpeaker: {{speaker}}", false)
+			assert.Nil(t, err)
+			template, err := queries.GetNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, templateId)
+			assert.Nil(t, err)
+			assert.Equal(t, "Lecture notes", template.Name)
+			assert.Equal(t, "{{course}}", template.Topic)
+			assert.False(t, template.Shared)
+			_, err = queries.GetNoteTemplate(tx, ctx, TEST_NOTE_READER_ID, templateId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.DeleteNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, templateId)
+			assert.Nil(t, err)
+			_, err = queries.GetNoteTemplate(tx, ctx, TEST_NOTE_OWNER_ID, templateId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
@@ -305,7 +305,7 @@ func TestDBNoteUpdate(t *testing.T) {
 		})()
 
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err = queries.DeleteNote(tx, ctx, expectedNoteId)
+			err = queries.DeleteNote(tx, ctx, expectedNoteId, TEST_NOTE_OWNER_ID)
 
 			assert.Nil(t, err)
 			return err
@@ -381,7 +381,7 @@ func TestDBNoteUpdate(t *testing.T) {
 func TestDBNoteDelete(t *testing.T) {
 	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteNote(tx, ctx, 1)
+			err := queries.DeleteNote(tx, ctx, 1, TEST_NOTE_OWNER_ID)
 
 			assert.Equal(t, sql.ErrNoRows, err)
 			return err
@@ -413,14 +413,14 @@ func TestDBNoteDelete(t *testing.T) {
 		})()
 
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err = queries.DeleteNote(tx, ctx, expectedNoteId)
+			err = queries.DeleteNote(tx, ctx, expectedNoteId, TEST_NOTE_OWNER_ID)
 
 			assert.Nil(t, err)
 			return err
 		})()
 
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err = queries.DeleteNote(tx, ctx, expectedNoteId)
+			err = queries.DeleteNote(tx, ctx, expectedNoteId, TEST_NOTE_OWNER_ID)
 
 			assert.Equal(t, sql.ErrNoRows, err)
 			return err
@@ -453,7 +453,7 @@ func TestDBNoteDelete(t *testing.T) {
 		})()
 
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err = queries.DeleteNote(tx, ctx, noteIdToDelete)
+			err = queries.DeleteNote(tx, ctx, noteIdToDelete, TEST_NOTE_OWNER_ID)
 
 			assert.Nil(t, err)
 			return err
@@ -478,7 +478,7 @@ func TestDBNoteDelete(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
 			expectedError := fmt.Errorf("error at deleting note, case after preparing statement: %s", "context deadline exceeded")
 			_, err := tx.ExecContext(ctx, "SELECT pg_sleep(10)")
-			err = queries.DeleteNote(tx, ctx, 1)
+			err = queries.DeleteNote(tx, ctx, 1, TEST_NOTE_OWNER_ID)
 
 			assert.Equal(t, expectedError, err)
 			return err
@@ -488,7 +488,7 @@ func TestDBNoteDelete(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
 			expectedError := fmt.Errorf("error at deleting note, case after preparing statement: %s", "context canceled")
 			cancel()
-			err := queries.DeleteNote(tx, ctx, 1)
+			err := queries.DeleteNote(tx, ctx, 1, TEST_NOTE_OWNER_ID)
 			assert.Equal(t, expectedError, err)
 			return err
 		})()
@@ -0,0 +1,103 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNoteWorkflow(t *testing.T) {
+	t.Run("PublicationDateCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			publishedId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			draftId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+
+			published, err := queries.GetNote(tx, ctx, publishedId)
+			assert.Nil(t, err)
+			assert.True(t, published.PublishedAt.Valid)
+			draft, _ := queries.GetNote(tx, ctx, draftId)
+			assert.False(t, draft.PublishedAt.Valid)
+
+			// the first publication date is kept after archiving and publishing again
+			err = queries.UpdateNote(tx, ctx, publishedId, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_ARCHIVED)
+			assert.Nil(t, err)
+			err = queries.UpdateNote(tx, ctx, publishedId, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			assert.Nil(t, err)
+			actual, _ := queries.GetNote(tx, ctx, publishedId)
+			assert.Equal(t, published.PublishedAt, actual.PublishedAt)
+
+			err = queries.UpdateNote(tx, ctx, draftId, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			assert.Nil(t, err)
+			draft, _ = queries.GetNote(tx, ctx, draftId)
+			assert.True(t, draft.PublishedAt.Valid)
+			return nil
+		})()
+	})))
+	t.Run("DraftVisibilityCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			draftId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+
+			_, err := queries.GetVisibleNote(tx, ctx, draftId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			_, err = queries.GetVisibleNote(tx, ctx, draftId, TEST_NOTE_STRANGER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			permission, err := queries.GetNotePermission(tx, ctx, draftId, TEST_NOTE_STRANGER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_PERMISSION_NONE, permission)
+
+			// the collaborators see the draft
+			err = queries.CreateNoteShare(tx, ctx, draftId, TEST_NOTE_READER_ID, entities.NOTE_PERMISSION_READ)
+			assert.Nil(t, err)
+			_, err = queries.GetVisibleNote(tx, ctx, draftId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			return nil
+		})()
+	})))
+	t.Run("ScheduledPublicationCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			dueId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+			laterId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_DRAFT)
+			publishedId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			now := time.Now()
+			dueDate := now.Add(time.Hour).Truncate(time.Second)
+			err := queries.UpdateNotePublishAt(tx, ctx, dueId, sql.NullTime{Time: dueDate, Valid: true})
+			assert.Nil(t, err)
+			err = queries.UpdateNotePublishAt(tx, ctx, laterId, sql.NullTime{Time: now.Add(3 * time.Hour), Valid: true})
+			assert.Nil(t, err)
+			err = queries.UpdateNotePublishAt(tx, ctx, publishedId, sql.NullTime{Time: dueDate, Valid: true})
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			ids, err := queries.PublishScheduledNotes(tx, ctx, now)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(ids))
+
+			ids, err = queries.PublishScheduledNotes(tx, ctx, now.Add(2*time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, []int{dueId}, ids)
+			due, _ := queries.GetNote(tx, ctx, dueId)
+			assert.Equal(t, entities.NOTE_STATE_PUBLISHED, due.State)
+			assert.False(t, due.PublishAt.Valid)
+			assert.Equal(t, dueDate.Unix(), due.PublishedAt.Time.Unix())
+
+			// the schedule is dropped when the draft is published manually
+			err = queries.UpdateNote(tx, ctx, laterId, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_ARCHIVED)
+			assert.Nil(t, err)
+			later, _ := queries.GetNote(tx, ctx, laterId)
+			assert.False(t, later.PublishAt.Valid)
+			return nil
+		})()
+	})))
+}
@@ -0,0 +1,128 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBNotebook(t *testing.T) {
+	t.Run("SubtreeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			rootId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{}, "Algorithms")
+			assert.Nil(t, err)
+			root := sql.NullInt32{Int32: int32(rootId), Valid: true}
+			graphsId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, root, "Graphs")
+			assert.Nil(t, err)
+			sortingId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, root, "Sorting")
+			assert.Nil(t, err)
+			pathsId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{Int32: int32(graphsId), Valid: true}, "Shortest paths")
+			assert.Nil(t, err)
+			otherRootId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{}, "Math")
+			assert.Nil(t, err)
+
+			// the positions are assigned among the siblings
+			children, err := queries.GetNotebooks(tx, ctx, TEST_NOTE_OWNER_ID, root)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(children))
+			assert.Equal(t, graphsId, children[0].Id)
+			assert.Equal(t, 0, children[0].Position)
+			assert.Equal(t, sortingId, children[1].Id)
+			assert.Equal(t, 1, children[1].Position)
+			roots, err := queries.GetNotebooks(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{})
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(roots))
+			roots, err = queries.GetNotebooks(tx, ctx, TEST_NOTE_READER_ID, sql.NullInt32{})
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(roots))
+
+			subtree, err := queries.GetNotebookSubtree(tx, ctx, TEST_NOTE_OWNER_ID, rootId)
+			assert.Nil(t, err)
+			assert.Equal(t, 4, len(subtree))
+			assert.Equal(t, rootId, subtree[0].Id)
+			assert.Equal(t, 0, subtree[0].Depth)
+			assert.Equal(t, pathsId, subtree[3].Id)
+			assert.Equal(t, 2, subtree[3].Depth)
+			subtree, err = queries.GetNotebookSubtree(tx, ctx, TEST_NOTE_READER_ID, rootId)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(subtree))
+
+			// the moved notebook goes to the end of the new parent with its descendants
+			err = queries.MoveNotebook(tx, ctx, TEST_NOTE_OWNER_ID, graphsId, sql.NullInt32{Int32: int32(otherRootId), Valid: true})
+			assert.Nil(t, err)
+			err = queries.MoveNotebook(tx, ctx, TEST_NOTE_READER_ID, graphsId, sql.NullInt32{})
+			assert.Equal(t, sql.ErrNoRows, err)
+			subtree, err = queries.GetNotebookSubtree(tx, ctx, TEST_NOTE_OWNER_ID, otherRootId)
+			assert.Nil(t, err)
+			assert.Equal(t, 3, len(subtree))
+			assert.Equal(t, pathsId, subtree[2].Id)
+
+			err = queries.SetNotebookPositions(tx, ctx, TEST_NOTE_OWNER_ID, []int{otherRootId, rootId})
+			assert.Nil(t, err)
+			roots, err = queries.GetNotebooks(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{})
+			assert.Nil(t, err)
+			assert.Equal(t, otherRootId, roots[0].Id)
+			return nil
+		})()
+	})))
+	t.Run("NotesCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			otherNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			rootId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{}, "Algorithms")
+			assert.Nil(t, err)
+			childId, err := queries.CreateNotebook(tx, ctx, TEST_NOTE_OWNER_ID, sql.NullInt32{Int32: int32(rootId), Valid: true}, "Graphs")
+			assert.Nil(t, err)
+
+			err = queries.SetNoteNotebook(tx, ctx, noteId, rootId)
+			assert.Nil(t, err)
+			err = queries.SetNoteNotebook(tx, ctx, otherNoteId, rootId)
+			assert.Nil(t, err)
+			// the repeated put keeps the position
+			err = queries.SetNoteNotebook(tx, ctx, noteId, rootId)
+			assert.Nil(t, err)
+			notes, err := queries.GetNotebookNotes(tx, ctx, []int{rootId})
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(notes))
+			assert.Equal(t, noteId, notes[0].NoteId)
+			assert.Equal(t, TEST_NOTE_TOPIC_1, notes[0].NoteTopic)
+
+			err = queries.SetNotebookNotePositions(tx, ctx, rootId, []int{otherNoteId, noteId})
+			assert.Nil(t, err)
+			notes, err = queries.GetNotebookNotes(tx, ctx, []int{rootId})
+			assert.Nil(t, err)
+			assert.Equal(t, otherNoteId, notes[0].NoteId)
+
+			// the note belongs to one notebook at most
+			err = queries.SetNoteNotebook(tx, ctx, noteId, childId)
+			assert.Nil(t, err)
+			notes, err = queries.GetNotebookNotes(tx, ctx, []int{rootId, childId})
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(notes))
+			assert.Equal(t, rootId, notes[0].NotebookId)
+			assert.Equal(t, childId, notes[1].NotebookId)
+
+			// the notes of the deleted subtree are kept without notebook
+			err = queries.DeleteNotebookSubtree(tx, ctx, TEST_NOTE_READER_ID, rootId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.DeleteNotebookSubtree(tx, ctx, TEST_NOTE_OWNER_ID, rootId)
+			assert.Nil(t, err)
+			_, err = queries.GetNotebook(tx, ctx, TEST_NOTE_OWNER_ID, childId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.DeleteNoteNotebook(tx, ctx, noteId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			_, err = queries.GetNote(tx, ctx, noteId)
+			assert.Nil(t, err)
+			return nil
+		})()
+	})))
+}
@@ -0,0 +1,106 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func noteNotification(userId int, notificationType string, actorId int) entities.Notification {
+	return entities.Notification{
+		UserId:  userId,
+		Type:    notificationType,
+		ActorId: sql.NullInt32{Int32: int32(actorId), Valid: true},
+		NoteId:  sql.NullInt32{Int32: 1, Valid: true},
+		Text:    TEST_NOTE_TOPIC_1,
+	}
+}
+
+func TestDBNotification(t *testing.T) {
+	t.Run("InboxCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			sharedId, err := queries.CreateNotification(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_SHARED, TEST_NOTE_OWNER_ID))
+			assert.Nil(t, err)
+			_, err = queries.CreateNotification(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, TEST_NOTE_STRANGER_ID))
+			assert.Nil(t, err)
+			_, err = queries.CreateNotification(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, TEST_NOTE_OWNER_ID))
+			assert.Nil(t, err)
+			_, err = queries.CreateNotification(tx, ctx, noteNotification(TEST_NOTE_OWNER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, TEST_NOTE_READER_ID))
+			assert.Nil(t, err)
+
+			all, err := queries.GetUserNotifications(tx, ctx, TEST_NOTE_READER_ID, queries.NotificationFilter{}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 3, len(all))
+			assert.Equal(t, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, all[0].Type)
+			assert.Equal(t, sharedId, all[2].Id)
+
+			counts, err := queries.GetUnreadNotificationCounts(tx, ctx, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]int{entities.NOTIFICATION_TYPE_NOTE_SHARED: 1, entities.NOTIFICATION_TYPE_NOTE_COMMENTED: 2}, counts)
+
+			err = queries.MarkNotificationRead(tx, ctx, TEST_NOTE_READER_ID, sharedId)
+			assert.Nil(t, err)
+			err = queries.MarkNotificationRead(tx, ctx, TEST_NOTE_STRANGER_ID, sharedId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			unread, err := queries.GetUserNotifications(tx, ctx, TEST_NOTE_READER_ID, queries.NotificationFilter{UnreadOnly: true}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(unread))
+
+			marked, err := queries.MarkAllNotificationsRead(tx, ctx, TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, marked)
+			counts, err = queries.GetUnreadNotificationCounts(tx, ctx, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(counts))
+
+			// the notifications of other users are kept unread
+			counts, err = queries.GetUnreadNotificationCounts(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, counts[entities.NOTIFICATION_TYPE_NOTE_COMMENTED])
+			return nil
+		})()
+	})))
+	t.Run("PreferencesCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			enabled, err := queries.IsNotificationTypeEnabled(tx, ctx, TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_SHARED)
+			assert.Nil(t, err)
+			assert.True(t, enabled)
+
+			err = queries.SetNotificationPreferences(tx, ctx, TEST_NOTE_READER_ID, []entities.NotificationPreference{{Type: entities.NOTIFICATION_TYPE_NOTE_SHARED, Enabled: false}})
+			assert.Nil(t, err)
+			enabled, err = queries.IsNotificationTypeEnabled(tx, ctx, TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_SHARED)
+			assert.Nil(t, err)
+			assert.False(t, enabled)
+
+			// the turned off type and the own actions are not notified
+			err = notifications.Notify(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_SHARED, TEST_NOTE_OWNER_ID))
+			assert.Nil(t, err)
+			err = notifications.Notify(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, TEST_NOTE_READER_ID))
+			assert.Nil(t, err)
+			err = notifications.Notify(tx, ctx, noteNotification(TEST_NOTE_READER_ID, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, TEST_NOTE_OWNER_ID))
+			assert.Nil(t, err)
+			all, err := queries.GetUserNotifications(tx, ctx, TEST_NOTE_READER_ID, queries.NotificationFilter{}, 10, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(all))
+			assert.Equal(t, entities.NOTIFICATION_TYPE_NOTE_COMMENTED, all[0].Type)
+
+			err = queries.SetNotificationPreferences(tx, ctx, TEST_NOTE_READER_ID, []entities.NotificationPreference{{Type: entities.NOTIFICATION_TYPE_NOTE_SHARED, Enabled: true}})
+			assert.Nil(t, err)
+			preferences, err := queries.GetNotificationPreferences(tx, ctx, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(preferences))
+			assert.True(t, preferences[0].Enabled)
+			return nil
+		})()
+	})))
+}
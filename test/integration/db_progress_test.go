@@ -0,0 +1,109 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBProgress(t *testing.T) {
+	t.Run("ActivityBucketsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			err := queries.AddUserActivity(tx, ctx, TEST_NOTE_OWNER_ID, entities.ACTIVITY_TYPE_NOTE_EDIT, 1)
+			assert.Nil(t, err)
+			err = queries.AddUserActivity(tx, ctx, TEST_NOTE_OWNER_ID, entities.ACTIVITY_TYPE_NOTE_EDIT, 2)
+			assert.Nil(t, err)
+			err = queries.AddUserActivity(tx, ctx, TEST_NOTE_READER_ID, entities.ACTIVITY_TYPE_TASK_DONE, 1)
+			assert.Nil(t, err)
+
+			buckets, err := queries.GetUserActivityBuckets(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			total := 0
+			for _, bucket := range buckets {
+				assert.Equal(t, entities.ACTIVITY_TYPE_NOTE_EDIT, bucket.Type)
+				total += bucket.Count
+			}
+			assert.Equal(t, 2, total)
+
+			buckets, err = queries.GetUserActivityBuckets(tx, ctx, TEST_NOTE_STRANGER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(buckets))
+			return nil
+		})()
+	})))
+	t.Run("GoalsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			err := queries.SetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID, []entities.UserGoal{
+				{UserId: TEST_NOTE_OWNER_ID, Type: entities.GOAL_TYPE_ACTIVE_DAYS, Target: 5},
+				{UserId: TEST_NOTE_OWNER_ID, Type: entities.GOAL_TYPE_CARD_REVIEWS, Target: 100},
+			})
+			assert.Nil(t, err)
+
+			goals, err := queries.GetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(goals))
+
+			// the goals are replaced entirely
+			err = queries.SetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID, []entities.UserGoal{
+				{UserId: TEST_NOTE_OWNER_ID, Type: entities.GOAL_TYPE_TASKS_DONE, Target: 3},
+			})
+			assert.Nil(t, err)
+			goals, _ = queries.GetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Equal(t, 1, len(goals))
+			assert.Equal(t, entities.GOAL_TYPE_TASKS_DONE, goals[0].Type)
+			assert.Equal(t, 3, goals[0].Target)
+
+			err = queries.SetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID, nil)
+			assert.Nil(t, err)
+			goals, _ = queries.GetUserGoals(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Equal(t, 0, len(goals))
+			return nil
+		})()
+	})))
+	t.Run("AchievementsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			awarded, err := queries.AwardUserAchievement(tx, ctx, TEST_NOTE_OWNER_ID, "FIRST_NOTE", time.Now())
+			assert.Nil(t, err)
+			assert.True(t, awarded)
+
+			// the achievement is awarded once
+			awarded, err = queries.AwardUserAchievement(tx, ctx, TEST_NOTE_OWNER_ID, "FIRST_NOTE", time.Now())
+			assert.Nil(t, err)
+			assert.False(t, awarded)
+
+			achievements, err := queries.GetUserAchievements(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(achievements))
+			assert.Equal(t, "FIRST_NOTE", achievements[0].Code)
+			return nil
+		})()
+	})))
+	t.Run("TimezoneCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			userId, err := queries.CreateUser(tx, ctx, TEST_USER_LOGIN_1, TEST_USER_EMAIL_1, TEST_USER_PASSWORD_1, TEST_USER_ROLE_1, TEST_USER_STATE_1)
+			assert.Nil(t, err)
+
+			timezone, err := queries.GetUserTimezone(tx, ctx, userId)
+			assert.Nil(t, err)
+			assert.Equal(t, "UTC", timezone)
+
+			err = queries.UpdateUserTimezone(tx, ctx, userId, "Europe/Berlin")
+			assert.Nil(t, err)
+			timezone, _ = queries.GetUserTimezone(tx, ctx, userId)
+			assert.Equal(t, "Europe/Berlin", timezone)
+
+			err = queries.UpdateUserTimezone(tx, ctx, userId+1, "Europe/Berlin")
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
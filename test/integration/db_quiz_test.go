@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/quiz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBQuiz(t *testing.T) {
+	t.Run("QuestionsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			quizId, err := queries.CreateQuiz(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", sql.NullInt32{Int32: int32(noteId), Valid: true}, sql.NullInt32{}, sql.NullInt32{})
+			assert.Nil(t, err)
+
+			questionId, err := queries.CreateQuizQuestion(tx, ctx, entities.QuizQuestion{QuizId: quizId, Type: entities.QUIZ_QUESTION_TYPE_SINGLE_CHOICE, Text: "Tree?", Points: 1,
+				Options: []entities.QuizQuestionOption{{Text: "Yes", Correct: true}, {Text: "No"}}})
+			assert.Nil(t, err)
+
+			questions, err := queries.GetQuizQuestions(tx, ctx, quizId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(questions))
+			assert.Equal(t, 2, len(questions[0].Options))
+			assert.True(t, questions[0].Options[0].Correct)
+
+			err = queries.UpdateQuizQuestion(tx, ctx, entities.QuizQuestion{Id: questionId, QuizId: quizId, Type: entities.QUIZ_QUESTION_TYPE_FREE_TEXT, Text: "Name it", Points: 2,
+				Answer: sql.NullString{String: "tree", Valid: true}, MatchType: sql.NullString{String: entities.QUIZ_MATCH_TYPE_EXACT, Valid: true}})
+			assert.Nil(t, err)
+			questions, _ = queries.GetQuizQuestions(tx, ctx, quizId)
+			assert.Equal(t, 0, len(questions[0].Options))
+			assert.Equal(t, "tree", questions[0].Answer.String)
+
+			quizzes, err := queries.GetNoteQuizzes(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(quizzes))
+
+			err = queries.DeleteQuiz(tx, ctx, quizId)
+			assert.Nil(t, err)
+			_, err = queries.GetQuiz(tx, ctx, quizId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("AttemptsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			courseId, _ := queries.CreateCourse(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", "")
+			quizId, _ := queries.CreateQuiz(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", sql.NullInt32{}, sql.NullInt32{Int32: int32(courseId), Valid: true}, sql.NullInt32{})
+			questionId, _ := queries.CreateQuizQuestion(tx, ctx, entities.QuizQuestion{QuizId: quizId, Type: entities.QUIZ_QUESTION_TYPE_FREE_TEXT, Text: "Name it", Points: 1,
+				Answer: sql.NullString{String: "tree", Valid: true}, MatchType: sql.NullString{String: entities.QUIZ_MATCH_TYPE_EXACT, Valid: true}})
+			questions, _ := queries.GetQuizQuestions(tx, ctx, quizId)
+
+			for i, text := range []string{"Tree", "graph"} {
+				attemptId, err := queries.CreateQuizAttempt(tx, ctx, quizId, TEST_NOTE_READER_ID, 1, time.Now(), sql.NullTime{})
+				assert.Nil(t, err)
+
+				answer := entities.QuizAnswer{AttemptId: attemptId, QuestionId: questionId, Text: text}
+				answer.Correct, answer.Points = quiz.Score(questions[0], answer)
+				assert.Equal(t, i == 0, answer.Correct)
+
+				err = queries.FinishQuizAttempt(tx, ctx, attemptId, entities.QUIZ_ATTEMPT_STATE_FINISHED, answer.Points, time.Now())
+				assert.Nil(t, err)
+				err = queries.FinishQuizAttempt(tx, ctx, attemptId, entities.QUIZ_ATTEMPT_STATE_FINISHED, answer.Points, time.Now())
+				assert.Equal(t, sql.ErrNoRows, err)
+				err = queries.SaveQuizAnswers(tx, ctx, []entities.QuizAnswer{answer})
+				assert.Nil(t, err)
+
+				answers, err := queries.GetQuizAnswers(tx, ctx, attemptId)
+				assert.Nil(t, err)
+				assert.Equal(t, 1, len(answers))
+				assert.Equal(t, []int{}, answers[0].OptionIds)
+			}
+
+			attempts, err := queries.GetUserQuizAttempts(tx, ctx, quizId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(attempts))
+
+			stats, err := queries.GetQuizQuestionStats(tx, ctx, quizId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(stats))
+			assert.Equal(t, 2, stats[0].AnswersCount)
+			assert.Equal(t, 1, stats[0].CorrectCount)
+			assert.InDelta(t, 0.5, stats[0].AveragePoints, 0.0001)
+			return nil
+		})()
+	})))
+}
@@ -0,0 +1,94 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBResource(t *testing.T) {
+	t.Run("LinksCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			resourceId, err := queries.CreateResource(tx, ctx, entities.Resource{
+				UserId:        TEST_NOTE_OWNER_ID,
+				Title:         "Introduction to Algorithms",
+				Type:          entities.RESOURCE_TYPE_BOOK,
+				Status:        entities.RESOURCE_STATUS_TO_READ,
+				ProgressTotal: sql.NullInt32{Int32: 1292, Valid: true},
+				TagIds:        []int{tagId},
+				NoteIds:       []int{noteId},
+			})
+			assert.Nil(t, err)
+
+			resource, err := queries.GetResource(tx, ctx, resourceId)
+			assert.Nil(t, err)
+			assert.Equal(t, []int{tagId}, resource.TagIds)
+			assert.Equal(t, []int{noteId}, resource.NoteIds)
+
+			resources, err := queries.GetNoteResources(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(resources))
+			resources, _ = queries.GetNoteResources(tx, ctx, noteId, TEST_NOTE_READER_ID)
+			assert.Equal(t, 0, len(resources))
+
+			// the links are replaced
+			resource.NoteIds = nil
+			err = queries.UpdateResource(tx, ctx, resource)
+			assert.Nil(t, err)
+			resource, _ = queries.GetResource(tx, ctx, resourceId)
+			assert.Equal(t, 0, len(resource.NoteIds))
+			assert.Equal(t, []int{tagId}, resource.TagIds)
+
+			err = queries.UpdateResourceProgress(tx, ctx, resourceId, entities.RESOURCE_STATUS_READING, 100, resource.ProgressTotal)
+			assert.Nil(t, err)
+			resource, _ = queries.GetResource(tx, ctx, resourceId)
+			assert.Equal(t, 100, resource.Progress)
+			assert.Equal(t, entities.RESOURCE_STATUS_READING, resource.Status)
+
+			err = queries.DeleteResource(tx, ctx, resourceId)
+			assert.Nil(t, err)
+			_, err = queries.GetResource(tx, ctx, resourceId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("FilterCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			bookId, _ := queries.CreateResource(tx, ctx, entities.Resource{UserId: TEST_NOTE_OWNER_ID, Title: "Book", Type: entities.RESOURCE_TYPE_BOOK,
+				Status: entities.RESOURCE_STATUS_READING, TagIds: []int{tagId}})
+			videoId, _ := queries.CreateResource(tx, ctx, entities.Resource{UserId: TEST_NOTE_OWNER_ID, Title: "Video", Type: entities.RESOURCE_TYPE_VIDEO,
+				Status: entities.RESOURCE_STATUS_DONE})
+			_, err := queries.CreateResource(tx, ctx, entities.Resource{UserId: TEST_NOTE_READER_ID, Title: "Paper", Type: entities.RESOURCE_TYPE_PAPER,
+				Status: entities.RESOURCE_STATUS_READING})
+			assert.Nil(t, err)
+
+			resources, err := queries.GetUserResources(tx, ctx, TEST_NOTE_OWNER_ID, queries.ResourceFilter{}, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(resources))
+
+			resources, _ = queries.GetUserResources(tx, ctx, TEST_NOTE_OWNER_ID, queries.ResourceFilter{Status: entities.RESOURCE_STATUS_DONE}, 50, 0)
+			assert.Equal(t, 1, len(resources))
+			assert.Equal(t, videoId, resources[0].Id)
+
+			resources, _ = queries.GetUserResources(tx, ctx, TEST_NOTE_OWNER_ID, queries.ResourceFilter{TagId: tagId}, 50, 0)
+			assert.Equal(t, 1, len(resources))
+			assert.Equal(t, bookId, resources[0].Id)
+
+			resources, _ = queries.GetUserResources(tx, ctx, TEST_NOTE_OWNER_ID, queries.ResourceFilter{Type: entities.RESOURCE_TYPE_PAPER}, 50, 0)
+			assert.Equal(t, 0, len(resources))
+			return nil
+		})()
+	})))
+}
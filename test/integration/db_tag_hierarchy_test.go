@@ -0,0 +1,157 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBTagHierarchy(t *testing.T) {
+	t.Run("ParentCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			rootId, _ := CreateTagInDB(t, tx, ctx, "Programming", entities.TAG_STATE_NEW)
+			childId, _ := CreateTagInDB(t, tx, ctx, "Go", entities.TAG_STATE_NEW)
+			grandchildId, _ := CreateTagInDB(t, tx, ctx, "Goroutines", entities.TAG_STATE_NEW)
+
+			err := queries.SetTagParent(tx, ctx, childId, sql.NullInt32{Int32: int32(rootId), Valid: true})
+			assert.Nil(t, err)
+			err = queries.SetTagParent(tx, ctx, grandchildId, sql.NullInt32{Int32: int32(childId), Valid: true})
+			assert.Nil(t, err)
+
+			children, err := queries.GetTagChildren(tx, ctx, rootId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(children))
+			assert.Equal(t, childId, children[0].Id)
+			assert.Equal(t, sql.NullInt32{Int32: int32(rootId), Valid: true}, children[0].ParentId)
+
+			ancestorIds, err := queries.GetTagAncestorIds(tx, ctx, grandchildId)
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, []int{grandchildId, childId, rootId}, ancestorIds)
+
+			// the children of the deleted tag are moved to its parent
+			err = queries.DeleteTag(tx, ctx, childId, TEST_CALLER_USER_ID)
+			assert.Nil(t, err)
+			tag, err := queries.GetTag(tx, ctx, grandchildId)
+			assert.Nil(t, err)
+			assert.Equal(t, sql.NullInt32{Int32: int32(rootId), Valid: true}, tag.ParentId)
+			return nil
+		})()
+	})))
+	t.Run("AliasesCase", RunWithRecreateDB((func(t *testing.T) {
+		var tagId int
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ = CreateTagInDB(t, tx, ctx, "Go", entities.TAG_STATE_NEW)
+			_, err := queries.CreateTagAlias(tx, ctx, tagId, "golang")
+			assert.Nil(t, err)
+			return err
+		})()
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			// the aliases are unique regardless of the case
+			_, err := queries.CreateTagAlias(tx, ctx, tagId, "GoLang")
+			assert.Equal(t, db.ErrorTagAliasDuplicateKey, err)
+			return err
+		})()
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tag, err := queries.GetTagByName(tx, ctx, "Golang")
+			assert.Nil(t, err)
+			assert.Equal(t, tagId, tag.Id)
+			alias, err := queries.GetTagAliasByName(tx, ctx, "GOLANG")
+			assert.Nil(t, err)
+			assert.Equal(t, "golang", alias.Name)
+
+			aliases, err := queries.GetTagsAliases(tx, ctx, []int{tagId})
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(aliases[tagId]))
+
+			err = queries.DeleteTagAlias(tx, ctx, tagId+1, alias.Id)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.DeleteTagAlias(tx, ctx, tagId, alias.Id)
+			assert.Nil(t, err)
+			_, err = queries.GetTagByName(tx, ctx, "golang")
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("MergeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			targetId, _ := CreateTagInDB(t, tx, ctx, "Go", entities.TAG_STATE_NEW)
+			sourceId, _ := CreateTagInDB(t, tx, ctx, "go-lang", entities.TAG_STATE_NEW)
+			childId, _ := CreateTagInDB(t, tx, ctx, "Goroutines", entities.TAG_STATE_NEW)
+			prerequisiteId, _ := CreateTagInDB(t, tx, ctx, "Programming", entities.TAG_STATE_NEW)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, sourceId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			bothTagsNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, targetId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			err := queries.AddNoteTags(tx, ctx, bothTagsNoteId, []int{sourceId})
+			assert.Nil(t, err)
+			err = queries.SetTagParent(tx, ctx, childId, sql.NullInt32{Int32: int32(sourceId), Valid: true})
+			assert.Nil(t, err)
+			err = queries.AddTagPrerequisite(tx, ctx, sourceId, prerequisiteId)
+			assert.Nil(t, err)
+			_, err = queries.CreateTagAlias(tx, ctx, sourceId, "golang")
+			assert.Nil(t, err)
+
+			counts, err := queries.GetTagsUsageCounts(tx, ctx, []int{targetId, sourceId})
+			assert.Nil(t, err)
+			assert.Equal(t, map[int]int{targetId: 1, sourceId: 2}, counts)
+
+			err = queries.MergeTag(tx, ctx, sourceId, targetId)
+			assert.Nil(t, err)
+			err = queries.DeleteTag(tx, ctx, sourceId, TEST_CALLER_USER_ID)
+			assert.Nil(t, err)
+
+			note, err := queries.GetNote(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, targetId, note.TagId)
+			counts, err = queries.GetTagsUsageCounts(tx, ctx, []int{targetId, sourceId})
+			assert.Nil(t, err)
+			assert.Equal(t, map[int]int{targetId: 2}, counts)
+
+			child, err := queries.GetTag(tx, ctx, childId)
+			assert.Nil(t, err)
+			assert.Equal(t, sql.NullInt32{Int32: int32(targetId), Valid: true}, child.ParentId)
+			prerequisites, err := queries.GetTagPrerequisites(tx, ctx, targetId)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(prerequisites))
+			assert.Equal(t, prerequisiteId, prerequisites[0].Id)
+
+			// the old name and the aliases of the merged tag resolve to the target
+			for _, name := range []string{"go-lang", "golang"} {
+				tag, err := queries.GetTagByName(tx, ctx, name)
+				assert.Nil(t, err)
+				assert.Equal(t, targetId, tag.Id)
+			}
+			_, err = queries.GetTag(tx, ctx, sourceId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("MergeIntoDescendantCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			rootId, _ := CreateTagInDB(t, tx, ctx, "Programming", entities.TAG_STATE_NEW)
+			sourceId, _ := CreateTagInDB(t, tx, ctx, "Languages", entities.TAG_STATE_NEW)
+			targetId, _ := CreateTagInDB(t, tx, ctx, "Go", entities.TAG_STATE_NEW)
+			err := queries.SetTagParent(tx, ctx, sourceId, sql.NullInt32{Int32: int32(rootId), Valid: true})
+			assert.Nil(t, err)
+			err = queries.SetTagParent(tx, ctx, targetId, sql.NullInt32{Int32: int32(sourceId), Valid: true})
+			assert.Nil(t, err)
+
+			err = queries.MergeTag(tx, ctx, sourceId, targetId)
+			assert.Nil(t, err)
+			err = queries.DeleteTag(tx, ctx, sourceId, TEST_CALLER_USER_ID)
+			assert.Nil(t, err)
+
+			// the target takes the place of the merged ancestor
+			target, err := queries.GetTag(tx, ctx, targetId)
+			assert.Nil(t, err)
+			assert.Equal(t, sql.NullInt32{Int32: int32(rootId), Valid: true}, target.ParentId)
+			return nil
+		})()
+	})))
+}
@@ -0,0 +1,75 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBTagPrerequisite(t *testing.T) {
+	t.Run("EdgesCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId1, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			tagId2, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_2, TEST_TAG_STATE_2)
+
+			err := queries.AddTagPrerequisite(tx, ctx, tagId2, tagId1)
+			assert.Nil(t, err)
+			// the existing prerequisite is kept
+			err = queries.AddTagPrerequisite(tx, ctx, tagId2, tagId1)
+			assert.Nil(t, err)
+
+			prerequisites, err := queries.GetTagPrerequisites(tx, ctx, tagId2)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(prerequisites))
+			assert.Equal(t, tagId1, prerequisites[0].Id)
+
+			edges, err := queries.GetTagPrerequisiteEdges(tx, ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, []entities.TagPrerequisite{{TagId: tagId2, PrerequisiteId: tagId1}}, edges)
+
+			// the edges of the deleted tags are ignored
+			err = queries.DeleteTag(tx, ctx, tagId1, TEST_CALLER_USER_ID)
+			assert.Nil(t, err)
+			edges, _ = queries.GetTagPrerequisiteEdges(tx, ctx)
+			assert.Equal(t, 0, len(edges))
+
+			err = queries.DeleteTagPrerequisite(tx, ctx, tagId2, tagId1)
+			assert.Nil(t, err)
+			err = queries.DeleteTagPrerequisite(tx, ctx, tagId2, tagId1)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("TagNotesCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId1, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			noteId2, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+
+			courseId, _ := queries.CreateCourse(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", "")
+			moduleId, _ := queries.CreateCourseModule(tx, ctx, courseId, "Basics")
+			itemId, err := queries.CreateCourseItem(tx, ctx, moduleId, sql.NullInt32{Int32: int32(noteId1), Valid: true}, sql.NullInt32{})
+			assert.Nil(t, err)
+			err = queries.EnrollCourse(tx, ctx, courseId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.CompleteCourseItem(tx, ctx, itemId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			notes, err := queries.GetTagNotes(tx, ctx, TEST_NOTE_OWNER_ID, []int{tagId})
+			assert.Nil(t, err)
+			assert.Equal(t, []entities.TagNote{
+				{TagId: tagId, NoteId: noteId1, Completed: true},
+				{TagId: tagId, NoteId: noteId2, Completed: false},
+			}, notes)
+			return nil
+		})()
+	})))
+}
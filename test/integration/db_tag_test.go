@@ -210,7 +210,7 @@ func TestDBTagUpdate(t *testing.T) {
 			return err
 		})()
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteTag(tx, ctx, expectedTagId)
+			err := queries.DeleteTag(tx, ctx, expectedTagId, TEST_CALLER_USER_ID)
 
 			assert.Nil(t, err)
 			return err
@@ -296,7 +296,7 @@ func TestDBTagUpdate(t *testing.T) {
 func TestDBTagDelete(t *testing.T) {
 	t.Run("NotFoundCase", RunWithRecreateDB((func(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteTag(tx, ctx, 1)
+			err := queries.DeleteTag(tx, ctx, 1, TEST_CALLER_USER_ID)
 
 			assert.Equal(t, sql.ErrNoRows, err)
 			return err
@@ -312,13 +312,13 @@ func TestDBTagDelete(t *testing.T) {
 			return err
 		})()
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteTag(tx, ctx, expectedTagId)
+			err := queries.DeleteTag(tx, ctx, expectedTagId, TEST_CALLER_USER_ID)
 
 			assert.Nil(t, err)
 			return err
 		})()
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteTag(tx, ctx, expectedTagId)
+			err := queries.DeleteTag(tx, ctx, expectedTagId, TEST_CALLER_USER_ID)
 
 			assert.Equal(t, sql.ErrNoRows, err)
 			return err
@@ -337,7 +337,7 @@ func TestDBTagDelete(t *testing.T) {
 		})()
 
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
-			err := queries.DeleteTag(tx, ctx, tagIdToDelete)
+			err := queries.DeleteTag(tx, ctx, tagIdToDelete, TEST_CALLER_USER_ID)
 
 			assert.Nil(t, err)
 			return err
@@ -362,7 +362,7 @@ func TestDBTagDelete(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
 			expectedError := fmt.Errorf("error at deleting tag, case after preparing statement: %s", "context deadline exceeded")
 			_, err := tx.ExecContext(ctx, "SELECT pg_sleep(10)")
-			err = queries.DeleteTag(tx, ctx, 1)
+			err = queries.DeleteTag(tx, ctx, 1, TEST_CALLER_USER_ID)
 
 			assert.Equal(t, expectedError, err)
 			return err
@@ -372,7 +372,7 @@ func TestDBTagDelete(t *testing.T) {
 		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
 			expectedError := fmt.Errorf("error at deleting tag, case after preparing statement: %s", "context canceled")
 			cancel()
-			err := queries.DeleteTag(tx, ctx, 1)
+			err := queries.DeleteTag(tx, ctx, 1, TEST_CALLER_USER_ID)
 			assert.Equal(t, expectedError, err)
 			return err
 		})()
@@ -0,0 +1,128 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBTimeEntry(t *testing.T) {
+	t.Run("TimerCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			now := time.Now()
+			_, err := queries.GetRunningTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			entryId, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{UserId: TEST_NOTE_OWNER_ID, Kind: entities.TIME_ENTRY_KIND_TIMER, StartDate: now.Add(-time.Hour)})
+			assert.Nil(t, err)
+
+			running, err := queries.GetRunningTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			assert.Equal(t, entryId, running.Id)
+
+			// the running entry could not be updated
+			running.EndDate = sql.NullTime{Time: now, Valid: true}
+			err = queries.UpdateTimeEntry(tx, ctx, running)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.FinishTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId, now)
+			assert.Nil(t, err)
+			err = queries.FinishTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId, now)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			entry, err := queries.GetTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId)
+			assert.Nil(t, err)
+			assert.True(t, entry.EndDate.Valid)
+
+			_, err = queries.GetTimeEntry(tx, ctx, TEST_NOTE_READER_ID, entryId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+	t.Run("SingleRunningTimerCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			_, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{UserId: TEST_NOTE_OWNER_ID, Kind: entities.TIME_ENTRY_KIND_TIMER, StartDate: time.Now()})
+			assert.Nil(t, err)
+			_, err = queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{UserId: TEST_NOTE_OWNER_ID, Kind: entities.TIME_ENTRY_KIND_TIMER, StartDate: time.Now()})
+			assert.NotNil(t, err)
+			return nil
+		})()
+	})))
+	t.Run("PomodoroCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			now := time.Now()
+			entryId, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{
+				UserId:          TEST_NOTE_OWNER_ID,
+				Kind:            entities.TIME_ENTRY_KIND_POMODORO,
+				StartDate:       now.Add(-30 * time.Minute),
+				PlannedDuration: sql.NullInt32{Int32: 25 * 60, Valid: true},
+			})
+			assert.Nil(t, err)
+
+			err = queries.FinishExpiredTimers(tx, ctx, TEST_NOTE_OWNER_ID, now)
+			assert.Nil(t, err)
+
+			// the pomodoro is finished at the end of the planned duration
+			entry, _ := queries.GetTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId)
+			assert.True(t, entry.EndDate.Valid)
+			assert.Equal(t, 25*time.Minute, entry.EndDate.Time.Sub(entry.StartDate))
+
+			count, err := queries.CountFinishedPomodoros(tx, ctx, TEST_NOTE_OWNER_ID, now.Add(-time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+			return nil
+		})()
+	})))
+	t.Run("RangeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, TEST_NOTE_STATE_1)
+			day := time.Date(2022, 8, 8, 0, 0, 0, 0, time.Local)
+
+			entryId1, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{
+				UserId:    TEST_NOTE_OWNER_ID,
+				NoteId:    sql.NullInt32{Int32: int32(noteId), Valid: true},
+				Kind:      entities.TIME_ENTRY_KIND_MANUAL,
+				StartDate: day.Add(10 * time.Hour),
+				EndDate:   sql.NullTime{Time: day.Add(11 * time.Hour), Valid: true},
+			})
+			assert.Nil(t, err)
+			_, err = queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{
+				UserId:    TEST_NOTE_OWNER_ID,
+				Kind:      entities.TIME_ENTRY_KIND_MANUAL,
+				StartDate: day.AddDate(0, 0, 2),
+				EndDate:   sql.NullTime{Time: day.AddDate(0, 0, 2).Add(time.Hour), Valid: true},
+			})
+			assert.Nil(t, err)
+
+			entries, err := queries.GetTimeEntriesInRange(tx, ctx, TEST_NOTE_OWNER_ID, day, day.AddDate(0, 0, 1))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, entryId1, entries[0].Id)
+
+			tags, err := queries.GetTimeEntryTagsInRange(tx, ctx, TEST_NOTE_OWNER_ID, day, day.AddDate(0, 0, 1))
+			assert.Nil(t, err)
+			assert.Equal(t, []entities.TimeEntryTag{{TimeEntryId: entryId1, Name: TEST_TAG_NAME_1}}, tags)
+
+			entries, err = queries.GetTimeEntries(tx, ctx, TEST_NOTE_OWNER_ID, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(entries))
+			assert.NotEqual(t, entryId1, entries[0].Id)
+
+			err = queries.DeleteTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId1)
+			assert.Nil(t, err)
+			err = queries.DeleteTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, entryId1)
+			assert.Equal(t, sql.ErrNoRows, err)
+			return nil
+		})()
+	})))
+}
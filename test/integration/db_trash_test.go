@@ -0,0 +1,243 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/entities"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/db/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBTrash(t *testing.T) {
+	t.Run("ListCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			otherTagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_2, TEST_TAG_STATE_2)
+			taskId, _ := CreateTaskInDB(t, tx, ctx, TEST_TASK_NAME_1, TEST_TASK_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			err := queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTask(tx, ctx, taskId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTag(tx, ctx, otherTagId, TEST_NOTE_READER_ID)
+			assert.Nil(t, err)
+
+			items, err := queries.GetTrashItems(tx, ctx, TEST_NOTE_OWNER_ID, "", 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(items))
+			// the deleted task is listed with its original name
+			names := map[string]string{}
+			for _, item := range items {
+				names[item.Type] = item.Name
+			}
+			assert.Equal(t, map[string]string{entities.TRASH_ITEM_TYPE_NOTE: TEST_NOTE_TOPIC_1, entities.TRASH_ITEM_TYPE_TASK: TEST_TASK_NAME_1}, names)
+
+			items, err = queries.GetTrashItems(tx, ctx, TEST_NOTE_OWNER_ID, entities.TRASH_ITEM_TYPE_NOTE, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(items))
+			assert.Equal(t, noteId, items[0].Id)
+
+			items, err = queries.GetTrashItems(tx, ctx, TEST_NOTE_READER_ID, "", 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(items))
+			assert.Equal(t, entities.TRASH_ITEM_TYPE_TAG, items[0].Type)
+			assert.Equal(t, TEST_TAG_NAME_2, items[0].Name)
+			return nil
+		})()
+	})))
+	t.Run("RestoreCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			taskId, _ := CreateTaskInDB(t, tx, ctx, TEST_TASK_NAME_1, entities.TASK_STATE_DONE)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+
+			err := queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTask(tx, ctx, taskId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			// only the owner of the trash restores the items
+			err = queries.RestoreNote(tx, ctx, TEST_NOTE_READER_ID, noteId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = queries.RestoreTask(tx, ctx, TEST_NOTE_READER_ID, taskId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.RestoreNote(tx, ctx, TEST_NOTE_OWNER_ID, noteId)
+			assert.Nil(t, err)
+			note, err := queries.GetNote(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, entities.NOTE_STATE_PUBLISHED, note.State)
+			err = queries.RestoreNote(tx, ctx, TEST_NOTE_OWNER_ID, noteId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.RestoreTask(tx, ctx, TEST_NOTE_OWNER_ID, taskId)
+			assert.Nil(t, err)
+			task, err := queries.GetTask(tx, ctx, taskId)
+			assert.Nil(t, err)
+			assert.Equal(t, TEST_TASK_NAME_1, task.Name)
+			assert.Equal(t, entities.TASK_STATE_DONE, task.State)
+
+			items, err := queries.GetTrashItems(tx, ctx, TEST_NOTE_OWNER_ID, "", 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(items))
+			return nil
+		})()
+	})))
+	t.Run("RestoreDuplicateCase", RunWithRecreateDB((func(t *testing.T) {
+		var tagId int
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ = CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, entities.TAG_STATE_NEW)
+			err := queries.DeleteTag(tx, ctx, tagId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, entities.TAG_STATE_NEW)
+			return err
+		})()
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			err := queries.RestoreTag(tx, ctx, TEST_NOTE_OWNER_ID, tagId)
+			assert.Equal(t, db.ErrorTagDuplicateKey, err)
+			return err
+		})()
+	})))
+	t.Run("RestoreMergedTagCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			sourceId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, entities.TAG_STATE_NEW)
+			targetId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_2, entities.TAG_STATE_NEW)
+			err := queries.MergeTag(tx, ctx, sourceId, targetId)
+			assert.Nil(t, err)
+			err = queries.DeleteTag(tx, ctx, sourceId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			// the name of the merged tag is the alias of the target
+			err = queries.RestoreTag(tx, ctx, TEST_NOTE_OWNER_ID, sourceId)
+			assert.Equal(t, db.ErrorTagDuplicateKey, err)
+			tag, err := queries.GetTagByName(tx, ctx, TEST_TAG_NAME_1)
+			assert.Nil(t, err)
+			assert.Equal(t, targetId, tag.Id)
+			return nil
+		})()
+	})))
+	t.Run("PurgeCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			tagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_1, TEST_TAG_STATE_1)
+			otherTagId, _ := CreateTagInDB(t, tx, ctx, TEST_TAG_NAME_2, TEST_TAG_STATE_2)
+			taskId, _ := CreateTaskInDB(t, tx, ctx, TEST_TASK_NAME_1, TEST_TASK_STATE_1)
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, tagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			otherNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, otherTagId, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			err := queries.AddNoteTags(tx, ctx, noteId, []int{otherTagId})
+			assert.Nil(t, err)
+			_, err = queries.CreateComment(tx, ctx, noteId, TEST_NOTE_READER_ID, "Nice note", 0, entities.COMMENT_STATE_NEW)
+			assert.Nil(t, err)
+
+			err = queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTask(tx, ctx, taskId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTag(tx, ctx, otherTagId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			// nothing is purged within the retention period
+			count, _, err := queries.PurgeNotes(tx, ctx, time.Now().Add(-time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 0, count)
+
+			count, _, err = queries.PurgeNotes(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+			count, err = queries.PurgeTasks(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+			// the tag is still the primary tag of the other note
+			count, err = queries.PurgeTags(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 0, count)
+
+			comments, err := queries.GetNoteComments(tx, ctx, noteId)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(comments))
+			err = queries.RestoreNote(tx, ctx, TEST_NOTE_OWNER_ID, noteId)
+			assert.Equal(t, sql.ErrNoRows, err)
+
+			err = queries.DeleteNote(tx, ctx, otherNoteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			_, _, err = queries.PurgeNotes(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			count, err = queries.PurgeTags(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+
+			items, err := queries.GetTrashItems(tx, ctx, TEST_NOTE_OWNER_ID, "", 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(items))
+			return nil
+		})()
+	})))
+	t.Run("PurgeStudyMaterialsCase", RunWithRecreateDB((func(t *testing.T) {
+		db.TxVoid(func(tx *sql.Tx, ctx context.Context, cancel context.CancelFunc) error {
+			noteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_1, TEST_NOTE_TOPIC_1, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			quizNoteId, _ := CreateNoteInDB(t, tx, ctx, TEST_NOTE_TEXT_2, TEST_NOTE_TOPIC_2, TEST_NOTE_TAG_ID_1, TEST_NOTE_OWNER_ID, entities.NOTE_STATE_PUBLISHED)
+			taskId, _ := CreateTaskInDB(t, tx, ctx, TEST_TASK_NAME_1, TEST_TASK_STATE_1)
+
+			cardId, err := queries.CreateCard(tx, ctx, noteId, TEST_NOTE_OWNER_ID, "Question", "Answer", entities.CARD_SOURCE_MANUAL)
+			assert.Nil(t, err)
+			now := time.Now()
+			err = queries.SaveCardReview(tx, ctx, entities.CardReview{CardId: cardId, UserId: TEST_NOTE_READER_ID, Repetitions: 1, IntervalDays: 1, EasinessFactor: 2.5,
+				DueDate: now.Add(24 * time.Hour), LastReviewDate: now}, 5)
+			assert.Nil(t, err)
+			noteEntryId, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{UserId: TEST_NOTE_OWNER_ID, NoteId: sql.NullInt32{Int32: int32(noteId), Valid: true},
+				Kind: entities.TIME_ENTRY_KIND_TIMER, StartDate: now.Add(-2 * time.Hour), EndDate: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}})
+			assert.Nil(t, err)
+			taskEntryId, err := queries.CreateTimeEntry(tx, ctx, entities.TimeEntry{UserId: TEST_NOTE_OWNER_ID, TaskId: sql.NullInt32{Int32: int32(taskId), Valid: true},
+				Kind: entities.TIME_ENTRY_KIND_TIMER, StartDate: now.Add(-time.Hour), EndDate: sql.NullTime{Time: now, Valid: true}})
+			assert.Nil(t, err)
+			_, err = queries.CreateQuiz(tx, ctx, TEST_NOTE_OWNER_ID, "Graphs", sql.NullInt32{Int32: int32(quizNoteId), Valid: true}, sql.NullInt32{}, sql.NullInt32{})
+			assert.Nil(t, err)
+
+			err = queries.DeleteNote(tx, ctx, noteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteNote(tx, ctx, quizNoteId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+			err = queries.DeleteTask(tx, ctx, taskId, TEST_NOTE_OWNER_ID)
+			assert.Nil(t, err)
+
+			// the note of the quiz is kept in the trash
+			count, _, err := queries.PurgeNotes(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+			count, err = queries.PurgeTasks(tx, ctx, time.Now().Add(time.Hour))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, count)
+
+			_, err = queries.GetCard(tx, ctx, cardId)
+			assert.Equal(t, sql.ErrNoRows, err)
+			_, err = queries.GetCardReview(tx, ctx, cardId, TEST_NOTE_READER_ID)
+			assert.Equal(t, sql.ErrNoRows, err)
+			var logCount int
+			err = tx.QueryRowContext(ctx, "SELECT count(*) FROM card_review_log WHERE card_id = $1", cardId).Scan(&logCount)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, logCount)
+
+			// the time entries are kept without the references
+			entry, err := queries.GetTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, noteEntryId)
+			assert.Nil(t, err)
+			assert.False(t, entry.NoteId.Valid)
+			entry, err = queries.GetTimeEntry(tx, ctx, TEST_NOTE_OWNER_ID, taskEntryId)
+			assert.Nil(t, err)
+			assert.False(t, entry.TaskId.Valid)
+
+			items, err := queries.GetTrashItems(tx, ctx, TEST_NOTE_OWNER_ID, entities.TRASH_ITEM_TYPE_NOTE, 50, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(items))
+			assert.Equal(t, quizNoteId, items[0].Id)
+			return nil
+		})()
+	})))
+}
@@ -11,11 +11,36 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/attachments"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/auth"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/bibliography"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/bookmarks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/cards"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/comments"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/courses"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/events"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/export"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/imports"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/links"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/mentions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/moderation"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notebooks"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notes"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/notifications"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/ping"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/progress"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/quizzes"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/reactions"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/related"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/resources"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/reviews"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/sharelinks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/shares"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/tags"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/tasks"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/templates"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/timeentries"
+	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/trash"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/api/rest/v1/users"
 	"github.com/ArtemVoronov/indefinite-studies-api/internal/app"
 
@@ -41,8 +66,21 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// the API handlers below are not behind app.AuthReqired(), so the caller is set explicitly
+const TEST_CALLER_USER_ID int = 1
+
+func FakeCaller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(auth.USER_ID_CONTEXT_KEY); !exists {
+			c.Set(auth.USER_ID_CONTEXT_KEY, TEST_CALLER_USER_ID)
+		}
+		c.Next()
+	}
+}
+
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	r.Use(FakeCaller())
 
 	authorized := r.Group("/")
 	authorized.Use(app.AuthReqired())
@@ -53,6 +91,7 @@ func SetupRouter() *gin.Engine {
 	r.GET("/ping", ping.Ping)
 	r.POST("/auth/login", auth.Authenicate)
 	r.POST("/auth/refresh-token", auth.RefreshToken)
+	r.GET("/shared/:token", sharelinks.GetSharedNote)
 
 	r.GET("/tasks", tasks.GetTasks)
 	r.GET("/tasks/:id", tasks.GetTask)
@@ -65,6 +104,15 @@ func SetupRouter() *gin.Engine {
 	r.POST("/tags", tags.CreateTag)
 	r.PUT("/tags/:id", tags.UpdateTag)
 	r.DELETE("/tags/:id", tags.DeleteTag)
+	r.GET("/tags/:id/prerequisites", tags.GetTagPrerequisites)
+	r.PUT("/tags/:id/prerequisites/:prerequisiteId", tags.AddTagPrerequisite)
+	r.DELETE("/tags/:id/prerequisites/:prerequisiteId", tags.DeleteTagPrerequisite)
+	r.GET("/tags/:id/learning-path", tags.GetLearningPath)
+	r.GET("/tags/:id/children", tags.GetTagChildren)
+	r.PUT("/tags/:id/parent", tags.SetTagParent)
+	r.POST("/tags/:id/aliases", tags.CreateTagAlias)
+	r.DELETE("/tags/:id/aliases/:aliasId", tags.DeleteTagAlias)
+	r.POST("/tags/:id/merge", tags.MergeTag)
 
 	r.GET("/users", users.GetUsers)
 	r.GET("/users/:id", users.GetUser)
@@ -77,6 +125,157 @@ func SetupRouter() *gin.Engine {
 	r.POST("/notes", notes.CreateNote)
 	r.PUT("/notes/:id", notes.UpdateNote)
 	r.DELETE("/notes/:id", notes.DeleteNote)
+	r.DELETE("/notes/:id/schedule", notes.CancelNotePublication)
+
+	r.GET("/notes/:id/attachments", attachments.GetAttachments)
+	r.GET("/notes/:id/attachments/:attachmentId", attachments.DownloadAttachment)
+	r.POST("/notes/:id/attachments", attachments.CreateAttachment)
+	r.DELETE("/notes/:id/attachments/:attachmentId", attachments.DeleteAttachment)
+
+	r.GET("/notes/:id/shares", shares.GetNoteShares)
+	r.POST("/notes/:id/shares", shares.CreateNoteShare)
+	r.DELETE("/notes/:id/shares/:userId", shares.DeleteNoteShare)
+
+	r.GET("/notes/:id/share-links", sharelinks.GetNoteShareLinks)
+	r.POST("/notes/:id/share-links", sharelinks.CreateNoteShareLink)
+	r.DELETE("/notes/:id/share-links/:linkId", sharelinks.RevokeNoteShareLink)
+
+	r.GET("/notes/:id/links", links.GetNoteLinks)
+	r.GET("/notes/:id/backlinks", links.GetNoteBacklinks)
+	r.GET("/graph", links.GetGraph)
+	r.GET("/notes/:id/related", related.GetRelatedNotes)
+
+	r.GET("/notes/:id/cards", cards.GetNoteCards)
+	r.POST("/notes/:id/cards", cards.CreateNoteCard)
+	r.POST("/notes/:id/cards/extract", cards.ExtractNoteCards)
+	r.PUT("/notes/:id/cards/:cardId", cards.UpdateNoteCard)
+	r.DELETE("/notes/:id/cards/:cardId", cards.DeleteNoteCard)
+	r.GET("/reviews/due", reviews.GetDueCards)
+	r.GET("/reviews/stats", reviews.GetReviewStats)
+	r.POST("/reviews/:cardId", reviews.ReviewCard)
+
+	r.GET("/courses", courses.GetCourses)
+	r.POST("/courses", courses.CreateCourse)
+	r.GET("/courses/:id", courses.GetCourse)
+	r.PUT("/courses/:id", courses.UpdateCourse)
+	r.DELETE("/courses/:id", courses.DeleteCourse)
+	r.POST("/courses/:id/modules", courses.CreateCourseModule)
+	r.PUT("/courses/:id/modules/order", courses.OrderCourseModules)
+	r.PUT("/courses/:id/modules/:moduleId", courses.UpdateCourseModule)
+	r.DELETE("/courses/:id/modules/:moduleId", courses.DeleteCourseModule)
+	r.POST("/courses/:id/modules/:moduleId/items", courses.CreateCourseItem)
+	r.PUT("/courses/:id/modules/:moduleId/items/order", courses.OrderCourseItems)
+	r.DELETE("/courses/:id/modules/:moduleId/items/:itemId", courses.DeleteCourseItem)
+	r.POST("/courses/:id/enrollment", courses.EnrollCourse)
+	r.DELETE("/courses/:id/enrollment", courses.LeaveCourse)
+	r.GET("/courses/:id/progress", courses.GetCourseProgress)
+	r.PUT("/courses/:id/progress/position", courses.SetCoursePosition)
+	r.POST("/courses/:id/items/:itemId/completion", courses.CompleteCourseItem)
+	r.DELETE("/courses/:id/items/:itemId/completion", courses.UncompleteCourseItem)
+	r.GET("/me/courses", courses.GetUserCourses)
+
+	r.GET("/notes/:id/quizzes", quizzes.GetNoteQuizzes)
+	r.GET("/courses/:id/quizzes", quizzes.GetCourseQuizzes)
+	r.POST("/quizzes", quizzes.CreateQuiz)
+	r.GET("/quizzes/:id", quizzes.GetQuiz)
+	r.PUT("/quizzes/:id", quizzes.UpdateQuiz)
+	r.DELETE("/quizzes/:id", quizzes.DeleteQuiz)
+	r.POST("/quizzes/:id/questions", quizzes.CreateQuizQuestion)
+	r.PUT("/quizzes/:id/questions/:questionId", quizzes.UpdateQuizQuestion)
+	r.DELETE("/quizzes/:id/questions/:questionId", quizzes.DeleteQuizQuestion)
+	r.GET("/quizzes/:id/stats", quizzes.GetQuizStats)
+	r.POST("/quizzes/:id/attempts", quizzes.StartQuizAttempt)
+	r.GET("/quizzes/:id/attempts", quizzes.GetQuizAttempts)
+	r.GET("/quizzes/:id/attempts/:attemptId", quizzes.GetQuizAttempt)
+	r.POST("/quizzes/:id/attempts/:attemptId/submit", quizzes.SubmitQuizAttempt)
+
+	r.GET("/me/progress", progress.GetProgress)
+	r.PUT("/me/goals", progress.SetGoals)
+	r.PUT("/me/timezone", progress.SetTimezone)
+
+	r.GET("/me/time-entries", timeentries.GetTimeEntries)
+	r.POST("/me/time-entries", timeentries.CreateTimeEntry)
+	r.PUT("/me/time-entries/:id", timeentries.UpdateTimeEntry)
+	r.DELETE("/me/time-entries/:id", timeentries.DeleteTimeEntry)
+	r.GET("/me/time-entries/timer", timeentries.GetTimer)
+	r.POST("/me/time-entries/timer/start", timeentries.StartTimer)
+	r.POST("/me/time-entries/timer/stop", timeentries.StopTimer)
+	r.GET("/me/reports/time", timeentries.GetTimeReport)
+
+	r.GET("/resources", resources.GetResources)
+	r.GET("/resources/:id", resources.GetResource)
+	r.POST("/resources", resources.CreateResource)
+	r.PUT("/resources/:id", resources.UpdateResource)
+	r.DELETE("/resources/:id", resources.DeleteResource)
+	r.PUT("/resources/:id/progress", resources.UpdateResourceProgress)
+	r.GET("/notes/:id/resources", resources.GetNoteResources)
+
+	r.GET("/bibliography", bibliography.GetBibEntries)
+	r.GET("/bibliography/:id", bibliography.GetBibEntry)
+	r.POST("/bibliography", bibliography.CreateBibEntry)
+	r.PUT("/bibliography/:id", bibliography.UpdateBibEntry)
+	r.DELETE("/bibliography/:id", bibliography.DeleteBibEntry)
+	r.POST("/bibliography/import", bibliography.ImportBibliography)
+	r.GET("/bibliography/export", bibliography.ExportBibliography)
+	r.GET("/notes/:id/references", bibliography.GetNoteReferences)
+	r.GET("/notes/:id/render", bibliography.RenderNote)
+
+	r.GET("/notes/:id/comments", comments.GetNoteComments)
+	r.POST("/notes/:id/comments", comments.CreateComment)
+	r.PUT("/comments/:id", comments.UpdateComment)
+	r.DELETE("/comments/:id", comments.DeleteComment)
+
+	r.POST("/notes/:id/reports", moderation.ReportNote)
+	r.POST("/comments/:id/reports", moderation.ReportComment)
+	r.GET("/moderation/queue", moderation.GetModerationQueue)
+	r.GET("/moderation/log", moderation.GetModerationLog)
+	r.POST("/moderation/:type/:id/approve", moderation.ApproveContent)
+	r.POST("/moderation/:type/:id/block", moderation.BlockContent)
+	r.POST("/moderation/:type/:id/dismiss", moderation.DismissContent)
+
+	r.GET("/me/notifications", notifications.GetNotifications)
+	r.POST("/me/notifications/read", notifications.MarkAllNotificationsRead)
+	r.POST("/me/notifications/:id/read", notifications.MarkNotificationRead)
+	r.GET("/me/notification-preferences", notifications.GetNotificationPreferences)
+	r.PUT("/me/notification-preferences", notifications.SetNotificationPreferences)
+	r.GET("/me/mentions", mentions.GetMentions)
+
+	r.PUT("/notes/:id/reactions/:type", reactions.AddNoteReaction)
+	r.DELETE("/notes/:id/reactions/:type", reactions.DeleteNoteReaction)
+	r.PUT("/notes/:id/bookmark", bookmarks.SetBookmark)
+	r.DELETE("/notes/:id/bookmark", bookmarks.DeleteBookmark)
+	r.GET("/me/bookmarks", bookmarks.GetBookmarks)
+	r.GET("/me/bookmark-folders", bookmarks.GetBookmarkFolders)
+	r.POST("/me/bookmark-folders", bookmarks.CreateBookmarkFolder)
+	r.PUT("/me/bookmark-folders/:id", bookmarks.UpdateBookmarkFolder)
+	r.DELETE("/me/bookmark-folders/:id", bookmarks.DeleteBookmarkFolder)
+
+	r.GET("/notebooks", notebooks.GetNotebooks)
+	r.POST("/notebooks", notebooks.CreateNotebook)
+	r.GET("/notebooks/:id", notebooks.GetNotebook)
+	r.PUT("/notebooks/:id", notebooks.UpdateNotebook)
+	r.DELETE("/notebooks/:id", notebooks.DeleteNotebook)
+	r.GET("/notebooks/:id/tree", notebooks.GetNotebookTree)
+	r.PUT("/notebooks/:id/parent", notebooks.MoveNotebook)
+	r.PUT("/notebooks/:id/children/order", notebooks.OrderNotebookChildren)
+	r.PUT("/notebooks/:id/notes/order", notebooks.OrderNotebookNotes)
+	r.PUT("/notes/:id/notebook", notebooks.SetNoteNotebook)
+	r.DELETE("/notes/:id/notebook", notebooks.DeleteNoteNotebook)
+
+	r.GET("/note-templates", templates.GetNoteTemplates)
+	r.POST("/note-templates", templates.CreateNoteTemplate)
+	r.GET("/note-templates/:id", templates.GetNoteTemplate)
+	r.PUT("/note-templates/:id", templates.UpdateNoteTemplate)
+	r.DELETE("/note-templates/:id", templates.DeleteNoteTemplate)
+
+	r.GET("/events", events.GetEvents)
+	r.GET("/events/ws", events.GetEventsWebSocket)
+
+	r.GET("/trash", trash.GetTrash)
+	r.POST("/trash/:type/:id/restore", trash.RestoreItem)
+
+	r.GET("/me/export", export.ExportNotes)
+	r.POST("/me/import", imports.ImportNotes)
 
 	return r
 }
@@ -123,6 +322,13 @@ func RunWithRecreateDB(f TestFunc) func(t *testing.T) {
 func Setup() {
 	InitTestEnv()
 	auth.Setup()
+	attachments.Setup()
+	imports.Setup()
+	moderation.Setup()
+	events.Setup()
+	mentions.Setup()
+	trash.Setup()
+	related.Setup()
 	db.GetInstance()
 }
 
@@ -6,6 +6,7 @@ package integration
 import (
 	"bytes"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -45,6 +46,11 @@ type NotesApi interface {
 	DeleteNote(id any) (int, string, error)
 }
 
+type MeApi interface {
+	ExportNotes() (int, []byte)
+	ImportNotes(archive []byte) (int, string, error)
+}
+
 type AuthApi interface {
 	Authenicate(email any, password any) (int, string, error)
 	RefreshToken(refreshToken any) (int, string, error)
@@ -60,6 +66,7 @@ type TestApi interface {
 	TasksApi
 	UsersApi
 	NotesApi
+	MeApi
 	AuthApi
 	PingApi
 }
@@ -311,6 +318,30 @@ func (p *TestHttpClient) DeleteNote(id any) (int, string, error) {
 	return w.Code, w.Body.String(), nil
 }
 
+func (p *TestHttpClient) ExportNotes() (int, []byte) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/me/export", nil)
+	TestRouter.ServeHTTP(w, req)
+	return w.Code, w.Body.Bytes()
+}
+
+func (p *TestHttpClient) ImportNotes(archive []byte) (int, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "notes.zip")
+	if err != nil {
+		return -1, "", err
+	}
+	part.Write(archive)
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/me/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	TestRouter.ServeHTTP(w, req)
+	return w.Code, w.Body.String(), nil
+}
+
 func (p *TestHttpClient) Authenicate(email any, password any) (int, string, error) {
 	body, err := CreateAuthenicateBody(email, password)
 	if err != nil {
@@ -48,12 +48,12 @@ const (
 	TEST_NOTE_TOPIC_1   string = "Test topic 1"
 	TEST_NOTE_TAG_ID_1  int    = 1
 	TEST_NOTE_USER_ID_1 int    = 1
-	TEST_NOTE_STATE_1   string = entities.NOTE_STATE_NEW
+	TEST_NOTE_STATE_1   string = entities.NOTE_STATE_PUBLISHED
 	TEST_NOTE_TEXT_2    string = "Test text 2"
 	TEST_NOTE_TOPIC_2   string = "Test topic 2"
 	TEST_NOTE_TAG_ID_2  int    = 2
 	TEST_NOTE_USER_ID_2 int    = 2
-	TEST_NOTE_STATE_2   string = entities.NOTE_STATE_BLOCKED
+	TEST_NOTE_STATE_2   string = entities.NOTE_STATE_ARCHIVED
 
 	TEST_NOTE_TEXT_TEMPLATE  string = "Test text "
 	TEST_NOTE_TOPIC_TEMPLATE string = "Test topic "
@@ -138,6 +138,7 @@ func (p *TestAsserts) AssertEqualNotes(t *testing.T, expected entities.Note, act
 	assert.Equal(t, expected.TagId, actual.TagId)
 	assert.Equal(t, expected.UserId, actual.UserId)
 	assert.Equal(t, expected.State, actual.State)
+	assert.Equal(t, expected.Visibility, actual.Visibility)
 }
 
 func (p *TestAsserts) AssertEqualNoteArrays(t *testing.T, expected []entities.Note, actual []entities.Note) {
@@ -236,12 +237,13 @@ func (p *TestEntityGenerators) GenerateNoteTopic(template string, id int) string
 
 func (p *TestEntityGenerators) GenerateNote(noteId int, userId int, tagId int) entities.Note {
 	return entities.Note{
-		Id:     noteId,
-		Text:   utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, noteId),
-		Topic:  utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, noteId),
-		TagId:  tagId,
-		UserId: userId,
-		State:  TEST_USER_STATE_1,
+		Id:         noteId,
+		Text:       utils.entityGenerators.GenerateNoteText(TEST_NOTE_TEXT_TEMPLATE, noteId),
+		Topic:      utils.entityGenerators.GenerateNoteTopic(TEST_NOTE_TOPIC_TEMPLATE, noteId),
+		TagId:      tagId,
+		UserId:     userId,
+		State:      TEST_USER_STATE_1,
+		Visibility: entities.NOTE_VISIBILITY_PUBLIC,
 	}
 }
 